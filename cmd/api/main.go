@@ -1,13 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/attendance/backend/internal/config"
 	"github.com/attendance/backend/internal/controller"
 	"github.com/attendance/backend/internal/middleware"
 	"github.com/attendance/backend/internal/service"
+	"github.com/attendance/backend/pkg/clock"
 	"github.com/attendance/backend/pkg/database"
+	"github.com/attendance/backend/pkg/jwt"
+	"github.com/attendance/backend/pkg/mailer"
+	"github.com/attendance/backend/pkg/storage"
+	"github.com/attendance/backend/pkg/timezone"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -20,6 +32,22 @@ func main() {
 
 	// Load configuration
 	cfg := config.LoadConfig()
+	if err := cfg.CORS.Validate(); err != nil {
+		log.Fatal("Invalid CORS configuration: ", err)
+	}
+	if err := cfg.Auth.Validate(); err != nil {
+		log.Fatal("Invalid auth configuration: ", err)
+	}
+	if err := cfg.Location.Validate(cfg.JWT.Secret); err != nil {
+		log.Fatal("Invalid location configuration: ", err)
+	}
+	if err := cfg.Attendance.Validate(); err != nil {
+		log.Fatal("Invalid attendance configuration: ", err)
+	}
+
+	// Cancelled on interrupt to stop background jobs and shut down the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
@@ -32,25 +60,97 @@ func main() {
 
 	log.Println("Database connected successfully")
 
+	// Initialize mailer (queued, with retries and a circuit breaker)
+	var baseMailer mailer.Mailer = mailer.LogMailer{}
+	if cfg.Mail.SMTPHost != "" {
+		baseMailer = mailer.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	}
+	mail := mailer.NewResilientMailer(baseMailer, mailer.Options{
+		MaxRetries:       cfg.Mail.MaxRetries,
+		RetryBaseDelay:   cfg.Mail.RetryBaseDelay,
+		QueueSize:        cfg.Mail.QueueSize,
+		BreakerThreshold: cfg.Mail.BreakerThreshold,
+		BreakerCooldown:  cfg.Mail.BreakerCooldown,
+	})
+	go mail.Start(ctx)
+
+	// Load token signing keys
+	jwtKeys, err := jwt.LoadKeys(cfg.JWT.Algorithm, cfg.JWT.Secret, cfg.JWT.PrivateKeyPath, cfg.JWT.PublicKeyPath)
+	if err != nil {
+		log.Fatal("Failed to load JWT keys: ", err)
+	}
+
+	// Uploaded files are written to local disk and only served through the attachment route
+	fileStorage := storage.NewLocalStorage(cfg.Storage.LocalDir, cfg.Storage.PublicURL)
+
+	// All time based attendance decisions read from one clock
+	systemClock := clock.System()
+	if cfg.Clock.NTPServer != "" {
+		go clock.StartSkewMonitor(ctx, systemClock, cfg.Clock.NTPServer, cfg.Clock.SkewCheckInterval, cfg.Clock.MaxSkew)
+	}
+
+	// Omitted location timezones are resolved offline from the coordinates when enabled
+	var timezoneResolver timezone.Resolver
+	if cfg.Location.TimezoneAutoResolve {
+		resolver, err := timezone.NewNearestResolver(cfg.Location.TimezoneMaxDistanceKm)
+		if err != nil {
+			log.Fatal("Failed to load timezone data: ", err)
+		}
+		timezoneResolver = resolver
+	}
+
 	// Initialize services
-	authService := service.NewAuthService(database.DB, cfg)
-	userService := service.NewUserService(database.DB)
-	locationService := service.NewLocationService(database.DB)
-	attendanceService := service.NewAttendanceService(database.DB, locationService)
-	scheduleService := service.NewScheduleService(database.DB)
+	webhookService := service.NewWebhookService(database.DB)
+	auditService := service.NewAuditService(database.DB)
+	authService := service.NewAuthService(database.DB, cfg, jwtKeys, webhookService)
+	userService := service.NewUserService(database.DB, webhookService, auditService, systemClock, cfg)
+	locationService := service.NewLocationService(database.DB, cfg, timezoneResolver)
+	attendanceService := service.NewAttendanceService(database.DB, locationService, auditService, fileStorage, systemClock, cfg)
+	scheduleService := service.NewScheduleService(database.DB, systemClock, cfg)
+	departmentService := service.NewDepartmentService(database.DB)
+	regionService := service.NewRegionService(database.DB)
+	holidayService := service.NewHolidayService(database.DB)
+	leaveService := service.NewLeaveService(database.DB, auditService)
+
+	// Start background jobs
+	go locationService.StartSuspensionExpiry(ctx, cfg.Location.SuspensionCheckInterval)
+	if cfg.Attendance.AbsenceJobEnabled {
+		go attendanceService.StartAbsenceMarking(ctx, cfg.Attendance.AbsenceJobInterval)
+	}
+	if cfg.Attendance.TrashRetention > 0 {
+		go attendanceService.StartTrashPurge(ctx, cfg.Attendance.TrashPurgeInterval, cfg.Attendance.TrashRetention)
+	}
+	if cfg.Auth.InactivityDays > 0 {
+		maxInactive := time.Duration(cfg.Auth.InactivityDays) * 24 * time.Hour
+		go userService.StartInactivityDeactivation(ctx, cfg.Auth.InactivityCheckInterval, maxInactive, cfg.Auth.InactivityExemptEmails)
+	}
 
 	// Initialize controllers
 	authController := controller.NewAuthController(authService)
 	userController := controller.NewUserController(userService)
-	locationController := controller.NewLocationController(locationService)
-	attendanceController := controller.NewAttendanceController(attendanceService)
+	locationController := controller.NewLocationController(locationService, systemClock)
+	attendanceController := controller.NewAttendanceController(attendanceService, service.NewPDFSummaryRenderer(), systemClock)
 	scheduleController := controller.NewScheduleController(scheduleService)
+	webhookController := controller.NewWebhookController(webhookService)
+	departmentController := controller.NewDepartmentController(departmentService)
+	regionController := controller.NewRegionController(regionService)
+	holidayController := controller.NewHolidayController(holidayService)
+	leaveController := controller.NewLeaveController(leaveService)
+	auditController := controller.NewAuditController(auditService)
+	metaController := controller.NewMetaController()
 
 	// Initialize Gin router
 	router := gin.Default()
 
 	// Apply middleware
-	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.CORSMiddleware(cfg.CORS))
+	if cfg.Server.PayloadLogEnabled {
+		if cfg.Server.GinMode == gin.ReleaseMode {
+			log.Println("SERVER_PAYLOAD_LOG_ENABLED is ignored in release mode")
+		} else {
+			router.Use(middleware.PayloadLogMiddleware(cfg.Server.PayloadLogRoutes))
+		}
+	}
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -64,38 +164,60 @@ func main() {
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
+		// Meta routes (public)
+		v1.GET("/meta/enums", metaController.GetEnums)
+
 		// Auth routes (public)
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/register", authController.Register)
+			// Closed registration always answers 403, so it is only throttled while open
+			if cfg.Auth.RegistrationEnabled {
+				auth.POST("/register", middleware.RateLimitMiddleware(cfg.Auth.RegisterRateLimit, time.Hour, cfg.Auth.TrustedProxies), authController.Register)
+			} else {
+				auth.POST("/register", authController.Register)
+			}
+			auth.GET("/check-email", middleware.RateLimitMiddleware(cfg.Auth.CheckEmailRateLimit, time.Minute, cfg.Auth.TrustedProxies), authController.CheckEmail)
 			auth.POST("/login", authController.Login)
 			auth.POST("/refresh-token", authController.RefreshToken)
 			auth.POST("/logout", authController.Logout)
 
 			// Protected auth routes
 			authProtected := auth.Group("")
-			authProtected.Use(middleware.AuthMiddleware(cfg))
+			authProtected.Use(middleware.AuthMiddleware(authService))
 			{
 				authProtected.GET("/me", authController.GetMe)
+				authProtected.PUT("/password", userController.UpdateMyPassword)
 			}
 		}
 
 		// Attendance routes (protected)
 		attendance := v1.Group("/attendance")
-		attendance.Use(middleware.AuthMiddleware(cfg))
+		attendance.Use(middleware.AuthMiddleware(authService))
+		attendance.Use(middleware.PasswordChangeMiddleware())
 		{
 			attendance.GET("/locations", locationController.GetNearbyLocations)
 			attendance.POST("/validate-location", locationController.ValidateLocation)
 			attendance.POST("/check-in", attendanceController.CheckIn)
+			attendance.POST("/check-in/arrive", attendanceController.Arrive)
+			attendance.POST("/check-in/start", attendanceController.StartWork)
 			attendance.POST("/check-out", attendanceController.CheckOut)
+			attendance.POST("/:id/attachments", attendanceController.AddAttachment)
+			attendance.GET("/:id/attachments/:attachmentId", attendanceController.GetAttachment)
 			attendance.GET("/today", attendanceController.GetTodayAttendance)
 			attendance.GET("/status", attendanceController.GetAttendanceStatus)
+			attendance.GET("/preview-status", attendanceController.PreviewStatus)
 			attendance.GET("/history", attendanceController.GetAttendanceHistory)
+			attendance.GET("/calendar", attendanceController.GetMonthlyCalendar)
+			attendance.GET("/planned", scheduleController.GetPlannedSchedule)
+			attendance.GET("/summary/weekly", attendanceController.GetWeeklySummary)
+			attendance.GET("/stats", attendanceController.GetMyAttendanceStats)
 		}
 
-		// Admin routes (protected + admin only)
+		// Admin routes (IP allowlist + protected + password changed + admin only)
 		admin := v1.Group("/admin")
-		admin.Use(middleware.AuthMiddleware(cfg))
+		admin.Use(middleware.IPAllowlistMiddleware(cfg.Auth.AdminIPAllowlist, cfg.Auth.TrustedProxies))
+		admin.Use(middleware.AuthMiddleware(authService))
+		admin.Use(middleware.PasswordChangeMiddleware())
 		admin.Use(middleware.AdminMiddleware())
 		{
 			// Profile management
@@ -108,11 +230,27 @@ func main() {
 			{
 				users.GET("", userController.GetAllUsers)
 				users.GET("/stats", userController.GetUserStats)
+				users.GET("/export", userController.ExportUsers)
 				users.GET("/:id", userController.GetUserByID)
 				users.POST("", userController.CreateUser)
+				users.POST("/bulk-deactivate", userController.BulkDeactivateUsers)
+				users.POST("/batch", userController.GetUsersBatch)
 				users.PUT("/:id", userController.UpdateUser)
 				users.DELETE("/:id", userController.DeleteUser)
 				users.PUT("/:id/password", userController.ChangeUserPassword)
+				users.POST("/:id/reset-password", userController.ResetUserPassword)
+				users.POST("/:id/merge", userController.MergeUsers)
+				users.GET("/:id/schedule-history", scheduleController.GetUserScheduleHistory)
+				users.GET("/:id/attendance", attendanceController.GetUserAttendanceByDate)
+				users.GET("/:id/attendance-history", attendanceController.GetUserAttendanceHistory)
+				users.GET("/:id/summary", attendanceController.GetUserMonthlySummary)
+				users.GET("/:id/summary/pdf", attendanceController.GetUserMonthlySummaryPDF)
+				users.GET("/:id/compliance", attendanceController.GetUserCompliance)
+				users.GET("/:id/storage", attendanceController.GetUserStorageUsage)
+				users.GET("/:id/points", attendanceController.GetUserPoints)
+				users.GET("/:id/attendance-stats", attendanceController.GetUserAttendanceStats)
+				users.POST("/:id/recompute-stats", attendanceController.RecomputeUserStats)
+				users.GET("/:id/recent-checkins", attendanceController.GetRecentCheckIns)
 			}
 
 			// Location management
@@ -123,12 +261,32 @@ func main() {
 				locations.POST("", locationController.CreateLocation)
 				locations.PUT("/:id", locationController.UpdateLocation)
 				locations.DELETE("/:id", locationController.DeleteLocation)
+				locations.PATCH("/:id/suspend", locationController.SuspendLocation)
+				locations.PATCH("/:id/activate", locationController.ActivateLocation)
+				locations.GET("/:id/qr", locationController.GetLocationQR)
+				locations.GET("/:id/duration-histogram", attendanceController.GetDurationHistogram)
+				locations.GET("/:id/schedules", scheduleController.GetSchedulesByLocation)
+				locations.POST("/:id/close-all", attendanceController.CloseAllAtLocation)
 			}
 
 			// Attendance management
 			attendances := admin.Group("/attendances")
 			{
 				attendances.GET("", attendanceController.GetAllAttendances)
+				attendances.GET("/open", attendanceController.GetOpenAttendances)
+				attendances.GET("/anomalies", attendanceController.GetAnomalies)
+				attendances.GET("/dashboard", attendanceController.GetDashboard)
+				attendances.GET("/present-now", attendanceController.GetPresentNow)
+				attendances.GET("/by-location", attendanceController.GetCountsByLocation)
+				attendances.GET("/daily-counts", attendanceController.GetDailyCounts)
+				attendances.GET("/trend", attendanceController.GetOrgAttendanceTrend)
+				attendances.POST("/bulk-status", attendanceController.BulkUpdateStatus)
+				attendances.GET("/trash", attendanceController.GetTrashedAttendances)
+				attendances.GET("/:id", attendanceController.GetAttendanceDetail)
+				attendances.PATCH("/:id/review", attendanceController.ReviewAttendance)
+				attendances.PATCH("/:id/admin-notes", attendanceController.UpdateAdminNotes)
+				attendances.DELETE("/:id", attendanceController.DeleteAttendance)
+				attendances.POST("/:id/restore", attendanceController.RestoreAttendance)
 			}
 
 			// Schedule management
@@ -141,17 +299,86 @@ func main() {
 				schedules.DELETE("/:id", scheduleController.DeleteSchedule)
 				schedules.POST("/assign", scheduleController.AssignSchedule)
 				schedules.GET("/user", scheduleController.GetUserSchedules)
+				schedules.GET("/on-date", scheduleController.GetAssignmentsOnDate)
+				schedules.POST("/:id/recalculate-statuses", attendanceController.RecalculateStatuses)
+			}
+
+			// Webhook management
+			webhooks := admin.Group("/webhooks")
+			{
+				webhooks.GET("", webhookController.GetAllWebhooks)
+				webhooks.GET("/events", webhookController.GetWebhookEvents)
+				webhooks.GET("/deliveries", webhookController.GetWebhookDeliveries)
+				webhooks.POST("/deliveries/:id/replay", webhookController.ReplayWebhookDelivery)
+				webhooks.POST("", webhookController.CreateWebhook)
+				webhooks.PUT("/:id", webhookController.UpdateWebhook)
+				webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+			}
+
+			// Department management
+			departments := admin.Group("/departments")
+			{
+				departments.GET("", departmentController.GetAllDepartments)
+				departments.POST("", departmentController.CreateDepartment)
+			}
+
+			// Region management
+			regions := admin.Group("/regions")
+			{
+				regions.GET("", regionController.GetAllRegions)
+				regions.GET("/:id", regionController.GetRegionByID)
+				regions.POST("", regionController.CreateRegion)
+				regions.PUT("/:id", regionController.UpdateRegion)
+				regions.DELETE("/:id", regionController.DeleteRegion)
+			}
+
+			// Holidays and leave, which excuse scheduled work days
+			holidays := admin.Group("/holidays")
+			{
+				holidays.GET("", holidayController.GetHolidays)
+				holidays.POST("", holidayController.CreateHoliday)
+				holidays.DELETE("/:id", holidayController.DeleteHoliday)
+			}
+			leaves := admin.Group("/leaves")
+			{
+				leaves.GET("", leaveController.GetLeaves)
+				leaves.POST("", leaveController.CreateLeave)
+				leaves.DELETE("/:id", leaveController.DeleteLeave)
 			}
+
+			// Audit logs
+			admin.GET("/audit-logs", auditController.GetAuditLogs)
 		}
 	}
 
 	// Start server
-	port := ":" + cfg.Server.Port
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
 	log.Printf("🚀 Server starting on port %s", cfg.Server.Port)
 	log.Printf("📝 Environment: %s", cfg.Server.GinMode)
 	log.Printf("💾 Database: %s", cfg.Database.DBName)
 
-	if err := router.Run(port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// Wait for interrupt signal, then shut down gracefully
+	<-ctx.Done()
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
 	}
 }
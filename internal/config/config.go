@@ -2,20 +2,58 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/attendance/backend/internal/model"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Auth       AuthConfig
+	CORS       CORSConfig
+	Attendance AttendanceConfig
+	Location   LocationConfig
+	Mail       MailConfig
+	Storage    StorageConfig
+	Clock      ClockConfig
+	Schedule   ScheduleConfig
+}
+
+type ScheduleConfig struct {
+	DefaultScheduleID       uint // assigned to new users when set together with DefaultLocationID
+	DefaultLocationID       uint
+	AssignmentMaxFutureDays int // effective dates further ahead are rejected, 0 disables
+	AssignmentMaxPastDays   int // effective dates further back are rejected, 0 disables
+}
+
+type ClockConfig struct {
+	NTPServer         string // empty disables skew detection
+	SkewCheckInterval time.Duration
+	MaxSkew           time.Duration // offsets beyond this are logged
+}
+
+type StorageConfig struct {
+	LocalDir  string // where uploaded files are written
+	PublicURL string // URL prefix stored files are recorded under; they are only served through the attachment route
 }
 
 type ServerConfig struct {
-	Port    string
-	GinMode string
+	Port              string
+	GinMode           string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	PayloadLogEnabled bool     // log sanitized request bodies of PayloadLogRoutes, ignored in release mode
+	PayloadLogRoutes  []string // route patterns, e.g. /api/v1/attendance/check-in
 }
 
 type DatabaseConfig struct {
@@ -28,21 +66,236 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret            string
-	Expiration        time.Duration
-	RefreshExpiration time.Duration
+	Algorithm          string // HS256 or RS256
+	Secret             string // HS256 only
+	PrivateKeyPath     string // RS256 only, PEM encoded
+	PublicKeyPath      string // RS256 only, derived from the private key when empty
+	Expiration         time.Duration
+	RefreshExpiration  time.Duration
+	SessionMaxLifetime time.Duration // refresh is refused this long after login, 0 disables
+}
+
+type AuthConfig struct {
+	RegistrationEnabled     bool
+	RegisterRateLimit       int // registrations per hour per IP, only applied while registration is enabled
+	CheckEmailRateLimit     int // requests per minute per IP
+	InactivityDays          int // deactivate accounts without a login for this many days, 0 disables
+	InactivityCheckInterval time.Duration
+	InactivityExemptEmails  []string // never auto-deactivated, admins are always exempt
+	DeviceBindingEnabled    bool     // bind tokens to the device they were issued to
+	TokenExpiryHeader       bool     // send X-Token-Expires-In on authenticated responses
+	PasswordHistorySize     int      // new passwords must differ from this many recent ones, 0 disables
+	AdminIPAllowlist        []string // CIDR ranges or IPs allowed to reach admin routes, empty allows any
+	TrustedProxies          []string // CIDR ranges or IPs whose X-Forwarded-For the allowlist and rate limits believe
+	RegistrationRequired    []string // optional registration fields made mandatory, from RequirableRegistrationFields
+}
+
+// RequirableRegistrationFields lists the optional registration fields a deployment can make mandatory
+var RequirableRegistrationFields = []string{"phone", "employee_id"}
+
+// Validate reports allowlist and proxy entries that are not valid IPs or CIDR ranges
+func (c *AuthConfig) Validate() error {
+	if _, err := ParseCIDRs(c.AdminIPAllowlist); err != nil {
+		return fmt.Errorf("AUTH_ADMIN_IP_ALLOWLIST: %w", err)
+	}
+	if _, err := ParseCIDRs(c.TrustedProxies); err != nil {
+		return fmt.Errorf("AUTH_TRUSTED_PROXIES: %w", err)
+	}
+	for _, field := range c.RegistrationRequired {
+		if !slices.Contains(RequirableRegistrationFields, field) {
+			return fmt.Errorf("AUTH_REGISTRATION_REQUIRED_FIELDS: unknown field %q, expected one of %s",
+				field, strings.Join(RequirableRegistrationFields, ", "))
+		}
+	}
+	return nil
+}
+
+// ParseCIDRs parses CIDR ranges, treating a bare IP as a single-address range
+func ParseCIDRs(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
 }
 
 type CORSConfig struct {
-	AllowedOrigins []string
+	AllowedOrigins   []string // "*" allows any origin
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration // how long browsers may cache preflight results
+	AllowCredentials bool
+}
+
+// Validate reports configuration the CORS middleware cannot honour
+func (c *CORSConfig) Validate() error {
+	if len(c.AllowedOrigins) == 0 {
+		return fmt.Errorf("CORS_ALLOWED_ORIGINS must not be empty")
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("CORS_ALLOWED_ORIGINS cannot be \"*\" when CORS_ALLOW_CREDENTIALS is true")
+			}
+			continue
+		}
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("invalid CORS origin %q: must start with http:// or https://", origin)
+		}
+	}
+
+	if len(c.AllowedMethods) == 0 {
+		return fmt.Errorf("CORS_ALLOWED_METHODS must not be empty")
+	}
+	for _, method := range c.AllowedMethods {
+		switch method {
+		case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		default:
+			return fmt.Errorf("invalid CORS method %q", method)
+		}
+	}
+
+	if c.MaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative")
+	}
+
+	return nil
+}
+
+type MailConfig struct {
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	From             string
+	MaxRetries       int
+	RetryBaseDelay   time.Duration
+	QueueSize        int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type LocationConfig struct {
+	DefaultRadius           int // meters, applied when a location is created without a radius
+	SuspensionCheckInterval time.Duration
+	NearbyMaxRadiusKm       float64       // largest radius_km accepted by the nearby search
+	NearbyMaxResults        int           // maximum locations returned by the nearby search
+	NearbyCacheTTL          time.Duration // 0 disables caching of active locations
+	QREnabled               bool          // QR check-in, which needs its own QRSecret
+	QRSecret                string        // signs check-in tokens shown as QR codes
+	QRTokenTTL              time.Duration
+	TimezoneAutoResolve     bool    // fill an omitted timezone from the coordinates on create
+	TimezoneMaxDistanceKm   float64 // coordinates farther from every zone's principal city stay unresolved, 0 disables
+	DuplicateCheckEnabled   bool    // reject new locations too close to an existing active one
+	DuplicateMinDistance    float64 // in meters
+}
+
+type AttendanceConfig struct {
+	GraceRadius        float64 // extra meters beyond location radius where check-in is flagged instead of rejected
+	AbsenceJobEnabled  bool
+	AbsenceJobInterval time.Duration
+	TrashRetention     time.Duration // how long deleted records can be restored, 0 keeps them forever
+	TrashPurgeInterval time.Duration
+	SoftGeofenceRoles  []string      // roles whose out-of-radius check-ins are flagged instead of rejected
+	MaxAttachments     int           // per attendance record
+	AttachmentMaxSize  int64         // in bytes
+	AttachmentTypes    []string      // accepted content types, detected from the file content
+	AttachmentQuota    int64         // total attachment bytes per user, 0 disables
+	MaxClientClockSkew time.Duration // client_time further than this from server time is flagged, 0 disables
+	// Payroll rounding of check-in/check-out times when computing work duration, 0 interval disables
+	PayrollRoundingInterval time.Duration
+	PayrollCheckInRounding  string // up, down or nearest
+	PayrollCheckOutRounding string // up, down or nearest
+	// Lateness points per attendance status, accumulated toward disciplinary thresholds; unlisted statuses score 0
+	StatusPoints map[string]int
+	// Multi-session mode lets a user check in and out several times a day, with at most
+	// MaxOpenSessions records open at once
+	MultiSession    bool
+	MaxOpenSessions int
+}
+
+// Validate reports attendance settings that cannot be applied
+func (c *AttendanceConfig) Validate() error {
+	if c.MaxOpenSessions < 1 {
+		return fmt.Errorf("ATTENDANCE_MAX_OPEN_SESSIONS must be at least 1")
+	}
+	if !slices.Contains(model.RoundingDirections, c.PayrollCheckInRounding) {
+		return fmt.Errorf("ATTENDANCE_PAYROLL_CHECK_IN_ROUNDING: unknown direction %q, expected one of %s",
+			c.PayrollCheckInRounding, strings.Join(model.RoundingDirections, ", "))
+	}
+	if !slices.Contains(model.RoundingDirections, c.PayrollCheckOutRounding) {
+		return fmt.Errorf("ATTENDANCE_PAYROLL_CHECK_OUT_ROUNDING: unknown direction %q, expected one of %s",
+			c.PayrollCheckOutRounding, strings.Join(model.RoundingDirections, ", "))
+	}
+	for _, role := range c.SoftGeofenceRoles {
+		if !slices.Contains(model.UserRoles, role) {
+			return fmt.Errorf("ATTENDANCE_SOFT_GEOFENCE_ROLES: unknown role %q, expected one of %s",
+				role, strings.Join(model.UserRoles, ", "))
+		}
+	}
+	return nil
+}
+
+// Validate reports a default radius that would create locations nobody can check in at,
+// and QR check-in enabled without a signing key of its own. Tokens must not be signed
+// with jwtSecret, so a leaked QR secret cannot be used to forge sessions.
+func (c *LocationConfig) Validate(jwtSecret string) error {
+	if c.DefaultRadius <= 0 {
+		return fmt.Errorf("LOCATION_DEFAULT_RADIUS must be greater than zero, got %d", c.DefaultRadius)
+	}
+	if !c.QREnabled {
+		return nil
+	}
+	if c.QRSecret == "" {
+		return fmt.Errorf("LOCATION_QR_SECRET is required when LOCATION_QR_ENABLED is true")
+	}
+	if c.QRSecret == jwtSecret {
+		return fmt.Errorf("LOCATION_QR_SECRET must differ from JWT_SECRET")
+	}
+	return nil
+}
+
+// IsSoftGeofenceRole reports whether check-ins by role use soft geofence enforcement
+func (c *AttendanceConfig) IsSoftGeofenceRole(role string) bool {
+	for _, r := range c.SoftGeofenceRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:    getEnv("PORT", "8000"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:              getEnv("PORT", "8000"),
+			GinMode:           getEnv("GIN_MODE", "debug"),
+			ReadTimeout:       getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+			ReadHeaderTimeout: getEnvDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+			WriteTimeout:      getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			IdleTimeout:       getEnvDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout:   getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 10*time.Second),
+			PayloadLogEnabled: parseBool(getEnv("SERVER_PAYLOAD_LOG_ENABLED", "false")),
+			PayloadLogRoutes: parseList(getEnv("SERVER_PAYLOAD_LOG_ROUTES",
+				"/api/v1/attendance/check-in,/api/v1/attendance/check-in/arrive,/api/v1/attendance/check-in/start,/api/v1/attendance/check-out")),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -53,14 +306,96 @@ func LoadConfig() *Config {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:            getEnv("JWT_SECRET", "your-secret-key-change-this"),
-			Expiration:        parseDuration(getEnv("JWT_EXPIRATION", "24h")),
-			RefreshExpiration: parseDuration(getEnv("JWT_REFRESH_EXPIRATION", "168h")),
+			Algorithm:          strings.ToUpper(getEnv("JWT_ALGORITHM", "HS256")),
+			Secret:             getEnv("JWT_SECRET", "your-secret-key-change-this"),
+			PrivateKeyPath:     getEnv("JWT_PRIVATE_KEY_PATH", ""),
+			PublicKeyPath:      getEnv("JWT_PUBLIC_KEY_PATH", ""),
+			Expiration:         parseDuration(getEnv("JWT_EXPIRATION", "24h")),
+			RefreshExpiration:  parseDuration(getEnv("JWT_REFRESH_EXPIRATION", "168h")),
+			SessionMaxLifetime: getEnvDuration("JWT_SESSION_MAX_LIFETIME", 0),
+		},
+		Auth: AuthConfig{
+			RegistrationEnabled:     parseBool(getEnv("AUTH_REGISTRATION_ENABLED", "true")),
+			RegisterRateLimit:       parseInt(getEnv("AUTH_REGISTER_RATE_LIMIT", "5"), 5),
+			CheckEmailRateLimit:     parseInt(getEnv("AUTH_CHECK_EMAIL_RATE_LIMIT", "10"), 10),
+			InactivityDays:          parseInt(getEnv("AUTH_INACTIVITY_DAYS", "0"), 0),
+			InactivityCheckInterval: getEnvDuration("AUTH_INACTIVITY_CHECK_INTERVAL", 24*time.Hour),
+			InactivityExemptEmails:  parseList(getEnv("AUTH_INACTIVITY_EXEMPT_EMAILS", "")),
+			DeviceBindingEnabled:    parseBool(getEnv("AUTH_DEVICE_BINDING_ENABLED", "false")),
+			TokenExpiryHeader:       parseBool(getEnv("AUTH_TOKEN_EXPIRY_HEADER", "false")),
+			PasswordHistorySize:     parseInt(getEnv("AUTH_PASSWORD_HISTORY_SIZE", "0"), 0),
+			AdminIPAllowlist:        parseList(getEnv("AUTH_ADMIN_IP_ALLOWLIST", "")),
+			TrustedProxies:          parseList(getEnv("AUTH_TRUSTED_PROXIES", "")),
+			RegistrationRequired:    parseList(getEnv("AUTH_REGISTRATION_REQUIRED_FIELDS", "")),
 		},
 		CORS: CORSConfig{
-			AllowedOrigins: []string{
-				getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:8080"),
-			},
+			AllowedOrigins: parseList(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:8080")),
+			AllowedMethods: parseList(strings.ToUpper(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS"))),
+			AllowedHeaders: parseList(getEnv("CORS_ALLOWED_HEADERS",
+				"Content-Type,Content-Length,Accept-Encoding,X-CSRF-Token,Authorization,Accept,Origin,Cache-Control,X-Requested-With,X-Request-ID,Idempotency-Key,X-Device-ID")),
+			ExposedHeaders:   parseList(getEnv("CORS_EXPOSED_HEADERS", "")),
+			MaxAge:           getEnvDuration("CORS_MAX_AGE", 12*time.Hour),
+			AllowCredentials: parseBool(getEnv("CORS_ALLOW_CREDENTIALS", "false")),
+		},
+		Attendance: AttendanceConfig{
+			GraceRadius:             parseFloat(getEnv("ATTENDANCE_GRACE_RADIUS", "0")),
+			AbsenceJobEnabled:       parseBool(getEnv("ATTENDANCE_ABSENCE_JOB_ENABLED", "false")),
+			AbsenceJobInterval:      getEnvDuration("ATTENDANCE_ABSENCE_JOB_INTERVAL", time.Hour),
+			TrashRetention:          getEnvDuration("ATTENDANCE_TRASH_RETENTION", 30*24*time.Hour),
+			TrashPurgeInterval:      getEnvDuration("ATTENDANCE_TRASH_PURGE_INTERVAL", time.Hour),
+			SoftGeofenceRoles:       parseList(getEnv("ATTENDANCE_SOFT_GEOFENCE_ROLES", "")),
+			MaxAttachments:          parseInt(getEnv("ATTENDANCE_MAX_ATTACHMENTS", "5"), 5),
+			AttachmentMaxSize:       int64(parseInt(getEnv("ATTENDANCE_ATTACHMENT_MAX_SIZE", "5242880"), 5242880)),
+			AttachmentTypes:         parseList(getEnv("ATTENDANCE_ATTACHMENT_TYPES", "image/jpeg,image/png,application/pdf")),
+			AttachmentQuota:         int64(parseInt(getEnv("ATTENDANCE_ATTACHMENT_QUOTA", "0"), 0)),
+			MaxClientClockSkew:      getEnvDuration("ATTENDANCE_MAX_CLIENT_CLOCK_SKEW", 5*time.Minute),
+			PayrollRoundingInterval: getEnvDuration("ATTENDANCE_PAYROLL_ROUNDING_INTERVAL", 0),
+			PayrollCheckInRounding:  getEnv("ATTENDANCE_PAYROLL_CHECK_IN_ROUNDING", "up"),
+			PayrollCheckOutRounding: getEnv("ATTENDANCE_PAYROLL_CHECK_OUT_ROUNDING", "down"),
+			StatusPoints:            parseIntMap(getEnv("ATTENDANCE_STATUS_POINTS", "late=1,half_day=2,absent=3")),
+			MultiSession:            parseBool(getEnv("ATTENDANCE_MULTI_SESSION", "false")),
+			MaxOpenSessions:         parseInt(getEnv("ATTENDANCE_MAX_OPEN_SESSIONS", "1"), 1),
+		},
+		Storage: StorageConfig{
+			LocalDir:  getEnv("STORAGE_LOCAL_DIR", "./uploads"),
+			PublicURL: getEnv("STORAGE_PUBLIC_URL", "/uploads"),
+		},
+		Clock: ClockConfig{
+			NTPServer:         getEnv("CLOCK_NTP_SERVER", ""),
+			SkewCheckInterval: getEnvDuration("CLOCK_SKEW_CHECK_INTERVAL", 10*time.Minute),
+			MaxSkew:           getEnvDuration("CLOCK_MAX_SKEW", 2*time.Second),
+		},
+		Schedule: ScheduleConfig{
+			DefaultScheduleID:       uint(parseInt(getEnv("SCHEDULE_DEFAULT_ID", "0"), 0)),
+			DefaultLocationID:       uint(parseInt(getEnv("SCHEDULE_DEFAULT_LOCATION_ID", "0"), 0)),
+			AssignmentMaxFutureDays: parseInt(getEnv("SCHEDULE_ASSIGNMENT_MAX_FUTURE_DAYS", "730"), 730),
+			AssignmentMaxPastDays:   parseInt(getEnv("SCHEDULE_ASSIGNMENT_MAX_PAST_DAYS", "365"), 365),
+		},
+		Mail: MailConfig{
+			SMTPHost:         getEnv("SMTP_HOST", ""),
+			SMTPPort:         getEnv("SMTP_PORT", "587"),
+			SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+			From:             getEnv("MAIL_FROM", "no-reply@attendance.com"),
+			MaxRetries:       parseInt(getEnv("MAIL_MAX_RETRIES", "3"), 3),
+			RetryBaseDelay:   getEnvDuration("MAIL_RETRY_BASE_DELAY", 2*time.Second),
+			QueueSize:        parseInt(getEnv("MAIL_QUEUE_SIZE", "100"), 100),
+			BreakerThreshold: parseInt(getEnv("MAIL_BREAKER_THRESHOLD", "5"), 5),
+			BreakerCooldown:  getEnvDuration("MAIL_BREAKER_COOLDOWN", time.Minute),
+		},
+		Location: LocationConfig{
+			DefaultRadius:           parseInt(getEnv("LOCATION_DEFAULT_RADIUS", "50"), 50),
+			SuspensionCheckInterval: getEnvDuration("LOCATION_SUSPENSION_CHECK_INTERVAL", 5*time.Minute),
+			NearbyMaxRadiusKm:       parseFloat(getEnv("LOCATION_NEARBY_MAX_RADIUS_KM", "10")),
+			NearbyMaxResults:        parseInt(getEnv("LOCATION_NEARBY_MAX_RESULTS", "50"), 50),
+			NearbyCacheTTL:          getEnvDuration("LOCATION_NEARBY_CACHE_TTL", time.Minute),
+			QREnabled:               parseBool(getEnv("LOCATION_QR_ENABLED", "false")),
+			QRSecret:                getEnv("LOCATION_QR_SECRET", ""),
+			QRTokenTTL:              getEnvDuration("LOCATION_QR_TOKEN_TTL", 2*time.Minute),
+			TimezoneAutoResolve:     parseBool(getEnv("LOCATION_TIMEZONE_AUTO_RESOLVE", "false")),
+			TimezoneMaxDistanceKm:   parseFloat(getEnv("LOCATION_TIMEZONE_MAX_DISTANCE_KM", "1000")),
+			DuplicateCheckEnabled:   parseBool(getEnv("LOCATION_DUPLICATE_CHECK_ENABLED", "false")),
+			DuplicateMinDistance:    parseFloat(getEnv("LOCATION_DUPLICATE_MIN_DISTANCE", "50")),
 		},
 	}
 }
@@ -81,6 +416,66 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseIntMap parses "key=value" pairs separated by commas, skipping malformed pairs
+func parseIntMap(s string) map[string]int {
+	values := make(map[string]int)
+	for _, item := range parseList(s) {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		i, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSpace(key)] = i
+	}
+	return values
+}
+
+func parseBool(s string) bool {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false
+	}
+	return b
+}
+
+func parseInt(s string, defaultValue int) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
 func parseDuration(s string) time.Duration {
 	d, err := time.ParseDuration(s)
 	if err != nil {
@@ -0,0 +1,416 @@
+package config
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAttendanceConfigValidate(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxOpenSessions int
+		wantErr         bool
+	}{
+		{"default", 1, false},
+		{"raised", 3, false},
+		{"zero", 0, true},
+		{"negative", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := AttendanceConfig{MaxOpenSessions: tt.maxOpenSessions, PayrollCheckInRounding: "up", PayrollCheckOutRounding: "down"}
+			if err := c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttendanceConfigValidateSoftGeofenceRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"existing role", []string{"user"}, false},
+		{"unknown role", []string{"user", "field"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := AttendanceConfig{MaxOpenSessions: 1, SoftGeofenceRoles: tt.roles, PayrollCheckInRounding: "up", PayrollCheckOutRounding: "down"}
+			if err := c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttendanceConfigValidatePayrollRounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		checkIn  string
+		checkOut string
+		wantErr  bool
+	}{
+		{"defaults", "up", "down", false},
+		{"nearest", "nearest", "nearest", false},
+		{"unknown check-in direction", "ceil", "down", true},
+		{"unknown check-out direction", "up", "Down", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := AttendanceConfig{MaxOpenSessions: 1, PayrollCheckInRounding: tt.checkIn, PayrollCheckOutRounding: tt.checkOut}
+			if err := c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMaxOpenSessions(t *testing.T) {
+	if got := LoadConfig().Attendance.MaxOpenSessions; got != 1 {
+		t.Errorf("default MaxOpenSessions = %d, want 1", got)
+	}
+
+	t.Setenv("ATTENDANCE_MAX_OPEN_SESSIONS", "4")
+	if got := LoadConfig().Attendance.MaxOpenSessions; got != 4 {
+		t.Errorf("MaxOpenSessions = %d, want 4", got)
+	}
+}
+
+func TestCORSConfigValidate(t *testing.T) {
+	valid := CORSConfig{
+		AllowedOrigins: []string{"http://localhost:3000"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		MaxAge:         time.Hour,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *CORSConfig)
+		wantErr bool
+	}{
+		{"valid", func(c *CORSConfig) {}, false},
+		{"any origin without credentials", func(c *CORSConfig) { c.AllowedOrigins = []string{"*"} }, false},
+		{"any origin with credentials", func(c *CORSConfig) { c.AllowedOrigins = []string{"*"}; c.AllowCredentials = true }, true},
+		{"no origins", func(c *CORSConfig) { c.AllowedOrigins = nil }, true},
+		{"origin without scheme", func(c *CORSConfig) { c.AllowedOrigins = []string{"localhost:3000"} }, true},
+		{"unknown method", func(c *CORSConfig) { c.AllowedMethods = []string{"FETCH"} }, true},
+		{"negative max age", func(c *CORSConfig) { c.MaxAge = -time.Second }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+			if err := c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigCORSCredentialsDefault(t *testing.T) {
+	cfg := LoadConfig()
+	if cfg.CORS.AllowCredentials {
+		t.Error("CORS_ALLOW_CREDENTIALS defaults to true, want false")
+	}
+	if err := cfg.CORS.Validate(); err != nil {
+		t.Errorf("default CORS configuration is invalid: %v", err)
+	}
+}
+
+func TestLoadConfigPayloadLogDefault(t *testing.T) {
+	cfg := LoadConfig()
+	if cfg.Server.PayloadLogEnabled {
+		t.Error("SERVER_PAYLOAD_LOG_ENABLED defaults to true, want false")
+	}
+	if len(cfg.Server.PayloadLogRoutes) == 0 || cfg.Server.PayloadLogRoutes[0] != "/api/v1/attendance/check-in" {
+		t.Errorf("default PayloadLogRoutes = %v, want the check-in routes", cfg.Server.PayloadLogRoutes)
+	}
+}
+
+func TestLocationConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  LocationConfig
+		wantErr bool
+	}{
+		{"QR disabled without secret", LocationConfig{DefaultRadius: 50}, false},
+		{"QR enabled with its own secret", LocationConfig{DefaultRadius: 50, QREnabled: true, QRSecret: "qr-secret"}, false},
+		{"QR enabled without secret", LocationConfig{DefaultRadius: 50, QREnabled: true}, true},
+		{"QR enabled reusing the JWT secret", LocationConfig{DefaultRadius: 50, QREnabled: true, QRSecret: "jwt-secret"}, true},
+		{"zero default radius", LocationConfig{}, true},
+		{"negative default radius", LocationConfig{DefaultRadius: -10}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate("jwt-secret"); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigQRSecretHasNoFallback(t *testing.T) {
+	t.Setenv("JWT_SECRET", "jwt-secret")
+	t.Setenv("LOCATION_QR_ENABLED", "true")
+
+	cfg := LoadConfig()
+	if cfg.Location.QRSecret != "" {
+		t.Errorf("QRSecret = %q, want empty without LOCATION_QR_SECRET", cfg.Location.QRSecret)
+	}
+	if err := cfg.Location.Validate(cfg.JWT.Secret); err == nil {
+		t.Error("Validate() accepted QR check-in without LOCATION_QR_SECRET")
+	}
+}
+
+func TestAuthConfigValidateRegistrationRequired(t *testing.T) {
+	tests := []struct {
+		name     string
+		required []string
+		wantErr  bool
+	}{
+		{"none", nil, false},
+		{"phone", []string{"phone"}, false},
+		{"phone and employee_id", []string{"phone", "employee_id"}, false},
+		{"unknown field", []string{"address"}, true},
+		{"not requirable", []string{"email"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := AuthConfig{RegistrationRequired: tt.required}
+			if err := c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []string
+		contains string // an address inside the parsed ranges
+		single   bool   // parsed as a single-address range
+		wantErr  bool
+	}{
+		{"range", []string{"203.0.113.0/24"}, "203.0.113.20", false, false},
+		{"bare IPv4", []string{"198.51.100.7"}, "198.51.100.7", true, false},
+		{"bare IPv6", []string{"2001:db8::1"}, "2001:db8::1", true, false},
+		{"invalid IP", []string{"198.51.100"}, "", false, true},
+		{"invalid range", []string{"203.0.113.0/33"}, "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networks, err := ParseCIDRs(tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(networks) != 1 || !networks[0].Contains(net.ParseIP(tt.contains)) {
+				t.Errorf("ParseCIDRs() = %v, want a range containing %s", networks, tt.contains)
+			}
+			if ones, bits := networks[0].Mask.Size(); (ones == bits) != tt.single {
+				t.Errorf("mask = /%d of %d bits, want single address %v", ones, bits, tt.single)
+			}
+		})
+	}
+}
+
+func TestAuthConfigValidateIPAllowlist(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist []string
+		proxies   []string
+		wantErr   bool
+	}{
+		{"disabled", nil, nil, false},
+		{"valid", []string{"203.0.113.0/24"}, []string{"10.0.0.1"}, false},
+		{"invalid allowlist", []string{"office"}, nil, true},
+		{"invalid trusted proxy", nil, []string{"10.0.0.0/40"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := AuthConfig{AdminIPAllowlist: tt.allowlist, TrustedProxies: tt.proxies}
+			if err := c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRegistrationRequired(t *testing.T) {
+	if got := LoadConfig().Auth.RegistrationRequired; len(got) != 0 {
+		t.Errorf("default RegistrationRequired = %v, want none", got)
+	}
+
+	t.Setenv("AUTH_REGISTRATION_REQUIRED_FIELDS", " phone , employee_id,")
+	got := LoadConfig().Auth.RegistrationRequired
+	if len(got) != 2 || got[0] != "phone" || got[1] != "employee_id" {
+		t.Errorf("RegistrationRequired = %v, want [phone employee_id]", got)
+	}
+}
+
+func TestParseIntMap(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]int
+	}{
+		{"empty", "", map[string]int{}},
+		{"defaults", "late=1,half_day=2,absent=3", map[string]int{"late": 1, "half_day": 2, "absent": 3}},
+		{"spaces around keys and values", " late = 5 , absent=10 ", map[string]int{"late": 5, "absent": 10}},
+		{"zero and negative values", "late=0,early=-1", map[string]int{"late": 0, "early": -1}},
+		{"malformed pairs skipped", "late,absent=x,half_day=2", map[string]int{"half_day": 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseIntMap(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseIntMap(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("parseIntMap(%q)[%s] = %d, want %d", tt.input, key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigStatusPoints(t *testing.T) {
+	got := LoadConfig().Attendance.StatusPoints
+	if got["late"] != 1 || got["half_day"] != 2 || got["absent"] != 3 || len(got) != 3 {
+		t.Errorf("default StatusPoints = %v, want late=1 half_day=2 absent=3", got)
+	}
+
+	t.Setenv("ATTENDANCE_STATUS_POINTS", "late=4")
+	if got := LoadConfig().Attendance.StatusPoints; got["late"] != 4 || len(got) != 1 {
+		t.Errorf("StatusPoints = %v, want late=4", got)
+	}
+}
+
+func TestLoadConfigServerTimeouts(t *testing.T) {
+	t.Setenv("SERVER_READ_TIMEOUT", "20s")
+	t.Setenv("SERVER_WRITE_TIMEOUT", "not-a-duration")
+	t.Setenv("SERVER_IDLE_TIMEOUT", "2m")
+
+	server := LoadConfig().Server
+	tests := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"read", server.ReadTimeout, 20 * time.Second},
+		{"read header default", server.ReadHeaderTimeout, 5 * time.Second},
+		{"invalid write falls back to default", server.WriteTimeout, 30 * time.Second},
+		{"idle", server.IdleTimeout, 2 * time.Minute},
+		{"shutdown default", server.ShutdownTimeout, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("timeout = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigLocationDefaultRadius(t *testing.T) {
+	if got := LoadConfig().Location.DefaultRadius; got != 50 {
+		t.Errorf("default DefaultRadius = %d, want 50", got)
+	}
+
+	t.Setenv("LOCATION_DEFAULT_RADIUS", "120")
+	if got := LoadConfig().Location.DefaultRadius; got != 120 {
+		t.Errorf("DefaultRadius = %d, want 120", got)
+	}
+
+	t.Setenv("LOCATION_DEFAULT_RADIUS", "wide")
+	if got := LoadConfig().Location.DefaultRadius; got != 50 {
+		t.Errorf("DefaultRadius with an invalid value = %d, want 50", got)
+	}
+}
+
+func TestLoadConfigNearbyLimits(t *testing.T) {
+	c := LoadConfig().Location
+	if c.NearbyMaxRadiusKm != 10 || c.NearbyMaxResults != 50 {
+		t.Errorf("default nearby limits = (%g km, %d), want (10 km, 50)", c.NearbyMaxRadiusKm, c.NearbyMaxResults)
+	}
+
+	t.Setenv("LOCATION_NEARBY_MAX_RADIUS_KM", "2.5")
+	t.Setenv("LOCATION_NEARBY_MAX_RESULTS", "20")
+	c = LoadConfig().Location
+	if c.NearbyMaxRadiusKm != 2.5 || c.NearbyMaxResults != 20 {
+		t.Errorf("nearby limits = (%g km, %d), want (2.5 km, 20)", c.NearbyMaxRadiusKm, c.NearbyMaxResults)
+	}
+}
+
+func TestLoadConfigNearbyCacheTTL(t *testing.T) {
+	if got := LoadConfig().Location.NearbyCacheTTL; got != time.Minute {
+		t.Errorf("default NearbyCacheTTL = %v, want 1m", got)
+	}
+
+	t.Setenv("LOCATION_NEARBY_CACHE_TTL", "0s")
+	if got := LoadConfig().Location.NearbyCacheTTL; got != 0 {
+		t.Errorf("NearbyCacheTTL = %v, want 0 to disable the cache", got)
+	}
+}
+
+func TestLoadConfigRegisterRateLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  int
+	}{
+		{"default", "", 5},
+		{"raised", "20", 20},
+		{"disabled", "0", 0},
+		{"invalid", "many", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AUTH_REGISTER_RATE_LIMIT", tt.value)
+			if got := LoadConfig().Auth.RegisterRateLimit; got != tt.want {
+				t.Errorf("RegisterRateLimit = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSoftGeofenceRole(t *testing.T) {
+	t.Setenv("ATTENDANCE_SOFT_GEOFENCE_ROLES", " user ,")
+	c := LoadConfig().Attendance
+
+	tests := []struct {
+		role string
+		want bool
+	}{
+		{"user", true},
+		{"admin", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			if got := c.IsSoftGeofenceRole(tt.role); got != tt.want {
+				t.Errorf("IsSoftGeofenceRole(%q) = %v, want %v", tt.role, got, tt.want)
+			}
+		})
+	}
+}
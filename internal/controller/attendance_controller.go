@@ -1,24 +1,82 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/attendance/backend/internal/model"
 	"github.com/attendance/backend/internal/service"
 	"github.com/attendance/backend/internal/utils"
+	"github.com/attendance/backend/pkg/clock"
 	"github.com/gin-gonic/gin"
 )
 
 type AttendanceController struct {
 	attendanceService *service.AttendanceService
+	summaryRenderer   service.SummaryRenderer
+	clock             clock.Clock // the attendance service's clock, for request defaults like the current month
 }
 
-func NewAttendanceController(attendanceService *service.AttendanceService) *AttendanceController {
+func NewAttendanceController(attendanceService *service.AttendanceService, summaryRenderer service.SummaryRenderer, clk clock.Clock) *AttendanceController {
 	return &AttendanceController{
 		attendanceService: attendanceService,
+		summaryRenderer:   summaryRenderer,
+		clock:             clk,
 	}
 }
 
+// toResponse converts an attendance to its response with payroll rounding applied
+// and its scheduled times. Admin notes are left out, so it is safe to return to the
+// record owner.
+func (ctrl *AttendanceController) toResponse(attendance *model.Attendance) (model.AttendanceResponse, error) {
+	responses, err := ctrl.toResponses([]model.Attendance{*attendance})
+	if err != nil {
+		return model.AttendanceResponse{}, err
+	}
+	return responses[0], nil
+}
+
+// toAdminResponse converts an attendance to its response for admin endpoints,
+// including the admin notes hidden from the record owner
+func (ctrl *AttendanceController) toAdminResponse(attendance *model.Attendance) (model.AttendanceResponse, error) {
+	responses, err := ctrl.toAdminResponses([]model.Attendance{*attendance})
+	if err != nil {
+		return model.AttendanceResponse{}, err
+	}
+	return responses[0], nil
+}
+
+// toResponses is toResponse for a list, loading the schedules of all records at once
+func (ctrl *AttendanceController) toResponses(attendances []model.Attendance) ([]model.AttendanceResponse, error) {
+	return ctrl.convertWithSchedules(attendances, (*model.Attendance).ToPayrollResponse)
+}
+
+// toAdminResponses is toAdminResponse for a list, loading the schedules of all records at once
+func (ctrl *AttendanceController) toAdminResponses(attendances []model.Attendance) ([]model.AttendanceResponse, error) {
+	return ctrl.convertWithSchedules(attendances, (*model.Attendance).ToAdminResponse)
+}
+
+// convertWithSchedules converts attendances with convert and adds their scheduled times
+func (ctrl *AttendanceController) convertWithSchedules(attendances []model.Attendance, convert func(*model.Attendance, model.PayrollRounding) model.AttendanceResponse) ([]model.AttendanceResponse, error) {
+	rounding := ctrl.attendanceService.PayrollRounding()
+	schedules, err := ctrl.attendanceService.AttendanceSchedules(attendances)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.AttendanceResponse, len(attendances))
+	for i := range attendances {
+		responses[i] = convert(&attendances[i], rounding)
+		responses[i].SetSchedule(schedules[attendances[i].ID])
+	}
+	return responses, nil
+}
+
 // CheckIn godoc
 // @Summary Check-in attendance
 // @Tags attendance
@@ -42,7 +100,70 @@ func (ctrl *AttendanceController) CheckIn(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "Check-in successful", attendance.ToResponse())
+	response, err := ctrl.toResponse(attendance)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	if attendance.OutsideRadius {
+		utils.SuccessResponse(c, http.StatusCreated, "Check-in recorded outside the allowed radius", response)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Check-in successful", response)
+}
+
+// Arrive godoc
+// @Summary Record arrival on site (two-phase check-in, step 1)
+// @Description Validates the location like a regular check-in but only records presence; the check-in time is set by /check-in/start
+// @Tags attendance
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CheckInRequest true "Check-in request"
+// @Success 201 {object} utils.Response
+// @Router /api/v1/attendance/check-in/arrive [post]
+func (ctrl *AttendanceController) Arrive(c *gin.Context) {
+	var req service.CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	userID := c.GetUint("userID")
+	arrival, err := ctrl.attendanceService.Arrive(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Arrival failed", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Arrival recorded", arrival)
+}
+
+// StartWork godoc
+// @Summary Start work (two-phase check-in, step 2)
+// @Description Turns today's arrival into an attendance whose check-in time, and so status, is now
+// @Tags attendance
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} utils.Response
+// @Router /api/v1/attendance/check-in/start [post]
+func (ctrl *AttendanceController) StartWork(c *gin.Context) {
+	userID := c.GetUint("userID")
+	attendance, err := ctrl.attendanceService.StartWork(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Check-in failed", err.Error())
+		return
+	}
+
+	response, err := ctrl.toResponse(attendance)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Check-in successful", response)
 }
 
 // CheckOut godoc
@@ -68,7 +189,124 @@ func (ctrl *AttendanceController) CheckOut(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Check-out successful", attendance.ToResponse())
+	response, err := ctrl.toResponse(attendance)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Check-out successful", response)
+}
+
+// AddAttachment godoc
+// @Summary Attach a photo or document to an attendance record
+// @Tags attendance
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Attendance ID"
+// @Param file formData file true "Photo or document"
+// @Param type formData string false "photo or document" default(photo)
+// @Param caption formData string false "Caption"
+// @Success 201 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 413 {object} utils.Response
+// @Router /api/v1/attendance/{id}/attachments [post]
+func (ctrl *AttendanceController) AddAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attendance ID", err.Error())
+		return
+	}
+
+	var req service.AddAttachmentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ValidationErrorResponse(c, "file is required")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read file", err.Error())
+		return
+	}
+	defer file.Close()
+
+	userID := c.GetUint("userID")
+	isAdmin := c.GetString("userRole") == "admin"
+	attachment, err := ctrl.attendanceService.AddAttachment(uint(id), userID, isAdmin, &req, file, fileHeader.Size)
+	if err != nil {
+		switch {
+		case err.Error() == "attendance not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "Attendance not found", err.Error())
+		case errors.Is(err, service.ErrAttachmentForbidden):
+			utils.ErrorResponse(c, http.StatusForbidden, "Failed to add attachment", err.Error())
+		case errors.Is(err, service.ErrAttachmentLimit), errors.Is(err, service.ErrAttachmentTooLarge), errors.Is(err, service.ErrAttachmentUnsupported):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Failed to add attachment", err.Error())
+		case errors.Is(err, service.ErrStorageQuotaExceeded):
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Failed to add attachment", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add attachment", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Attachment added", attachment.ToResponse())
+}
+
+// GetAttachment godoc
+// @Summary Download an attachment of an attendance record
+// @Description Stream the attached file; only the record's owner or an admin may read it
+// @Tags attendance
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path int true "Attendance ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/attendance/{id}/attachments/{attachmentId} [get]
+func (ctrl *AttendanceController) GetAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attendance ID", err.Error())
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attachment ID", err.Error())
+		return
+	}
+
+	userID := c.GetUint("userID")
+	isAdmin := c.GetString("userRole") == "admin"
+	attachment, content, err := ctrl.attendanceService.OpenAttachment(uint(id), uint(attachmentID), userID, isAdmin)
+	if err != nil {
+		switch {
+		case err.Error() == "attendance not found", err.Error() == "attachment not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "Attachment not found", err.Error())
+		case errors.Is(err, service.ErrAttachmentHidden):
+			utils.ErrorResponse(c, http.StatusForbidden, "Failed to get attachment", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get attachment", err.Error())
+		}
+		return
+	}
+	defer content.Close()
+
+	// The stored content type was detected from the file; keep browsers from re-sniffing it
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    "inline",
+		"X-Content-Type-Options": "nosniff",
+	})
 }
 
 // GetTodayAttendance godoc
@@ -86,7 +324,13 @@ func (ctrl *AttendanceController) GetTodayAttendance(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Today's attendance retrieved", attendance.ToResponse())
+	response, err := ctrl.toResponse(attendance)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Today's attendance retrieved", response)
 }
 
 // GetAttendanceStatus godoc
@@ -107,11 +351,31 @@ func (ctrl *AttendanceController) GetAttendanceStatus(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Status retrieved", status)
 }
 
+// PreviewStatus godoc
+// @Summary Preview the status a check-in now would get
+// @Description Based on the active schedule and the current time; nothing is recorded
+// @Tags attendance
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/v1/attendance/preview-status [get]
+func (ctrl *AttendanceController) PreviewStatus(c *gin.Context) {
+	userID := c.GetUint("userID")
+	preview, err := ctrl.attendanceService.PreviewCheckInStatus(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to preview status", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Status preview retrieved", preview)
+}
+
 // GetAttendanceHistory godoc
 // @Summary Get attendance history
 // @Tags attendance
 // @Produce json
 // @Security BearerAuth
+// @Param status query string false "Filter by status"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
 // @Success 200 {object} utils.Response
@@ -127,19 +391,29 @@ func (ctrl *AttendanceController) GetAttendanceHistory(c *gin.Context) {
 		limit = 10
 	}
 
+	// Build filters
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" {
+		if !model.IsValidAttendanceStatus(status) {
+			utils.ValidationErrorResponse(c, "status must be one of "+strings.Join(model.AttendanceStatuses, ", "))
+			return
+		}
+		filters["status"] = status
+	}
+
 	offset := (page - 1) * limit
 	userID := c.GetUint("userID")
 
-	attendances, total, err := ctrl.attendanceService.GetUserAttendanceHistory(userID, limit, offset)
+	attendances, total, err := ctrl.attendanceService.GetUserAttendanceHistory(userID, filters, limit, offset)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get history", err.Error())
 		return
 	}
 
-	// Convert to responses
-	responses := make([]interface{}, len(attendances))
-	for i, att := range attendances {
-		responses[i] = att.ToResponse()
+	responses, err := ctrl.toResponses(attendances)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "History retrieved", gin.H{
@@ -147,71 +421,1377 @@ func (ctrl *AttendanceController) GetAttendanceHistory(c *gin.Context) {
 		"total":      total,
 		"page":       page,
 		"limit":      limit,
-		"total_page": (int(total) + limit - 1) / limit,
+		"total_page": utils.TotalPages(total, limit),
 	})
 }
 
-// GetAllAttendances godoc
-// @Summary Get all attendances (Admin)
+// GetMonthlyCalendar godoc
+// @Summary Get per-day attendance statuses for a month
+// @Tags attendance
+// @Produce json
+// @Security BearerAuth
+// @Param year query int false "Year" default(current year)
+// @Param month query int false "Month (1-12)" default(current month)
+// @Success 200 {object} utils.Response
+// @Router /api/v1/attendance/calendar [get]
+func (ctrl *AttendanceController) GetMonthlyCalendar(c *gin.Context) {
+	year, month, ok := parseYearMonth(c, ctrl.clock.Now())
+	if !ok {
+		return
+	}
+
+	userID := c.GetUint("userID")
+	calendar, err := ctrl.attendanceService.GetMonthlyCalendar(userID, year, time.Month(month))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get calendar", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Calendar retrieved", gin.H{
+		"year":  year,
+		"month": month,
+		"days":  calendar,
+	})
+}
+
+// parseYearMonth reads the year and month query parameters, defaulting to the month of
+// now. It writes a validation error and returns false when either is invalid.
+func parseYearMonth(c *gin.Context, now time.Time) (int, int, bool) {
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(now.Year())))
+	if err != nil || year < 1 {
+		utils.ValidationErrorResponse(c, "invalid year")
+		return 0, 0, false
+	}
+	month, err := strconv.Atoi(c.DefaultQuery("month", strconv.Itoa(int(now.Month()))))
+	if err != nil || month < 1 || month > 12 {
+		utils.ValidationErrorResponse(c, "invalid month")
+		return 0, 0, false
+	}
+	return year, month, true
+}
+
+// GetWeeklySummary godoc
+// @Summary Get status counts and worked hours for an ISO week
+// @Tags attendance
+// @Produce json
+// @Security BearerAuth
+// @Param week_start query string false "Any date in the week (YYYY-MM-DD)" default(current week)
+// @Success 200 {object} utils.Response
+// @Router /api/v1/attendance/summary/weekly [get]
+func (ctrl *AttendanceController) GetWeeklySummary(c *gin.Context) {
+	weekStart := ctrl.clock.Now()
+	if value := c.Query("week_start"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "week_start must be in YYYY-MM-DD format")
+			return
+		}
+		weekStart = parsed
+	}
+
+	userID := c.GetUint("userID")
+	summary, err := ctrl.attendanceService.GetWeeklySummary(userID, weekStart)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get weekly summary", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Weekly summary retrieved", summary)
+}
+
+// GetMyAttendanceStats godoc
+// @Summary Get the current user's attendance streaks and month counts
+// @Description Served from the stats cache, which is refreshed once a day or by an admin recompute.
+// @Tags attendance
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/v1/attendance/stats [get]
+func (ctrl *AttendanceController) GetMyAttendanceStats(c *gin.Context) {
+	stats, err := ctrl.attendanceService.GetUserAttendanceStats(c.GetUint("userID"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get stats", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stats retrieved", stats)
+}
+
+// GetUserAttendanceByDate godoc
+// @Summary Get a user's attendance on a specific date (Admin)
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
-// @Param user_id query int false "Filter by user ID"
-// @Param location_id query int false "Filter by location ID"
+// @Param id path int true "User ID"
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/users/:id/attendance [get]
+func (ctrl *AttendanceController) GetUserAttendanceByDate(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", c.Query("date"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "date is required in YYYY-MM-DD format")
+		return
+	}
+
+	attendances, err := ctrl.attendanceService.GetUserAttendanceByDate(uint(userID), date)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get attendance", err.Error())
+		return
+	}
+
+	responses, err := ctrl.toAdminResponses(attendances)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attendance retrieved", responses)
+}
+
+// GetUserAttendanceHistory godoc
+// @Summary Get a user's attendance history (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
 // @Param status query string false "Filter by status"
 // @Param date_from query string false "Filter from date (YYYY-MM-DD)"
 // @Param date_to query string false "Filter to date (YYYY-MM-DD)"
 // @Param page query int false "Page number" default(1)
-// @Param limit query int false "Items per page" default(20)
+// @Param limit query int false "Items per page" default(10)
 // @Success 200 {object} utils.Response
-// @Router /api/v1/admin/attendances [get]
-func (ctrl *AttendanceController) GetAllAttendances(c *gin.Context) {
+// @Router /api/v1/admin/users/:id/attendance-history [get]
+func (ctrl *AttendanceController) GetUserAttendanceHistory(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
 	if page < 1 {
 		page = 1
 	}
 	if limit < 1 || limit > 100 {
-		limit = 20
+		limit = 10
 	}
 
 	// Build filters
 	filters := make(map[string]interface{})
-	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
-		filters["user_id"] = uint(userID)
-	}
-	if locationID, err := strconv.ParseUint(c.Query("location_id"), 10, 32); err == nil {
-		filters["location_id"] = uint(locationID)
-	}
 	if status := c.Query("status"); status != "" {
+		if !model.IsValidAttendanceStatus(status) {
+			utils.ValidationErrorResponse(c, "status must be one of "+strings.Join(model.AttendanceStatuses, ", "))
+			return
+		}
 		filters["status"] = status
 	}
-	if dateFrom := c.Query("date_from"); dateFrom != "" {
-		filters["date_from"] = dateFrom
-	}
-	if dateTo := c.Query("date_to"); dateTo != "" {
-		filters["date_to"] = dateTo
+	for _, key := range []string{"date_from", "date_to"} {
+		if value := c.Query(key); value != "" {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				utils.ValidationErrorResponse(c, key+" must be in YYYY-MM-DD format")
+				return
+			}
+			filters[key] = value
+		}
 	}
 
 	offset := (page - 1) * limit
-	attendances, total, err := ctrl.attendanceService.GetAllAttendances(filters, limit, offset)
+	attendances, total, err := ctrl.attendanceService.GetUserAttendanceHistoryForAdmin(uint(userID), filters, limit, offset)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get attendances", err.Error())
+		if err.Error() == "user not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get history", err.Error())
 		return
 	}
 
-	// Convert to responses
-	responses := make([]interface{}, len(attendances))
-	for i, att := range attendances {
-		responses[i] = att.ToResponse()
+	responses, err := ctrl.toAdminResponses(attendances)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Attendances retrieved", gin.H{
+	utils.SuccessResponse(c, http.StatusOK, "History retrieved", gin.H{
 		"data":       responses,
 		"total":      total,
 		"page":       page,
 		"limit":      limit,
-		"total_page": (int(total) + limit - 1) / limit,
+		"total_page": utils.TotalPages(total, limit),
 	})
 }
+
+// GetUserMonthlySummary godoc
+// @Summary Get a user's per-day statuses and totals for a month (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param year query int false "Year" default(current year)
+// @Param month query int false "Month (1-12)" default(current month)
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/users/:id/summary [get]
+func (ctrl *AttendanceController) GetUserMonthlySummary(c *gin.Context) {
+	summary, ok := ctrl.loadMonthlySummary(c)
+	if !ok {
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Monthly summary retrieved", summary)
+}
+
+// GetUserMonthlySummaryPDF godoc
+// @Summary Download a user's monthly attendance sheet as PDF (Admin)
+// @Tags admin
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param year query int false "Year" default(current year)
+// @Param month query int false "Month (1-12)" default(current month)
+// @Success 200 {file} file
+// @Router /api/v1/admin/users/:id/summary/pdf [get]
+func (ctrl *AttendanceController) GetUserMonthlySummaryPDF(c *gin.Context) {
+	summary, ok := ctrl.loadMonthlySummary(c)
+	if !ok {
+		return
+	}
+
+	document, err := ctrl.summaryRenderer.RenderMonthlySummary(summary)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to render monthly summary", err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("attendance-%d-%04d-%02d.pdf", summary.UserID, summary.Year, summary.Month)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/pdf", document)
+}
+
+// loadMonthlySummary parses the user ID and month from the request and fetches the
+// summary, writing the error response and returning false on failure
+func (ctrl *AttendanceController) loadMonthlySummary(c *gin.Context) (*service.MonthlySummary, bool) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return nil, false
+	}
+
+	year, month, ok := parseYearMonth(c, ctrl.clock.Now())
+	if !ok {
+		return nil, false
+	}
+
+	summary, err := ctrl.attendanceService.GetMonthlySummary(uint(userID), year, time.Month(month))
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+			return nil, false
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get monthly summary", err.Error())
+		return nil, false
+	}
+
+	return summary, true
+}
+
+// GetUserCompliance godoc
+// @Summary Get the share of a user's scheduled days they were on time (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param date_from query string true "From date (YYYY-MM-DD)"
+// @Param date_to query string true "To date (YYYY-MM-DD), inclusive"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/users/:id/compliance [get]
+func (ctrl *AttendanceController) GetUserCompliance(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", c.Query("date_from"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "date_from is required in YYYY-MM-DD format")
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("date_to"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "date_to is required in YYYY-MM-DD format")
+		return
+	}
+	if to.Before(from) {
+		utils.ValidationErrorResponse(c, "date_to must not be before date_from")
+		return
+	}
+
+	compliance, err := ctrl.attendanceService.GetComplianceRate(uint(userID), from, to)
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get compliance rate", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Compliance rate retrieved", compliance)
+}
+
+// GetUserPoints godoc
+// @Summary Get the lateness points a user accumulated in a period (Admin)
+// @Description Points per status come from ATTENDANCE_STATUS_POINTS.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param from query string true "From date (YYYY-MM-DD)"
+// @Param to query string true "To date (YYYY-MM-DD), inclusive, at most 366 days after from"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/users/:id/points [get]
+func (ctrl *AttendanceController) GetUserPoints(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "from is required in YYYY-MM-DD format")
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "to is required in YYYY-MM-DD format")
+		return
+	}
+
+	points, err := ctrl.attendanceService.GetUserPoints(uint(userID), from, to)
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		if err.Error() == "user not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get points", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Points retrieved", points)
+}
+
+// GetUserStorageUsage godoc
+// @Summary Get the attachment storage used by a user's records (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/users/:id/storage [get]
+func (ctrl *AttendanceController) GetUserStorageUsage(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	usage, err := ctrl.attendanceService.GetStorageUsage(uint(userID))
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get storage usage", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Storage usage retrieved", usage)
+}
+
+// GetUserAttendanceStats godoc
+// @Summary Get a user's cached attendance stats (Admin)
+// @Description Served from the stats cache, which is refreshed once a day; use recompute-stats after editing records.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/users/:id/attendance-stats [get]
+func (ctrl *AttendanceController) GetUserAttendanceStats(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	stats, err := ctrl.attendanceService.GetUserAttendanceStats(uint(userID))
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get stats", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stats retrieved", stats)
+}
+
+// RecomputeUserStats godoc
+// @Summary Rebuild a user's cached attendance stats from their records (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/users/:id/recompute-stats [post]
+func (ctrl *AttendanceController) RecomputeUserStats(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	stats, err := ctrl.attendanceService.RecomputeUserStats(uint(userID))
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to recompute stats", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stats recomputed", stats)
+}
+
+// GetRecentCheckIns godoc
+// @Summary A user's recent check-ins with distances, for fraud review (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param n query int false "Number of check-ins (max 100)" default(20)
+// @Param threshold query number false "Flag check-ins farther than this many meters (defaults to each location's radius)"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/users/{id}/recent-checkins [get]
+func (ctrl *AttendanceController) GetRecentCheckIns(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	n, _ := strconv.Atoi(c.DefaultQuery("n", "20"))
+	if n < 1 || n > 100 {
+		n = 20
+	}
+
+	var threshold float64
+	if value := c.Query("threshold"); value != "" {
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(threshold) || math.IsInf(threshold, 0) || threshold < 0 {
+			utils.ValidationErrorResponse(c, "threshold must be a non-negative number of meters")
+			return
+		}
+	}
+
+	checkIns, err := ctrl.attendanceService.GetRecentCheckInsWithDistance(uint(userID), n, threshold)
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get recent check-ins", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Recent check-ins retrieved", checkIns)
+}
+
+// GetAllAttendances godoc
+// @Summary Get all attendances (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "Filter by user ID"
+// @Param location_id query int false "Filter by location ID"
+// @Param region_id query int false "Filter by the region of the record's location"
+// @Param schedule_id query int false "Filter by users assigned to this schedule on the record's date"
+// @Param status query string false "Filter by status"
+// @Param outside_radius query bool false "Filter by grace-band check-ins"
+// @Param clock_skew_suspicious query bool false "Filter by check-ins whose device clock was off"
+// @Param reviewed query bool false "Filter by review state"
+// @Param min_distance query number false "Only check-ins farther than this many meters from the location"
+// @Param date_from query string false "Filter from date (YYYY-MM-DD)"
+// @Param date_to query string false "Filter to date (YYYY-MM-DD)"
+// @Param format query string false "Response format: nested or flat" default(nested)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances [get]
+func (ctrl *AttendanceController) GetAllAttendances(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	format := c.DefaultQuery("format", "nested")
+	if format != "nested" && format != "flat" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid format", "format must be nested or flat")
+		return
+	}
+
+	// Build filters
+	filters := make(map[string]interface{})
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		filters["user_id"] = uint(userID)
+	}
+	if locationID, err := strconv.ParseUint(c.Query("location_id"), 10, 32); err == nil {
+		filters["location_id"] = uint(locationID)
+	}
+	if regionID, err := strconv.ParseUint(c.Query("region_id"), 10, 32); err == nil {
+		filters["region_id"] = uint(regionID)
+	}
+	if scheduleID, err := strconv.ParseUint(c.Query("schedule_id"), 10, 32); err == nil {
+		filters["schedule_id"] = uint(scheduleID)
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if outsideRadius, err := strconv.ParseBool(c.Query("outside_radius")); err == nil {
+		filters["outside_radius"] = outsideRadius
+	}
+	if clockSkew, err := strconv.ParseBool(c.Query("clock_skew_suspicious")); err == nil {
+		filters["clock_skew_suspicious"] = clockSkew
+	}
+	if reviewed, err := strconv.ParseBool(c.Query("reviewed")); err == nil {
+		filters["reviewed"] = reviewed
+	}
+	if value := c.Query("min_distance"); value != "" {
+		minDistance, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(minDistance) || math.IsInf(minDistance, 0) || minDistance < 0 {
+			utils.ValidationErrorResponse(c, "min_distance must be a non-negative number of meters")
+			return
+		}
+		filters["min_distance"] = minDistance
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		filters["date_from"] = dateFrom
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		filters["date_to"] = dateTo
+	}
+
+	offset := (page - 1) * limit
+	attendances, total, err := ctrl.attendanceService.GetAllAttendances(filters, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get attendances", err.Error())
+		return
+	}
+
+	// Convert to responses
+	var responses interface{}
+	if format == "flat" {
+		rows := make([]model.AttendanceFlatRow, len(attendances))
+		for i, att := range attendances {
+			rows[i] = att.ToFlatRow(ctrl.attendanceService.PayrollRounding())
+		}
+		responses = rows
+	} else {
+		converted, err := ctrl.toAdminResponses(attendances)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+			return
+		}
+		responses = converted
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attendances retrieved", gin.H{
+		"data":       responses,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// GetDashboard godoc
+// @Summary Get a day's check-in counts across all users (Admin)
+// @Description Suspicious counts the records with an anomaly that are not reviewed yet.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param date query string false "Date (YYYY-MM-DD)" default(today)
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/admin/attendances/dashboard [get]
+func (ctrl *AttendanceController) GetDashboard(c *gin.Context) {
+	date := ctrl.clock.Now()
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "date must be in YYYY-MM-DD format")
+			return
+		}
+		date = parsed
+	}
+
+	counts, err := ctrl.attendanceService.GetDashboardCounts(date)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get dashboard counts", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dashboard counts retrieved", counts)
+}
+
+// GetAnomalies godoc
+// @Summary Get records with any anomaly across all users (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param flag query string false "Only this anomaly: outside_radius, clock_skew or unscheduled"
+// @Param user_id query int false "Filter by user ID"
+// @Param include_reviewed query bool false "Include records already reviewed" default(false)
+// @Param date_from query string false "Filter from date (YYYY-MM-DD)"
+// @Param date_to query string false "Filter to date (YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/anomalies [get]
+func (ctrl *AttendanceController) GetAnomalies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	// Build filters
+	filters := make(map[string]interface{})
+	if flag := c.Query("flag"); flag != "" {
+		known := false
+		for _, f := range service.AnomalyFlags {
+			if f == flag {
+				known = true
+				break
+			}
+		}
+		if !known {
+			utils.ValidationErrorResponse(c, "flag must be one of: "+strings.Join(service.AnomalyFlags, ", "))
+			return
+		}
+		filters["flag"] = flag
+	}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		filters["user_id"] = uint(userID)
+	}
+	if includeReviewed, err := strconv.ParseBool(c.Query("include_reviewed")); err == nil {
+		filters["include_reviewed"] = includeReviewed
+	}
+	for _, key := range []string{"date_from", "date_to"} {
+		if value := c.Query(key); value != "" {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				utils.ValidationErrorResponse(c, key+" must be in YYYY-MM-DD format")
+				return
+			}
+			filters[key] = value
+		}
+	}
+
+	offset := (page - 1) * limit
+	anomalies, total, err := ctrl.attendanceService.GetAnomalies(filters, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get anomalies", err.Error())
+		return
+	}
+
+	// Convert to responses
+	attendances := make([]model.Attendance, len(anomalies))
+	for i, anomaly := range anomalies {
+		attendances[i] = anomaly.Attendance
+	}
+	attendanceResponses, err := ctrl.toAdminResponses(attendances)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+	responses := make([]interface{}, len(anomalies))
+	for i, anomaly := range anomalies {
+		responses[i] = gin.H{
+			"attendance": attendanceResponses[i],
+			"reasons":    anomaly.Reasons,
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Anomalies retrieved", gin.H{
+		"data":       responses,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// GetOpenAttendances godoc
+// @Summary Get attendances from previous days that were never checked out (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param location_id query int false "Filter by location ID"
+// @Param date_from query string false "Filter from date (YYYY-MM-DD)"
+// @Param date_to query string false "Filter to date (YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/open [get]
+func (ctrl *AttendanceController) GetOpenAttendances(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	// Build filters
+	filters := make(map[string]interface{})
+	if locationID, err := strconv.ParseUint(c.Query("location_id"), 10, 32); err == nil {
+		filters["location_id"] = uint(locationID)
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		filters["date_from"] = dateFrom
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		filters["date_to"] = dateTo
+	}
+
+	offset := (page - 1) * limit
+	attendances, total, err := ctrl.attendanceService.GetOpenAttendances(filters, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get open attendances", err.Error())
+		return
+	}
+
+	responses, err := ctrl.toAdminResponses(attendances)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Open attendances retrieved", gin.H{
+		"data":       responses,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// GetPresentNow godoc
+// @Summary List everyone currently checked in, for roll-calls (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param location_id query int false "Filter by location ID"
+// @Param department_id query int false "Filter by department ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/present-now [get]
+func (ctrl *AttendanceController) GetPresentNow(c *gin.Context) {
+	filters := make(map[string]interface{})
+	if locationID, err := strconv.ParseUint(c.Query("location_id"), 10, 32); err == nil {
+		filters["location_id"] = uint(locationID)
+	}
+	if departmentID, err := strconv.ParseUint(c.Query("department_id"), 10, 32); err == nil {
+		filters["department_id"] = uint(departmentID)
+	}
+
+	present, err := ctrl.attendanceService.GetCurrentlyCheckedIn(filters)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get present users", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Present users retrieved", gin.H{
+		"data":  present,
+		"total": len(present),
+	})
+}
+
+// GetAttendanceDetail godoc
+// @Summary Get attendance detail with previous/next record of the same user (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Attendance ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/attendances/:id [get]
+func (ctrl *AttendanceController) GetAttendanceDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attendance ID", err.Error())
+		return
+	}
+
+	attendance, previousID, nextID, err := ctrl.attendanceService.GetAttendanceWithNeighbors(uint(id))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "attendance not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to get attendance", err.Error())
+		return
+	}
+
+	response, err := ctrl.toAdminResponse(attendance)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attendance retrieved", gin.H{
+		"attendance":  response,
+		"previous_id": previousID,
+		"next_id":     nextID,
+	})
+}
+
+// ReviewAttendance godoc
+// @Summary Confirm or clear the flag on an attendance record (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Attendance ID"
+// @Param request body service.ReviewAttendanceRequest true "Review request"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/:id/review [patch]
+func (ctrl *AttendanceController) ReviewAttendance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attendance ID", err.Error())
+		return
+	}
+
+	var req service.ReviewAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	reviewerID := c.GetUint("userID")
+	attendance, err := ctrl.attendanceService.ReviewAttendance(uint(id), reviewerID, &req)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if err.Error() == "attendance not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to review attendance", err.Error())
+		return
+	}
+
+	response, err := ctrl.toAdminResponse(attendance)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attendance reviewed successfully", response)
+}
+
+// UpdateAdminNotes godoc
+// @Summary Set the admin-only notes of an attendance record (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Attendance ID"
+// @Param request body service.UpdateAdminNotesRequest true "Admin notes"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/:id/admin-notes [patch]
+func (ctrl *AttendanceController) UpdateAdminNotes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attendance ID", err.Error())
+		return
+	}
+
+	var req service.UpdateAdminNotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	actorID := c.GetUint("userID")
+	attendance, err := ctrl.attendanceService.UpdateAdminNotes(uint(id), actorID, &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "attendance not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to update admin notes", err.Error())
+		return
+	}
+
+	response, err := ctrl.toAdminResponse(attendance)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Admin notes updated successfully", response)
+}
+
+// DeleteAttendance godoc
+// @Summary Move an attendance record to the trash (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Attendance ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/:id [delete]
+func (ctrl *AttendanceController) DeleteAttendance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attendance ID", err.Error())
+		return
+	}
+
+	actorID := c.GetUint("userID")
+	if err := ctrl.attendanceService.DeleteAttendance(uint(id), actorID); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "attendance not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to delete attendance", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attendance moved to trash", nil)
+}
+
+// GetTrashedAttendances godoc
+// @Summary Get deleted attendance records that can still be restored (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/trash [get]
+func (ctrl *AttendanceController) GetTrashedAttendances(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+	attendances, total, err := ctrl.attendanceService.GetTrashedAttendances(limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get trashed attendances", err.Error())
+		return
+	}
+
+	responses, err := ctrl.toAdminResponses(attendances)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Trashed attendances retrieved", gin.H{
+		"data":       responses,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// RestoreAttendance godoc
+// @Summary Restore an attendance record from the trash (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Attendance ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/:id/restore [post]
+func (ctrl *AttendanceController) RestoreAttendance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attendance ID", err.Error())
+		return
+	}
+
+	actorID := c.GetUint("userID")
+	attendance, err := ctrl.attendanceService.RestoreAttendance(uint(id), actorID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "attendance not found in trash":
+			statusCode = http.StatusNotFound
+		case "user already has attendance on that day":
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to restore attendance", err.Error())
+		return
+	}
+
+	response, err := ctrl.toAdminResponse(attendance)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attendance restored successfully", response)
+}
+
+// RecalculateStatuses godoc
+// @Summary Recalculate attendance statuses under a schedule (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param request body service.RecalculateStatusesRequest true "Date range"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/schedules/:id/recalculate-statuses [post]
+func (ctrl *AttendanceController) RecalculateStatuses(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err.Error())
+		return
+	}
+
+	var req service.RecalculateStatusesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", req.DateFrom)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "date_from must be in YYYY-MM-DD format")
+		return
+	}
+	to, err := time.Parse("2006-01-02", req.DateTo)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "date_to must be in YYYY-MM-DD format")
+		return
+	}
+	if to.Before(from) {
+		utils.ValidationErrorResponse(c, "date_to must not be before date_from")
+		return
+	}
+
+	actorID := c.GetUint("userID")
+	changed, err := ctrl.attendanceService.RecalculateStatuses(uint(id), from, to, actorID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "schedule not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to recalculate statuses", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Statuses recalculated", gin.H{
+		"changed": changed,
+	})
+}
+
+// BulkUpdateStatus godoc
+// @Summary Set the status of many attendance records at once (Admin)
+// @Description Records are selected by ids or by filter, never both. Every changed record gets its own audit entry.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.BulkStatusRequest true "Records and target status"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/admin/attendances/bulk-status [post]
+func (ctrl *AttendanceController) BulkUpdateStatus(c *gin.Context) {
+	var req service.BulkStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	actorID := c.GetUint("userID")
+	changed, err := ctrl.attendanceService.BulkUpdateStatus(&req, actorID)
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update statuses", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Statuses updated", gin.H{
+		"changed": changed,
+	})
+}
+
+// CloseAllAtLocation godoc
+// @Summary Check out everyone still checked in today at a location (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Location ID"
+// @Param request body service.CloseAllRequest false "Check-out time, defaults to now"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/locations/:id/close-all [post]
+func (ctrl *AttendanceController) CloseAllAtLocation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid location ID", err.Error())
+		return
+	}
+
+	var req service.CloseAllRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+	}
+
+	actorID := c.GetUint("userID")
+	closed, err := ctrl.attendanceService.CloseAllAtLocation(uint(id), req.CheckOutTime, actorID)
+	if err != nil {
+		var fieldErr *service.FieldError
+		switch {
+		case errors.As(err, &fieldErr):
+			utils.ValidationErrorResponse(c, fieldErr)
+		case errors.Is(err, service.ErrLocationNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "Location not found", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to close attendances", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attendances closed", gin.H{
+		"closed": closed,
+	})
+}
+
+// GetDurationHistogram godoc
+// @Summary Get the distribution of work durations at a location (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Location ID"
+// @Param date_from query string false "Filter from date (YYYY-MM-DD)"
+// @Param date_to query string false "Filter to date (YYYY-MM-DD)"
+// @Param bucket_minutes query int false "Bucket size in minutes (5-720)" default(30)
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/locations/{id}/duration-histogram [get]
+func (ctrl *AttendanceController) GetDurationHistogram(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid location ID")
+		return
+	}
+
+	dateFrom := c.Query("date_from")
+	dateTo := c.Query("date_to")
+	for _, date := range []string{dateFrom, dateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			utils.ValidationErrorResponse(c, "dates must be in YYYY-MM-DD format")
+			return
+		}
+	}
+
+	bucketMinutes, err := strconv.Atoi(c.DefaultQuery("bucket_minutes", "30"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "bucket_minutes must be a number")
+		return
+	}
+
+	buckets, err := ctrl.attendanceService.GetDurationHistogram(uint(id), dateFrom, dateTo, bucketMinutes)
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		if err.Error() == "location not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "Location not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get duration histogram", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Duration histogram retrieved", buckets)
+}
+
+// GetDailyCounts godoc
+// @Summary Get check-in counts per day for a heatmap (Admin)
+// @Description Every day in the range is present, days without check-ins count zero.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param date_from query string true "From date (YYYY-MM-DD)"
+// @Param date_to query string true "To date (YYYY-MM-DD), inclusive, at most 366 days after date_from"
+// @Param location_id query int false "Filter by location ID"
+// @Param department_id query int false "Filter by department ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/admin/attendances/daily-counts [get]
+func (ctrl *AttendanceController) GetDailyCounts(c *gin.Context) {
+	from, err := time.Parse("2006-01-02", c.Query("date_from"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "date_from is required in YYYY-MM-DD format")
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("date_to"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "date_to is required in YYYY-MM-DD format")
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if locationID, err := strconv.ParseUint(c.Query("location_id"), 10, 32); err == nil {
+		filters["location_id"] = uint(locationID)
+	}
+	if departmentID, err := strconv.ParseUint(c.Query("department_id"), 10, 32); err == nil {
+		filters["department_id"] = uint(departmentID)
+	}
+
+	counts, err := ctrl.attendanceService.GetDailyCounts(from, to, filters)
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get daily counts", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Daily counts retrieved", counts)
+}
+
+// GetOrgAttendanceTrend godoc
+// @Summary Get the organization-wide attendance rate per day, week or month (Admin)
+// @Description The rate is the share of active users' scheduled work days with a record that is not absent. Holidays at the assigned location and days on leave are not scheduled.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param from query string true "From date (YYYY-MM-DD)"
+// @Param to query string true "To date (YYYY-MM-DD), inclusive"
+// @Param granularity query string false "Bucket size: day, week or month" default(week)
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/admin/attendances/trend [get]
+func (ctrl *AttendanceController) GetOrgAttendanceTrend(c *gin.Context) {
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "from is required in YYYY-MM-DD format")
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "to is required in YYYY-MM-DD format")
+		return
+	}
+
+	trend, err := ctrl.attendanceService.GetOrgAttendanceTrend(from, to, c.DefaultQuery("granularity", "week"))
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get attendance trend", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attendance trend retrieved", trend)
+}
+
+// GetCountsByLocation godoc
+// @Summary Get check-in totals grouped by location (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param date_from query string false "Filter from date (YYYY-MM-DD)"
+// @Param date_to query string false "Filter to date (YYYY-MM-DD)"
+// @Param region_id query int false "Only locations in this region"
+// @Param include_empty query bool false "Include locations without check-ins"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/attendances/by-location [get]
+func (ctrl *AttendanceController) GetCountsByLocation(c *gin.Context) {
+	dateFrom := c.Query("date_from")
+	dateTo := c.Query("date_to")
+	for _, date := range []string{dateFrom, dateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			utils.ValidationErrorResponse(c, "dates must be in YYYY-MM-DD format")
+			return
+		}
+	}
+	var regionID uint
+	if id, err := strconv.ParseUint(c.Query("region_id"), 10, 32); err == nil {
+		regionID = uint(id)
+	}
+	includeEmpty, _ := strconv.ParseBool(c.Query("include_empty"))
+
+	counts, err := ctrl.attendanceService.GetCountsByLocation(dateFrom, dateTo, regionID, includeEmpty)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get location totals", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Location totals retrieved", counts)
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseYearMonth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 2, 27, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantYear  int
+		wantMonth int
+		wantOK    bool
+	}{
+		{"defaults to the clock's month", "", 2026, 2, true},
+		{"explicit month", "?year=2025&month=12", 2025, 12, true},
+		{"year only", "?year=2024", 2024, 2, true},
+		{"month out of range", "?month=13", 0, 0, false},
+		{"invalid year", "?year=abc", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/calendar"+tt.query, nil)
+
+			year, month, ok := parseYearMonth(c, now)
+			if year != tt.wantYear || month != tt.wantMonth || ok != tt.wantOK {
+				t.Errorf("parseYearMonth() = %d, %d, %v; want %d, %d, %v", year, month, ok, tt.wantYear, tt.wantMonth, tt.wantOK)
+			}
+			if !ok && w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestGetUserAttendanceByDateValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Rejected before the service is reached
+	router.GET("/api/v1/admin/users/:id/attendance", NewAttendanceController(nil, nil, testClock).GetUserAttendanceByDate)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"invalid user id", "/api/v1/admin/users/abc/attendance?date=2026-03-02"},
+		{"missing date", "/api/v1/admin/users/7/attendance"},
+		{"malformed date", "/api/v1/admin/users/7/attendance?date=02-03-2026"},
+		{"impossible date", "/api/v1/admin/users/7/attendance?date=2026-02-30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestGetAllAttendancesRejectsUnknownFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Rejected before the service is reached
+	router.GET("/api/v1/admin/attendances", NewAttendanceController(nil, nil, testClock).GetAllAttendances)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/attendances?format=csv", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGetAttendanceHistoryRejectsUnknownStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Rejected before the service is reached
+	router.GET("/api/v1/attendance/history", NewAttendanceController(nil, nil, testClock).GetAttendanceHistory)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attendance/history?status=tardy", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGetAllAttendancesRejectsInvalidMinDistance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Rejected before the service is reached
+	router.GET("/api/v1/admin/attendances", NewAttendanceController(nil, nil, testClock).GetAllAttendances)
+
+	for _, value := range []string{"-1", "far", "NaN", "Inf", "+Inf", "-Inf", "1e400"} {
+		t.Run(value, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/attendances?min_distance="+value, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestGetRecentCheckInsRejectsInvalidThreshold(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Rejected before the service is reached
+	router.GET("/api/v1/admin/users/:id/recent-checkins", NewAttendanceController(nil, nil, testClock).GetRecentCheckIns)
+
+	for _, value := range []string{"-1", "far", "NaN", "Inf", "+Inf", "-Inf", "1e400"} {
+		t.Run(value, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/users/7/recent-checkins?threshold="+value, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestGetUserAttendanceHistoryValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Rejected before the service is reached
+	router.GET("/api/v1/admin/users/:id/attendance-history", NewAttendanceController(nil, nil, testClock).GetUserAttendanceHistory)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"invalid user id", "/api/v1/admin/users/abc/attendance-history"},
+		{"unknown status", "/api/v1/admin/users/7/attendance-history?status=tardy"},
+		{"malformed date_from", "/api/v1/admin/users/7/attendance-history?date_from=01-03-2026"},
+		{"malformed date_to", "/api/v1/admin/users/7/attendance-history?date_to=2026-3-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/attendance/backend/internal/service"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	auditService *service.AuditService
+}
+
+func NewAuditController(auditService *service.AuditService) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+	}
+}
+
+// GetAuditLogs godoc
+// @Summary Search audit logs (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param entity_type query string false "Filter by entity type, e.g. user"
+// @Param entity_id query int false "Filter by entity ID"
+// @Param actor_id query int false "Filter by the user who performed the action"
+// @Param action query string false "Filter by action, e.g. user.deactivated"
+// @Param date_from query string false "Filter from date (YYYY-MM-DD)"
+// @Param date_to query string false "Filter to date (YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/admin/audit-logs [get]
+func (ctrl *AuditController) GetAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	// Build filters
+	filters := make(map[string]interface{})
+	if entityType := c.Query("entity_type"); entityType != "" {
+		filters["entity_type"] = entityType
+	}
+	if entityID, err := strconv.ParseUint(c.Query("entity_id"), 10, 32); err == nil {
+		filters["entity_id"] = uint(entityID)
+	}
+	if actorID, err := strconv.ParseUint(c.Query("actor_id"), 10, 32); err == nil {
+		filters["actor_id"] = uint(actorID)
+	}
+	if action := c.Query("action"); action != "" {
+		filters["action"] = action
+	}
+	for _, key := range []string{"date_from", "date_to"} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			utils.ValidationErrorResponse(c, key+" must be a date in YYYY-MM-DD format")
+			return
+		}
+		filters[key] = date
+	}
+
+	offset := (page - 1) * limit
+	logs, total, err := ctrl.auditService.GetAuditLogs(filters, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get audit logs", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Audit logs retrieved", gin.H{
+		"data":       logs,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetAuditLogsRejectsInvalidDate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Rejected before the service is reached
+	router.GET("/api/v1/admin/audit-logs", NewAuditController(nil).GetAuditLogs)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"date_from not a date", "date_from=yesterday"},
+		{"date_to in another format", "date_from=2026-03-01&date_to=09/03/2026"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit-logs?"+tt.query, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/attendance/backend/internal/service"
 	"github.com/attendance/backend/internal/utils"
@@ -37,12 +38,29 @@ func (ctrl *AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	response, err := ctrl.authService.Register(&req)
+	response, err := ctrl.authService.Register(&req, utils.DeviceFingerprint(c.Request))
 	if err != nil {
 		if errors.Is(err, service.ErrEmailAlreadyExists) {
 			utils.ErrorResponse(c, http.StatusConflict, "Email already exists", err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrPhoneAlreadyExists) {
+			utils.ErrorResponse(c, http.StatusConflict, "Phone already exists", err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrEmployeeIDExists) {
+			utils.ErrorResponse(c, http.StatusConflict, "Employee ID already exists", err.Error())
+			return
+		}
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		if errors.Is(err, service.ErrRegistrationClosed) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Registration is closed", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to register user", err.Error())
 		return
 	}
@@ -50,6 +68,38 @@ func (ctrl *AuthController) Register(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, "User registered successfully", response)
 }
 
+// CheckEmail godoc
+// @Summary Check whether an email is available for registration
+// @Tags auth
+// @Produce json
+// @Param email query string true "Email address"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/auth/check-email [get]
+func (ctrl *AuthController) CheckEmail(c *gin.Context) {
+	var req struct {
+		Email string `form:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	available, err := ctrl.authService.IsEmailAvailable(req.Email)
+	if err != nil {
+		if errors.Is(err, service.ErrRegistrationClosed) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Registration is closed", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check email", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email checked", gin.H{
+		"available": available,
+	})
+}
+
 // Login godoc
 // @Summary Login user
 // @Tags auth
@@ -66,8 +116,16 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := ctrl.authService.Login(&req)
+	response, err := ctrl.authService.Login(&req, utils.DeviceFingerprint(c.Request))
 	if err != nil {
+		if errors.Is(err, service.ErrIdentifierRequired) {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrAmbiguousIdentifier) {
+			utils.ErrorResponse(c, http.StatusConflict, "Ambiguous identifier", err.Error())
+			return
+		}
 		if errors.Is(err, service.ErrInvalidCredentials) {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials", err.Error())
 			return
@@ -110,12 +168,16 @@ func (ctrl *AuthController) RefreshToken(c *gin.Context) {
 	refreshToken := tokenParts[1]
 
 	// Generate new tokens
-	tokens, err := ctrl.authService.RefreshToken(refreshToken)
+	tokens, err := ctrl.authService.RefreshToken(refreshToken, utils.DeviceFingerprint(c.Request))
 	if err != nil {
-		if errors.Is(err, jwtPkg.ErrInvalidToken) || errors.Is(err, jwtPkg.ErrExpiredToken) {
+		if errors.Is(err, jwtPkg.ErrInvalidToken) || errors.Is(err, jwtPkg.ErrExpiredToken) || errors.Is(err, service.ErrTokenRevoked) || errors.Is(err, service.ErrDeviceMismatch) {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired token", err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrSessionExpired) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Session expired", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to refresh token", err.Error())
 		return
 	}
@@ -139,13 +201,19 @@ func (ctrl *AuthController) GetMe(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.authService.GetUserByID(userID.(uint))
+	var tokenExpiresAt *time.Time
+	if expiresAt, ok := c.Get("tokenExpiresAt"); ok {
+		t := expiresAt.(time.Time)
+		tokenExpiresAt = &t
+	}
+
+	me, err := ctrl.authService.GetMe(userID.(uint), tokenExpiresAt)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "User info retrieved", user.ToResponse())
+	utils.SuccessResponse(c, http.StatusOK, "User info retrieved", me)
 }
 
 // Logout godoc
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/attendance/backend/internal/service"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type DepartmentController struct {
+	departmentService *service.DepartmentService
+}
+
+func NewDepartmentController(departmentService *service.DepartmentService) *DepartmentController {
+	return &DepartmentController{
+		departmentService: departmentService,
+	}
+}
+
+// CreateDepartment godoc
+// @Summary Create department (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateDepartmentRequest true "Create department request"
+// @Success 201 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /api/v1/admin/departments [post]
+func (ctrl *DepartmentController) CreateDepartment(c *gin.Context) {
+	var req service.CreateDepartmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	department, err := ctrl.departmentService.CreateDepartment(&req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "department already exists" {
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to create department", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Department created successfully", department)
+}
+
+// GetAllDepartments godoc
+// @Summary Get all departments (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/departments [get]
+func (ctrl *DepartmentController) GetAllDepartments(c *gin.Context) {
+	departments, err := ctrl.departmentService.GetAllDepartments()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get departments", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Departments retrieved", departments)
+}
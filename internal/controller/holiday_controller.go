@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/attendance/backend/internal/service"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type HolidayController struct {
+	holidayService *service.HolidayService
+}
+
+func NewHolidayController(holidayService *service.HolidayService) *HolidayController {
+	return &HolidayController{
+		holidayService: holidayService,
+	}
+}
+
+// CreateHoliday godoc
+// @Summary Create holiday (Admin)
+// @Description A holiday without location_id applies to every location
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateHolidayRequest true "Create holiday request"
+// @Success 201 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /api/v1/admin/holidays [post]
+func (ctrl *HolidayController) CreateHoliday(c *gin.Context) {
+	var req service.CreateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	holiday, err := ctrl.holidayService.CreateHoliday(&req)
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "holiday already exists" {
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to create holiday", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Holiday created successfully", holiday)
+}
+
+// GetHolidays godoc
+// @Summary Get holidays (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param year query int false "Only holidays in this year"
+// @Param location_id query int false "Only holidays observed at this location"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/holidays [get]
+func (ctrl *HolidayController) GetHolidays(c *gin.Context) {
+	year := 0
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			utils.ValidationErrorResponse(c, "invalid year")
+			return
+		}
+		year = parsed
+	}
+
+	var locationID *uint
+	if value := c.Query("location_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "invalid location_id")
+			return
+		}
+		id := uint(parsed)
+		locationID = &id
+	}
+
+	holidays, err := ctrl.holidayService.GetHolidays(year, locationID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get holidays", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Holidays retrieved", holidays)
+}
+
+// DeleteHoliday godoc
+// @Summary Delete holiday (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Holiday ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/holidays/:id [delete]
+func (ctrl *HolidayController) DeleteHoliday(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid holiday ID", err.Error())
+		return
+	}
+
+	if err := ctrl.holidayService.DeleteHoliday(uint(id)); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "holiday not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to delete holiday", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Holiday deleted successfully", nil)
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/attendance/backend/internal/service"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type LeaveController struct {
+	leaveService *service.LeaveService
+}
+
+func NewLeaveController(leaveService *service.LeaveService) *LeaveController {
+	return &LeaveController{
+		leaveService: leaveService,
+	}
+}
+
+// CreateLeave godoc
+// @Summary Grant a user leave (Admin)
+// @Description Days on leave are neither absences nor scheduled days in reports
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateLeaveRequest true "Create leave request"
+// @Success 201 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /api/v1/admin/leaves [post]
+func (ctrl *LeaveController) CreateLeave(c *gin.Context) {
+	var req service.CreateLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	leave, err := ctrl.leaveService.CreateLeave(&req, c.GetUint("userID"))
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			statusCode = http.StatusNotFound
+		case "leave overlaps existing leave":
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to create leave", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Leave created successfully", leave)
+}
+
+// GetLeaves godoc
+// @Summary Get leave (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param user_id query int false "Filter by user"
+// @Param type query string false "Filter by leave type"
+// @Param date_from query string false "Leave ending on or after this date (YYYY-MM-DD)"
+// @Param date_to query string false "Leave starting on or before this date (YYYY-MM-DD)"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/leaves [get]
+func (ctrl *LeaveController) GetLeaves(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filters := map[string]interface{}{
+		"type":      c.Query("type"),
+		"date_from": c.Query("date_from"),
+		"date_to":   c.Query("date_to"),
+	}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		filters["user_id"] = uint(userID)
+	}
+
+	offset := (page - 1) * limit
+	leaves, total, err := ctrl.leaveService.GetLeaves(filters, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get leave", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave retrieved", gin.H{
+		"data":       leaves,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// DeleteLeave godoc
+// @Summary Withdraw leave (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Leave ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/leaves/:id [delete]
+func (ctrl *LeaveController) DeleteLeave(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave ID", err.Error())
+		return
+	}
+
+	if err := ctrl.leaveService.DeleteLeave(uint(id), c.GetUint("userID")); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "leave not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to delete leave", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave deleted successfully", nil)
+}
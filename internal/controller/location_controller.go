@@ -1,21 +1,28 @@
 package controller
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/attendance/backend/internal/service"
 	"github.com/attendance/backend/internal/utils"
+	"github.com/attendance/backend/pkg/clock"
 	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
 )
 
 type LocationController struct {
 	locationService *service.LocationService
+	clock           clock.Clock // issues QR tokens on the same clock check-ins validate them with
 }
 
-func NewLocationController(locationService *service.LocationService) *LocationController {
+func NewLocationController(locationService *service.LocationService, clk clock.Clock) *LocationController {
 	return &LocationController{
 		locationService: locationService,
+		clock:           clk,
 	}
 }
 
@@ -27,6 +34,8 @@ func NewLocationController(locationService *service.LocationService) *LocationCo
 // @Param latitude query float64 true "User latitude"
 // @Param longitude query float64 true "User longitude"
 // @Param radius_km query float64 true "Search radius in km"
+// @Param fresh query bool false "Read locations from the database instead of the cache"
+// @Param include_nearest query bool false "Return the closest location, flagged outside_search, when none is within the radius"
 // @Success 200 {object} utils.Response
 // @Router /api/v1/attendance/locations [get]
 func (ctrl *LocationController) GetNearbyLocations(c *gin.Context) {
@@ -38,6 +47,11 @@ func (ctrl *LocationController) GetNearbyLocations(c *gin.Context) {
 
 	locations, err := ctrl.locationService.GetNearbyLocations(&req)
 	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get locations", err.Error())
 		return
 	}
@@ -51,7 +65,9 @@ func (ctrl *LocationController) GetNearbyLocations(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} utils.Response
+// @Success 200 {object} utils.Response "is_valid is false when outside the radius"
+// @Failure 404 {object} utils.Response "Location does not exist"
+// @Failure 409 {object} utils.Response "Location is inactive"
 // @Router /api/v1/attendance/validate-location [post]
 func (ctrl *LocationController) ValidateLocation(c *gin.Context) {
 	var req struct {
@@ -72,7 +88,14 @@ func (ctrl *LocationController) ValidateLocation(c *gin.Context) {
 	)
 
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Validation failed", err.Error())
+		switch {
+		case errors.Is(err, service.ErrLocationNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "Location not found", err.Error())
+		case errors.Is(err, service.ErrLocationInactive):
+			utils.ErrorResponse(c, http.StatusConflict, "Location is inactive", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Validation failed", err.Error())
+		}
 		return
 	}
 
@@ -113,6 +136,19 @@ func (ctrl *LocationController) CreateLocation(c *gin.Context) {
 
 	location, err := ctrl.locationService.CreateLocation(&req, userID)
 	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		var conflictErr *service.LocationConflictError
+		if errors.As(err, &conflictErr) {
+			utils.ErrorResponse(c, http.StatusConflict, "Location is too close to an existing one", gin.H{
+				"conflicting_location": conflictErr.Location.ToResponse(),
+				"distance":             conflictErr.Distance,
+			})
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create location", err.Error())
 		return
 	}
@@ -199,6 +235,11 @@ func (ctrl *LocationController) UpdateLocation(c *gin.Context) {
 
 	location, err := ctrl.locationService.UpdateLocation(uint(id), &req)
 	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update location", err.Error())
 		return
 	}
@@ -228,3 +269,125 @@ func (ctrl *LocationController) DeleteLocation(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Location deleted successfully", nil)
 }
+
+// SuspendLocation godoc
+// @Summary Temporarily suspend a location (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Location ID"
+// @Param request body service.SuspendLocationRequest false "Suspend location request"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/locations/:id/suspend [patch]
+func (ctrl *LocationController) SuspendLocation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid location ID", err.Error())
+		return
+	}
+
+	var req service.SuspendLocationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+	}
+
+	location, err := ctrl.locationService.SuspendLocation(uint(id), &req)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if err.Error() == "location not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to suspend location", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Location suspended successfully", location.ToResponse())
+}
+
+// ActivateLocation godoc
+// @Summary Lift a location suspension (Admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Location ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/locations/:id/activate [patch]
+func (ctrl *LocationController) ActivateLocation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid location ID", err.Error())
+		return
+	}
+
+	location, err := ctrl.locationService.ActivateLocation(uint(id))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "location not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to activate location", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Location activated successfully", location.ToResponse())
+}
+
+// GetLocationQR godoc
+// @Summary Get a fresh check-in QR code for a location (Admin only)
+// @Description Returns a PNG by default; format=json returns the token and a base64 PNG instead.
+// @Tags admin
+// @Produce png
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Location ID"
+// @Param format query string false "png or json" default(png)
+// @Success 200 {file} file
+// @Router /api/v1/admin/locations/:id/qr [get]
+func (ctrl *LocationController) GetLocationQR(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid location ID", err.Error())
+		return
+	}
+
+	format := c.DefaultQuery("format", "png")
+	if format != "png" && format != "json" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid format", "format must be png or json")
+		return
+	}
+
+	token, expiresAt, err := ctrl.locationService.GenerateCheckInToken(uint(id), ctrl.clock.Now())
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "location not found" {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrQRCheckInDisabled) {
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to generate QR code", err.Error())
+		return
+	}
+
+	png, err := qrcode.Encode(token, qrcode.Medium, 256)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate QR code", err.Error())
+		return
+	}
+
+	if format == "json" {
+		utils.SuccessResponse(c, http.StatusOK, "QR code generated", gin.H{
+			"token":      token,
+			"expires_at": expiresAt,
+			"image":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Token-Expires-At", expiresAt.Format(time.RFC3339))
+	c.Data(http.StatusOK, "image/png", png)
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateLocationStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		lookup     func(mock sqlmock.Sqlmock)
+		wantStatus int
+	}{
+		{
+			"outside the radius",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "latitude", "longitude", "radius", "is_active"}).AddRow(3, -6.1, 106.8, 100, true))
+			},
+			http.StatusOK,
+		},
+		{
+			"unknown location",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			http.StatusNotFound,
+		},
+		{
+			"inactive location",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "latitude", "longitude", "radius", "is_active"}).AddRow(3, -6.2, 106.8, 100, false))
+			},
+			http.StatusConflict,
+		},
+		{
+			"lookup failure",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).WillReturnError(errors.New("connection reset"))
+			},
+			http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			tt.lookup(mock)
+
+			router := gin.New()
+			router.POST("/api/v1/attendance/validate-location", NewLocationController(service.NewLocationService(db, &config.Config{}, nil), testClock).ValidateLocation)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/attendance/validate-location", strings.NewReader(`{"location_id":3,"latitude":-6.2,"longitude":106.8}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && !strings.Contains(w.Body.String(), `"is_valid":false`) {
+				t.Errorf("body = %s, want is_valid false", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateLocationConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock := newMockDB(t)
+	cfg := &config.Config{}
+	cfg.Location.DuplicateCheckEnabled = true
+	cfg.Location.DuplicateMinDistance = 50
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE is_active = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "is_active"}).AddRow(2, "HQ", -6.2, 106.8, true))
+	mock.ExpectRollback()
+
+	router := gin.New()
+	router.POST("/api/v1/admin/locations", func(c *gin.Context) { c.Set("userID", uint(1)) },
+		NewLocationController(service.NewLocationService(db, cfg, nil), testClock).CreateLocation)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/locations", strings.NewReader(`{"name":"HQ copy","latitude":-6.2,"longitude":106.8,"radius":100}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// The conflicting location is returned so the admin can reuse it
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"conflicting_location":{"id":2`) {
+		t.Errorf("status = %d, body = %s, want 409 naming location 2", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/attendance/backend/internal/model"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MetaController struct{}
+
+func NewMetaController() *MetaController {
+	return &MetaController{}
+}
+
+// GetEnums godoc
+// @Summary Get server-side enum values for client dropdowns
+// @Tags meta
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Router /api/v1/meta/enums [get]
+func (ctrl *MetaController) GetEnums(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Enums retrieved", gin.H{
+		"attendance_statuses": model.AttendanceStatuses,
+		"review_resolutions":  model.ReviewResolutions,
+		"roles":               model.UserRoles,
+		"leave_types":         model.LeaveTypes,
+		"webhook_events":      model.WebhookEvents,
+	})
+}
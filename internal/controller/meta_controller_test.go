@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/attendance/backend/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetEnums(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/meta/enums", NewMetaController().GetEnums)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/meta/enums", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var body struct {
+		Data map[string][]string `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	want := map[string][]string{
+		"attendance_statuses": model.AttendanceStatuses,
+		"review_resolutions":  model.ReviewResolutions,
+		"roles":               model.UserRoles,
+		"leave_types":         model.LeaveTypes,
+		"webhook_events":      model.WebhookEvents,
+	}
+	for key, values := range want {
+		if !reflect.DeepEqual(body.Data[key], values) {
+			t.Errorf("%s = %v, want %v", key, body.Data[key], values)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/pkg/clock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testClock fixes "now" for controllers under test, so no test depends on the day it runs
+var testClock = clock.Fixed(time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC))
+
+// newMockDB returns a GORM handle on the Postgres dialect backed by sqlmock, for
+// driving controllers through real services. Every expectation must be met by the
+// end of the test.
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet database expectations: %v", err)
+		}
+		sqlDB.Close()
+	})
+	return db, mock
+}
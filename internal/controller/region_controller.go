@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/attendance/backend/internal/service"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type RegionController struct {
+	regionService *service.RegionService
+}
+
+func NewRegionController(regionService *service.RegionService) *RegionController {
+	return &RegionController{
+		regionService: regionService,
+	}
+}
+
+// CreateRegion godoc
+// @Summary Create region (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateRegionRequest true "Create region request"
+// @Success 201 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /api/v1/admin/regions [post]
+func (ctrl *RegionController) CreateRegion(c *gin.Context) {
+	var req service.CreateRegionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	region, err := ctrl.regionService.CreateRegion(&req)
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "region already exists" {
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to create region", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Region created successfully", region)
+}
+
+// GetAllRegions godoc
+// @Summary Get all regions (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/regions [get]
+func (ctrl *RegionController) GetAllRegions(c *gin.Context) {
+	regions, err := ctrl.regionService.GetAllRegions()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get regions", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Regions retrieved", regions)
+}
+
+// GetRegionByID godoc
+// @Summary Get region by ID (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Region ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/regions/:id [get]
+func (ctrl *RegionController) GetRegionByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid region ID", err.Error())
+		return
+	}
+
+	region, err := ctrl.regionService.GetRegionByID(uint(id))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "region not found" {
+			statusCode = http.StatusNotFound
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to get region", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Region retrieved", region)
+}
+
+// UpdateRegion godoc
+// @Summary Rename region (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Region ID"
+// @Param request body service.UpdateRegionRequest true "Update region request"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /api/v1/admin/regions/:id [put]
+func (ctrl *RegionController) UpdateRegion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid region ID", err.Error())
+		return
+	}
+
+	var req service.UpdateRegionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	region, err := ctrl.regionService.UpdateRegion(uint(id), &req)
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "region not found":
+			statusCode = http.StatusNotFound
+		case "region already exists":
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to update region", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Region updated successfully", region)
+}
+
+// DeleteRegion godoc
+// @Summary Delete region (Admin)
+// @Description Regions that still have locations cannot be deleted; move or unassign the locations first.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Region ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /api/v1/admin/regions/:id [delete]
+func (ctrl *RegionController) DeleteRegion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid region ID", err.Error())
+		return
+	}
+
+	if err := ctrl.regionService.DeleteRegion(uint(id)); err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "region not found":
+			statusCode = http.StatusNotFound
+		case "region still has locations":
+			statusCode = http.StatusConflict
+		}
+		utils.ErrorResponse(c, statusCode, "Failed to delete region", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Region deleted successfully", nil)
+}
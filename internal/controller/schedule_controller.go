@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/attendance/backend/internal/service"
 	"github.com/attendance/backend/internal/utils"
@@ -26,7 +28,9 @@ func NewScheduleController(scheduleService *service.ScheduleService) *ScheduleCo
 // @Produce json
 // @Security BearerAuth
 // @Param request body service.CreateScheduleRequest true "Create schedule request"
+// @Param return_existing query bool false "Return the schedule with the same name instead of failing"
 // @Success 201 {object} utils.Response
+// @Success 200 {object} utils.Response "Existing schedule returned"
 // @Router /api/v1/admin/schedules [post]
 func (ctrl *ScheduleController) CreateSchedule(c *gin.Context) {
 	var req service.CreateScheduleRequest
@@ -35,12 +39,27 @@ func (ctrl *ScheduleController) CreateSchedule(c *gin.Context) {
 		return
 	}
 
-	schedule, err := ctrl.scheduleService.CreateSchedule(&req)
+	returnExisting, _ := strconv.ParseBool(c.Query("return_existing"))
+	schedule, created, err := ctrl.scheduleService.CreateSchedule(&req, returnExisting)
 	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		if err.Error() == "schedule name already exists" {
+			utils.ErrorResponse(c, http.StatusConflict, "Failed to create schedule", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create schedule", err.Error())
 		return
 	}
 
+	if !created {
+		utils.SuccessResponse(c, http.StatusOK, "Schedule already exists", schedule.ToResponse())
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusCreated, "Schedule created successfully", schedule.ToResponse())
 }
 
@@ -116,6 +135,15 @@ func (ctrl *ScheduleController) UpdateSchedule(c *gin.Context) {
 
 	schedule, err := ctrl.scheduleService.UpdateSchedule(uint(id), &req)
 	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		if err.Error() == "schedule name already exists" {
+			utils.ErrorResponse(c, http.StatusConflict, "Failed to update schedule", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update schedule", err.Error())
 		return
 	}
@@ -162,8 +190,14 @@ func (ctrl *ScheduleController) AssignSchedule(c *gin.Context) {
 		return
 	}
 
-	userSchedule, err := ctrl.scheduleService.AssignScheduleToUser(&req)
+	userID := c.GetUint("userID")
+	userSchedule, err := ctrl.scheduleService.AssignScheduleToUser(&req, userID)
 	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to assign schedule", err.Error())
 		return
 	}
@@ -177,6 +211,8 @@ func (ctrl *ScheduleController) AssignSchedule(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param user_id query int true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
 // @Success 200 {object} utils.Response
 // @Router /api/v1/admin/schedules/user [get]
 func (ctrl *ScheduleController) GetUserSchedules(c *gin.Context) {
@@ -186,7 +222,18 @@ func (ctrl *ScheduleController) GetUserSchedules(c *gin.Context) {
 		return
 	}
 
-	userSchedules, err := ctrl.scheduleService.GetUserSchedules(uint(userID))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+	userSchedules, total, err := ctrl.scheduleService.GetUserSchedules(uint(userID), limit, offset)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get user schedules", err.Error())
 		return
@@ -198,5 +245,207 @@ func (ctrl *ScheduleController) GetUserSchedules(c *gin.Context) {
 		responses[i] = us.ToResponse()
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "User schedules retrieved", responses)
+	utils.SuccessResponse(c, http.StatusOK, "User schedules retrieved", gin.H{
+		"data":       responses,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// GetAssignmentsOnDate godoc
+// @Summary Get schedule assignments effective on a date (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Param location_id query int false "Filter by location ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/admin/schedules/on-date [get]
+func (ctrl *ScheduleController) GetAssignmentsOnDate(c *gin.Context) {
+	date, err := time.Parse("2006-01-02", c.Query("date"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "date is required in YYYY-MM-DD format")
+		return
+	}
+
+	var locationID uint
+	if value := c.Query("location_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid location ID")
+			return
+		}
+		locationID = uint(id)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+	userSchedules, total, err := ctrl.scheduleService.GetAssignmentsOnDate(date, locationID, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get schedule assignments", err.Error())
+		return
+	}
+
+	// Convert to responses
+	responses := make([]interface{}, len(userSchedules))
+	for i, us := range userSchedules {
+		responses[i] = us.ToResponse()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Schedule assignments retrieved", gin.H{
+		"data":       responses,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// GetSchedulesByLocation godoc
+// @Summary Get schedules operating at a location (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Location ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/admin/locations/:id/schedules [get]
+func (ctrl *ScheduleController) GetSchedulesByLocation(c *gin.Context) {
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid location ID", err.Error())
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+	schedules, total, err := ctrl.scheduleService.GetSchedulesByLocation(uint(locationID), limit, offset)
+	if err != nil {
+		if errors.Is(err, service.ErrLocationNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Location not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get location schedules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Location schedules retrieved", gin.H{
+		"data":       schedules,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// GetUserScheduleHistory godoc
+// @Summary Get user's schedule assignment history (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/users/:id/schedule-history [get]
+func (ctrl *ScheduleController) GetUserScheduleHistory(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+	userSchedules, total, err := ctrl.scheduleService.GetUserScheduleHistory(uint(userID), limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get schedule history", err.Error())
+		return
+	}
+
+	// Convert to responses
+	responses := make([]interface{}, len(userSchedules))
+	for i, us := range userSchedules {
+		responses[i] = us.ToResponse()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Schedule history retrieved", gin.H{
+		"data":       responses,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// GetPlannedSchedule godoc
+// @Summary Get the current user's schedule resolved to one entry per day
+// @Description Each day is "work" with the expected times in the location's timezone, "holiday" (with holiday_name) or "leave" (with leave_type) when a scheduled work day is excused, or "off".
+// @Tags attendance
+// @Produce json
+// @Security BearerAuth
+// @Param from query string true "From date (YYYY-MM-DD)"
+// @Param to query string true "To date (YYYY-MM-DD), inclusive, at most 366 days after from"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/attendance/planned [get]
+func (ctrl *ScheduleController) GetPlannedSchedule(c *gin.Context) {
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "from is required in YYYY-MM-DD format")
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "to is required in YYYY-MM-DD format")
+		return
+	}
+
+	userID := c.GetUint("userID")
+	days, err := ctrl.scheduleService.ResolveUserSchedule(userID, from, to)
+	if err != nil {
+		var fieldErr *service.FieldError
+		if errors.As(err, &fieldErr) {
+			utils.ValidationErrorResponse(c, fieldErr)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get planned schedule", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Planned schedule retrieved", days)
 }
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetUserSchedulesPaginated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "user_schedules" WHERE user_id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	// Newest first, second page of one
+	mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id = \$1 ORDER BY effective_from DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs(7, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id", "effective_from"}).
+			AddRow(4, 7, 2, 3, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectQuery(`SELECT \* FROM "work_schedules"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Office"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+
+	router := gin.New()
+	router.GET("/api/v1/admin/schedules/user", NewScheduleController(service.NewScheduleService(db, testClock, &config.Config{})).GetUserSchedules)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/schedules/user?user_id=7&page=2&limit=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var body struct {
+		Data struct {
+			Data      []map[string]interface{} `json:"data"`
+			Total     int64                    `json:"total"`
+			Page      int                      `json:"page"`
+			Limit     int                      `json:"limit"`
+			TotalPage int                      `json:"total_page"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	got := body.Data
+	if got.Total != 3 || got.Page != 2 || got.Limit != 1 || got.TotalPage != 3 {
+		t.Errorf("envelope = total %d, page %d, limit %d, total_page %d, want 3, 2, 1, 3", got.Total, got.Page, got.Limit, got.TotalPage)
+	}
+	if len(got.Data) != 1 || got.Data[0]["id"] != float64(4) {
+		t.Errorf("data = %v, want assignment 4", got.Data)
+	}
+}
@@ -1,9 +1,15 @@
 package controller
 
 import (
+	"encoding/csv"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/attendance/backend/internal/model"
 	"github.com/attendance/backend/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -25,11 +31,14 @@ func NewUserController(userService *service.UserService) *UserController {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param search query string false "Match email, full name or phone"
+// @Param role query string false "Filter by role"
+// @Param is_active query bool false "Filter by active state"
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /admin/users [get]
 func (ctrl *UserController) GetAllUsers(c *gin.Context) {
-	users, err := ctrl.userService.GetAllUsers()
+	users, err := ctrl.userService.GetAllUsers(userFilters(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -52,6 +61,72 @@ func (ctrl *UserController) GetAllUsers(c *gin.Context) {
 	})
 }
 
+// ExportUsers godoc
+// @Summary Export users as CSV
+// @Description Stream every user matching the list filters as CSV; password hashes are never included (Admin only)
+// @Tags Admin - Users
+// @Produce text/csv
+// @Security BearerAuth
+// @Param search query string false "Match email, full name or phone"
+// @Param role query string false "Filter by role"
+// @Param is_active query bool false "Filter by active state"
+// @Success 200 {file} file
+// @Router /admin/users/export [get]
+func (ctrl *UserController) ExportUsers(c *gin.Context) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="users-`+time.Now().Format("20060102")+`.csv"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"id", "email", "full_name", "phone", "role", "is_active", "created_at"})
+
+	err := ctrl.userService.ExportUsers(userFilters(c), func(user *model.User) error {
+		writer.Write([]string{
+			strconv.FormatUint(uint64(user.ID), 10),
+			csvCell(user.Email),
+			csvCell(user.FullName),
+			csvCell(user.Phone),
+			user.Role,
+			strconv.FormatBool(user.IsActive),
+			user.CreatedAt.Format(time.RFC3339),
+		})
+		return writer.Error()
+	})
+	writer.Flush()
+
+	// Headers are already sent, so a failure can only truncate the file
+	if err == nil {
+		err = writer.Error()
+	}
+	if err != nil {
+		log.Printf("user export: %v", err)
+	}
+}
+
+// csvCell escapes a user-supplied value so a spreadsheet opening the export shows it as
+// text rather than evaluating it as a formula
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// userFilters reads the user list filters from the query string
+func userFilters(c *gin.Context) map[string]interface{} {
+	filters := make(map[string]interface{})
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		filters["search"] = search
+	}
+	if role := c.Query("role"); role != "" {
+		filters["role"] = role
+	}
+	if isActive, err := strconv.ParseBool(c.Query("is_active")); err == nil {
+		filters["is_active"] = isActive
+	}
+	return filters
+}
+
 // GetUserByID godoc
 // @Summary Get user by ID
 // @Description Get a specific user by ID (Admin only)
@@ -91,6 +166,54 @@ func (ctrl *UserController) GetUserByID(c *gin.Context) {
 	})
 }
 
+// GetUsersBatch godoc
+// @Summary Get several users by ID
+// @Description Resolve up to 100 user IDs in one request, reporting IDs that do not exist (Admin only)
+// @Tags Admin - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.BatchUsersRequest true "User IDs"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /admin/users/batch [post]
+func (ctrl *UserController) GetUsersBatch(c *gin.Context) {
+	var req service.BatchUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	users, notFound, err := ctrl.userService.GetUsersByIDs(req.UserIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to retrieve users",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Convert to response format (without password hash)
+	userResponses := make([]interface{}, len(users))
+	for i, user := range users {
+		userResponses[i] = user.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Users retrieved successfully",
+		"data": gin.H{
+			"users":     userResponses,
+			"not_found": notFound,
+		},
+	})
+}
+
 // CreateUser godoc
 // @Summary Create new user
 // @Description Create a new user (Admin only)
@@ -118,8 +241,10 @@ func (ctrl *UserController) CreateUser(c *gin.Context) {
 	user, err := ctrl.userService.CreateUser(&req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "email already exists" {
+		if err.Error() == "email already exists" || err.Error() == "phone already exists" || err.Error() == "employee id already exists" {
 			statusCode = http.StatusConflict
+		} else if err.Error() == "department not found" {
+			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -169,13 +294,15 @@ func (ctrl *UserController) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.userService.UpdateUser(uint(userID), &req)
+	user, err := ctrl.userService.UpdateUser(uint(userID), &req, c.GetUint("userID"))
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "user not found" {
 			statusCode = http.StatusNotFound
-		} else if err.Error() == "email already exists" {
+		} else if err.Error() == "email already exists" || err.Error() == "phone already exists" || err.Error() == "employee id already exists" {
 			statusCode = http.StatusConflict
+		} else if err.Error() == "department not found" {
+			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -245,6 +372,45 @@ func (ctrl *UserController) DeleteUser(c *gin.Context) {
 	})
 }
 
+// BulkDeactivateUsers godoc
+// @Summary Bulk deactivate users
+// @Description Deactivate several users at once and report the result per ID (Admin only)
+// @Tags Admin - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.BulkUserIDsRequest true "User IDs"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /admin/users/bulk-deactivate [post]
+func (ctrl *UserController) BulkDeactivateUsers(c *gin.Context) {
+	var req service.BulkUserIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	results, err := ctrl.userService.BulkDeactivateUsers(req.UserIDs, req.Reason, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to deactivate users",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Bulk deactivation completed",
+		"data":    results,
+	})
+}
+
 // ChangeUserPassword godoc
 // @Summary Change user password
 // @Description Change a user's password (Admin only)
@@ -284,6 +450,8 @@ func (ctrl *UserController) ChangeUserPassword(c *gin.Context) {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "user not found" {
 			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrPasswordReused) {
+			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -298,6 +466,109 @@ func (ctrl *UserController) ChangeUserPassword(c *gin.Context) {
 	})
 }
 
+// ResetUserPassword godoc
+// @Summary Reset user password to a temporary one
+// @Description Generate a random temporary password the user must change, revoking their tokens (Admin only). The password is only returned once.
+// @Tags Admin - Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/users/{id}/reset-password [post]
+func (ctrl *UserController) ResetUserPassword(c *gin.Context) {
+	// Parse user ID
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	password, err := ctrl.userService.ResetUserPassword(uint(userID), c.GetUint("userID"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "user not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Password reset successfully",
+		"data": gin.H{
+			"temporary_password": password,
+		},
+	})
+}
+
+// MergeUsers godoc
+// @Summary Merge a duplicate user into another account
+// @Description Move the attendance, schedule history and audit references of the user in the path to target_id, then deactivate it (Admin only)
+// @Tags Admin - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Duplicate (source) user ID"
+// @Param request body service.MergeUsersRequest true "Target user"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /admin/users/{id}/merge [post]
+func (ctrl *UserController) MergeUsers(c *gin.Context) {
+	// Parse user ID
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	var req service.MergeUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := ctrl.userService.MergeUsers(uint(sourceID), req.TargetID, c.GetUint("userID"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "user not found":
+			statusCode = http.StatusNotFound
+		case err.Error() == "cannot merge a user into itself", err.Error() == "cannot merge an admin into a non-admin user":
+			statusCode = http.StatusBadRequest
+		case strings.HasPrefix(err.Error(), "both users have"):
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Users merged successfully",
+		"data":    result,
+	})
+}
+
 // GetUserStats godoc
 // @Summary Get user statistics
 // @Description Get statistics about users (Admin only)
@@ -305,12 +576,32 @@ func (ctrl *UserController) ChangeUserPassword(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param department_id query int false "Scope to a department"
 // @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
 // @Router /admin/users/stats [get]
 func (ctrl *UserController) GetUserStats(c *gin.Context) {
-	stats, err := ctrl.userService.GetUserStats()
+	var departmentID *uint
+	if value := c.Query("department_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid department ID",
+			})
+			return
+		}
+		deptID := uint(id)
+		departmentID = &deptID
+	}
+
+	stats, err := ctrl.userService.GetUserStats(departmentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "department not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{
 			"status":  "error",
 			"message": "Failed to retrieve user statistics",
 			"error":   err.Error(),
@@ -398,7 +689,7 @@ func (ctrl *UserController) UpdateMyProfile(c *gin.Context) {
 	user, err := ctrl.userService.UpdateMyProfile(userID.(uint), &req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "email already exists" {
+		if err.Error() == "email already exists" || err.Error() == "phone already exists" {
 			statusCode = http.StatusConflict
 		}
 		c.JSON(statusCode, gin.H{
@@ -427,6 +718,7 @@ func (ctrl *UserController) UpdateMyProfile(c *gin.Context) {
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Router /admin/profile/password [put]
+// @Router /auth/password [put]
 func (ctrl *UserController) UpdateMyPassword(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("userID")
@@ -451,7 +743,7 @@ func (ctrl *UserController) UpdateMyPassword(c *gin.Context) {
 	err := ctrl.userService.UpdateMyPassword(userID.(uint), &req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "old password is incorrect" {
+		if err.Error() == "old password is incorrect" || errors.Is(err, service.ErrPasswordReused) {
 			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, gin.H{
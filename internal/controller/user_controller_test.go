@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetUsersBatchValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Rejected before the service is reached
+	router.POST("/api/v1/admin/users/batch", NewUserController(nil).GetUsersBatch)
+
+	tooMany := "[" + strings.Repeat("1,", 100) + "1]"
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing user_ids", `{}`},
+		{"empty user_ids", `{"user_ids":[]}`},
+		{"more than 100 ids", `{"user_ids":` + tooMany + `}`},
+		{"not a list of ids", `{"user_ids":["7"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestExportUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock := newMockDB(t)
+
+	createdAt := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE \(email ILIKE \$1 OR full_name ILIKE \$2 OR phone ILIKE \$3\) AND role = \$4 AND is_active = \$5 ORDER BY id ASC`).
+		WithArgs("%ann%", "%ann%", "%ann%", "employee", true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "password_hash", "full_name", "phone", "role", "is_active", "created_at"}).
+			AddRow(1, "ann@example.com", "$2a$10$secrethash", "Ann, Jr.", "0812", "employee", true, createdAt).
+			AddRow(2, "joanne@example.com", "$2a$10$otherhash", "Joanne", "0813", "employee", true, createdAt).
+			AddRow(3, "anna@example.com", "$2a$10$thirdhash", `=HYPERLINK("http://evil.example","Anna")`, "+62812", "employee", true, createdAt))
+
+	router := gin.New()
+	router.GET("/api/v1/admin/users/export", NewUserController(service.NewUserService(db, nil, nil, testClock, &config.Config{})).ExportUsers)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/users/export?search=ann&role=employee&is_active=true", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+	if strings.Contains(w.Body.String(), "hash") {
+		t.Errorf("export contains the password hash:\n%s", w.Body.String())
+	}
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	want := [][]string{
+		{"id", "email", "full_name", "phone", "role", "is_active", "created_at"},
+		{"1", "ann@example.com", "Ann, Jr.", "0812", "employee", "true", "2026-03-09T08:00:00Z"},
+		{"2", "joanne@example.com", "Joanne", "0813", "employee", "true", "2026-03-09T08:00:00Z"},
+		// Values a spreadsheet would evaluate are exported as text
+		{"3", "anna@example.com", `'=HYPERLINK("http://evil.example","Anna")`, "'+62812", "employee", "true", "2026-03-09T08:00:00Z"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d rows, want %d:\n%s", len(records), len(want), w.Body.String())
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d = %v, want %v", i, records[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/attendance/backend/internal/model"
+	"github.com/attendance/backend/internal/service"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookController(webhookService *service.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary Create webhook subscription (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateWebhookRequest true "Create webhook request"
+// @Success 201 {object} utils.Response
+// @Router /api/v1/admin/webhooks [post]
+func (ctrl *WebhookController) CreateWebhook(c *gin.Context) {
+	var req service.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	userID := c.GetUint("userID")
+	webhook, err := ctrl.webhookService.CreateWebhook(&req, userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create webhook", err.Error())
+		return
+	}
+
+	// The signing secret is only returned once, on creation
+	utils.SuccessResponse(c, http.StatusCreated, "Webhook created successfully", gin.H{
+		"webhook": webhook.ToResponse(),
+		"secret":  webhook.Secret,
+	})
+}
+
+// GetAllWebhooks godoc
+// @Summary Get all webhooks (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/webhooks [get]
+func (ctrl *WebhookController) GetAllWebhooks(c *gin.Context) {
+	webhooks, err := ctrl.webhookService.GetAllWebhooks()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get webhooks", err.Error())
+		return
+	}
+
+	// Convert to responses
+	responses := make([]interface{}, len(webhooks))
+	for i, webhook := range webhooks {
+		responses[i] = webhook.ToResponse()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhooks retrieved", responses)
+}
+
+// GetWebhookEvents godoc
+// @Summary List subscribable webhook events (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/webhooks/events [get]
+func (ctrl *WebhookController) GetWebhookEvents(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Webhook events retrieved", model.WebhookEvents)
+}
+
+// UpdateWebhook godoc
+// @Summary Update webhook subscription (Admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param request body service.UpdateWebhookRequest true "Update webhook request"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/webhooks/:id [put]
+func (ctrl *WebhookController) UpdateWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	var req service.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	webhook, err := ctrl.webhookService.UpdateWebhook(uint(id), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update webhook", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook updated successfully", webhook.ToResponse())
+}
+
+// DeleteWebhook godoc
+// @Summary Delete webhook subscription (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/admin/webhooks/:id [delete]
+func (ctrl *WebhookController) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	if err := ctrl.webhookService.DeleteWebhook(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete webhook", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary List webhook deliveries, newest first (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, succeeded or failed"
+// @Param webhook_id query int false "Filter by webhook ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/admin/webhooks/deliveries [get]
+func (ctrl *WebhookController) GetWebhookDeliveries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	// Build filters
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" {
+		switch status {
+		case model.WebhookDeliveryPending, model.WebhookDeliverySucceeded, model.WebhookDeliveryFailed:
+			filters["status"] = status
+		default:
+			utils.ValidationErrorResponse(c, "status must be pending, succeeded or failed")
+			return
+		}
+	}
+	if webhookID, err := strconv.ParseUint(c.Query("webhook_id"), 10, 32); err == nil {
+		filters["webhook_id"] = uint(webhookID)
+	}
+
+	offset := (page - 1) * limit
+	deliveries, total, err := ctrl.webhookService.GetDeliveries(filters, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get webhook deliveries", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook deliveries retrieved", gin.H{
+		"data":       deliveries,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"total_page": utils.TotalPages(total, limit),
+	})
+}
+
+// ReplayWebhookDelivery godoc
+// @Summary Resend a webhook delivery, re-signed with the webhook's current secret (Admin)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /api/v1/admin/webhooks/deliveries/{id}/replay [post]
+func (ctrl *WebhookController) ReplayWebhookDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID", err.Error())
+		return
+	}
+
+	delivery, err := ctrl.webhookService.ReplayDelivery(uint(id))
+	if err != nil {
+		switch err.Error() {
+		case "webhook delivery not found", "webhook not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "Failed to replay delivery", err.Error())
+		case service.ErrDeliveryInProgress.Error():
+			utils.ErrorResponse(c, http.StatusConflict, "Failed to replay delivery", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to replay delivery", err.Error())
+		}
+		return
+	}
+
+	if delivery.Status == model.WebhookDeliveryFailed {
+		utils.SuccessResponse(c, http.StatusOK, "Delivery replayed but failed again", delivery)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery replayed successfully", delivery)
+}
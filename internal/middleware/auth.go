@@ -1,17 +1,19 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/service"
 	"github.com/attendance/backend/internal/utils"
-	"github.com/attendance/backend/pkg/jwt"
 	"github.com/gin-gonic/gin"
 )
 
 // AuthMiddleware validates JWT token
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from header
 		authHeader := c.GetHeader("Authorization")
@@ -32,17 +34,62 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		token := tokenParts[1]
 
 		// Validate token
-		claims, err := jwt.ValidateToken(token, cfg.JWT.Secret)
+		claims, err := authService.ValidateToken(token)
 		if err != nil {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired token", err.Error())
 			c.Abort()
 			return
 		}
 
+		// Reject tokens of deactivated or deleted users and revoked tokens. Users who must
+		// change their password stay signed in, PasswordChangeMiddleware limits where to.
+		mustChangePassword := false
+		if err := authService.ValidateTokenVersion(claims.UserID, claims.TokenVersion); errors.Is(err, service.ErrPasswordChangeRequired) {
+			mustChangePassword = true
+		} else if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired token", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Reject tokens presented from a device other than the one they were issued to
+		if err := authService.VerifyFingerprint(claims.Fingerprint, utils.DeviceFingerprint(c.Request)); err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired token", err.Error())
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
 		c.Set("userID", claims.UserID)
 		c.Set("userEmail", claims.Email)
 		c.Set("userRole", claims.Role)
+		c.Set("mustChangePassword", mustChangePassword)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+
+			// Lets clients refresh ahead of expiry without decoding the token
+			if authService.TokenExpiryHeaderEnabled() {
+				expiresIn := int64(time.Until(claims.ExpiresAt.Time).Seconds())
+				if expiresIn < 0 {
+					expiresIn = 0
+				}
+				c.Header("X-Token-Expires-In", strconv.FormatInt(expiresIn, 10))
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// PasswordChangeMiddleware rejects users who must change their password, which an
+// admin password reset requires. Routes without it, such as PUT /auth/password, stay open.
+func PasswordChangeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("mustChangePassword") {
+			utils.ErrorResponse(c, http.StatusForbidden, "Password change required", service.ErrPasswordChangeRequired.Error())
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/service"
+	"github.com/attendance/backend/pkg/jwt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestAuthMiddlewareTokenExpiryHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := jwt.NewHMACKeys("test-secret")
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		expiration time.Duration
+		wantHeader bool
+	}{
+		{"disabled", false, time.Hour, false},
+		{"enabled", true, time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer sqlDB.Close()
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+				Logger: logger.Default.LogMode(logger.Silent),
+			})
+			if err != nil {
+				t.Fatalf("gorm: %v", err)
+			}
+
+			mock.ExpectQuery(`SELECT "id","is_active","token_version","must_change_password" FROM "users"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "is_active", "token_version", "must_change_password"}).AddRow(7, true, 0, false))
+
+			cfg := &config.Config{}
+			cfg.Auth.TokenExpiryHeader = tt.enabled
+			authService := service.NewAuthService(db, cfg, keys, nil)
+
+			token, err := jwt.GenerateToken(7, "user@example.com", "employee", 0, "", time.Now(), keys, tt.expiration)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+
+			router := gin.New()
+			router.Use(AuthMiddleware(authService))
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			header := w.Header().Get("X-Token-Expires-In")
+			if !tt.wantHeader {
+				if header != "" {
+					t.Errorf("X-Token-Expires-In = %q, want no header", header)
+				}
+			} else {
+				seconds, err := strconv.ParseInt(header, 10, 64)
+				if err != nil {
+					t.Fatalf("X-Token-Expires-In = %q, want seconds", header)
+				}
+				if max := int64(tt.expiration.Seconds()); seconds <= max-5 || seconds > max {
+					t.Errorf("X-Token-Expires-In = %d, want about %d", seconds, max)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet database expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPasswordChangeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := jwt.NewHMACKeys("test-secret")
+
+	tests := []struct {
+		name               string
+		mustChangePassword bool
+		method, path       string
+		wantStatus         int
+	}{
+		{"password changed", false, http.MethodGet, "/attendance/today", http.StatusOK},
+		{"password change pending", true, http.MethodGet, "/attendance/today", http.StatusForbidden},
+		{"password change pending on the change-password route", true, http.MethodPut, "/auth/password", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer sqlDB.Close()
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+				Logger: logger.Default.LogMode(logger.Silent),
+			})
+			if err != nil {
+				t.Fatalf("gorm: %v", err)
+			}
+
+			mock.ExpectQuery(`SELECT "id","is_active","token_version","must_change_password" FROM "users"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "is_active", "token_version", "must_change_password"}).AddRow(7, true, 0, tt.mustChangePassword))
+
+			authService := service.NewAuthService(db, &config.Config{}, keys, nil)
+			token, err := jwt.GenerateToken(7, "user@example.com", "employee", 0, "", time.Now(), keys, time.Hour)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+
+			router := gin.New()
+			auth := router.Group("/auth")
+			auth.Use(AuthMiddleware(authService))
+			auth.PUT("/password", func(c *gin.Context) { c.Status(http.StatusOK) })
+			attendance := router.Group("/attendance")
+			attendance.Use(AuthMiddleware(authService), PasswordChangeMiddleware())
+			attendance.GET("/today", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet database expectations: %v", err)
+			}
+		})
+	}
+}
@@ -1,19 +1,55 @@
 package middleware
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/attendance/backend/internal/config"
 	"github.com/gin-gonic/gin"
 )
 
-// CORSMiddleware handles CORS
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware handles CORS using the configured origins, methods and headers.
+// Preflight requests are answered here for every path, registered or not.
+func CORSMiddleware(cfg config.CORSConfig) gin.HandlerFunc {
+	allowAnyOrigin := false
+	allowedOrigins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAnyOrigin = true
+		}
+		allowedOrigins[origin] = true
+	}
+
+	allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		origin := c.GetHeader("Origin")
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		if origin != "" && (allowAnyOrigin || allowedOrigins[origin]) {
+			if allowAnyOrigin {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if c.Request.Method == http.MethodOptions {
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+			header.Set("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
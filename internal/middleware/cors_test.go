@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attendance/backend/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func newCORSRouter(cfg config.CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSMiddleware(cfg))
+	router.POST("/api/v1/attendance/check-in", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func TestCORSPreflightWithCustomHeader(t *testing.T) {
+	cfg := config.CORSConfig{
+		AllowedOrigins: []string{"http://localhost:3000"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-Request-ID", "Idempotency-Key"},
+		MaxAge:         10 * time.Minute,
+	}
+	router := newCORSRouter(cfg)
+
+	tests := []struct {
+		name       string
+		path       string
+		origin     string
+		wantOrigin string
+	}{
+		{"registered route", "/api/v1/attendance/check-in", "http://localhost:3000", "http://localhost:3000"},
+		{"unregistered route", "/api/v1/unknown", "http://localhost:3000", "http://localhost:3000"},
+		{"foreign origin", "/api/v1/attendance/check-in", "http://evil.example", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "Idempotency-Key, X-Request-ID")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			allowHeaders := w.Header().Get("Access-Control-Allow-Headers")
+			for _, h := range []string{"X-Request-ID", "Idempotency-Key"} {
+				if !strings.Contains(allowHeaders, h) {
+					t.Errorf("Access-Control-Allow-Headers = %q, missing %s", allowHeaders, h)
+				}
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+				t.Errorf("Access-Control-Max-Age = %q, want 600", got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want it unset", got)
+			}
+		})
+	}
+}
+
+func TestCORSAllowCredentials(t *testing.T) {
+	router := newCORSRouter(config.CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:3000"},
+		AllowedMethods:   []string{"POST"},
+		AllowCredentials: true,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/attendance/check-in", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// IPAllowlistMiddleware rejects requests from client IPs outside allowlist with 403.
+// X-Forwarded-For is only believed when the connection comes from one of trustedProxies,
+// so clients cannot spoof their address by sending the header themselves. An empty
+// allowlist disables the check. Entries are validated at startup by AuthConfig.Validate.
+func IPAllowlistMiddleware(allowlist, trustedProxies []string) gin.HandlerFunc {
+	allowed, _ := config.ParseCIDRs(allowlist)
+	proxies, _ := config.ParseCIDRs(trustedProxies)
+
+	return func(c *gin.Context) {
+		if len(allowed) == 0 {
+			c.Next()
+			return
+		}
+
+		ip := clientIP(c, proxies)
+		if ip == nil || !containsIP(allowed, ip) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Access denied from this IP address", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientIP returns the address of the client, walking X-Forwarded-For from the right
+// past trusted proxies. The first untrusted hop is the client; a malformed hop yields nil.
+func clientIP(c *gin.Context, proxies []*net.IPNet) net.IP {
+	remoteIP := net.ParseIP(c.RemoteIP())
+	if remoteIP == nil || !containsIP(proxies, remoteIP) {
+		return remoteIP
+	}
+
+	forwarded := c.GetHeader("X-Forwarded-For")
+	if forwarded == "" {
+		return remoteIP
+	}
+
+	hops := strings.Split(forwarded, ",")
+	ip := remoteIP
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip = net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			return nil
+		}
+		if !containsIP(proxies, ip) {
+			return ip
+		}
+	}
+	// Every hop is a trusted proxy; the leftmost is the closest we get to the client
+	return ip
+}
+
+// containsIP reports whether ip falls inside any of networks
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
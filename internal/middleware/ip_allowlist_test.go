@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	office := []string{"203.0.113.0/24", "198.51.100.7"}
+	proxy := []string{"10.0.0.0/8"}
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		proxies   []string
+		remote    string
+		forwarded string
+		want      int
+	}{
+		{"disabled", nil, nil, "192.0.2.1", "", http.StatusOK},
+		{"allowed range", office, nil, "203.0.113.20", "", http.StatusOK},
+		{"allowed single address", office, nil, "198.51.100.7", "", http.StatusOK},
+		{"blocked", office, nil, "192.0.2.1", "", http.StatusForbidden},
+		// Without a trusted proxy the header is the client's own claim
+		{"spoofed header", office, nil, "192.0.2.1", "203.0.113.20", http.StatusForbidden},
+		{"header from an untrusted peer", office, proxy, "192.0.2.1", "203.0.113.20", http.StatusForbidden},
+		{"allowed behind a trusted proxy", office, proxy, "10.0.0.5", "203.0.113.20", http.StatusOK},
+		{"blocked behind a trusted proxy", office, proxy, "10.0.0.5", "192.0.2.1", http.StatusForbidden},
+		// The client prepended an allowed address; the rightmost untrusted hop counts
+		{"spoofed hop behind a trusted proxy", office, proxy, "10.0.0.5", "203.0.113.20, 192.0.2.1, 10.0.0.9", http.StatusForbidden},
+		{"chain of trusted proxies", office, proxy, "10.0.0.5", "203.0.113.20, 10.0.0.9", http.StatusOK},
+		{"malformed hop", office, proxy, "10.0.0.5", "not-an-ip", http.StatusForbidden},
+		{"trusted proxy without the header", office, proxy, "10.0.0.5", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(IPAllowlistMiddleware(tt.allowlist, tt.proxies))
+			router.GET("/api/v1/admin/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+			req.RemoteAddr = tt.remote + ":12345"
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
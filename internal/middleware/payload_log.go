@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxLoggedPayload is the largest request body PayloadLogMiddleware reads for logging
+const maxLoggedPayload = 64 << 10
+
+// redactedKeys are matched, case-insensitively, as substrings of JSON object keys
+var redactedKeys = []string{"password", "secret", "token"}
+
+// PayloadLogMiddleware logs the JSON request bodies sent to the given route patterns
+// (as registered, e.g. "/api/v1/attendance/check-in") with the request ID, for debugging
+// rejected requests. Values of keys containing password, secret or token are replaced by
+// "[REDACTED]"; bodies that are not JSON or too large are only described, never logged.
+// The request ID is taken from X-Request-ID, or generated and echoed back when missing.
+func PayloadLogMiddleware(routes []string) gin.HandlerFunc {
+	logged := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		logged[route] = true
+	}
+
+	return func(c *gin.Context) {
+		if !logged[c.FullPath()] || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = newRequestID()
+			c.Header("X-Request-ID", requestID)
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedPayload+1))
+		if err != nil {
+			c.Next()
+			return
+		}
+		// Hand the handler the bytes already read followed by whatever was left unread
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+		log.Printf("payload [%s] %s %s: %s", requestID, c.Request.Method, c.Request.URL.Path, sanitizePayload(body))
+		c.Next()
+	}
+}
+
+// sanitizePayload renders body for the log with sensitive values redacted
+func sanitizePayload(body []byte) string {
+	if len(body) > maxLoggedPayload {
+		return "<body too large to log>"
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return "<empty>"
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Sprintf("<non-JSON body, %d bytes>", len(body))
+	}
+
+	sanitized, err := json.Marshal(redactPayload(payload))
+	if err != nil {
+		return "<unprintable body>"
+	}
+	return string(sanitized)
+}
+
+// redactPayload replaces the values of sensitive keys at any depth
+func redactPayload(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isRedactedKey(key) {
+				v[key] = "[REDACTED]"
+				continue
+			}
+			v[key] = redactPayload(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactPayload(item)
+		}
+	}
+	return value
+}
+
+func isRedactedKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, redacted := range redactedKeys {
+		if strings.Contains(key, redacted) {
+			return true
+		}
+	}
+	return false
+}
+
+// newRequestID returns a random identifier to correlate log lines of one request
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
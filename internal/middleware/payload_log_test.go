@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSanitizePayload(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"password", `{"email":"budi@example.com","password":"secret1"}`, `{"email":"budi@example.com","password":"[REDACTED]"}`},
+		{"keys matched ignoring case", `{"NewPassword":"secret2","refresh_token":"abc","client_secret":"xyz"}`,
+			`{"NewPassword":"[REDACTED]","client_secret":"[REDACTED]","refresh_token":"[REDACTED]"}`},
+		{"nested", `{"user":{"old_password":"a"},"items":[{"token":"b","note":"c"}]}`,
+			`{"items":[{"note":"c","token":"[REDACTED]"}],"user":{"old_password":"[REDACTED]"}}`},
+		{"redacted object", `{"secret":{"value":"a"}}`, `{"secret":"[REDACTED]"}`},
+		{"nothing sensitive", `{"latitude":-6.2,"longitude":106.8}`, `{"latitude":-6.2,"longitude":106.8}`},
+		{"empty", "  ", "<empty>"},
+		{"not JSON", "password=secret1", "<non-JSON body, 16 bytes>"},
+		{"too large", `{"note":"` + strings.Repeat("a", maxLoggedPayload) + `"}`, "<body too large to log>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizePayload([]byte(tt.body)); got != tt.want {
+				t.Errorf("sanitizePayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayloadLogMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"email":"budi@example.com","password":"secret1"}`
+
+	tests := []struct {
+		name      string
+		path      string
+		requestID string
+		wantLog   bool
+	}{
+		{"logged route", "/api/v1/auth/login", "req-1", true},
+		{"logged route without a request ID", "/api/v1/auth/login", "", true},
+		{"other route", "/api/v1/auth/register", "req-2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&logs)
+
+			var received string
+			handler := func(c *gin.Context) {
+				b, _ := io.ReadAll(c.Request.Body)
+				received = string(b)
+				c.Status(http.StatusOK)
+			}
+			router := gin.New()
+			router.Use(PayloadLogMiddleware([]string{"/api/v1/auth/login"}))
+			router.POST("/api/v1/auth/login", handler)
+			router.POST("/api/v1/auth/register", handler)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body))
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// The handler still gets the untouched body
+			if received != body {
+				t.Errorf("handler body = %s, want %s", received, body)
+			}
+			if strings.Contains(logs.String(), "secret1") {
+				t.Errorf("log contains the password: %s", logs.String())
+			}
+			if got := strings.Contains(logs.String(), `"password":"[REDACTED]"`); got != tt.wantLog {
+				t.Errorf("payload logged = %v, want %v: %s", got, tt.wantLog, logs.String())
+			}
+			if tt.wantLog {
+				requestID := tt.requestID
+				if requestID == "" {
+					requestID = w.Header().Get("X-Request-ID")
+				}
+				if requestID == "" || !strings.Contains(logs.String(), "payload ["+requestID+"]") {
+					t.Errorf("log = %s, want request ID %q", logs.String(), requestID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type rateLimitEntry struct {
+	count   int
+	resetAt time.Time
+}
+
+// RateLimitMiddleware limits each client IP to limit requests per window. The client
+// is resolved like IPAllowlistMiddleware does, so X-Forwarded-For only counts when sent
+// by one of trustedProxies and a client cannot dodge the limit by varying the header.
+func RateLimitMiddleware(limit int, window time.Duration, trustedProxies []string) gin.HandlerFunc {
+	proxies, _ := config.ParseCIDRs(trustedProxies)
+	var mu sync.Mutex
+	clients := make(map[string]*rateLimitEntry)
+
+	return func(c *gin.Context) {
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		// A malformed forwarded hop falls back to the peer, which is still one bucket per proxy
+		ip := c.RemoteIP()
+		if client := clientIP(c, proxies); client != nil {
+			ip = client.String()
+		}
+		now := time.Now()
+
+		mu.Lock()
+		entry, exists := clients[ip]
+		if !exists || now.After(entry.resetAt) {
+			// Drop expired entries so the map doesn't grow unbounded
+			for key, e := range clients {
+				if now.After(e.resetAt) {
+					delete(clients, key)
+				}
+			}
+			entry = &rateLimitEntry{resetAt: now.Add(window)}
+			clients[ip] = entry
+		}
+		entry.count++
+		allowed := entry.count <= limit
+		mu.Unlock()
+
+		if !allowed {
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many requests", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
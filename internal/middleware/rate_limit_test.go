@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name  string
+		limit int
+		ips   []string
+		want  []int
+	}{
+		{"within the limit", 2, []string{"10.0.0.1", "10.0.0.1"}, []int{http.StatusOK, http.StatusOK}},
+		{"over the limit", 2, []string{"10.0.0.1", "10.0.0.1", "10.0.0.1"}, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		{"counted per client", 1, []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"}, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		{"zero limit disables", 0, []string{"10.0.0.1", "10.0.0.1"}, []int{http.StatusOK, http.StatusOK}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RateLimitMiddleware(tt.limit, time.Minute, nil))
+			router.GET("/api/v1/auth/email-available", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, ip := range tt.ips {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/email-available", nil)
+				req.RemoteAddr = ip + ":12345"
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != tt.want[i] {
+					t.Errorf("request %d from %s: status = %d, want %d", i+1, ip, w.Code, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddlewareForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	proxy := []string{"10.0.0.0/8"}
+
+	tests := []struct {
+		name      string
+		proxies   []string
+		remote    string
+		forwarded []string
+		want      []int
+	}{
+		// Without a trusted proxy a fresh header on each request is the client's own claim
+		{"spoofed header still limited", nil, "192.0.2.1", []string{"203.0.113.1", "203.0.113.2"}, []int{http.StatusOK, http.StatusTooManyRequests}},
+		{"spoofed header from an untrusted peer", proxy, "192.0.2.1", []string{"203.0.113.1", "203.0.113.2"}, []int{http.StatusOK, http.StatusTooManyRequests}},
+		{"clients behind a trusted proxy", proxy, "10.0.0.5", []string{"203.0.113.1", "203.0.113.2"}, []int{http.StatusOK, http.StatusOK}},
+		{"same client behind a trusted proxy", proxy, "10.0.0.5", []string{"203.0.113.1", "203.0.113.1"}, []int{http.StatusOK, http.StatusTooManyRequests}},
+		// The client prepended a fresh address; the rightmost untrusted hop counts
+		{"spoofed hop behind a trusted proxy", proxy, "10.0.0.5", []string{"198.51.100.1, 203.0.113.1", "198.51.100.2, 203.0.113.1"}, []int{http.StatusOK, http.StatusTooManyRequests}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RateLimitMiddleware(1, time.Minute, tt.proxies))
+			router.POST("/api/v1/auth/register", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, forwarded := range tt.forwarded {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", nil)
+				req.RemoteAddr = tt.remote + ":12345"
+				req.Header.Set("X-Forwarded-For", forwarded)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != tt.want[i] {
+					t.Errorf("request %d forwarded for %s: status = %d, want %d", i+1, forwarded, w.Code, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddlewareWindowResets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimitMiddleware(1, 20*time.Millisecond, nil))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", code, http.StatusOK)
+	}
+	if code := get(); code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if code := get(); code != http.StatusOK {
+		t.Errorf("request after the window status = %d, want %d", code, http.StatusOK)
+	}
+}
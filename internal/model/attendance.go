@@ -2,28 +2,60 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
+// AttendanceStatuses lists every status an attendance record can have
+var AttendanceStatuses = []string{"present", "early", "late", "half_day", "remote", "absent"}
+
+// IsValidAttendanceStatus reports whether status is one of AttendanceStatuses
+func IsValidAttendanceStatus(status string) bool {
+	for _, s := range AttendanceStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewResolutions lists the outcomes of an admin review of a flagged record
+var ReviewResolutions = []string{"confirmed", "cleared"}
+
 type Attendance struct {
-	ID                   uint       `gorm:"primaryKey" json:"id"`
-	UserID               uint       `gorm:"not null" json:"user_id"`
-	LocationID           uint       `gorm:"not null" json:"location_id"`
-	CheckInTime          time.Time  `gorm:"not null" json:"check_in_time"`
-	CheckOutTime         *time.Time `json:"check_out_time"`
-	CheckInLatitude      float64    `gorm:"not null;type:decimal(10,8)" json:"check_in_latitude"`
-	CheckInLongitude     float64    `gorm:"not null;type:decimal(11,8)" json:"check_in_longitude"`
-	CheckOutLatitude     *float64   `gorm:"type:decimal(10,8)" json:"check_out_latitude"`
-	CheckOutLongitude    *float64   `gorm:"type:decimal(11,8)" json:"check_out_longitude"`
-	DistanceFromLocation float64    `gorm:"type:decimal(10,2)" json:"distance_from_location"` // in meters
-	Status               string     `gorm:"default:present" json:"status"`                     // 'present', 'late', 'half_day'
-	Notes                string     `json:"notes"`
-	PhotoURL             string     `json:"photo_url"`
-	CreatedAt            time.Time  `json:"created_at"`
-	UpdatedAt            time.Time  `json:"updated_at"`
+	ID                   uint           `gorm:"primaryKey" json:"id"`
+	UserID               uint           `gorm:"not null" json:"user_id"`
+	LocationID           uint           `gorm:"not null" json:"location_id"`
+	CheckInTime          time.Time      `gorm:"not null" json:"check_in_time"`
+	ArrivalTime          *time.Time     `json:"arrival_time"` // set by two-phase check-in, when the user was first seen on site
+	CheckOutTime         *time.Time     `json:"check_out_time"`
+	CheckInLatitude      float64        `gorm:"not null;type:decimal(10,8)" json:"check_in_latitude"`
+	CheckInLongitude     float64        `gorm:"not null;type:decimal(11,8)" json:"check_in_longitude"`
+	CheckOutLatitude     *float64       `gorm:"type:decimal(10,8)" json:"check_out_latitude"`
+	CheckOutLongitude    *float64       `gorm:"type:decimal(11,8)" json:"check_out_longitude"`
+	DistanceFromLocation float64        `gorm:"type:decimal(10,2)" json:"distance_from_location"` // in meters
+	OutsideRadius        bool           `gorm:"default:false" json:"outside_radius"`              // checked in outside the radius but allowed
+	ClientTime           *time.Time     `json:"client_time"`                                      // device clock at check-in, as reported by the client
+	ClockSkewSuspicious  bool           `gorm:"default:false" json:"clock_skew_suspicious"`       // client_time was too far from server time
+	CheckOutDistance     *float64       `gorm:"type:decimal(10,2)" json:"check_out_distance"`     // in meters
+	AutoCheckout         bool           `gorm:"default:false" json:"auto_checkout"`               // checked out by an admin action, not by the user
+	Status               string         `gorm:"default:present" json:"status"`                    // 'present', 'early', 'late', 'half_day', 'remote'
+	Notes                string         `json:"notes"`
+	AdminNotes           string         `json:"-"` // admin only, exposed through ToAdminResponse
+	PhotoURL             string         `json:"photo_url"`
+	Reviewed             bool           `gorm:"default:false" json:"reviewed"`
+	ReviewedBy           *uint          `json:"reviewed_by"`
+	ReviewedAt           *time.Time     `json:"reviewed_at"`
+	ReviewResolution     string         `json:"review_resolution"` // 'confirmed' or 'cleared'
+	ReviewNote           string         `json:"review_note"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `gorm:"index" json:"-"` // set while the record is in the trash
 
 	// Relations
-	User     User               `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Location AttendanceLocation `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	User        User                   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Location    AttendanceLocation     `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Attachments []AttendanceAttachment `gorm:"foreignKey:AttendanceID" json:"attachments,omitempty"`
 }
 
 // TableName specifies the table name for Attendance model
@@ -33,24 +65,45 @@ func (Attendance) TableName() string {
 
 // AttendanceResponse represents attendance data with relations
 type AttendanceResponse struct {
-	ID                   uint                `json:"id"`
-	UserID               uint                `json:"user_id"`
-	LocationID           uint                `json:"location_id"`
-	CheckInTime          time.Time           `json:"check_in_time"`
-	CheckOutTime         *time.Time          `json:"check_out_time"`
-	CheckInLatitude      float64             `json:"check_in_latitude"`
-	CheckInLongitude     float64             `json:"check_in_longitude"`
-	CheckOutLatitude     *float64            `json:"check_out_latitude"`
-	CheckOutLongitude    *float64            `json:"check_out_longitude"`
-	DistanceFromLocation float64             `json:"distance_from_location"`
-	Status               string              `json:"status"`
-	Notes                string              `json:"notes"`
-	PhotoURL             string              `json:"photo_url"`
-	WorkDuration         *string             `json:"work_duration,omitempty"` // calculated field
-	User                 *UserResponse       `json:"user,omitempty"`
-	Location             *LocationResponse   `json:"location,omitempty"`
-	CreatedAt            time.Time           `json:"created_at"`
-	UpdatedAt            time.Time           `json:"updated_at"`
+	ID                   uint                 `json:"id"`
+	UserID               uint                 `json:"user_id"`
+	LocationID           uint                 `json:"location_id"`
+	CheckInTime          time.Time            `json:"check_in_time"`
+	ArrivalTime          *time.Time           `json:"arrival_time,omitempty"`
+	CheckOutTime         *time.Time           `json:"check_out_time"`
+	CheckInLatitude      float64              `json:"check_in_latitude"`
+	CheckInLongitude     float64              `json:"check_in_longitude"`
+	CheckOutLatitude     *float64             `json:"check_out_latitude"`
+	CheckOutLongitude    *float64             `json:"check_out_longitude"`
+	DistanceFromLocation float64              `json:"distance_from_location"`
+	OutsideRadius        bool                 `json:"outside_radius"`
+	ClientTime           *time.Time           `json:"client_time,omitempty"`
+	ClockSkewSuspicious  bool                 `json:"clock_skew_suspicious"`
+	CheckOutDistance     *float64             `json:"check_out_distance"`
+	AutoCheckout         bool                 `json:"auto_checkout"`
+	Status               string               `json:"status"`
+	Notes                string               `json:"notes"`
+	AdminNotes           string               `json:"admin_notes,omitempty"` // only set by ToAdminResponse
+	PhotoURL             string               `json:"photo_url"`
+	Reviewed             bool                 `json:"reviewed"`
+	ReviewedBy           *uint                `json:"reviewed_by,omitempty"`
+	ReviewedAt           *time.Time           `json:"reviewed_at,omitempty"`
+	ReviewResolution     string               `json:"review_resolution,omitempty"`
+	ReviewNote           string               `json:"review_note,omitempty"`
+	WorkDuration         *string              `json:"work_duration,omitempty"` // calculated field
+	RoundedCheckInTime   *time.Time           `json:"rounded_check_in_time,omitempty"`
+	RoundedCheckOutTime  *time.Time           `json:"rounded_check_out_time,omitempty"`
+	RawWorkDuration      *string              `json:"raw_work_duration,omitempty"`
+	ScheduledCheckIn     *time.Time           `json:"scheduled_check_in"`      // check-in deadline of the schedule that applied, nil without one
+	ScheduledCheckOut    *time.Time           `json:"scheduled_check_out"`     // check-out start of that schedule
+	CheckInDeltaMinutes  *int                 `json:"check_in_delta_minutes"`  // positive when checked in late
+	CheckOutDeltaMinutes *int                 `json:"check_out_delta_minutes"` // negative when checked out early, nil while open
+	User                 *UserResponse        `json:"user,omitempty"`
+	Location             *LocationResponse    `json:"location,omitempty"`
+	Attachments          []AttachmentResponse `json:"attachments,omitempty"`
+	CreatedAt            time.Time            `json:"created_at"`
+	UpdatedAt            time.Time            `json:"updated_at"`
+	DeletedAt            *time.Time           `json:"deleted_at,omitempty"` // only set for trashed records
 }
 
 // ToResponse converts Attendance to AttendanceResponse
@@ -60,19 +113,34 @@ func (a *Attendance) ToResponse() AttendanceResponse {
 		UserID:               a.UserID,
 		LocationID:           a.LocationID,
 		CheckInTime:          a.CheckInTime,
+		ArrivalTime:          a.ArrivalTime,
 		CheckOutTime:         a.CheckOutTime,
 		CheckInLatitude:      a.CheckInLatitude,
 		CheckInLongitude:     a.CheckInLongitude,
 		CheckOutLatitude:     a.CheckOutLatitude,
 		CheckOutLongitude:    a.CheckOutLongitude,
 		DistanceFromLocation: a.DistanceFromLocation,
+		OutsideRadius:        a.OutsideRadius,
+		ClientTime:           a.ClientTime,
+		ClockSkewSuspicious:  a.ClockSkewSuspicious,
+		CheckOutDistance:     a.CheckOutDistance,
+		AutoCheckout:         a.AutoCheckout,
 		Status:               a.Status,
 		Notes:                a.Notes,
 		PhotoURL:             a.PhotoURL,
+		Reviewed:             a.Reviewed,
+		ReviewedBy:           a.ReviewedBy,
+		ReviewedAt:           a.ReviewedAt,
+		ReviewResolution:     a.ReviewResolution,
+		ReviewNote:           a.ReviewNote,
 		CreatedAt:            a.CreatedAt,
 		UpdatedAt:            a.UpdatedAt,
 	}
 
+	if a.DeletedAt.Valid {
+		response.DeletedAt = &a.DeletedAt.Time
+	}
+
 	// Calculate work duration if checked out
 	if a.CheckOutTime != nil {
 		duration := a.CheckOutTime.Sub(a.CheckInTime)
@@ -92,9 +160,142 @@ func (a *Attendance) ToResponse() AttendanceResponse {
 		response.Location = &locResp
 	}
 
+	// Add attachments if loaded
+	for i := range a.Attachments {
+		response.Attachments = append(response.Attachments, a.Attachments[i].ToResponse())
+	}
+
 	return response
 }
 
+// ToAdminResponse converts Attendance to AttendanceResponse including the admin notes.
+// It must only be used for admin endpoints; the record owner gets ToResponse.
+func (a *Attendance) ToAdminResponse(rounding PayrollRounding) AttendanceResponse {
+	response := a.ToPayrollResponse(rounding)
+	response.AdminNotes = a.AdminNotes
+	return response
+}
+
+// SetSchedule fills the scheduled times and the deltas of the actual times against them
+// in whole minutes. A nil schedule, or a check-in on a day the schedule does not work,
+// leaves them null.
+func (r *AttendanceResponse) SetSchedule(schedule *WorkSchedule) {
+	if schedule == nil || !schedule.WorksOn(r.CheckInTime.Weekday()) {
+		return
+	}
+	checkIn, checkOut, ok := schedule.ScheduledTimes(r.CheckInTime)
+	if !ok {
+		return
+	}
+
+	r.ScheduledCheckIn = &checkIn
+	r.ScheduledCheckOut = &checkOut
+	checkInDelta := int(r.CheckInTime.Sub(checkIn).Minutes())
+	r.CheckInDeltaMinutes = &checkInDelta
+	if r.CheckOutTime != nil {
+		checkOutDelta := int(r.CheckOutTime.Sub(checkOut).Minutes())
+		r.CheckOutDeltaMinutes = &checkOutDelta
+	}
+}
+
+// ToPayrollResponse converts Attendance to AttendanceResponse with work duration
+// computed from rounded times. The raw times and duration are kept alongside.
+func (a *Attendance) ToPayrollResponse(rounding PayrollRounding) AttendanceResponse {
+	response := a.ToResponse()
+	if !rounding.Enabled() {
+		return response
+	}
+
+	checkIn := rounding.RoundCheckIn(a.CheckInTime)
+	response.RoundedCheckInTime = &checkIn
+
+	if a.CheckOutTime != nil {
+		checkOut := rounding.RoundCheckOut(*a.CheckOutTime)
+		response.RoundedCheckOutTime = &checkOut
+
+		durationStr := formatDuration(rounding.WorkDuration(a.CheckInTime, *a.CheckOutTime))
+		response.RawWorkDuration = response.WorkDuration
+		response.WorkDuration = &durationStr
+	}
+
+	return response
+}
+
+// AttendanceFlatRow is a denormalized attendance record with user and
+// location fields inlined, one row per attendance for spreadsheet imports
+type AttendanceFlatRow struct {
+	ID                   uint       `json:"id"`
+	UserID               uint       `json:"user_id"`
+	UserName             string     `json:"user_name"`
+	UserEmail            string     `json:"user_email"`
+	UserPhone            string     `json:"user_phone"`
+	LocationID           uint       `json:"location_id"`
+	LocationName         string     `json:"location_name"`
+	LocationLatitude     float64    `json:"location_latitude"`
+	LocationLongitude    float64    `json:"location_longitude"`
+	CheckInTime          time.Time  `json:"check_in_time"`
+	CheckOutTime         *time.Time `json:"check_out_time"`
+	WorkDuration         string     `json:"work_duration"`
+	RoundedCheckInTime   *time.Time `json:"rounded_check_in_time,omitempty"`
+	RoundedCheckOutTime  *time.Time `json:"rounded_check_out_time,omitempty"`
+	RawWorkDuration      string     `json:"raw_work_duration,omitempty"`
+	Status               string     `json:"status"`
+	DistanceFromLocation float64    `json:"distance_from_location"`
+	CheckOutDistance     *float64   `json:"check_out_distance"`
+	OutsideRadius        bool       `json:"outside_radius"`
+	ClockSkewSuspicious  bool       `json:"clock_skew_suspicious"`
+	Reviewed             bool       `json:"reviewed"`
+	ReviewResolution     string     `json:"review_resolution"`
+	Notes                string     `json:"notes"`
+	AdminNotes           string     `json:"admin_notes"`
+}
+
+// ToFlatRow converts Attendance to AttendanceFlatRow.
+// User and Location must be preloaded for their fields to be filled.
+// Work duration is computed from rounded times when rounding is enabled.
+// Rows include admin notes, so they are for admin exports only.
+func (a *Attendance) ToFlatRow(rounding PayrollRounding) AttendanceFlatRow {
+	row := AttendanceFlatRow{
+		ID:                   a.ID,
+		UserID:               a.UserID,
+		UserName:             a.User.FullName,
+		UserEmail:            a.User.Email,
+		UserPhone:            a.User.Phone,
+		LocationID:           a.LocationID,
+		LocationName:         a.Location.Name,
+		LocationLatitude:     a.Location.Latitude,
+		LocationLongitude:    a.Location.Longitude,
+		CheckInTime:          a.CheckInTime,
+		CheckOutTime:         a.CheckOutTime,
+		Status:               a.Status,
+		DistanceFromLocation: a.DistanceFromLocation,
+		CheckOutDistance:     a.CheckOutDistance,
+		OutsideRadius:        a.OutsideRadius,
+		ClockSkewSuspicious:  a.ClockSkewSuspicious,
+		Reviewed:             a.Reviewed,
+		ReviewResolution:     a.ReviewResolution,
+		Notes:                a.Notes,
+		AdminNotes:           a.AdminNotes,
+	}
+
+	if a.CheckOutTime != nil {
+		row.WorkDuration = formatDuration(a.CheckOutTime.Sub(a.CheckInTime))
+	}
+
+	if rounding.Enabled() {
+		checkIn := rounding.RoundCheckIn(a.CheckInTime)
+		row.RoundedCheckInTime = &checkIn
+		if a.CheckOutTime != nil {
+			checkOut := rounding.RoundCheckOut(*a.CheckOutTime)
+			row.RoundedCheckOutTime = &checkOut
+			row.RawWorkDuration = row.WorkDuration
+			row.WorkDuration = formatDuration(rounding.WorkDuration(a.CheckInTime, *a.CheckOutTime))
+		}
+	}
+
+	return row
+}
+
 // formatDuration formats duration to human-readable string
 func formatDuration(d time.Duration) string {
 	hours := int(d.Hours())
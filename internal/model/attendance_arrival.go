@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// AttendanceArrival is the first phase of a two-phase check-in: the user was
+// seen on site (e.g. by a turnstile) but has not started work yet. It becomes
+// an Attendance once work is started and is removed at that point.
+type AttendanceArrival struct {
+	ID                   uint       `gorm:"primaryKey" json:"id"`
+	UserID               uint       `gorm:"not null" json:"user_id"`
+	LocationID           uint       `gorm:"not null" json:"location_id"`
+	ArrivedAt            time.Time  `gorm:"not null" json:"arrived_at"`
+	Latitude             float64    `gorm:"not null;type:decimal(10,8)" json:"latitude"`
+	Longitude            float64    `gorm:"not null;type:decimal(11,8)" json:"longitude"`
+	DistanceFromLocation float64    `gorm:"type:decimal(10,2)" json:"distance_from_location"` // in meters
+	OutsideRadius        bool       `gorm:"default:false" json:"outside_radius"`
+	ClientTime           *time.Time `json:"client_time"`
+	ClockSkewSuspicious  bool       `gorm:"default:false" json:"clock_skew_suspicious"`
+	Notes                string     `json:"notes"`
+	PhotoURL             string     `json:"photo_url"`
+	CreatedAt            time.Time  `json:"created_at"`
+
+	// Relations
+	Location AttendanceLocation `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+}
+
+// TableName specifies the table name for AttendanceArrival model
+func (AttendanceArrival) TableName() string {
+	return "attendance_arrivals"
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// AttachmentTypes lists the kinds of files that can be attached to an attendance
+var AttachmentTypes = []string{"photo", "document"}
+
+type AttendanceAttachment struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	AttendanceID uint      `gorm:"not null" json:"attendance_id"`
+	Type         string    `gorm:"not null;default:photo" json:"type"` // 'photo' or 'document'
+	Caption      string    `json:"caption"`
+	URL          string    `gorm:"not null" json:"-"` // storage location, never served directly
+	ContentType  string    `gorm:"not null" json:"content_type"`
+	Size         int64     `json:"size"` // in bytes
+	UploadedBy   *uint     `json:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for AttendanceAttachment model
+func (AttendanceAttachment) TableName() string {
+	return "attendance_attachments"
+}
+
+// AttachmentResponse represents an attachment as returned by the API. The file is
+// downloaded through the authorized attachment route rather than from storage.
+type AttachmentResponse struct {
+	ID           uint      `json:"id"`
+	AttendanceID uint      `json:"attendance_id"`
+	Type         string    `json:"type"`
+	Caption      string    `json:"caption"`
+	URL          string    `json:"url"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	UploadedBy   *uint     `json:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ToResponse converts AttendanceAttachment to AttachmentResponse
+func (a *AttendanceAttachment) ToResponse() AttachmentResponse {
+	return AttachmentResponse{
+		ID:           a.ID,
+		AttendanceID: a.AttendanceID,
+		Type:         a.Type,
+		Caption:      a.Caption,
+		URL:          fmt.Sprintf("/api/v1/attendance/%d/attachments/%d", a.AttendanceID, a.ID),
+		ContentType:  a.ContentType,
+		Size:         a.Size,
+		UploadedBy:   a.UploadedBy,
+		CreatedAt:    a.CreatedAt,
+	}
+}
@@ -1,18 +1,32 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type AttendanceLocation struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Name        string    `gorm:"not null" json:"name"`
-	Description string    `json:"description"`
-	Latitude    float64   `gorm:"not null;type:decimal(10,8)" json:"latitude"`
-	Longitude   float64   `gorm:"not null;type:decimal(11,8)" json:"longitude"`
-	Radius      int       `gorm:"default:10" json:"radius"` // in meters
-	IsActive    bool      `gorm:"default:true" json:"is_active"`
-	CreatedBy   *uint     `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                 uint          `gorm:"primaryKey" json:"id"`
+	Name               string        `gorm:"not null" json:"name"`
+	Description        string        `json:"description"`
+	Latitude           float64       `gorm:"not null;type:decimal(10,8)" json:"latitude"`
+	Longitude          float64       `gorm:"not null;type:decimal(11,8)" json:"longitude"`
+	Radius             int           `json:"radius"`           // in meters, defaults to LOCATION_DEFAULT_RADIUS
+	CheckOutRadius     *int          `json:"check_out_radius"` // in meters, nil means check-out uses radius
+	IsActive           bool          `gorm:"default:true" json:"is_active"`
+	OperatingDays      pq.Int64Array `gorm:"type:integer[]" json:"operating_days"`   // [1..7], empty means every day
+	Timezone           string        `json:"timezone"`                               // IANA name, empty means server local time
+	RegionID           *uint         `json:"region_id"`                              // nil when the location is not in a region
+	QRRequired         bool          `gorm:"default:false" json:"qr_required"`       // check-in needs a token from the site's QR code
+	CheckInWindowStart *string       `gorm:"type:time" json:"check_in_window_start"` // "05:00:00", nil means check-ins all day
+	CheckInWindowEnd   *string       `gorm:"type:time" json:"check_in_window_end"`   // before the start when the window crosses midnight
+	SuspendedAt        *time.Time    `json:"suspended_at"`
+	SuspendedUntil     *time.Time    `json:"suspended_until"` // nil means until manually activated
+	SuspensionReason   string        `json:"suspension_reason"`
+	CreatedBy          *uint         `json:"created_by"`
+	CreatedAt          time.Time     `json:"created_at"`
+	UpdatedAt          time.Time     `json:"updated_at"`
 
 	// Relations
 	Creator *User `gorm:"foreignKey:CreatedBy" json:"creator,omitempty"`
@@ -25,30 +39,154 @@ func (AttendanceLocation) TableName() string {
 
 // LocationResponse represents location data with creator info
 type LocationResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Latitude    float64   `json:"latitude"`
-	Longitude   float64   `json:"longitude"`
-	Radius      int       `json:"radius"`
-	IsActive    bool      `json:"is_active"`
-	CreatedBy   *uint     `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                 uint          `json:"id"`
+	Name               string        `json:"name"`
+	Description        string        `json:"description"`
+	Latitude           float64       `json:"latitude"`
+	Longitude          float64       `json:"longitude"`
+	Radius             int           `json:"radius"`
+	CheckOutRadius     *int          `json:"check_out_radius"`
+	IsActive           bool          `json:"is_active"`
+	OperatingDays      []int         `json:"operating_days"`
+	Timezone           string        `json:"timezone"`
+	RegionID           *uint         `json:"region_id"`
+	QRRequired         bool          `json:"qr_required"`
+	CheckInWindowStart *string       `json:"check_in_window_start"`
+	CheckInWindowEnd   *string       `json:"check_in_window_end"`
+	IsSuspended        bool          `json:"is_suspended"`
+	SuspendedAt        *time.Time    `json:"suspended_at,omitempty"`
+	SuspendedUntil     *time.Time    `json:"suspended_until,omitempty"`
+	SuspensionReason   string        `json:"suspension_reason,omitempty"`
+	CreatedBy          *uint         `json:"created_by"`
+	Creator            *UserResponse `json:"creator,omitempty"`
+	CreatedAt          time.Time     `json:"created_at"`
+	UpdatedAt          time.Time     `json:"updated_at"`
+}
+
+// EffectiveCheckOutRadius returns the radius check-outs are validated against
+func (l *AttendanceLocation) EffectiveCheckOutRadius() int {
+	if l.CheckOutRadius != nil {
+		return *l.CheckOutRadius
+	}
+	return l.Radius
+}
+
+// IsSuspended reports whether the location is temporarily offline
+func (l *AttendanceLocation) IsSuspended() bool {
+	return l.SuspendedAt != nil
+}
+
+// IsSuspendedDuring reports whether a suspension covers any part of [from, to)
+func (l *AttendanceLocation) IsSuspendedDuring(from, to time.Time) bool {
+	if l.SuspendedAt == nil {
+		return false
+	}
+	return l.SuspendedAt.Before(to) && (l.SuspendedUntil == nil || l.SuspendedUntil.After(from))
+}
+
+// TimeLocation returns the location's timezone, falling back to server local time
+func (l *AttendanceLocation) TimeLocation() *time.Location {
+	if l.Timezone == "" {
+		return time.Local
+	}
+	tz, err := time.LoadLocation(l.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return tz
+}
+
+// IsOpenOn reports whether the location operates on the weekday of t in the
+// location's timezone. Locations without operating days configured are open every day.
+func (l *AttendanceLocation) IsOpenOn(t time.Time) bool {
+	return l.isOpenOnWeekday(t.In(l.TimeLocation()).Weekday())
+}
+
+// IsOpenOnDate reports whether the location operates on the calendar date of day,
+// whatever timezone day is in. Use it for dates rather than instants.
+func (l *AttendanceLocation) IsOpenOnDate(day time.Time) bool {
+	return l.isOpenOnWeekday(day.Weekday())
+}
+
+func (l *AttendanceLocation) isOpenOnWeekday(wd time.Weekday) bool {
+	if len(l.OperatingDays) == 0 {
+		return true
+	}
+
+	weekday := int64(wd)
+	if weekday == 0 {
+		weekday = 7 // 1=Monday ... 7=Sunday
+	}
+
+	for _, day := range l.OperatingDays {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWithinCheckInWindow reports whether t, in the location's timezone, falls inside the
+// check-in window (both ends inclusive). Locations without a window accept check-ins all day.
+func (l *AttendanceLocation) IsWithinCheckInWindow(t time.Time) bool {
+	if l.CheckInWindowStart == nil || l.CheckInWindowEnd == nil {
+		return true
+	}
+
+	start, errStart := time.Parse("15:04:05", *l.CheckInWindowStart)
+	end, errEnd := time.Parse("15:04:05", *l.CheckInWindowEnd)
+	if errStart != nil || errEnd != nil {
+		return true
+	}
+
+	local := t.In(l.TimeLocation())
+	second := local.Hour()*3600 + local.Minute()*60 + local.Second()
+	startSecond := start.Hour()*3600 + start.Minute()*60 + start.Second()
+	endSecond := end.Hour()*3600 + end.Minute()*60 + end.Second()
+
+	if startSecond <= endSecond {
+		return second >= startSecond && second <= endSecond
+	}
+	// Window crosses midnight, e.g. 22:00-02:00
+	return second >= startSecond || second <= endSecond
 }
 
 // ToResponse converts AttendanceLocation to LocationResponse
 func (l *AttendanceLocation) ToResponse() LocationResponse {
-	return LocationResponse{
-		ID:          l.ID,
-		Name:        l.Name,
-		Description: l.Description,
-		Latitude:    l.Latitude,
-		Longitude:   l.Longitude,
-		Radius:      l.Radius,
-		IsActive:    l.IsActive,
-		CreatedBy:   l.CreatedBy,
-		CreatedAt:   l.CreatedAt,
-		UpdatedAt:   l.UpdatedAt,
+	operatingDays := make([]int, len(l.OperatingDays))
+	for i, day := range l.OperatingDays {
+		operatingDays[i] = int(day)
 	}
+
+	response := LocationResponse{
+		ID:                 l.ID,
+		Name:               l.Name,
+		Description:        l.Description,
+		Latitude:           l.Latitude,
+		Longitude:          l.Longitude,
+		Radius:             l.Radius,
+		CheckOutRadius:     l.CheckOutRadius,
+		IsActive:           l.IsActive,
+		OperatingDays:      operatingDays,
+		Timezone:           l.Timezone,
+		RegionID:           l.RegionID,
+		QRRequired:         l.QRRequired,
+		CheckInWindowStart: l.CheckInWindowStart,
+		CheckInWindowEnd:   l.CheckInWindowEnd,
+		IsSuspended:        l.IsSuspended(),
+		SuspendedAt:        l.SuspendedAt,
+		SuspendedUntil:     l.SuspendedUntil,
+		SuspensionReason:   l.SuspensionReason,
+		CreatedBy:          l.CreatedBy,
+		CreatedAt:          l.CreatedAt,
+		UpdatedAt:          l.UpdatedAt,
+	}
+
+	// Add creator info if loaded
+	if l.Creator != nil {
+		creatorResp := l.Creator.ToResponse()
+		response.Creator = &creatorResp
+	}
+
+	return response
 }
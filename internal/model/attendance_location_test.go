@@ -0,0 +1,132 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestAttendanceLocationIsOpenOn(t *testing.T) {
+	// 2 March 2026 is a Monday
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 10, 0, 0, 0, time.UTC) }
+	weekdays := pq.Int64Array{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name string
+		days pq.Int64Array
+		at   time.Time
+		want bool
+	}{
+		{"no operating days is open every day", nil, day(8), true},
+		{"Monday on weekdays", weekdays, day(2), true},
+		{"Friday on weekdays", weekdays, day(6), true},
+		{"Saturday on weekdays", weekdays, day(7), false},
+		{"Sunday is day 7", pq.Int64Array{7}, day(8), true},
+		{"Sunday is not day 0", pq.Int64Array{0}, day(8), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := AttendanceLocation{OperatingDays: tt.days}
+			if got := l.IsOpenOn(tt.at); got != tt.want {
+				t.Errorf("IsOpenOn(%s) = %v, want %v", tt.at.Weekday(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttendanceLocationIsOpenOnTimezone(t *testing.T) {
+	// Open Monday to Friday in Jakarta (UTC+7)
+	l := AttendanceLocation{OperatingDays: pq.Int64Array{1, 2, 3, 4, 5}, Timezone: "Asia/Jakarta"}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"Friday evening in UTC is Saturday in Jakarta", time.Date(2026, 3, 6, 18, 0, 0, 0, time.UTC), false},
+		{"Sunday evening in UTC is Monday in Jakarta", time.Date(2026, 3, 8, 18, 0, 0, 0, time.UTC), true},
+		{"Friday morning in UTC is still Friday in Jakarta", time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.IsOpenOn(tt.at); got != tt.want {
+				t.Errorf("IsOpenOn(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+
+	// A calendar date keeps its weekday whatever the zone it is given in
+	if saturday := time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC); l.IsOpenOnDate(saturday) {
+		t.Errorf("IsOpenOnDate(%s) = true, want false", saturday.Format("2006-01-02"))
+	}
+}
+
+func TestAttendanceLocationToResponseCreator(t *testing.T) {
+	tests := []struct {
+		name        string
+		creator     *User
+		wantCreator string
+	}{
+		{"creator not loaded", nil, ""},
+		{"creator loaded", &User{ID: 5, FullName: "Jane"}, "Jane"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creatorID := uint(5)
+			location := AttendanceLocation{ID: 3, CreatedBy: &creatorID, Creator: tt.creator}
+
+			response := location.ToResponse()
+			if response.CreatedBy == nil || *response.CreatedBy != creatorID {
+				t.Errorf("CreatedBy = %v, want %d", response.CreatedBy, creatorID)
+			}
+			if tt.wantCreator == "" {
+				if response.Creator != nil {
+					t.Errorf("Creator = %+v, want nil", response.Creator)
+				}
+				return
+			}
+			if response.Creator == nil || response.Creator.ID != 5 || response.Creator.FullName != tt.wantCreator {
+				t.Errorf("Creator = %+v, want user 5 %q", response.Creator, tt.wantCreator)
+			}
+		})
+	}
+}
+
+func TestAttendanceLocationIsWithinCheckInWindow(t *testing.T) {
+	// 2026-03-09 00:00 UTC is 07:00 in Jakarta
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 9, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name       string
+		start, end *string
+		timezone   string
+		at         time.Time
+		want       bool
+	}{
+		{"no window", nil, nil, "", at(3, 0), true},
+		{"inside", strPtr("05:00:00"), strPtr("11:00:00"), "", at(9, 30), true},
+		{"at the start", strPtr("05:00:00"), strPtr("11:00:00"), "", at(5, 0), true},
+		{"at the end", strPtr("05:00:00"), strPtr("11:00:00"), "", at(11, 0), true},
+		{"after the end", strPtr("05:00:00"), strPtr("11:00:00"), "", at(11, 1), false},
+		{"in the location's timezone", strPtr("05:00:00"), strPtr("11:00:00"), "Asia/Jakarta", at(0, 0), true},
+		{"outside in the location's timezone", strPtr("05:00:00"), strPtr("11:00:00"), "Asia/Jakarta", at(9, 30), false},
+		{"overnight before midnight", strPtr("22:00:00"), strPtr("02:00:00"), "", at(23, 0), true},
+		{"overnight after midnight", strPtr("22:00:00"), strPtr("02:00:00"), "", at(1, 0), true},
+		{"overnight during the day", strPtr("22:00:00"), strPtr("02:00:00"), "", at(12, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := AttendanceLocation{CheckInWindowStart: tt.start, CheckInWindowEnd: tt.end, Timezone: tt.timezone}
+			if got := location.IsWithinCheckInWindow(tt.at); got != tt.want {
+				t.Errorf("IsWithinCheckInWindow(%s) = %v, want %v", tt.at.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string { return &s }
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestAttendanceToFlatRow(t *testing.T) {
+	checkIn := time.Date(2026, 3, 9, 8, 7, 0, 0, time.UTC)
+	checkOut := time.Date(2026, 3, 9, 17, 4, 30, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		checkOut     *time.Time
+		rounding     PayrollRounding
+		wantDuration string
+		wantRaw      string
+		wantRounded  bool
+	}{
+		{"open session", nil, PayrollRounding{}, "", "", false},
+		{"closed session", &checkOut, PayrollRounding{}, "8h57m0s", "", false},
+		{"closed session with rounding", &checkOut, PayrollRounding{Interval: 15 * time.Minute, CheckIn: "up", CheckOut: "down"}, "8h45m0s", "8h57m0s", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attendance := Attendance{
+				ID:           11,
+				UserID:       7,
+				User:         User{ID: 7, FullName: "Jane", Email: "jane@example.com", Phone: "0812"},
+				LocationID:   3,
+				Location:     AttendanceLocation{ID: 3, Name: "HQ", Latitude: -6.2, Longitude: 106.8},
+				CheckInTime:  checkIn,
+				CheckOutTime: tt.checkOut,
+				Status:       "present",
+				Notes:        "late bus",
+				AdminNotes:   "verify with HR",
+			}
+
+			row := attendance.ToFlatRow(tt.rounding)
+			if row.UserName != "Jane" || row.UserEmail != "jane@example.com" || row.UserPhone != "0812" {
+				t.Errorf("user fields = (%q, %q, %q), want Jane's", row.UserName, row.UserEmail, row.UserPhone)
+			}
+			if row.LocationName != "HQ" || row.LocationLatitude != -6.2 || row.LocationLongitude != 106.8 {
+				t.Errorf("location fields = (%q, %g, %g), want HQ's", row.LocationName, row.LocationLatitude, row.LocationLongitude)
+			}
+			if row.Notes != "late bus" {
+				t.Errorf("Notes = %q, want %q", row.Notes, "late bus")
+			}
+			if row.AdminNotes != "verify with HR" {
+				t.Errorf("AdminNotes = %q, want %q", row.AdminNotes, "verify with HR")
+			}
+			if row.WorkDuration != tt.wantDuration || row.RawWorkDuration != tt.wantRaw {
+				t.Errorf("durations = (%q, %q), want (%q, %q)", row.WorkDuration, row.RawWorkDuration, tt.wantDuration, tt.wantRaw)
+			}
+			if (row.RoundedCheckInTime != nil) != tt.wantRounded {
+				t.Errorf("RoundedCheckInTime = %v, want set %v", row.RoundedCheckInTime, tt.wantRounded)
+			}
+		})
+	}
+}
+
+func TestAttendanceAdminNotesVisibility(t *testing.T) {
+	attendance := Attendance{ID: 12, CheckInTime: time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), Notes: "traffic", AdminNotes: "verify with HR"}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		visible bool
+	}{
+		{"record", attendance, false},
+		{"owner response", attendance.ToResponse(), false},
+		{"payroll response", attendance.ToPayrollResponse(PayrollRounding{}), false},
+		{"admin response", attendance.ToAdminResponse(PayrollRounding{}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if got := strings.Contains(string(encoded), "verify with HR"); got != tt.visible {
+				t.Errorf("admin notes visible = %v, want %v: %s", got, tt.visible, encoded)
+			}
+		})
+	}
+}
+
+func TestAttendanceResponseSetSchedule(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2026, 3, 9, hour, minute, 0, 0, time.UTC) }
+	schedule := &WorkSchedule{CheckInStart: "08:00:00", CheckInEnd: "09:00:00", CheckOutStart: "17:00:00", WorkDays: pq.Int64Array{1, 2, 3, 4, 5}}
+
+	tests := []struct {
+		name         string
+		schedule     *WorkSchedule
+		checkIn      time.Time
+		checkOut     *time.Time
+		wantCheckIn  string // "null" when no schedule applies
+		wantCheckOut string
+	}{
+		{"early", schedule, at(8, 50), nil, "-10", "null"},
+		{"on time", schedule, at(9, 0), nil, "0", "null"},
+		{"late", schedule, at(9, 25), nil, "25", "null"},
+		{"left early", schedule, at(8, 55), timePtr(at(16, 30)), "-5", "-30"},
+		{"overtime", schedule, at(9, 0), timePtr(at(18, 15)), "0", "75"},
+		{"without a schedule", nil, at(9, 25), timePtr(at(17, 0)), "null", "null"},
+		{"unparsable schedule", &WorkSchedule{CheckInEnd: "9am", CheckOutStart: "5pm", WorkDays: pq.Int64Array{1}}, at(9, 25), nil, "null", "null"},
+		// Sunday, outside the schedule's work days
+		{"on a day off", schedule, at(9, 25).AddDate(0, 0, -1), timePtr(at(17, 0).AddDate(0, 0, -1)), "null", "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attendance := Attendance{ID: 12, CheckInTime: tt.checkIn, CheckOutTime: tt.checkOut}
+			response := attendance.ToResponse()
+			response.SetSchedule(tt.schedule)
+
+			checkIn, _ := json.Marshal(response.CheckInDeltaMinutes)
+			checkOut, _ := json.Marshal(response.CheckOutDeltaMinutes)
+			if string(checkIn) != tt.wantCheckIn || string(checkOut) != tt.wantCheckOut {
+				t.Errorf("deltas = %s, %s, want %s, %s", checkIn, checkOut, tt.wantCheckIn, tt.wantCheckOut)
+			}
+			if (response.ScheduledCheckIn != nil) != (tt.wantCheckIn != "null") {
+				t.Errorf("ScheduledCheckIn = %v, want set %v", response.ScheduledCheckIn, tt.wantCheckIn != "null")
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
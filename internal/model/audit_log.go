@@ -0,0 +1,24 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditLog struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	ActorID    *uint           `json:"actor_id"`
+	Action     string          `gorm:"not null" json:"action"`      // e.g., 'attendance.review'
+	EntityType string          `gorm:"not null" json:"entity_type"` // e.g., 'attendance'
+	EntityID   uint            `gorm:"not null" json:"entity_id"`
+	Details    json.RawMessage `gorm:"type:jsonb" json:"details"`
+	CreatedAt  time.Time       `json:"created_at"`
+
+	// Relations
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+}
+
+// TableName specifies the table name for AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
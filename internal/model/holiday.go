@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Holiday is a day nobody is expected to work, or only nobody at one location
+type Holiday struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Date       time.Time `gorm:"not null;type:date" json:"date"`
+	Name       string    `gorm:"not null" json:"name"`
+	LocationID *uint     `json:"location_id"` // nil applies to every location
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Holiday model
+func (Holiday) TableName() string {
+	return "holidays"
+}
+
+// AppliesTo reports whether the holiday is observed on day at locationID (0 for no location)
+func (h *Holiday) AppliesTo(day time.Time, locationID uint) bool {
+	if h.Date.Format("2006-01-02") != day.Format("2006-01-02") {
+		return false
+	}
+	return h.LocationID == nil || *h.LocationID == locationID
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// LeaveTypes lists every kind of leave a user can be granted
+var LeaveTypes = []string{"annual", "sick", "unpaid", "other"}
+
+// IsValidLeaveType reports whether leaveType is one of LeaveTypes
+func IsValidLeaveType(leaveType string) bool {
+	for _, t := range LeaveTypes {
+		if t == leaveType {
+			return true
+		}
+	}
+	return false
+}
+
+// Leave excuses a user from work from StartDate through EndDate
+type Leave struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	Type      string    `gorm:"not null" json:"type"` // one of LeaveTypes
+	StartDate time.Time `gorm:"not null;type:date" json:"start_date"`
+	EndDate   time.Time `gorm:"not null;type:date" json:"end_date"`
+	Reason    string    `json:"reason"`
+	CreatedBy *uint     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Leave model
+func (Leave) TableName() string {
+	return "leaves"
+}
+
+// Covers reports whether day falls within the leave
+func (l *Leave) Covers(day time.Time) bool {
+	date := day.Format("2006-01-02")
+	return date >= l.StartDate.Format("2006-01-02") && date <= l.EndDate.Format("2006-01-02")
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// PasswordHistory is a password hash a user had, kept to prevent reuse
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null" json:"user_id"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for PasswordHistory model
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
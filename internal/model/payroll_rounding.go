@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// Payroll rounding directions
+const (
+	RoundUp      = "up"
+	RoundDown    = "down"
+	RoundNearest = "nearest"
+)
+
+// RoundingDirections lists the accepted payroll rounding directions
+var RoundingDirections = []string{RoundUp, RoundDown, RoundNearest}
+
+// PayrollRounding describes how check-in and check-out times are rounded when
+// computing work duration and overtime. Stored timestamps are never rounded.
+type PayrollRounding struct {
+	Interval time.Duration // 0 disables rounding
+	CheckIn  string        // up, down or nearest
+	CheckOut string        // up, down or nearest
+}
+
+// Enabled reports whether any rounding applies
+func (r PayrollRounding) Enabled() bool {
+	return r.Interval > 0
+}
+
+// RoundCheckIn rounds a check-in time in the configured direction
+func (r PayrollRounding) RoundCheckIn(t time.Time) time.Time {
+	return r.round(t, r.CheckIn)
+}
+
+// RoundCheckOut rounds a check-out time in the configured direction
+func (r PayrollRounding) RoundCheckOut(t time.Time) time.Time {
+	return r.round(t, r.CheckOut)
+}
+
+// WorkDuration returns the time worked between checkIn and checkOut after rounding.
+// Rounding never makes the duration negative.
+func (r PayrollRounding) WorkDuration(checkIn, checkOut time.Time) time.Duration {
+	duration := r.RoundCheckOut(checkOut).Sub(r.RoundCheckIn(checkIn))
+	if duration < 0 {
+		return 0
+	}
+	return duration
+}
+
+// round rounds t to a multiple of the interval counted from local midnight,
+// so intervals line up with the wall clock in any time zone
+func (r PayrollRounding) round(t time.Time, direction string) time.Time {
+	if !r.Enabled() {
+		return t
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	down := offset.Truncate(r.Interval)
+
+	switch direction {
+	case RoundUp:
+		if down < offset {
+			down += r.Interval
+		}
+	case RoundNearest:
+		down = offset.Round(r.Interval)
+	case RoundDown:
+	default:
+		return t
+	}
+
+	return midnight.Add(down)
+}
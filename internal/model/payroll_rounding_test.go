@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPayrollRoundingRound(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	at := func(h, m, s int) time.Time { return time.Date(2026, 3, 9, h, m, s, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		direction string
+		t         time.Time
+		want      time.Time
+	}{
+		{"disabled", 0, RoundUp, at(8, 7, 0), at(8, 7, 0)},
+		{"up", 15 * time.Minute, RoundUp, at(8, 7, 0), at(8, 15, 0)},
+		{"up on a boundary", 15 * time.Minute, RoundUp, at(8, 15, 0), at(8, 15, 0)},
+		{"up by a second", 15 * time.Minute, RoundUp, at(8, 15, 1), at(8, 30, 0)},
+		{"down", 15 * time.Minute, RoundDown, at(17, 14, 59), at(17, 0, 0)},
+		{"nearest, below half", 15 * time.Minute, RoundNearest, at(8, 7, 0), at(8, 0, 0)},
+		{"nearest, at half", 15 * time.Minute, RoundNearest, at(8, 7, 30), at(8, 15, 0)},
+		{"unknown direction", 15 * time.Minute, "sideways", at(8, 7, 0), at(8, 7, 0)},
+		// Counted from local midnight, so a 45 minute interval lines up with the local wall clock
+		{"local wall clock", 45 * time.Minute, RoundUp, time.Date(2026, 3, 9, 8, 10, 0, 0, jakarta), time.Date(2026, 3, 9, 8, 15, 0, 0, jakarta)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := PayrollRounding{Interval: tt.interval, CheckIn: tt.direction}
+			if got := r.RoundCheckIn(tt.t); !got.Equal(tt.want) {
+				t.Errorf("RoundCheckIn(%s) = %s, want %s", tt.t.Format("15:04:05"), got.Format("15:04:05"), tt.want.Format("15:04:05"))
+			}
+		})
+	}
+}
+
+func TestPayrollRoundingWorkDuration(t *testing.T) {
+	r := PayrollRounding{Interval: 15 * time.Minute, CheckIn: RoundUp, CheckOut: RoundDown}
+
+	tests := []struct {
+		name              string
+		checkIn, checkOut time.Time
+		want              time.Duration
+	}{
+		{"full day", time.Date(2026, 3, 9, 8, 7, 0, 0, time.UTC), time.Date(2026, 3, 9, 17, 4, 0, 0, time.UTC), 8*time.Hour + 45*time.Minute},
+		// Both round into the same interval in opposite directions
+		{"never negative", time.Date(2026, 3, 9, 8, 1, 0, 0, time.UTC), time.Date(2026, 3, 9, 8, 14, 0, 0, time.UTC), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.WorkDuration(tt.checkIn, tt.checkOut); got != tt.want {
+				t.Errorf("WorkDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Region groups locations, e.g. by city, for reporting
+type Region struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"uniqueIndex;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Region model
+func (Region) TableName() string {
+	return "regions"
+}
@@ -6,16 +6,50 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// UserRoles lists every role a user can have
+var UserRoles = []string{"admin", "user"}
+
+// RolePermissions maps each role to the permissions it grants
+var RolePermissions = map[string][]string{
+	"admin": {
+		"attendance:self",
+		"attendances:manage",
+		"users:manage",
+		"locations:manage",
+		"schedules:manage",
+		"webhooks:manage",
+	},
+	"user": {
+		"attendance:self",
+	},
+}
+
+// Permissions returns the permissions granted by the user's role
+func (u *User) Permissions() []string {
+	if permissions, ok := RolePermissions[u.Role]; ok {
+		return permissions
+	}
+	return []string{}
+}
+
 type User struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Email        string    `gorm:"uniqueIndex;not null" json:"email"`
-	PasswordHash string    `gorm:"not null" json:"-"`
-	FullName     string    `gorm:"not null" json:"full_name"`
-	Phone        string    `json:"phone"`
-	Role         string    `gorm:"not null;default:user" json:"role"` // 'admin' or 'user'
-	IsActive     bool      `gorm:"default:true" json:"is_active"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	Email              string     `gorm:"uniqueIndex;not null" json:"email"`
+	PasswordHash       string     `gorm:"not null" json:"-"`
+	FullName           string     `gorm:"not null" json:"full_name"`
+	Phone              string     `json:"phone"`                             // optional, unique when set
+	EmployeeID         string     `json:"employee_id"`                       // optional, unique when set
+	Role               string     `gorm:"not null;default:user" json:"role"` // 'admin' or 'user'
+	IsActive           bool       `gorm:"default:true" json:"is_active"`
+	DeactivationReason string     `json:"deactivation_reason"` // why the account was deactivated, cleared on reactivation
+	DeactivatedAt      *time.Time `json:"deactivated_at"`
+	ReactivatedAt      *time.Time `json:"reactivated_at"` // restarts the inactivity clock
+	DepartmentID       *uint      `json:"department_id"`
+	TokenVersion       int        `gorm:"not null;default:0" json:"-"` // bumped to revoke issued tokens
+	LastLoginAt        *time.Time `json:"last_login_at"`
+	MustChangePassword bool       `gorm:"not null;default:false" json:"must_change_password"` // set by an admin password reset
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
 // TableName specifies the table name for User model
@@ -41,26 +75,38 @@ func (u *User) CheckPassword(password string) bool {
 
 // UserResponse represents user data without sensitive information
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Email     string    `json:"email"`
-	FullName  string    `json:"full_name"`
-	Phone     string    `json:"phone"`
-	Role      string    `json:"role"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 uint       `json:"id"`
+	Email              string     `json:"email"`
+	FullName           string     `json:"full_name"`
+	Phone              string     `json:"phone"`
+	EmployeeID         string     `json:"employee_id"`
+	Role               string     `json:"role"`
+	IsActive           bool       `json:"is_active"`
+	DeactivationReason string     `json:"deactivation_reason,omitempty"`
+	DeactivatedAt      *time.Time `json:"deactivated_at,omitempty"`
+	DepartmentID       *uint      `json:"department_id"`
+	LastLoginAt        *time.Time `json:"last_login_at"`
+	MustChangePassword bool       `json:"must_change_password"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
 // ToResponse converts User to UserResponse
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Email:     u.Email,
-		FullName:  u.FullName,
-		Phone:     u.Phone,
-		Role:      u.Role,
-		IsActive:  u.IsActive,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:                 u.ID,
+		Email:              u.Email,
+		FullName:           u.FullName,
+		Phone:              u.Phone,
+		EmployeeID:         u.EmployeeID,
+		Role:               u.Role,
+		IsActive:           u.IsActive,
+		DeactivationReason: u.DeactivationReason,
+		DeactivatedAt:      u.DeactivatedAt,
+		DepartmentID:       u.DepartmentID,
+		LastLoginAt:        u.LastLoginAt,
+		MustChangePassword: u.MustChangePassword,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
 	}
 }
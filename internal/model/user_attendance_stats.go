@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// UserAttendanceStats caches attendance aggregates for one user. The values are
+// derived from the attendances table and can be rebuilt at any time.
+type UserAttendanceStats struct {
+	UserID        uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	CurrentStreak int       `json:"current_streak"` // consecutive attended work days up to today
+	LongestStreak int       `json:"longest_streak"`
+	Month         string    `json:"month"` // month the Month* counts refer to, "2025-03"
+	MonthPresent  int       `json:"month_present"`
+	MonthLate     int       `json:"month_late"`
+	MonthHalfDay  int       `json:"month_half_day"`
+	MonthRemote   int       `json:"month_remote"`
+	MonthAbsent   int       `json:"month_absent"`
+	MonthHours    float64   `gorm:"type:decimal(10,2)" json:"month_hours"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
+
+// TableName specifies the table name for UserAttendanceStats model
+func (UserAttendanceStats) TableName() string {
+	return "user_attendance_stats"
+}
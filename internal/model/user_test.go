@@ -0,0 +1,43 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUserPermissions(t *testing.T) {
+	tests := []struct {
+		role string
+		want []string
+	}{
+		{"admin", RolePermissions["admin"]},
+		{"user", []string{"attendance:self"}},
+		{"auditor", []string{}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			u := User{Role: tt.role}
+			if got := u.Permissions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Permissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminPermissionsIncludeUserPermissions(t *testing.T) {
+	admin := RolePermissions["admin"]
+	for _, permission := range RolePermissions["user"] {
+		found := false
+		for _, p := range admin {
+			if p == permission {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("admin lacks user permission %q", permission)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook event types
+const (
+	WebhookEventUserCreated     = "user.created"
+	WebhookEventUserDeactivated = "user.deactivated"
+	WebhookEventUserDeleted     = "user.deleted"
+)
+
+// WebhookEvents lists all event types a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventUserCreated,
+	WebhookEventUserDeactivated,
+	WebhookEventUserDeleted,
+}
+
+type Webhook struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	URL       string         `gorm:"not null" json:"url"`
+	Secret    string         `gorm:"not null" json:"-"`
+	Events    pq.StringArray `gorm:"type:text[]" json:"events"`
+	IsActive  bool           `gorm:"default:true" json:"is_active"`
+	CreatedBy *uint          `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TableName specifies the table name for Webhook model
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Subscribes reports whether the webhook wants to receive the given event
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookResponse represents webhook data without the signing secret
+type WebhookResponse struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"is_active"`
+	CreatedBy *uint     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ToResponse converts Webhook to WebhookResponse
+func (w *Webhook) ToResponse() WebhookResponse {
+	return WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    w.Events,
+		IsActive:  w.IsActive,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery records an event sent to a webhook and the outcome of the latest attempt
+type WebhookDelivery struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	WebhookID     *uint           `json:"webhook_id"` // nil once the webhook is deleted
+	Event         string          `gorm:"not null" json:"event"`
+	URL           string          `gorm:"not null" json:"url"`
+	Payload       json.RawMessage `gorm:"type:text" json:"payload"`      // the exact body sent, so replays match the original signature
+	Status        string          `gorm:"default:pending" json:"status"` // 'pending', 'succeeded', 'failed'
+	StatusCode    *int            `json:"status_code"`                   // nil when no response was received
+	Attempts      int             `gorm:"default:0" json:"attempts"`
+	LastError     string          `json:"last_error"`
+	LastAttemptAt *time.Time      `json:"last_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// TableName specifies the table name for WebhookDelivery model
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookPayload is the body delivered to webhook subscribers
+type WebhookPayload struct {
+	Event     string      `json:"event"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
@@ -1,22 +1,24 @@
 package model
 
 import (
-	"database/sql/driver"
-	"encoding/json"
 	"time"
 
 	"github.com/lib/pq"
 )
 
 type WorkSchedule struct {
-	ID             uint          `gorm:"primaryKey" json:"id"`
-	Name           string        `gorm:"not null" json:"name"`
-	CheckInStart   string        `gorm:"not null;type:time" json:"check_in_start"`   // e.g., "08:00:00"
-	CheckInEnd     string        `gorm:"not null;type:time" json:"check_in_end"`     // e.g., "09:00:00"
-	CheckOutStart  string        `gorm:"not null;type:time" json:"check_out_start"`  // e.g., "17:00:00"
-	WorkDays       pq.Int64Array `gorm:"type:integer[]" json:"work_days"`            // [1,2,3,4,5] for Mon-Fri
-	CreatedAt      time.Time     `json:"created_at"`
-	UpdatedAt      time.Time     `json:"updated_at"`
+	ID                      uint          `gorm:"primaryKey" json:"id"`
+	Name                    string        `gorm:"not null" json:"name"`
+	CheckInStart            string        `gorm:"not null;type:time" json:"check_in_start"`         // e.g., "08:00:00"
+	CheckInEnd              string        `gorm:"not null;type:time" json:"check_in_end"`           // e.g., "09:00:00"
+	CheckOutStart           string        `gorm:"not null;type:time" json:"check_out_start"`        // e.g., "17:00:00"
+	WorkDays                pq.Int64Array `gorm:"type:integer[]" json:"work_days"`                  // [1,2,3,4,5] for Mon-Fri
+	RemoteAllowed           bool          `gorm:"default:false" json:"remote_allowed"`              // skip geofencing on check-in
+	RequireNoteOnEarlyLeave bool          `gorm:"default:false" json:"require_note_on_early_leave"` // check-out before check_out_start needs a note
+	EarlyStatusMinutes      int           `gorm:"default:0" json:"early_status_minutes"`            // check-ins more than this before check_in_start are "early", 0 disables
+	MinRestMinutes          int           `gorm:"default:0" json:"min_rest_minutes"`                // required gap between a check-out and the next check-in, 0 disables
+	CreatedAt               time.Time     `json:"created_at"`
+	UpdatedAt               time.Time     `json:"updated_at"`
 }
 
 // TableName specifies the table name for WorkSchedule model
@@ -24,16 +26,52 @@ func (WorkSchedule) TableName() string {
 	return "work_schedules"
 }
 
+// ScheduledTimes returns the schedule's check-in deadline and check-out start on the
+// day of t, in t's location. Check-out falls on the next day for schedules crossing
+// midnight. ok is false when the schedule times cannot be parsed.
+func (s *WorkSchedule) ScheduledTimes(t time.Time) (checkIn, checkOut time.Time, ok bool) {
+	checkInEnd, errIn := time.Parse("15:04:05", s.CheckInEnd)
+	checkOutStart, errOut := time.Parse("15:04:05", s.CheckOutStart)
+	if errIn != nil || errOut != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	checkIn = time.Date(t.Year(), t.Month(), t.Day(), checkInEnd.Hour(), checkInEnd.Minute(), checkInEnd.Second(), 0, t.Location())
+	checkOut = time.Date(t.Year(), t.Month(), t.Day(), checkOutStart.Hour(), checkOutStart.Minute(), checkOutStart.Second(), 0, t.Location())
+	if checkOut.Before(checkIn) {
+		checkOut = checkOut.AddDate(0, 0, 1)
+	}
+	return checkIn, checkOut, true
+}
+
+// WorksOn reports whether weekday is one of the schedule's work days
+func (s *WorkSchedule) WorksOn(weekday time.Weekday) bool {
+	day := int64(weekday)
+	if day == 0 {
+		day = 7 // work_days uses 1=Monday ... 7=Sunday
+	}
+	for _, workDay := range s.WorkDays {
+		if workDay == day {
+			return true
+		}
+	}
+	return false
+}
+
 // ScheduleResponse represents work schedule data
 type ScheduleResponse struct {
-	ID            uint      `json:"id"`
-	Name          string    `json:"name"`
-	CheckInStart  string    `json:"check_in_start"`
-	CheckInEnd    string    `json:"check_in_end"`
-	CheckOutStart string    `json:"check_out_start"`
-	WorkDays      []int     `json:"work_days"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID                      uint      `json:"id"`
+	Name                    string    `json:"name"`
+	CheckInStart            string    `json:"check_in_start"`
+	CheckInEnd              string    `json:"check_in_end"`
+	CheckOutStart           string    `json:"check_out_start"`
+	WorkDays                []int     `json:"work_days"`
+	RemoteAllowed           bool      `json:"remote_allowed"`
+	RequireNoteOnEarlyLeave bool      `json:"require_note_on_early_leave"`
+	EarlyStatusMinutes      int       `json:"early_status_minutes"`
+	MinRestMinutes          int       `json:"min_rest_minutes"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
 }
 
 // ToResponse converts WorkSchedule to ScheduleResponse
@@ -44,14 +82,18 @@ func (w *WorkSchedule) ToResponse() ScheduleResponse {
 	}
 
 	return ScheduleResponse{
-		ID:            w.ID,
-		Name:          w.Name,
-		CheckInStart:  w.CheckInStart,
-		CheckInEnd:    w.CheckInEnd,
-		CheckOutStart: w.CheckOutStart,
-		WorkDays:      workDays,
-		CreatedAt:     w.CreatedAt,
-		UpdatedAt:     w.UpdatedAt,
+		ID:                      w.ID,
+		Name:                    w.Name,
+		CheckInStart:            w.CheckInStart,
+		CheckInEnd:              w.CheckInEnd,
+		CheckOutStart:           w.CheckOutStart,
+		WorkDays:                workDays,
+		RemoteAllowed:           w.RemoteAllowed,
+		RequireNoteOnEarlyLeave: w.RequireNoteOnEarlyLeave,
+		EarlyStatusMinutes:      w.EarlyStatusMinutes,
+		MinRestMinutes:          w.MinRestMinutes,
+		CreatedAt:               w.CreatedAt,
+		UpdatedAt:               w.UpdatedAt,
 	}
 }
 
@@ -62,12 +104,15 @@ type UserSchedule struct {
 	LocationID    uint       `gorm:"not null" json:"location_id"`
 	EffectiveFrom time.Time  `gorm:"not null;type:date" json:"effective_from"`
 	EffectiveTo   *time.Time `gorm:"type:date" json:"effective_to"`
+	CreatedBy     *uint      `json:"created_by"`
 	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 
 	// Relations
 	User     User               `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Schedule WorkSchedule       `gorm:"foreignKey:ScheduleID" json:"schedule,omitempty"`
 	Location AttendanceLocation `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Creator  *User              `gorm:"foreignKey:CreatedBy" json:"creator,omitempty"`
 }
 
 // TableName specifies the table name for UserSchedule model
@@ -83,10 +128,26 @@ type UserScheduleResponse struct {
 	LocationID    uint              `json:"location_id"`
 	EffectiveFrom time.Time         `json:"effective_from"`
 	EffectiveTo   *time.Time        `json:"effective_to"`
+	Status        string            `json:"status"` // 'active', 'upcoming' or 'expired'
+	CreatedBy     *uint             `json:"created_by"`
 	User          *UserResponse     `json:"user,omitempty"`
 	Schedule      *ScheduleResponse `json:"schedule,omitempty"`
 	Location      *LocationResponse `json:"location,omitempty"`
+	Creator       *UserResponse     `json:"creator,omitempty"`
 	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// StatusAt returns whether the assignment is active, upcoming or expired at the given time
+func (us *UserSchedule) StatusAt(t time.Time) string {
+	date := t.Format("2006-01-02")
+	if date < us.EffectiveFrom.Format("2006-01-02") {
+		return "upcoming"
+	}
+	if us.EffectiveTo != nil && date > us.EffectiveTo.Format("2006-01-02") {
+		return "expired"
+	}
+	return "active"
 }
 
 // ToResponse converts UserSchedule to UserScheduleResponse
@@ -98,7 +159,10 @@ func (us *UserSchedule) ToResponse() UserScheduleResponse {
 		LocationID:    us.LocationID,
 		EffectiveFrom: us.EffectiveFrom,
 		EffectiveTo:   us.EffectiveTo,
+		Status:        us.StatusAt(time.Now()),
+		CreatedBy:     us.CreatedBy,
 		CreatedAt:     us.CreatedAt,
+		UpdatedAt:     us.UpdatedAt,
 	}
 
 	// Add user info if loaded
@@ -119,19 +183,11 @@ func (us *UserSchedule) ToResponse() UserScheduleResponse {
 		response.Location = &locResp
 	}
 
-	return response
-}
-
-// Value implements driver.Valuer for JSON marshaling
-func (w WorkSchedule) Value() (driver.Value, error) {
-	return json.Marshal(w)
-}
-
-// Scan implements sql.Scanner for JSON unmarshaling
-func (w *WorkSchedule) Scan(value interface{}) error {
-	b, ok := value.([]byte)
-	if !ok {
-		return nil
+	// Add creator info if loaded
+	if us.Creator != nil {
+		creatorResp := us.Creator.ToResponse()
+		response.Creator = &creatorResp
 	}
-	return json.Unmarshal(b, &w)
+
+	return response
 }
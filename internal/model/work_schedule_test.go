@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserScheduleStatusAt(t *testing.T) {
+	date := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	to := date(20)
+
+	tests := []struct {
+		name string
+		us   UserSchedule
+		at   time.Time
+		want string
+	}{
+		{"before it starts", UserSchedule{EffectiveFrom: date(10)}, date(9), "upcoming"},
+		{"on its first day", UserSchedule{EffectiveFrom: date(10)}, date(10).Add(23 * time.Hour), "active"},
+		{"open-ended", UserSchedule{EffectiveFrom: date(10)}, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), "active"},
+		{"on its last day", UserSchedule{EffectiveFrom: date(10), EffectiveTo: &to}, date(20).Add(18 * time.Hour), "active"},
+		{"after it ends", UserSchedule{EffectiveFrom: date(10), EffectiveTo: &to}, date(21), "expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.us.StatusAt(tt.at); got != tt.want {
+				t.Errorf("StatusAt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkScheduleScheduledTimes(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	day := time.Date(2026, 3, 9, 10, 0, 0, 0, jakarta)
+
+	tests := []struct {
+		name         string
+		schedule     WorkSchedule
+		wantCheckIn  time.Time
+		wantCheckOut time.Time
+		wantOK       bool
+	}{
+		{"day shift", WorkSchedule{CheckInEnd: "09:00:00", CheckOutStart: "17:00:00"},
+			time.Date(2026, 3, 9, 9, 0, 0, 0, jakarta), time.Date(2026, 3, 9, 17, 0, 0, 0, jakarta), true},
+		// Check-out falls on the next day
+		{"night shift", WorkSchedule{CheckInEnd: "22:00:00", CheckOutStart: "06:00:00"},
+			time.Date(2026, 3, 9, 22, 0, 0, 0, jakarta), time.Date(2026, 3, 10, 6, 0, 0, 0, jakarta), true},
+		{"unparsable", WorkSchedule{CheckInEnd: "09:00", CheckOutStart: "17:00:00"}, time.Time{}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkIn, checkOut, ok := tt.schedule.ScheduledTimes(day)
+			if ok != tt.wantOK || !checkIn.Equal(tt.wantCheckIn) || !checkOut.Equal(tt.wantCheckOut) {
+				t.Errorf("ScheduledTimes() = %v, %v, %v, want %v, %v, %v", checkIn, checkOut, ok, tt.wantCheckIn, tt.wantCheckOut, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,96 +1,664 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/attendance/backend/internal/config"
 	"github.com/attendance/backend/internal/model"
+	"github.com/attendance/backend/internal/utils"
+	"github.com/attendance/backend/pkg/clock"
+	"github.com/attendance/backend/pkg/storage"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrAttachmentForbidden   = errors.New("not allowed to add attachments to this attendance")
+	ErrAttachmentHidden      = errors.New("not allowed to view attachments of this attendance")
+	ErrAttachmentLimit       = errors.New("attachment limit reached")
+	ErrAttachmentTooLarge    = errors.New("attachment is too large")
+	ErrAttachmentUnsupported = errors.New("attachment content type is not allowed")
+	ErrStorageQuotaExceeded  = errors.New("attachment storage quota exceeded")
+	ErrEarlyLeaveNoteMissing = errors.New("a note explaining the early departure is required before the scheduled check-out time")
+)
+
+// OpenSessionLimitError is returned in multi-session mode when a check-in would give the
+// user more open records than ATTENDANCE_MAX_OPEN_SESSIONS allows
+type OpenSessionLimitError struct {
+	Limit int
+}
+
+func (e *OpenSessionLimitError) Error() string {
+	return fmt.Sprintf("open session limit reached: at most %d open session(s) allowed, check out first", e.Limit)
+}
+
 type AttendanceService struct {
 	db              *gorm.DB
 	locationService *LocationService
+	auditService    *AuditService
+	storage         storage.Storage
+	clock           clock.Clock
+	config          *config.Config
 }
 
-func NewAttendanceService(db *gorm.DB, locationService *LocationService) *AttendanceService {
+func NewAttendanceService(db *gorm.DB, locationService *LocationService, auditService *AuditService, store storage.Storage, clk clock.Clock, cfg *config.Config) *AttendanceService {
 	return &AttendanceService{
 		db:              db,
 		locationService: locationService,
+		auditService:    auditService,
+		storage:         store,
+		clock:           clk,
+		config:          cfg,
+	}
+}
+
+// PayrollRounding returns the configured rounding applied to check-in and
+// check-out times when computing work duration and overtime
+func (s *AttendanceService) PayrollRounding() model.PayrollRounding {
+	return model.PayrollRounding{
+		Interval: s.config.Attendance.PayrollRoundingInterval,
+		CheckIn:  s.config.Attendance.PayrollCheckInRounding,
+		CheckOut: s.config.Attendance.PayrollCheckOutRounding,
 	}
 }
 
 // CheckInRequest represents check-in request
 type CheckInRequest struct {
-	LocationID uint    `json:"location_id" binding:"required"`
-	Latitude   float64 `json:"latitude" binding:"required"`
-	Longitude  float64 `json:"longitude" binding:"required"`
-	PhotoURL   string  `json:"photo_url"`
-	Notes      string  `json:"notes"`
+	LocationID    uint       `json:"location_id" binding:"required"`
+	Latitude      *float64   `json:"latitude" binding:"required,min=-90,max=90"` // pointer so 0 is a valid coordinate
+	Longitude     *float64   `json:"longitude" binding:"required,min=-180,max=180"`
+	PhotoURL      string     `json:"photo_url"`
+	Notes         string     `json:"notes" binding:"max=500"`
+	LocationToken string     `json:"location_token"` // from the location's QR code, required when the location enables it
+	ClientTime    *time.Time `json:"client_time"`    // device clock, RFC 3339; only used to detect tampering
 }
 
 // CheckOutRequest represents check-out request
 type CheckOutRequest struct {
-	Latitude  float64 `json:"latitude" binding:"required"`
-	Longitude float64 `json:"longitude" binding:"required"`
-	Notes     string  `json:"notes"`
+	Latitude  *float64 `json:"latitude" binding:"required,min=-90,max=90"` // pointer so 0 is a valid coordinate
+	Longitude *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+	Notes     string   `json:"notes" binding:"max=500"`
+}
+
+// AddAttachmentRequest represents the metadata sent along with an uploaded attachment
+type AddAttachmentRequest struct {
+	Type    string `form:"type" binding:"omitempty,oneof=photo document"` // defaults to photo
+	Caption string `form:"caption"`
+}
+
+// RecalculateStatusesRequest represents the date range to recalculate statuses for
+type RecalculateStatusesRequest struct {
+	DateFrom string `json:"date_from" binding:"required"` // "2025-01-01"
+	DateTo   string `json:"date_to" binding:"required"`   // "2025-01-31"
+}
+
+// CloseAllRequest represents the admin request to check out everyone at a location
+type CloseAllRequest struct {
+	CheckOutTime *time.Time `json:"check_out_time"` // RFC 3339, defaults to now
+}
+
+// maxBulkStatusRecords is the most records one bulk status change may touch, so a
+// broad filter cannot hold a transaction over the whole table
+const maxBulkStatusRecords = 1000
+
+// BulkStatusRequest represents the admin request to set the status of many records.
+// Exactly one of IDs and Filter selects the records.
+type BulkStatusRequest struct {
+	IDs    []uint            `json:"ids" binding:"omitempty,max=1000"`
+	Filter *BulkStatusFilter `json:"filter"`
+	Status string            `json:"status" binding:"required"`
+}
+
+// BulkStatusFilter selects records for a bulk status change; at least one field must be set
+type BulkStatusFilter struct {
+	UserID     uint   `json:"user_id"`
+	LocationID uint   `json:"location_id"`
+	Status     string `json:"status"`    // current status
+	DateFrom   string `json:"date_from"` // "2025-01-01", check-in date
+	DateTo     string `json:"date_to"`   // "2025-01-31"
+}
+
+// ReviewAttendanceRequest represents the admin review of a flagged attendance
+type ReviewAttendanceRequest struct {
+	Resolution string `json:"resolution" binding:"required,oneof=confirmed cleared"`
+	Note       string `json:"note"`
+}
+
+// UpdateAdminNotesRequest represents the admin-only notes of an attendance
+type UpdateAdminNotesRequest struct {
+	AdminNotes string `json:"admin_notes" binding:"max=2000"` // empty clears the notes
+}
+
+// LocationAttendanceCount represents check-in totals for a single location
+type LocationAttendanceCount struct {
+	LocationID      uint    `json:"location_id"`
+	LocationName    string  `json:"location_name"`
+	TotalCheckIns   int64   `json:"total_check_ins"`
+	UniqueUsers     int64   `json:"unique_users"`
+	AverageDistance float64 `json:"average_distance"` // in meters
+}
+
+// PeriodTotals holds status counts and worked hours over a range of days
+type PeriodTotals struct {
+	ScheduledDays int     `json:"scheduled_days"`
+	Present       int     `json:"present"`
+	Early         int     `json:"early"`
+	Late          int     `json:"late"`
+	HalfDay       int     `json:"half_day"`
+	Remote        int     `json:"remote"`
+	Absent        int     `json:"absent"`
+	Holiday       int     `json:"holiday"` // scheduled days off for a holiday
+	Leave         int     `json:"leave"`   // scheduled days off on leave
+	TotalHours    float64 `json:"total_hours"`
+	OvertimeHours float64 `json:"overtime_hours"`
+}
+
+// WeeklySummary represents a user's attendance totals for one ISO week
+type WeeklySummary struct {
+	WeekStart string `json:"week_start"` // Monday, "2025-03-10"
+	WeekEnd   string `json:"week_end"`   // Sunday
+	PeriodTotals
+}
+
+// MonthlySummary represents a user's per-day statuses and totals for one month
+type MonthlySummary struct {
+	UserID   uint              `json:"user_id"`
+	UserName string            `json:"user_name"`
+	Year     int               `json:"year"`
+	Month    int               `json:"month"`
+	Days     map[string]string `json:"days"` // same values as GetMonthlyCalendar
+	PeriodTotals
 }
 
+// StorageUsage is the attachment storage used by a user's attendance records
+type StorageUsage struct {
+	UserID         uint   `json:"user_id"`
+	Attachments    int64  `json:"attachments"`
+	UsedBytes      int64  `json:"used_bytes"`
+	QuotaBytes     *int64 `json:"quota_bytes"`     // null when no quota is configured
+	RemainingBytes *int64 `json:"remaining_bytes"` // null when no quota is configured
+}
+
+// ComplianceRate is the share of a user's scheduled days in a period they were on time
+type ComplianceRate struct {
+	UserID        uint     `json:"user_id"`
+	DateFrom      string   `json:"date_from"`
+	DateTo        string   `json:"date_to"`
+	ScheduledDays int      `json:"scheduled_days"`
+	OnTime        int      `json:"on_time"` // present, early or remote
+	Late          int      `json:"late"`
+	HalfDay       int      `json:"half_day"`
+	Absent        int      `json:"absent"`
+	ExcludedDays  int      `json:"excluded_days"` // scheduled days the assigned location was closed
+	HolidayDays   int      `json:"holiday_days"`  // scheduled days that were a holiday at the assigned location
+	LeaveDays     int      `json:"leave_days"`    // scheduled days the user was on leave
+	Rate          *float64 `json:"rate"`          // percentage, null without scheduled days
+}
+
+// UserPoints is the running total of lateness points a user earned in a period, derived
+// from the statuses of their records with the ATTENDANCE_STATUS_POINTS mapping
+type UserPoints struct {
+	UserID   uint           `json:"user_id"`
+	DateFrom string         `json:"date_from"`
+	DateTo   string         `json:"date_to"`
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"` // points per status
+	Entries  []PointsEntry  `json:"entries"`   // records that scored points, oldest first
+}
+
+// PointsEntry is one record that scored points, with the total up to and including it
+type PointsEntry struct {
+	AttendanceID uint   `json:"attendance_id"`
+	Date         string `json:"date"`
+	Status       string `json:"status"`
+	Points       int    `json:"points"`
+	RunningTotal int    `json:"running_total"`
+}
+
+// AnomalyFlags lists the reasons a record shows up in GetAnomalies
+var AnomalyFlags = []string{"outside_radius", "clock_skew", "unscheduled"}
+
+// unscheduledCondition matches attendances checked in on a day that is not a work day
+// of any schedule assigned to the user on that day
+const unscheduledCondition = `NOT EXISTS (SELECT 1 FROM user_schedules us
+	JOIN work_schedules ws ON ws.id = us.schedule_id
+	WHERE us.user_id = attendances.user_id
+	AND us.effective_from <= DATE(attendances.check_in_time)
+	AND (us.effective_to IS NULL OR us.effective_to >= DATE(attendances.check_in_time))
+	AND EXTRACT(ISODOW FROM attendances.check_in_time)::int = ANY(ws.work_days))`
+
+// anomalyCondition matches attendances with any of AnomalyFlags
+const anomalyCondition = "(attendances.outside_radius OR attendances.clock_skew_suspicious OR " + unscheduledCondition + ")"
+
+// DashboardCounts summarizes one day's check-ins for the admin dashboard
+type DashboardCounts struct {
+	Date       string `json:"date"`
+	CheckIns   int64  `json:"check_ins"` // records that are not absent
+	Late       int64  `json:"late"`
+	Open       int64  `json:"open"`       // not checked out yet
+	Suspicious int64  `json:"suspicious"` // with an anomaly and not reviewed yet
+}
+
+// AttendanceAnomaly is an attendance record together with every anomaly it has
+type AttendanceAnomaly struct {
+	Attendance model.Attendance
+	Reasons    []string // values of AnomalyFlags
+}
+
+// CheckInStatusPreview is the status a check-in at At would receive
+type CheckInStatusPreview struct {
+	At               time.Time `json:"at"`
+	Status           string    `json:"status"`
+	MinutesLate      int       `json:"minutes_late"` // past the last on-time minute, 0 unless late or half day
+	HasSchedule      bool      `json:"has_schedule"`
+	AlreadyCheckedIn bool      `json:"already_checked_in"`
+}
+
+// PresentUser is a user who is checked in today and has not checked out yet
+type PresentUser struct {
+	AttendanceID uint      `json:"attendance_id"`
+	UserID       uint      `json:"user_id"`
+	FullName     string    `json:"full_name"`
+	Phone        string    `json:"phone"`
+	DepartmentID *uint     `json:"department_id"`
+	LocationID   uint      `json:"location_id"`
+	LocationName string    `json:"location_name"`
+	CheckInTime  time.Time `json:"check_in_time"`
+}
+
+// RecentCheckIn is a check-in with its distance from the location and from the
+// user's previous check-in, for fraud review
+type RecentCheckIn struct {
+	AttendanceID         uint      `json:"attendance_id"`
+	CheckInTime          time.Time `json:"check_in_time"`
+	LocationID           uint      `json:"location_id"`
+	LocationName         string    `json:"location_name"`
+	Latitude             float64   `json:"latitude"`
+	Longitude            float64   `json:"longitude"`
+	Distance             float64   `json:"distance"`               // meters from the location
+	DistanceFromPrevious *float64  `json:"distance_from_previous"` // meters from the next older check-in in the list
+	Threshold            float64   `json:"threshold"`              // meters
+	BeyondThreshold      bool      `json:"beyond_threshold"`
+}
+
+// DurationBucket is the number of completed records whose work duration
+// falls within [FromMinutes, ToMinutes)
+type DurationBucket struct {
+	FromMinutes int   `json:"from_minutes"`
+	ToMinutes   int   `json:"to_minutes"`
+	Count       int64 `json:"count"`
+}
+
+// TrendGranularities lists the bucket sizes accepted by GetOrgAttendanceTrend
+var TrendGranularities = []string{"day", "week", "month"}
+
+// AttendanceTrendBucket is the organization-wide attendance rate over one bucket
+// of the trend, limited to the requested range
+type AttendanceTrendBucket struct {
+	From          string   `json:"from"`
+	To            string   `json:"to"`
+	ScheduledDays int64    `json:"scheduled_days"` // scheduled work days of active users
+	AttendedDays  int64    `json:"attended_days"`  // of those, days with a record that is not absent
+	Rate          *float64 `json:"rate"`           // percentage, null without scheduled days
+}
+
+// orgTrendQuery counts, per bucket, the scheduled work days of active users and those
+// with a record. A day is scheduled when it is a work day of the user's assignment on
+// that day, the assigned location operates on it, it is not a holiday there and the
+// user is not on leave, the same days GetComplianceRate counts.
+const orgTrendQuery = `WITH scheduled AS (
+	SELECT d::date AS day, us.user_id
+	FROM generate_series(?::date, ?::date, interval '1 day') AS d
+	JOIN user_schedules us ON us.effective_from <= d::date AND (us.effective_to IS NULL OR us.effective_to >= d::date)
+	JOIN work_schedules ws ON ws.id = us.schedule_id
+	JOIN attendance_locations l ON l.id = us.location_id
+	JOIN users u ON u.id = us.user_id AND u.is_active = true
+	WHERE EXTRACT(ISODOW FROM d)::int = ANY(ws.work_days)
+	AND (COALESCE(cardinality(l.operating_days), 0) = 0 OR EXTRACT(ISODOW FROM d)::int = ANY(l.operating_days))
+	AND NOT EXISTS (SELECT 1 FROM holidays h
+		WHERE h.date = d::date AND (h.location_id IS NULL OR h.location_id = us.location_id))
+	AND NOT EXISTS (SELECT 1 FROM leaves lv
+		WHERE lv.user_id = us.user_id AND lv.start_date <= d::date AND lv.end_date >= d::date)
+)
+SELECT TO_CHAR(DATE_TRUNC(?, scheduled.day), 'YYYY-MM-DD') AS bucket,
+	COUNT(*) AS scheduled_days,
+	COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM attendances a
+		WHERE a.user_id = scheduled.user_id AND DATE(a.check_in_time) = scheduled.day
+		AND a.status <> 'absent' AND a.deleted_at IS NULL)) AS attended_days
+FROM scheduled
+GROUP BY bucket`
+
 // CheckIn creates a new attendance record
 func (s *AttendanceService) CheckIn(userID uint, req *CheckInRequest) (*model.Attendance, error) {
-	// Check if already checked in today
-	hasCheckedIn, err := s.HasCheckedInToday(userID)
+	if err := s.checkCanCheckIn(s.db, userID); err != nil {
+		return nil, err
+	}
+
+	// Every time based decision below uses the same instant
+	now := s.clock.Now()
+
+	presence, err := s.validatePresence(userID, req, now)
 	if err != nil {
 		return nil, err
 	}
-	if hasCheckedIn {
-		return nil, errors.New("already checked in today")
+
+	// Create attendance record
+	attendance := model.Attendance{
+		UserID:               userID,
+		LocationID:           req.LocationID,
+		CheckInTime:          now,
+		CheckInLatitude:      *req.Latitude,
+		CheckInLongitude:     *req.Longitude,
+		DistanceFromLocation: presence.distance,
+		OutsideRadius:        presence.outsideRadius,
+		ClientTime:           req.ClientTime,
+		ClockSkewSuspicious:  presence.clockSkewSuspicious,
+		Status:               s.checkInStatus(presence.userSchedule, presence.location, now),
+		Notes:                req.Notes,
+		PhotoURL:             req.PhotoURL,
+	}
+
+	if err := s.createCheckIn(&attendance, nil); err != nil {
+		return nil, err
 	}
 
-	// Validate location
-	isValid, distance, err := s.locationService.ValidateLocationForAttendance(
-		req.LocationID,
-		req.Latitude,
-		req.Longitude,
-	)
-	if err != nil {
+	// Load relations
+	s.db.Preload("User").Preload("Location").First(&attendance, attendance.ID)
+
+	return &attendance, nil
+}
+
+// Arrive records the first phase of a two-phase check-in: the location checks
+// are the same as for CheckIn, but no attendance exists until StartWork
+func (s *AttendanceService) Arrive(userID uint, req *CheckInRequest) (*model.AttendanceArrival, error) {
+	if err := s.checkCanCheckIn(s.db, userID); err != nil {
 		return nil, err
 	}
 
-	if !isValid {
-		return nil, errors.New("you are outside the allowed radius")
+	now := s.clock.Now()
+
+	if _, err := s.getTodayArrival(userID, now); err == nil {
+		return nil, errors.New("arrival already recorded today")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
 	}
 
-	// Determine status based on time
-	status := s.determineAttendanceStatus(time.Now())
+	presence, err := s.validatePresence(userID, req, now)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create attendance record
-	attendance := model.Attendance{
+	arrival := model.AttendanceArrival{
 		UserID:               userID,
 		LocationID:           req.LocationID,
-		CheckInTime:          time.Now(),
-		CheckInLatitude:      req.Latitude,
-		CheckInLongitude:     req.Longitude,
-		DistanceFromLocation: distance,
-		Status:               status,
+		ArrivedAt:            now,
+		Latitude:             *req.Latitude,
+		Longitude:            *req.Longitude,
+		DistanceFromLocation: presence.distance,
+		OutsideRadius:        presence.outsideRadius,
+		ClientTime:           req.ClientTime,
+		ClockSkewSuspicious:  presence.clockSkewSuspicious,
 		Notes:                req.Notes,
 		PhotoURL:             req.PhotoURL,
 	}
 
-	if err := s.db.Create(&attendance).Error; err != nil {
+	if err := s.db.Create(&arrival).Error; err != nil {
+		return nil, err
+	}
+
+	s.db.Preload("Location").First(&arrival, arrival.ID)
+
+	return &arrival, nil
+}
+
+// StartWork completes a two-phase check-in. The attendance takes its location
+// data from today's arrival, while the check-in time, and so the status, is
+// the moment work starts. The location must still accept check-ins then, so an
+// arrival does not outlast a suspension or the end of the check-in window.
+func (s *AttendanceService) StartWork(userID uint) (*model.Attendance, error) {
+	if err := s.checkCanCheckIn(s.db, userID); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+
+	arrival, err := s.getTodayArrival(userID, now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("no arrival recorded today")
+		}
+		return nil, err
+	}
+
+	location, err := s.locationService.GetLocationByID(arrival.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocationAccepting(location, now); err != nil {
+		return nil, err
+	}
+
+	userSchedule, err := s.getActiveUserSchedule(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	arrivedAt := arrival.ArrivedAt
+	attendance := model.Attendance{
+		UserID:               userID,
+		LocationID:           arrival.LocationID,
+		CheckInTime:          now,
+		ArrivalTime:          &arrivedAt,
+		CheckInLatitude:      arrival.Latitude,
+		CheckInLongitude:     arrival.Longitude,
+		DistanceFromLocation: arrival.DistanceFromLocation,
+		OutsideRadius:        arrival.OutsideRadius,
+		ClientTime:           arrival.ClientTime,
+		ClockSkewSuspicious:  arrival.ClockSkewSuspicious,
+		Status:               s.checkInStatus(userSchedule, location, now),
+		Notes:                arrival.Notes,
+		PhotoURL:             arrival.PhotoURL,
+	}
+	if attendance.Status == "remote" {
+		attendance.OutsideRadius = false
+	}
+
+	err = s.createCheckIn(&attendance, func(tx *gorm.DB) error {
+		return tx.Delete(arrival).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Load relations
 	s.db.Preload("User").Preload("Location").First(&attendance, attendance.ID)
 
 	return &attendance, nil
 }
 
+// getTodayArrival returns the user's pending arrival for the day of now
+func (s *AttendanceService) getTodayArrival(userID uint, now time.Time) (*model.AttendanceArrival, error) {
+	var arrival model.AttendanceArrival
+	err := s.db.Where("user_id = ? AND DATE(arrived_at) = ?", userID, now.Format("2006-01-02")).
+		Order("arrived_at DESC").
+		First(&arrival).Error
+	if err != nil {
+		return nil, err
+	}
+	return &arrival, nil
+}
+
+// checkLocationAccepting rejects check-ins on days the location is closed, while it is
+// suspended and outside its check-in window
+func checkLocationAccepting(location *model.AttendanceLocation, now time.Time) error {
+	if !location.IsOpenOn(now) {
+		return errors.New("location is closed today")
+	}
+	if location.IsSuspended() && (location.SuspendedUntil == nil || now.Before(*location.SuspendedUntil)) {
+		if location.SuspensionReason != "" {
+			return fmt.Errorf("location is temporarily suspended: %s", location.SuspensionReason)
+		}
+		return errors.New("location is temporarily suspended")
+	}
+	if !location.IsWithinCheckInWindow(now) {
+		return fmt.Errorf("check-in at this location is only accepted between %s and %s",
+			*location.CheckInWindowStart, *location.CheckInWindowEnd)
+	}
+	return nil
+}
+
+// presenceCheck is the outcome of validating that a user may check in at a location
+type presenceCheck struct {
+	location            *model.AttendanceLocation
+	userSchedule        *model.UserSchedule
+	distance            float64
+	outsideRadius       bool
+	clockSkewSuspicious bool
+}
+
+// validatePresence runs the location, schedule and device clock checks shared
+// by single-phase check-in and the arrival phase of two-phase check-in
+func (s *AttendanceService) validatePresence(userID uint, req *CheckInRequest, now time.Time) (*presenceCheck, error) {
+	location, err := s.locationService.GetLocationByID(req.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocationAccepting(location, now); err != nil {
+		return nil, err
+	}
+	if location.QRRequired {
+		if err := s.locationService.ValidateCheckInToken(location.ID, req.LocationToken, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate location, allowing the configured grace band beyond the radius
+	isValid, inGrace, distance, err := s.locationService.ValidateLocationWithGrace(
+		req.LocationID,
+		*req.Latitude,
+		*req.Longitude,
+		s.config.Attendance.GraceRadius,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remote schedules skip geofencing entirely
+	userSchedule, err := s.getActiveUserSchedule(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	isRemote := userSchedule != nil && userSchedule.Schedule.RemoteAllowed
+
+	if userSchedule != nil && userSchedule.Schedule.MinRestMinutes > 0 {
+		if err := s.checkRestGap(userID, userSchedule.Schedule.MinRestMinutes, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if !isRemote && !isValid && !inGrace {
+		// Soft geofence roles are recorded and flagged instead of rejected
+		var user model.User
+		if err := s.db.Select("id", "role").First(&user, userID).Error; err != nil {
+			return nil, err
+		}
+		if !s.config.Attendance.IsSoftGeofenceRole(user.Role) {
+			return nil, errors.New("you are outside the allowed radius")
+		}
+		inGrace = true
+	}
+	if isRemote {
+		inGrace = false
+	}
+
+	// The device clock never decides anything, but a large skew hints at tampering
+	clockSkewSuspicious := false
+	if req.ClientTime != nil {
+		skew := req.ClientTime.Sub(now)
+		if maxSkew := s.config.Attendance.MaxClientClockSkew; maxSkew > 0 && (skew > maxSkew || skew < -maxSkew) {
+			clockSkewSuspicious = true
+		}
+	}
+
+	return &presenceCheck{
+		location:            location,
+		userSchedule:        userSchedule,
+		distance:            distance,
+		outsideRadius:       inGrace,
+		clockSkewSuspicious: clockSkewSuspicious,
+	}, nil
+}
+
+// checkRestGap rejects a check-in less than minRestMinutes after the user's last
+// check-out and records the rejection in the audit log
+func (s *AttendanceService) checkRestGap(userID uint, minRestMinutes int, now time.Time) error {
+	var last model.Attendance
+	err := s.db.Select("id", "check_out_time").
+		Where("user_id = ? AND check_out_time IS NOT NULL", userID).
+		Order("check_out_time DESC").
+		First(&last).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	rest := now.Sub(*last.CheckOutTime)
+	minRest := time.Duration(minRestMinutes) * time.Minute
+	if rest >= minRest {
+		return nil
+	}
+
+	remaining := int(math.Ceil((minRest - rest).Minutes()))
+	reason := fmt.Sprintf("a rest of %d minutes is required after checking out, try again in %d minutes", minRestMinutes, remaining)
+	if err := s.auditService.Log(userID, "attendance.check_in_rejected", "attendance", last.ID, map[string]interface{}{
+		"reason":           reason,
+		"last_check_out":   last.CheckOutTime,
+		"min_rest_minutes": minRestMinutes,
+	}); err != nil {
+		log.Printf("attendance: failed to record rejected check-in of user %d: %v", userID, err)
+	}
+
+	return errors.New(reason)
+}
+
+// checkInStatus determines the status of a check-in at t under userSchedule. The
+// schedule's times are read on the wall clock of location, or of the server when nil.
+func (s *AttendanceService) checkInStatus(userSchedule *model.UserSchedule, location *model.AttendanceLocation, t time.Time) string {
+	if location != nil {
+		t = t.In(location.TimeLocation())
+	}
+	if userSchedule == nil {
+		return s.determineAttendanceStatus(t, nil)
+	}
+	if userSchedule.Schedule.RemoteAllowed {
+		return "remote"
+	}
+	return s.determineAttendanceStatus(t, &userSchedule.Schedule)
+}
+
 // CheckOut updates attendance record with check-out time
 func (s *AttendanceService) CheckOut(userID uint, req *CheckOutRequest) (*model.Attendance, error) {
-	// Get today's attendance
-	attendance, err := s.GetTodayAttendance(userID)
+	// Get today's attendance, in multi-session mode the latest one still open
+	var attendance *model.Attendance
+	var err error
+	if s.config.Attendance.MultiSession {
+		attendance, err = s.getLatestOpenSession(userID)
+	} else {
+		attendance, err = s.GetTodayAttendance(userID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -99,25 +667,36 @@ func (s *AttendanceService) CheckOut(userID uint, req *CheckOutRequest) (*model.
 		return nil, errors.New("already checked out today")
 	}
 
-	// Validate location (should be near check-in location)
-	isValid, _, err := s.locationService.ValidateLocationForAttendance(
+	// Validate location (should be near check-in location), except for remote work
+	isValid, distance, err := s.locationService.ValidateLocationForCheckOut(
 		attendance.LocationID,
-		req.Latitude,
-		req.Longitude,
+		*req.Latitude,
+		*req.Longitude,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	if !isValid {
+	if !isValid && attendance.Status != "remote" {
 		return nil, errors.New("you are outside the allowed radius for check-out")
 	}
 
+	// Schedules may demand an explanation for leaving before check-out time
+	now := s.clock.Now()
+	userSchedule, err := s.getActiveUserSchedule(userID, attendance.CheckInTime)
+	if err != nil {
+		return nil, err
+	}
+	if userSchedule != nil && userSchedule.Schedule.RequireNoteOnEarlyLeave &&
+		isEarlyLeave(now, attendance.CheckInTime, &userSchedule.Schedule) && strings.TrimSpace(req.Notes) == "" {
+		return nil, ErrEarlyLeaveNoteMissing
+	}
+
 	// Update check-out info
-	now := time.Now()
 	attendance.CheckOutTime = &now
-	attendance.CheckOutLatitude = &req.Latitude
-	attendance.CheckOutLongitude = &req.Longitude
+	attendance.CheckOutLatitude = req.Latitude
+	attendance.CheckOutLongitude = req.Longitude
+	attendance.CheckOutDistance = &distance
 
 	if req.Notes != "" {
 		if attendance.Notes != "" {
@@ -137,12 +716,84 @@ func (s *AttendanceService) CheckOut(userID uint, req *CheckOutRequest) (*model.
 	return attendance, nil
 }
 
+// createCheckIn inserts a check-in, and runs extra in the same transaction when given.
+// The check against today's records is repeated under a lock on the user, so two
+// concurrent check-ins cannot both pass it before either is inserted.
+func (s *AttendanceService) createCheckIn(attendance *model.Attendance, extra func(tx *gorm.DB) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", attendance.UserID).Error; err != nil {
+			return err
+		}
+		if err := s.checkCanCheckIn(tx, attendance.UserID); err != nil {
+			return err
+		}
+		if err := tx.Create(attendance).Error; err != nil {
+			return err
+		}
+		if extra != nil {
+			return extra(tx)
+		}
+		return nil
+	})
+}
+
+// checkCanCheckIn rejects a check-in that would give the user a second record today, or
+// in multi-session mode, more open records today than ATTENDANCE_MAX_OPEN_SESSIONS
+func (s *AttendanceService) checkCanCheckIn(db *gorm.DB, userID uint) error {
+	if !s.config.Attendance.MultiSession {
+		hasCheckedIn, err := s.hasCheckedInToday(db, userID)
+		if err != nil {
+			return err
+		}
+		if hasCheckedIn {
+			return errors.New("already checked in today")
+		}
+		return nil
+	}
+
+	var open int64
+	if err := s.openSessionsToday(db, userID).Count(&open).Error; err != nil {
+		return err
+	}
+	if limit := s.config.Attendance.MaxOpenSessions; open >= int64(limit) {
+		return &OpenSessionLimitError{Limit: limit}
+	}
+	return nil
+}
+
+// openSessionsToday scopes to the user's records checked in today and not checked out yet
+func (s *AttendanceService) openSessionsToday(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&model.Attendance{}).
+		Where("user_id = ? AND DATE(check_in_time) = ? AND check_out_time IS NULL AND status <> ?",
+			userID, s.clock.Now().Format("2006-01-02"), "absent")
+}
+
+// getLatestOpenSession gets the most recent of the user's open records today
+func (s *AttendanceService) getLatestOpenSession(userID uint) (*model.Attendance, error) {
+	var attendance model.Attendance
+	err := s.openSessionsToday(s.db, userID).Preload("User").Preload("Location").
+		Order("check_in_time DESC").
+		First(&attendance).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("no open session to check out")
+		}
+		return nil, err
+	}
+
+	return &attendance, nil
+}
+
 // HasCheckedInToday checks if user has checked in today
 func (s *AttendanceService) HasCheckedInToday(userID uint) (bool, error) {
+	return s.hasCheckedInToday(s.db, userID)
+}
+
+func (s *AttendanceService) hasCheckedInToday(db *gorm.DB, userID uint) (bool, error) {
 	var count int64
-	today := time.Now().Format("2006-01-02")
+	today := s.clock.Now().Format("2006-01-02")
 
-	err := s.db.Model(&model.Attendance{}).
+	err := db.Model(&model.Attendance{}).
 		Where("user_id = ? AND DATE(check_in_time) = ?", userID, today).
 		Count(&count).Error
 
@@ -152,7 +803,7 @@ func (s *AttendanceService) HasCheckedInToday(userID uint) (bool, error) {
 // GetTodayAttendance gets user's attendance for today
 func (s *AttendanceService) GetTodayAttendance(userID uint) (*model.Attendance, error) {
 	var attendance model.Attendance
-	today := time.Now().Format("2006-01-02")
+	today := s.clock.Now().Format("2006-01-02")
 
 	err := s.db.Preload("User").Preload("Location").
 		Where("user_id = ? AND DATE(check_in_time) = ?", userID, today).
@@ -172,11 +823,28 @@ func (s *AttendanceService) GetTodayAttendance(userID uint) (*model.Attendance,
 func (s *AttendanceService) GetAttendanceStatus(userID uint) (map[string]interface{}, error) {
 	attendance, err := s.GetTodayAttendance(userID)
 	if err != nil {
-		// No check-in today
+		now := s.clock.Now()
+		minutesUntilLate, err := s.minutesUntilLate(userID, now)
+		if err != nil {
+			return nil, err
+		}
+
+		// No check-in today, though a two-phase check-in may be half done
+		if arrival, err := s.getTodayArrival(userID, now); err == nil {
+			return map[string]interface{}{
+				"has_checked_in":     false,
+				"has_checked_out":    false,
+				"has_arrived":        true,
+				"arrival_time":       arrival.ArrivedAt,
+				"minutes_until_late": minutesUntilLate,
+				"message":            "You have arrived but not started work yet",
+			}, nil
+		}
 		return map[string]interface{}{
-			"has_checked_in":  false,
-			"has_checked_out": false,
-			"message":         "You haven't checked in today",
+			"has_checked_in":     false,
+			"has_checked_out":    false,
+			"minutes_until_late": minutesUntilLate,
+			"message":            "You haven't checked in today",
 		}, nil
 	}
 
@@ -190,34 +858,140 @@ func (s *AttendanceService) GetAttendanceStatus(userID uint) (map[string]interfa
 	}, nil
 }
 
-// GetUserAttendanceHistory gets attendance history for a user
-func (s *AttendanceService) GetUserAttendanceHistory(userID uint, limit, offset int) ([]model.Attendance, int64, error) {
-	var attendances []model.Attendance
-	var total int64
+// PreviewCheckInStatus returns the status the user would get by checking in now under
+// their active schedule, without recording anything. Location checks are not part of
+// the preview, so the check-in itself may still be rejected.
+func (s *AttendanceService) PreviewCheckInStatus(userID uint) (*CheckInStatusPreview, error) {
+	now := s.clock.Now()
 
-	// Count total
-	s.db.Model(&model.Attendance{}).Where("user_id = ?", userID).Count(&total)
+	userSchedule, err := s.getActiveUserSchedule(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	location, err := s.assignedLocation(userSchedule)
+	if err != nil {
+		return nil, err
+	}
+	hasCheckedIn, err := s.HasCheckedInToday(userID)
+	if err != nil {
+		return nil, err
+	}
 
-	// Get paginated records
-	err := s.db.Preload("Location").
-		Where("user_id = ?", userID).
-		Order("check_in_time DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&attendances).Error
+	preview := CheckInStatusPreview{
+		At:               now,
+		Status:           s.checkInStatus(userSchedule, location, now),
+		HasSchedule:      userSchedule != nil,
+		AlreadyCheckedIn: hasCheckedIn,
+	}
 
-	if err != nil {
-		return nil, 0, err
+	if preview.Status == "late" || preview.Status == "half_day" {
+		var schedule *model.WorkSchedule
+		if userSchedule != nil {
+			schedule = &userSchedule.Schedule
+		}
+		lastOnTime, _, _ := statusCutoffs(schedule)
+		local := now
+		if location != nil {
+			local = now.In(location.TimeLocation())
+		}
+		preview.MinutesLate = max(minutesOfDay(local)-lastOnTime, 0)
 	}
 
-	return attendances, total, nil
+	return &preview, nil
 }
 
-// GetAllAttendances gets all attendances with filters (Admin)
-func (s *AttendanceService) GetAllAttendances(filters map[string]interface{}, limit, offset int) ([]model.Attendance, int64, error) {
-	var attendances []model.Attendance
-	var total int64
-
+// minutesUntilLate returns how many whole minutes are left at now before a
+// check-in would be marked late under the user's active schedule, negative once
+// past. Nil without a schedule or on a day the schedule does not work.
+func (s *AttendanceService) minutesUntilLate(userID uint, now time.Time) (*int, error) {
+	userSchedule, err := s.getActiveUserSchedule(userID, now)
+	if err != nil || userSchedule == nil {
+		return nil, err
+	}
+	location, err := s.assignedLocation(userSchedule)
+	if err != nil {
+		return nil, err
+	}
+	now = now.In(location.TimeLocation())
+	if !isScheduledWorkDay([]model.UserSchedule{*userSchedule}, now) {
+		return nil, nil
+	}
+
+	checkInEnd, err := parseTimeOfDay(userSchedule.Schedule.CheckInEnd)
+	if err != nil {
+		return nil, nil
+	}
+
+	// Matches determineAttendanceStatus: the whole check_in_end minute still counts as on time
+	minutes := minutesOfDay(checkInEnd) - minutesOfDay(now)
+	return &minutes, nil
+}
+
+// GetUserAttendanceHistory gets attendance history for a user, optionally filtered by status
+func (s *AttendanceService) GetUserAttendanceHistory(userID uint, filters map[string]interface{}, limit, offset int) ([]model.Attendance, int64, error) {
+	var attendances []model.Attendance
+	var total int64
+
+	query := s.db.Model(&model.Attendance{}).Where("user_id = ?", userID)
+
+	// Apply filters
+	if status, ok := filters["status"].(string); ok && status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if dateFrom, ok := filters["date_from"].(string); ok && dateFrom != "" {
+		query = query.Where("DATE(check_in_time) >= ?", dateFrom)
+	}
+	if dateTo, ok := filters["date_to"].(string); ok && dateTo != "" {
+		query = query.Where("DATE(check_in_time) <= ?", dateTo)
+	}
+
+	// Count total
+	query.Count(&total)
+
+	// Get paginated records
+	err := query.Preload("Location").Preload("Attachments").
+		Order("check_in_time DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&attendances).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return attendances, total, nil
+}
+
+// GetUserAttendanceHistoryForAdmin is GetUserAttendanceHistory for any user, failing
+// with "user not found" instead of returning an empty page for unknown users
+func (s *AttendanceService) GetUserAttendanceHistoryForAdmin(userID uint, filters map[string]interface{}, limit, offset int) ([]model.Attendance, int64, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, 0, err
+	}
+	return s.GetUserAttendanceHistory(userID, filters, limit, offset)
+}
+
+// GetUserAttendanceByDate gets all attendance records of a user on a specific date
+func (s *AttendanceService) GetUserAttendanceByDate(userID uint, date time.Time) ([]model.Attendance, error) {
+	attendances := []model.Attendance{}
+
+	err := s.db.Preload("User").Preload("Location").
+		Where("user_id = ? AND DATE(check_in_time) = ?", userID, date.Format("2006-01-02")).
+		Order("check_in_time ASC").
+		Find(&attendances).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attendances, nil
+}
+
+// GetAllAttendances gets all attendances with filters (Admin)
+func (s *AttendanceService) GetAllAttendances(filters map[string]interface{}, limit, offset int) ([]model.Attendance, int64, error) {
+	var attendances []model.Attendance
+	var total int64
+
 	query := s.db.Model(&model.Attendance{})
 
 	// Apply filters
@@ -227,9 +1001,31 @@ func (s *AttendanceService) GetAllAttendances(filters map[string]interface{}, li
 	if locationID, ok := filters["location_id"].(uint); ok && locationID > 0 {
 		query = query.Where("location_id = ?", locationID)
 	}
+	if regionID, ok := filters["region_id"].(uint); ok && regionID > 0 {
+		query = query.Where("location_id IN (SELECT id FROM attendance_locations WHERE region_id = ?)", regionID)
+	}
+	if scheduleID, ok := filters["schedule_id"].(uint); ok && scheduleID > 0 {
+		// Only records dated within the user's assignment to the schedule
+		query = query.Where(`EXISTS (SELECT 1 FROM user_schedules us
+			WHERE us.user_id = attendances.user_id AND us.schedule_id = ?
+			AND us.effective_from <= DATE(attendances.check_in_time)
+			AND (us.effective_to IS NULL OR us.effective_to >= DATE(attendances.check_in_time)))`, scheduleID)
+	}
 	if status, ok := filters["status"].(string); ok && status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if outsideRadius, ok := filters["outside_radius"].(bool); ok {
+		query = query.Where("outside_radius = ?", outsideRadius)
+	}
+	if clockSkew, ok := filters["clock_skew_suspicious"].(bool); ok {
+		query = query.Where("clock_skew_suspicious = ?", clockSkew)
+	}
+	if reviewed, ok := filters["reviewed"].(bool); ok {
+		query = query.Where("reviewed = ?", reviewed)
+	}
+	if minDistance, ok := filters["min_distance"].(float64); ok {
+		query = query.Where("distance_from_location > ?", minDistance)
+	}
 	if dateFrom, ok := filters["date_from"].(string); ok && dateFrom != "" {
 		query = query.Where("DATE(check_in_time) >= ?", dateFrom)
 	}
@@ -254,18 +1050,1844 @@ func (s *AttendanceService) GetAllAttendances(filters map[string]interface{}, li
 	return attendances, total, nil
 }
 
-// determineAttendanceStatus determines status based on check-in time
-func (s *AttendanceService) determineAttendanceStatus(checkInTime time.Time) string {
-	// For now, simple logic: late if after 9 AM
-	hour := checkInTime.Hour()
+// GetMonthlyCalendar returns attendance status per day (YYYY-MM-DD) for the given month.
+// Days with a record show its status; in multi-session mode a day with several records
+// shows the status of its first session not marked absent. Other days outside the user's scheduled work days
+// are marked "off", scheduled days that are a holiday at the assigned location or fall in
+// the user's leave are "holiday" or "leave", and the remaining scheduled days without a
+// record are "absent" once they have passed. Future work days are omitted.
+func (s *AttendanceService) GetMonthlyCalendar(userID uint, year int, month time.Month) (map[string]string, error) {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
 
-	if hour < 9 {
-		return "present"
-	} else if hour == 9 {
-		return "present"
-	} else if hour < 12 {
-		return "late"
-	} else {
-		return "half_day"
+	attendances, userSchedules, err := s.loadPeriod(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	off, err := loadTimeOff(s.db, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCalendar(attendances, userSchedules, off, start, end, s.clock.Now()), nil
+}
+
+// GetWeeklySummary returns status counts and worked hours for the ISO week (Monday to
+// Sunday) containing weekStart. Scheduled days follow whichever schedule is effective on
+// each day, so a week spanning two assignments is counted per day.
+func (s *AttendanceService) GetWeeklySummary(userID uint, weekStart time.Time) (*WeeklySummary, error) {
+	weekday := int(weekStart.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	start := time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day()-(weekday-1), 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 7)
+
+	attendances, userSchedules, err := s.loadPeriod(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	off, err := loadTimeOff(s.db, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := buildCalendar(attendances, userSchedules, off, start, end, s.clock.Now())
+
+	return &WeeklySummary{
+		WeekStart:    start.Format("2006-01-02"),
+		WeekEnd:      end.AddDate(0, 0, -1).Format("2006-01-02"),
+		PeriodTotals: summarizePeriod(attendances, userSchedules, start, end, calendar, s.PayrollRounding()),
+	}, nil
+}
+
+// GetMonthlySummary returns the monthly calendar of a user together with status
+// counts and worked hours computed the same way as GetWeeklySummary. A month without
+// any records still yields a summary, with every past work day counted as absent.
+func (s *AttendanceService) GetMonthlySummary(userID uint, year int, month time.Month) (*MonthlySummary, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+
+	attendances, userSchedules, err := s.loadPeriod(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	off, err := loadTimeOff(s.db, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := buildCalendar(attendances, userSchedules, off, start, end, s.clock.Now())
+
+	return &MonthlySummary{
+		UserID:       user.ID,
+		UserName:     user.FullName,
+		Year:         year,
+		Month:        int(month),
+		Days:         calendar,
+		PeriodTotals: summarizePeriod(attendances, userSchedules, start, end, calendar, s.PayrollRounding()),
+	}, nil
+}
+
+// GetUserPoints accumulates the lateness points of a user's records checked in between
+// from and to (inclusive), scoring each record by its status (Admin). The range is
+// limited to 366 days.
+func (s *AttendanceService) GetUserPoints(userID uint, from, to time.Time) (*UserPoints, error) {
+	if to.Before(from) {
+		return nil, &FieldError{Field: "to", Message: "must not be before from"}
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return nil, &FieldError{Field: "to", Message: "range must not exceed 366 days"}
+	}
+
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	var attendances []model.Attendance
+	if err := s.db.Where("user_id = ? AND DATE(check_in_time) BETWEEN ? AND ?", userID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("check_in_time ASC").
+		Find(&attendances).Error; err != nil {
+		return nil, err
+	}
+
+	points := UserPoints{
+		UserID:   userID,
+		DateFrom: from.Format("2006-01-02"),
+		DateTo:   to.Format("2006-01-02"),
+		ByStatus: map[string]int{},
+		Entries:  []PointsEntry{},
+	}
+	for _, att := range attendances {
+		scored := s.config.Attendance.StatusPoints[att.Status]
+		if scored == 0 {
+			continue
+		}
+		points.Total += scored
+		points.ByStatus[att.Status] += scored
+		points.Entries = append(points.Entries, PointsEntry{
+			AttendanceID: att.ID,
+			Date:         att.CheckInTime.Format("2006-01-02"),
+			Status:       att.Status,
+			Points:       scored,
+			RunningTotal: points.Total,
+		})
+	}
+
+	return &points, nil
+}
+
+// GetUserAttendanceStats returns the cached aggregates of a user. They are recomputed
+// when there are none yet or they were computed on an earlier day, since the streak and
+// the month counts move with the date; edits to records are picked up by RecomputeUserStats.
+func (s *AttendanceService) GetUserAttendanceStats(userID uint) (*model.UserAttendanceStats, error) {
+	var stats model.UserAttendanceStats
+	err := s.db.Where("user_id = ?", userID).First(&stats).Error
+	if err == nil && stats.ComputedAt.Format("2006-01-02") == s.clock.Now().Format("2006-01-02") {
+		return &stats, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return s.RecomputeUserStats(userID)
+}
+
+// RecomputeUserStats rebuilds the cached aggregates of a user from their attendance
+// records and stores them. A streak counts consecutive days with a non-absent record;
+// unscheduled days, holidays and leave without a record do not break it, and today
+// only extends it.
+func (s *AttendanceService) RecomputeUserStats(userID uint) (*model.UserAttendanceStats, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	stats := model.UserAttendanceStats{
+		UserID:     userID,
+		Month:      now.Format("2006-01"),
+		ComputedAt: now,
+	}
+
+	var first *time.Time
+	if err := s.db.Model(&model.Attendance{}).Where("user_id = ?", userID).
+		Select("MIN(check_in_time)").Scan(&first).Error; err != nil {
+		return nil, err
+	}
+
+	if first != nil {
+		start := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.Local)
+		attendances, userSchedules, err := s.loadPeriod(userID, start, today.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+		off, err := loadTimeOff(s.db, userID, start, today.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+
+		attended := make(map[string]bool, len(attendances))
+		for _, att := range attendances {
+			if att.Status != "absent" {
+				attended[att.CheckInTime.Format("2006-01-02")] = true
+			}
+		}
+
+		streak := 0
+		for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+			if attended[day.Format("2006-01-02")] {
+				streak++
+				if streak > stats.LongestStreak {
+					stats.LongestStreak = streak
+				}
+				continue
+			}
+			if day.Before(today) && isScheduledWorkDay(userSchedules, day) && excusedOn(userSchedules, off, day) == "" {
+				streak = 0
+			}
+		}
+		stats.CurrentStreak = streak
+	}
+
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	attendances, userSchedules, err := s.loadPeriod(userID, monthStart, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+	off, err := loadTimeOff(s.db, userID, monthStart, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+	totals := summarizePeriod(attendances, userSchedules, monthStart, monthEnd,
+		buildCalendar(attendances, userSchedules, off, monthStart, monthEnd, now), s.PayrollRounding())
+	stats.MonthPresent = totals.Present + totals.Early // early arrivals are on time too
+	stats.MonthLate = totals.Late
+	stats.MonthHalfDay = totals.HalfDay
+	stats.MonthRemote = totals.Remote
+	stats.MonthAbsent = totals.Absent
+	stats.MonthHours = totals.TotalHours
+
+	if err := s.db.Save(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// summarizePeriod counts the statuses in calendar and the hours worked in attendances.
+// Scheduled days leave out the holidays and leave marked in calendar.
+// Overtime is time worked in a day, over all its sessions, beyond the scheduled shift
+// (check-in start to check-out start), or beyond eight hours on days without a schedule.
+// Both use the payroll rounded times.
+func summarizePeriod(attendances []model.Attendance, userSchedules []model.UserSchedule, start, end time.Time, calendar map[string]string, rounding model.PayrollRounding) PeriodTotals {
+	var totals PeriodTotals
+
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if status := calendar[day.Format("2006-01-02")]; status == "holiday" || status == "leave" {
+			continue
+		}
+		if isScheduledWorkDay(userSchedules, day) {
+			totals.ScheduledDays++
+		}
+	}
+
+	for _, status := range calendar {
+		switch status {
+		case "present":
+			totals.Present++
+		case "early":
+			totals.Early++
+		case "late":
+			totals.Late++
+		case "half_day":
+			totals.HalfDay++
+		case "remote":
+			totals.Remote++
+		case "absent":
+			totals.Absent++
+		case "holiday":
+			totals.Holiday++
+		case "leave":
+			totals.Leave++
+		}
+	}
+
+	var worked, overtime time.Duration
+	daily := make(map[string]time.Duration)
+	days := make(map[string]time.Time)
+	for _, att := range attendances {
+		if att.CheckOutTime == nil {
+			continue
+		}
+		duration := rounding.WorkDuration(att.CheckInTime, *att.CheckOutTime)
+		worked += duration
+		date := att.CheckInTime.Format("2006-01-02")
+		daily[date] += duration
+		days[date] = att.CheckInTime
+	}
+	for date, duration := range daily {
+		if extra := duration - scheduledShiftLength(userSchedules, days[date]); extra > 0 {
+			overtime += extra
+		}
+	}
+	totals.TotalHours = math.Round(worked.Hours()*100) / 100
+	totals.OvertimeHours = math.Round(overtime.Hours()*100) / 100
+
+	return totals
+}
+
+// GetComplianceRate computes the percentage of scheduled days in [from, to] the user
+// was on time. Scheduled days are the work days of the schedule assigned on each day;
+// days without an assignment, days the assigned location is closed, holidays, the
+// user's leave and days still to come without a record are left out.
+func (s *AttendanceService) GetComplianceRate(userID uint, from, to time.Time) (*ComplianceRate, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	end := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, time.Local)
+
+	attendances, userSchedules, err := s.loadPeriod(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	off, err := loadTimeOff(s.db, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := dayStatuses(attendances)
+
+	compliance := ComplianceRate{
+		UserID:   userID,
+		DateFrom: start.Format("2006-01-02"),
+		DateTo:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+
+	today := s.clock.Now().Format("2006-01-02")
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		us := scheduleOn(userSchedules, day)
+		if us == nil || !isScheduledWorkDay([]model.UserSchedule{*us}, day) {
+			continue
+		}
+		if !us.Location.IsOpenOnDate(day) {
+			compliance.ExcludedDays++
+			continue
+		}
+		switch off.on(day, us.LocationID) {
+		case "holiday":
+			compliance.HolidayDays++
+			continue
+		case "leave":
+			compliance.LeaveDays++
+			continue
+		}
+
+		date := day.Format("2006-01-02")
+		status, ok := recorded[date]
+		if !ok && date >= today {
+			continue
+		}
+
+		compliance.ScheduledDays++
+		switch status {
+		case "present", "early", "remote":
+			compliance.OnTime++
+		case "late":
+			compliance.Late++
+		case "half_day":
+			compliance.HalfDay++
+		default:
+			compliance.Absent++
+		}
+	}
+
+	if compliance.ScheduledDays > 0 {
+		rate := math.Round(float64(compliance.OnTime)/float64(compliance.ScheduledDays)*10000) / 100
+		compliance.Rate = &rate
+	}
+
+	return &compliance, nil
+}
+
+// loadPeriod loads the user's attendances checked in within [start, end) and every
+// schedule assignment overlapping that range with its schedule and location, most recent first
+func (s *AttendanceService) loadPeriod(userID uint, start, end time.Time) ([]model.Attendance, []model.UserSchedule, error) {
+	var attendances []model.Attendance
+	if err := s.db.Where("user_id = ? AND check_in_time >= ? AND check_in_time < ?", userID, start, end).
+		Find(&attendances).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var userSchedules []model.UserSchedule
+	if err := s.db.Preload("Schedule").Preload("Location").
+		Where("user_id = ? AND effective_from < ? AND (effective_to IS NULL OR effective_to >= ?)", userID, end, start).
+		Order("effective_from DESC").
+		Find(&userSchedules).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return attendances, userSchedules, nil
+}
+
+// dayStatuses gives the status of each day (YYYY-MM-DD) with a record. In multi-session
+// mode a day can have several records; the first session of the day not marked absent
+// decides, as later sessions only resume work, and the day is absent when all of them are.
+func dayStatuses(attendances []model.Attendance) map[string]string {
+	first := make(map[string]*model.Attendance, len(attendances))
+	for i := range attendances {
+		att := &attendances[i]
+		date := att.CheckInTime.Format("2006-01-02")
+		current, ok := first[date]
+		switch {
+		case !ok,
+			current.Status == "absent" && att.Status != "absent",
+			att.Status != "absent" && att.CheckInTime.Before(current.CheckInTime):
+			first[date] = att
+		}
+	}
+
+	statuses := make(map[string]string, len(first))
+	for date, att := range first {
+		statuses[date] = att.Status
+	}
+	return statuses
+}
+
+// excusedOn returns "holiday" when day is a holiday at the location assigned on it,
+// "leave" when it falls in the user's leave, or "" otherwise
+func excusedOn(userSchedules []model.UserSchedule, off *timeOff, day time.Time) string {
+	var locationID uint
+	if us := scheduleOn(userSchedules, day); us != nil {
+		locationID = us.LocationID
+	}
+	return off.on(day, locationID)
+}
+
+// buildCalendar derives the status of every day in [start, end) as described on GetMonthlyCalendar,
+// treating days before now as passed
+func buildCalendar(attendances []model.Attendance, userSchedules []model.UserSchedule, off *timeOff, start, end, now time.Time) map[string]string {
+	recorded := dayStatuses(attendances)
+
+	today := now.Format("2006-01-02")
+	calendar := make(map[string]string)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+
+		if status, ok := recorded[date]; ok {
+			calendar[date] = status
+			continue
+		}
+
+		if !isScheduledWorkDay(userSchedules, day) {
+			calendar[date] = "off"
+			continue
+		}
+
+		if excused := excusedOn(userSchedules, off, day); excused != "" {
+			calendar[date] = excused
+			continue
+		}
+
+		if date < today {
+			calendar[date] = "absent"
+		}
+	}
+
+	return calendar
+}
+
+// isScheduledWorkDay reports whether day is a work day according to the schedule
+// effective on that day. Without an assigned schedule, Monday to Friday is assumed.
+func isScheduledWorkDay(userSchedules []model.UserSchedule, day time.Time) bool {
+	if us := scheduleOn(userSchedules, day); us != nil {
+		return us.Schedule.WorksOn(day.Weekday())
+	}
+
+	weekday := day.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// scheduleOn returns the assignment effective on day, or nil when none is.
+// userSchedules must be ordered most recent first. Dates are compared as calendar
+// days, since effective dates come back from the date columns as UTC midnight.
+func scheduleOn(userSchedules []model.UserSchedule, day time.Time) *model.UserSchedule {
+	date := day.Format("2006-01-02")
+	for i := range userSchedules {
+		us := &userSchedules[i]
+		if us.EffectiveFrom.Format("2006-01-02") > date {
+			continue
+		}
+		if us.EffectiveTo != nil && us.EffectiveTo.Format("2006-01-02") < date {
+			continue
+		}
+		return us
+	}
+	return nil
+}
+
+// scheduledShiftLength returns the planned working time on day: from the schedule's
+// check-in start to its check-out start, or eight hours without a usable schedule
+func scheduledShiftLength(userSchedules []model.UserSchedule, day time.Time) time.Duration {
+	if us := scheduleOn(userSchedules, day); us != nil {
+		checkInStart, errIn := parseTimeOfDay(us.Schedule.CheckInStart)
+		checkOutStart, errOut := parseTimeOfDay(us.Schedule.CheckOutStart)
+		if errIn == nil && errOut == nil && checkOutStart.After(checkInStart) {
+			return checkOutStart.Sub(checkInStart)
+		}
+	}
+	return 8 * time.Hour
+}
+
+// getUser loads a user, returning "user not found" when there is none
+func (s *AttendanceService) getUser(userID uint) (*model.User, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// getActiveUserSchedule returns the schedule assignment effective for the user at the given
+// time, or nil when the user has no schedule assigned
+func (s *AttendanceService) getActiveUserSchedule(userID uint, at time.Time) (*model.UserSchedule, error) {
+	var userSchedule model.UserSchedule
+	date := at.Format("2006-01-02")
+
+	err := s.db.Preload("Schedule").
+		Where("user_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", userID, date, date).
+		Order("effective_from DESC").
+		First(&userSchedule).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
 	}
+
+	return &userSchedule, nil
+}
+
+// assignedLocation loads the location userSchedule assigns, on whose wall clock the
+// schedule's times are read. Nil without a schedule.
+func (s *AttendanceService) assignedLocation(userSchedule *model.UserSchedule) (*model.AttendanceLocation, error) {
+	if userSchedule == nil {
+		return nil, nil
+	}
+	return s.locationService.GetLocationByID(userSchedule.LocationID)
+}
+
+// AttendanceSchedules returns the work schedule that applied to each attendance on its
+// check-in date, keyed by attendance ID, loading the assignments of all users at once.
+// Records without a schedule are left out.
+func (s *AttendanceService) AttendanceSchedules(attendances []model.Attendance) (map[uint]*model.WorkSchedule, error) {
+	schedules := make(map[uint]*model.WorkSchedule)
+	if len(attendances) == 0 {
+		return schedules, nil
+	}
+
+	userIDs := make([]uint, 0, len(attendances))
+	seen := make(map[uint]bool)
+	for _, att := range attendances {
+		if !seen[att.UserID] {
+			seen[att.UserID] = true
+			userIDs = append(userIDs, att.UserID)
+		}
+	}
+
+	var assignments []model.UserSchedule
+	if err := s.db.Preload("Schedule").
+		Where("user_id IN ?", userIDs).
+		Order("effective_from DESC").
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+
+	for _, att := range attendances {
+		date := att.CheckInTime.Format("2006-01-02")
+		// Newest assignment first, as in getActiveUserSchedule
+		for i := range assignments {
+			assignment := &assignments[i]
+			if assignment.UserID != att.UserID || assignment.EffectiveFrom.Format("2006-01-02") > date {
+				continue
+			}
+			if assignment.EffectiveTo != nil && assignment.EffectiveTo.Format("2006-01-02") < date {
+				continue
+			}
+			schedules[att.ID] = &assignment.Schedule
+			break
+		}
+	}
+
+	return schedules, nil
+}
+
+// GetAttendanceByID gets a single attendance record with relations
+func (s *AttendanceService) GetAttendanceByID(id uint) (*model.Attendance, error) {
+	var attendance model.Attendance
+	if err := s.db.Preload("User").Preload("Location").Preload("Attachments").First(&attendance, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("attendance not found")
+		}
+		return nil, err
+	}
+	return &attendance, nil
+}
+
+// GetAttendanceWithNeighbors retrieves an attendance record along with the IDs of the
+// same user's chronologically previous and next records (Admin). A neighbor ID is nil
+// when the record is the user's first or last.
+func (s *AttendanceService) GetAttendanceWithNeighbors(id uint) (*model.Attendance, *uint, *uint, error) {
+	attendance, err := s.GetAttendanceByID(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	previousID, err := s.neighborID(attendance, "(check_in_time < ? OR (check_in_time = ? AND id < ?))", "check_in_time DESC, id DESC")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nextID, err := s.neighborID(attendance, "(check_in_time > ? OR (check_in_time = ? AND id > ?))", "check_in_time ASC, id ASC")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return attendance, previousID, nextID, nil
+}
+
+// neighborID returns the ID of the first record of the same user matching condition in the given order
+func (s *AttendanceService) neighborID(attendance *model.Attendance, condition, order string) (*uint, error) {
+	var ids []uint
+	err := s.db.Model(&model.Attendance{}).
+		Where("user_id = ?", attendance.UserID).
+		Where(condition, attendance.CheckInTime, attendance.CheckInTime, attendance.ID).
+		Order(order).
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
+
+// AddAttachment stores an uploaded file and attaches it to an attendance record.
+// Only the record's owner or an admin may add attachments. The content type is detected
+// from the file itself rather than trusted from the client.
+func (s *AttendanceService) AddAttachment(attendanceID, userID uint, isAdmin bool, req *AddAttachmentRequest, file io.Reader, size int64) (*model.AttendanceAttachment, error) {
+	var attendance model.Attendance
+	if err := s.db.First(&attendance, attendanceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("attendance not found")
+		}
+		return nil, err
+	}
+	if attendance.UserID != userID && !isAdmin {
+		return nil, ErrAttachmentForbidden
+	}
+
+	if size > s.config.Attendance.AttachmentMaxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	// Detect the content type from the first bytes of the file
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := strings.SplitN(http.DetectContentType(head), ";", 2)[0]
+	allowed := false
+	for _, t := range s.config.Attendance.AttachmentTypes {
+		if t == contentType {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, ErrAttachmentUnsupported
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("attendances/%d/%s%s", attendanceID, hex.EncodeToString(name), attachmentExtension(contentType))
+	attachmentType := req.Type
+	if attachmentType == "" {
+		attachmentType = "photo"
+	}
+
+	// Write the file before taking the owner's lock, so slow storage does not hold up
+	// the owner's other uploads; it is removed again if the record cannot be saved
+	url, err := s.storage.Save(key, io.MultiReader(bytes.NewReader(head), file))
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := model.AttendanceAttachment{
+		AttendanceID: attendanceID,
+		Type:         attachmentType,
+		Caption:      req.Caption,
+		URL:          url,
+		ContentType:  contentType,
+		Size:         size,
+		UploadedBy:   &userID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the record owner serializes concurrent uploads against the
+		// attachment limit and the quota
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", attendance.UserID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.AttendanceAttachment{}).Where("attendance_id = ?", attendanceID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(s.config.Attendance.MaxAttachments) {
+			return ErrAttachmentLimit
+		}
+
+		// Storage is charged to the record owner, also when an admin uploads
+		if quota := s.config.Attendance.AttachmentQuota; quota > 0 {
+			_, used, err := attachmentUsage(tx, attendance.UserID)
+			if err != nil {
+				return err
+			}
+			if used+size > quota {
+				return ErrStorageQuotaExceeded
+			}
+		}
+
+		return tx.Create(&attachment).Error
+	})
+	if err != nil {
+		if deleteErr := s.storage.Delete(url); deleteErr != nil {
+			log.Printf("attachment upload: %v", deleteErr)
+		}
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// OpenAttachment returns an attachment of an attendance record with its file content,
+// which the caller must close. Only the record's owner or an admin may read it.
+func (s *AttendanceService) OpenAttachment(attendanceID, attachmentID, userID uint, isAdmin bool) (*model.AttendanceAttachment, io.ReadCloser, error) {
+	var attendance model.Attendance
+	if err := s.db.First(&attendance, attendanceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("attendance not found")
+		}
+		return nil, nil, err
+	}
+	if attendance.UserID != userID && !isAdmin {
+		return nil, nil, ErrAttachmentHidden
+	}
+
+	var attachment model.AttendanceAttachment
+	if err := s.db.Where("id = ? AND attendance_id = ?", attachmentID, attendanceID).First(&attachment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("attachment not found")
+		}
+		return nil, nil, err
+	}
+
+	content, err := s.storage.Open(attachment.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &attachment, content, nil
+}
+
+// GetStorageUsage returns the attachment storage used by the user's records (Admin)
+func (s *AttendanceService) GetStorageUsage(userID uint) (*StorageUsage, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	count, used, err := attachmentUsage(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &StorageUsage{
+		UserID:      userID,
+		Attachments: count,
+		UsedBytes:   used,
+	}
+	if quota := s.config.Attendance.AttachmentQuota; quota > 0 {
+		remaining := max(quota-used, 0)
+		usage.QuotaBytes = &quota
+		usage.RemainingBytes = &remaining
+	}
+	return usage, nil
+}
+
+// attachmentUsage counts the attachments on the user's records and their total size in bytes.
+// Records in the trash still count, since their files stay stored until the record is purged.
+func attachmentUsage(db *gorm.DB, userID uint) (int64, int64, error) {
+	var usage struct {
+		Count int64
+		Bytes int64
+	}
+	err := db.Model(&model.AttendanceAttachment{}).
+		Select("COUNT(attendance_attachments.id) AS count, COALESCE(SUM(attendance_attachments.size), 0) AS bytes").
+		Joins("JOIN attendances ON attendances.id = attendance_attachments.attendance_id").
+		Where("attendances.user_id = ?", userID).
+		Scan(&usage).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return usage.Count, usage.Bytes, nil
+}
+
+// attachmentExtension returns the file extension used to store a content type
+func attachmentExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "application/pdf":
+		return ".pdf"
+	}
+	if extensions, err := mime.ExtensionsByType(contentType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ""
+}
+
+// ReviewAttendance resolves the flag on an attendance record after admin review (Admin)
+func (s *AttendanceService) ReviewAttendance(id, reviewerID uint, req *ReviewAttendanceRequest) (*model.Attendance, error) {
+	attendance, err := s.GetAttendanceByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Review the same anomalies GetAnomalies lists, so unscheduled records can be cleared too
+	unscheduled, err := s.unscheduledIDs([]uint{attendance.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(anomalyReasons(attendance, unscheduled[attendance.ID])) == 0 {
+		return nil, errors.New("attendance is not flagged for review")
+	}
+
+	previousResolution := attendance.ReviewResolution
+	now := s.clock.Now()
+	attendance.Reviewed = true
+	attendance.ReviewedBy = &reviewerID
+	attendance.ReviewedAt = &now
+	attendance.ReviewResolution = req.Resolution
+	attendance.ReviewNote = req.Note
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(attendance).Select("Reviewed", "ReviewedBy", "ReviewedAt", "ReviewResolution", "ReviewNote").
+			Updates(attendance).Error; err != nil {
+			return err
+		}
+
+		return s.auditService.WithTx(tx).Log(reviewerID, "attendance.review", "attendance", attendance.ID, map[string]interface{}{
+			"previous_resolution": previousResolution,
+			"resolution":          req.Resolution,
+			"note":                req.Note,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attendance, nil
+}
+
+// UpdateAdminNotes replaces the admin-only notes of an attendance record
+func (s *AttendanceService) UpdateAdminNotes(id, actorID uint, req *UpdateAdminNotesRequest) (*model.Attendance, error) {
+	attendance, err := s.GetAttendanceByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance.AdminNotes = req.AdminNotes
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Not through the loaded record, whose preloaded user and location would be saved too
+		if err := tx.Model(&model.Attendance{}).Where("id = ?", attendance.ID).Update("admin_notes", req.AdminNotes).Error; err != nil {
+			return err
+		}
+
+		return s.auditService.WithTx(tx).Log(actorID, "attendance.admin_notes", "attendance", attendance.ID, map[string]interface{}{
+			"admin_notes": req.AdminNotes,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attendance, nil
+}
+
+// DeleteAttendance moves an attendance record to the trash (Admin). It disappears from
+// every listing and report but can be restored until the trash purge removes it.
+func (s *AttendanceService) DeleteAttendance(id, actorID uint) error {
+	attendance, err := s.GetAttendanceByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(attendance).Error; err != nil {
+			return err
+		}
+
+		return s.auditService.WithTx(tx).Log(actorID, "attendance.delete", "attendance", attendance.ID, map[string]interface{}{
+			"user_id":       attendance.UserID,
+			"check_in_time": attendance.CheckInTime,
+			"status":        attendance.Status,
+		})
+	})
+}
+
+// GetTrashedAttendances gets the records in the trash, most recently deleted first (Admin)
+func (s *AttendanceService) GetTrashedAttendances(limit, offset int) ([]model.Attendance, int64, error) {
+	var attendances []model.Attendance
+	var total int64
+
+	query := s.db.Unscoped().Model(&model.Attendance{}).Where("attendances.deleted_at IS NOT NULL")
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("User").Preload("Location").
+		Order("attendances.deleted_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&attendances).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return attendances, total, nil
+}
+
+// RestoreAttendance takes a record out of the trash (Admin). It fails when the user
+// has since got another record on the same day, since a day holds one record, unless
+// multi-session mode allows several records a day.
+func (s *AttendanceService) RestoreAttendance(id, actorID uint) (*model.Attendance, error) {
+	var attendance model.Attendance
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at IS NOT NULL").First(&attendance, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("attendance not found in trash")
+			}
+			return err
+		}
+
+		if !s.config.Attendance.MultiSession {
+			// Locking the owner keeps two restores of the same day from both passing the check
+			if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", attendance.UserID).Error; err != nil {
+				return err
+			}
+
+			var existing int64
+			if err := tx.Model(&model.Attendance{}).
+				Where("user_id = ? AND DATE(check_in_time) = ?", attendance.UserID, attendance.CheckInTime.Format("2006-01-02")).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				return errors.New("user already has attendance on that day")
+			}
+		}
+
+		if err := tx.Unscoped().Model(&attendance).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		return s.auditService.WithTx(tx).Log(actorID, "attendance.restore", "attendance", attendance.ID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAttendanceByID(attendance.ID)
+}
+
+// PurgeTrashedAttendances permanently removes records that were moved to the trash
+// before the given time, along with their attachments, and returns how many were removed.
+// Attachment files are deleted from storage once the rows are gone; a file that cannot
+// be deleted is logged and left behind rather than failing the purge.
+func (s *AttendanceService) PurgeTrashedAttendances(before time.Time) (int64, error) {
+	var purged int64
+	var attachments []model.AttendanceAttachment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&model.Attendance{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("attendance_id IN ?", ids).Find(&attachments).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("attendance_id IN ?", ids).Delete(&model.AttendanceAttachment{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Attendance{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, attachment := range attachments {
+		if err := s.storage.Delete(attachment.URL); err != nil {
+			log.Printf("attendance trash job: attachment %d: %v", attachment.ID, err)
+		}
+	}
+
+	return purged, nil
+}
+
+// StartTrashPurge runs PurgeTrashedAttendances every interval until ctx is cancelled,
+// removing records that stayed in the trash longer than retention
+func (s *AttendanceService) StartTrashPurge(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := s.PurgeTrashedAttendances(s.clock.Now().Add(-retention)); err != nil {
+			log.Printf("attendance trash job: %v", err)
+		} else if purged > 0 {
+			log.Printf("attendance trash job: purged %d records", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MarkAbsences creates "absent" records for users who had a scheduled work day that has
+// already ended in their location's timezone but never checked in. Users on leave that
+// day, holidays at their location and days the location was inactive or suspended are
+// skipped. It is idempotent: users with any record on that day are skipped too. Returns
+// the number of records created.
+func (s *AttendanceService) MarkAbsences(now time.Time) (int, error) {
+	var userSchedules []model.UserSchedule
+	if err := s.db.Preload("Schedule").Preload("Location").
+		Joins("JOIN users ON users.id = user_schedules.user_id AND users.is_active = ?", true).
+		Where("user_schedules.effective_from < ?", now.Format("2006-01-02")).
+		Where("user_schedules.effective_to IS NULL OR user_schedules.effective_to >= ?", now.AddDate(0, 0, -2).Format("2006-01-02")).
+		Find(&userSchedules).Error; err != nil {
+		return 0, err
+	}
+
+	marked := 0
+	for _, us := range userSchedules {
+		day := lastEndedDay(now, us.Location.TimeLocation())
+		nextDay := day.AddDate(0, 0, 1)
+
+		if us.StatusAt(day) != "active" || !isScheduledWorkDay([]model.UserSchedule{us}, day) || !us.Location.IsOpenOn(day) {
+			continue
+		}
+		if !us.Location.IsActive || us.Location.IsSuspendedDuring(day, nextDay) {
+			continue
+		}
+
+		off, err := loadTimeOff(s.db, us.UserID, day, nextDay)
+		if err != nil {
+			return marked, err
+		}
+		if off.on(day, us.LocationID) != "" {
+			continue
+		}
+
+		// check_in_time holds server-local time, so the location's day is bounded in it
+		// rather than compared by server-local calendar date
+		dayStart, dayEnd := day.In(time.Local), nextDay.In(time.Local)
+		var count int64
+		if err := s.db.Model(&model.Attendance{}).
+			Where("user_id = ? AND check_in_time >= ? AND check_in_time < ?", us.UserID, dayStart, dayEnd).
+			Count(&count).Error; err != nil {
+			return marked, err
+		}
+		if count > 0 {
+			continue
+		}
+
+		absence := model.Attendance{
+			UserID:      us.UserID,
+			LocationID:  us.LocationID,
+			CheckInTime: dayStart,
+			Status:      "absent",
+			Notes:       "Marked absent automatically",
+		}
+		if err := s.db.Create(&absence).Error; err != nil {
+			return marked, err
+		}
+		marked++
+	}
+
+	return marked, nil
+}
+
+// lastEndedDay returns the start of the most recent day that has fully ended at now in loc
+func lastEndedDay(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, loc)
+}
+
+// StartAbsenceMarking runs MarkAbsences every interval until ctx is cancelled
+func (s *AttendanceService) StartAbsenceMarking(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if marked, err := s.MarkAbsences(s.clock.Now()); err != nil {
+			log.Printf("absence job: %v", err)
+		} else if marked > 0 {
+			log.Printf("absence job: marked %d absences", marked)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetCountsByLocation returns check-in totals per location between the given dates (Admin).
+// Empty dates leave that side of the range open; locations without activity are only
+// included when includeEmpty is set. A non-zero regionID limits the report to that region's locations.
+func (s *AttendanceService) GetCountsByLocation(dateFrom, dateTo string, regionID uint, includeEmpty bool) ([]LocationAttendanceCount, error) {
+	joinCondition := "attendances.location_id = attendance_locations.id AND attendances.deleted_at IS NULL AND attendances.status <> ?"
+	args := []interface{}{"absent"}
+	if dateFrom != "" {
+		joinCondition += " AND DATE(attendances.check_in_time) >= ?"
+		args = append(args, dateFrom)
+	}
+	if dateTo != "" {
+		joinCondition += " AND DATE(attendances.check_in_time) <= ?"
+		args = append(args, dateTo)
+	}
+
+	query := s.db.Model(&model.AttendanceLocation{}).
+		Select("attendance_locations.id AS location_id, attendance_locations.name AS location_name, "+
+			"COUNT(attendances.id) AS total_check_ins, "+
+			"COUNT(DISTINCT attendances.user_id) AS unique_users, "+
+			"COALESCE(AVG(attendances.distance_from_location), 0) AS average_distance").
+		Joins("LEFT JOIN attendances ON "+joinCondition, args...).
+		Group("attendance_locations.id, attendance_locations.name").
+		Order("total_check_ins DESC, attendance_locations.name ASC")
+
+	if regionID > 0 {
+		query = query.Where("attendance_locations.region_id = ?", regionID)
+	}
+	if !includeEmpty {
+		query = query.Having("COUNT(attendances.id) > 0")
+	}
+
+	counts := []LocationAttendanceCount{}
+	if err := query.Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetDashboardCounts counts the check-ins on date across all users (Admin). Suspicious
+// records are those GetAnomalies lists by default, so a review takes them off the count.
+func (s *AttendanceService) GetDashboardCounts(date time.Time) (*DashboardCounts, error) {
+	day := date.Format("2006-01-02")
+	var counts DashboardCounts
+	err := s.db.Model(&model.Attendance{}).
+		Select("COUNT(*) AS check_ins, "+
+			"COUNT(*) FILTER (WHERE attendances.status = 'late') AS late, "+
+			"COUNT(*) FILTER (WHERE attendances.check_out_time IS NULL) AS open, "+
+			"COUNT(*) FILTER (WHERE NOT attendances.reviewed AND "+anomalyCondition+") AS suspicious").
+		Where("DATE(attendances.check_in_time) = ? AND attendances.status <> ?", day, "absent").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	counts.Date = day
+
+	return &counts, nil
+}
+
+// GetDailyCounts returns the number of check-ins per day between from and to (inclusive),
+// keyed by "2006-01-02" with days without check-ins set to zero (Admin). Absent records
+// are not counted. Optionally narrowed by location_id and department_id.
+func (s *AttendanceService) GetDailyCounts(from, to time.Time, filters map[string]interface{}) (map[string]int64, error) {
+	if to.Before(from) {
+		return nil, &FieldError{Field: "date_to", Message: "must not be before date_from"}
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return nil, &FieldError{Field: "date_to", Message: "range must not exceed 366 days"}
+	}
+
+	query := s.db.Model(&model.Attendance{}).
+		Select("DATE(attendances.check_in_time) AS day, COUNT(*) AS count").
+		Where("DATE(attendances.check_in_time) BETWEEN ? AND ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Where("attendances.status <> ?", "absent").
+		Group("DATE(attendances.check_in_time)")
+
+	if locationID, ok := filters["location_id"].(uint); ok && locationID > 0 {
+		query = query.Where("attendances.location_id = ?", locationID)
+	}
+	if departmentID, ok := filters["department_id"].(uint); ok && departmentID > 0 {
+		query = query.Joins("JOIN users ON users.id = attendances.user_id").
+			Where("users.department_id = ?", departmentID)
+	}
+
+	var rows []struct {
+		Day   time.Time
+		Count int64
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		counts[day.Format("2006-01-02")] = 0
+	}
+	for _, row := range rows {
+		counts[row.Day.Format("2006-01-02")] = row.Count
+	}
+
+	return counts, nil
+}
+
+// GetOrgAttendanceTrend computes the organization-wide attendance rate per day, week
+// (starting Monday) or month between from and to (inclusive, at most 366 buckets). Days
+// after today are not counted yet, and buckets without scheduled days have a null rate.
+func (s *AttendanceService) GetOrgAttendanceTrend(from, to time.Time, granularity string) ([]AttendanceTrendBucket, error) {
+	var step func(time.Time) time.Time
+	switch granularity {
+	case "day":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case "week":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case "month":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	default:
+		return nil, &FieldError{Field: "granularity", Message: "must be one of " + strings.Join(TrendGranularities, ", ")}
+	}
+	if to.Before(from) {
+		return nil, &FieldError{Field: "to", Message: "must not be before from"}
+	}
+
+	// Bucket starts, aligned like DATE_TRUNC
+	var starts []time.Time
+	start := from
+	switch granularity {
+	case "week":
+		start = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	case "month":
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location())
+	}
+	for ; !start.After(to); start = step(start) {
+		starts = append(starts, start)
+		if len(starts) > 366 {
+			return nil, &FieldError{Field: "to", Message: "range must not exceed 366 buckets"}
+		}
+	}
+
+	counted := to
+	now := s.clock.Now()
+	if today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, to.Location()); today.Before(counted) {
+		counted = today
+	}
+
+	counts := make(map[string]AttendanceTrendBucket)
+	if !counted.Before(from) {
+		var rows []struct {
+			Bucket        string
+			ScheduledDays int64
+			AttendedDays  int64
+		}
+		if err := s.db.Raw(orgTrendQuery, from.Format("2006-01-02"), counted.Format("2006-01-02"), granularity).
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			counts[row.Bucket] = AttendanceTrendBucket{ScheduledDays: row.ScheduledDays, AttendedDays: row.AttendedDays}
+		}
+	}
+
+	buckets := make([]AttendanceTrendBucket, 0, len(starts))
+	for _, start := range starts {
+		bucket := counts[start.Format("2006-01-02")]
+		bucket.From = start.Format("2006-01-02")
+		if start.Before(from) {
+			bucket.From = from.Format("2006-01-02")
+		}
+		end := step(start).AddDate(0, 0, -1)
+		if end.After(to) {
+			end = to
+		}
+		bucket.To = end.Format("2006-01-02")
+		if bucket.ScheduledDays > 0 {
+			rate := math.Round(float64(bucket.AttendedDays)/float64(bucket.ScheduledDays)*10000) / 100
+			bucket.Rate = &rate
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, nil
+}
+
+// GetDurationHistogram counts completed records at a location per work-duration bucket of
+// bucketMinutes (Admin). Open and absent records are excluded; empty dates leave that side
+// of the range open. Buckets between the shortest and longest shift are always returned,
+// even when empty.
+func (s *AttendanceService) GetDurationHistogram(locationID uint, dateFrom, dateTo string, bucketMinutes int) ([]DurationBucket, error) {
+	if bucketMinutes < 5 || bucketMinutes > 720 {
+		return nil, &FieldError{Field: "bucket_minutes", Message: "must be between 5 and 720"}
+	}
+	if _, err := s.locationService.GetLocationByID(locationID); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&model.Attendance{}).
+		Select("FLOOR(EXTRACT(EPOCH FROM (check_out_time - check_in_time)) / 60 / ?)::int AS bucket, COUNT(*) AS count", bucketMinutes).
+		Where("location_id = ? AND check_out_time IS NOT NULL AND status <> ?", locationID, "absent")
+	if dateFrom != "" {
+		query = query.Where("DATE(check_in_time) >= ?", dateFrom)
+	}
+	if dateTo != "" {
+		query = query.Where("DATE(check_in_time) <= ?", dateTo)
+	}
+
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+	if err := query.Group("bucket").Order("bucket").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	buckets := []DurationBucket{}
+	if len(rows) == 0 {
+		return buckets, nil
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+	for b := rows[0].Bucket; b <= rows[len(rows)-1].Bucket; b++ {
+		buckets = append(buckets, DurationBucket{
+			FromMinutes: b * bucketMinutes,
+			ToMinutes:   (b + 1) * bucketMinutes,
+			Count:       counts[b],
+		})
+	}
+
+	return buckets, nil
+}
+
+// GetAnomalies gets records across all users with at least one anomaly: checked in
+// outside the radius, with a suspicious device clock, or on a day the user had no
+// scheduled work (Admin). Reviewed records are left out unless include_reviewed is set.
+// The flag filter limits the result to one anomaly.
+func (s *AttendanceService) GetAnomalies(filters map[string]interface{}, limit, offset int) ([]AttendanceAnomaly, int64, error) {
+	var attendances []model.Attendance
+	var total int64
+
+	query := s.db.Model(&model.Attendance{}).Where("attendances.status <> ?", "absent")
+
+	// Apply filters
+	switch flag, _ := filters["flag"].(string); flag {
+	case "outside_radius":
+		query = query.Where("attendances.outside_radius = ?", true)
+	case "clock_skew":
+		query = query.Where("attendances.clock_skew_suspicious = ?", true)
+	case "unscheduled":
+		query = query.Where(unscheduledCondition)
+	default:
+		query = query.Where(anomalyCondition)
+	}
+	if includeReviewed, _ := filters["include_reviewed"].(bool); !includeReviewed {
+		query = query.Where("attendances.reviewed = ?", false)
+	}
+	if userID, ok := filters["user_id"].(uint); ok && userID > 0 {
+		query = query.Where("attendances.user_id = ?", userID)
+	}
+	if dateFrom, ok := filters["date_from"].(string); ok && dateFrom != "" {
+		query = query.Where("DATE(attendances.check_in_time) >= ?", dateFrom)
+	}
+	if dateTo, ok := filters["date_to"].(string); ok && dateTo != "" {
+		query = query.Where("DATE(attendances.check_in_time) <= ?", dateTo)
+	}
+
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated records
+	err := query.Preload("User").Preload("Location").
+		Order("attendances.check_in_time DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&attendances).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Work out which records on this page are unscheduled
+	ids := make([]uint, len(attendances))
+	for i, att := range attendances {
+		ids[i] = att.ID
+	}
+	unscheduled, err := s.unscheduledIDs(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	anomalies := make([]AttendanceAnomaly, len(attendances))
+	for i, att := range attendances {
+		anomalies[i] = AttendanceAnomaly{Attendance: att, Reasons: anomalyReasons(&att, unscheduled[att.ID])}
+	}
+
+	return anomalies, total, nil
+}
+
+// unscheduledIDs reports which of the given records were checked in on a day that is
+// not a work day of any schedule assigned to the user on that day
+func (s *AttendanceService) unscheduledIDs(ids []uint) (map[uint]bool, error) {
+	unscheduled := make(map[uint]bool)
+	if len(ids) == 0 {
+		return unscheduled, nil
+	}
+
+	var unscheduledIDs []uint
+	if err := s.db.Model(&model.Attendance{}).
+		Where("attendances.id IN ?", ids).
+		Where(unscheduledCondition).
+		Pluck("attendances.id", &unscheduledIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range unscheduledIDs {
+		unscheduled[id] = true
+	}
+	return unscheduled, nil
+}
+
+// anomalyReasons lists the AnomalyFlags that apply to a record. A record is reviewable,
+// and listed by GetAnomalies, exactly when the list is not empty.
+func anomalyReasons(att *model.Attendance, unscheduled bool) []string {
+	reasons := []string{}
+	if att.OutsideRadius {
+		reasons = append(reasons, "outside_radius")
+	}
+	if att.ClockSkewSuspicious {
+		reasons = append(reasons, "clock_skew")
+	}
+	if unscheduled {
+		reasons = append(reasons, "unscheduled")
+	}
+	return reasons
+}
+
+// GetOpenAttendances gets records from previous days that were never checked out (Admin)
+func (s *AttendanceService) GetOpenAttendances(filters map[string]interface{}, limit, offset int) ([]model.Attendance, int64, error) {
+	var attendances []model.Attendance
+	var total int64
+
+	today := s.clock.Now().Format("2006-01-02")
+	query := s.db.Model(&model.Attendance{}).
+		Where("check_out_time IS NULL AND DATE(check_in_time) < ? AND status <> ?", today, "absent")
+
+	// Apply filters
+	if locationID, ok := filters["location_id"].(uint); ok && locationID > 0 {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if dateFrom, ok := filters["date_from"].(string); ok && dateFrom != "" {
+		query = query.Where("DATE(check_in_time) >= ?", dateFrom)
+	}
+	if dateTo, ok := filters["date_to"].(string); ok && dateTo != "" {
+		query = query.Where("DATE(check_in_time) <= ?", dateTo)
+	}
+
+	// Count total
+	query.Count(&total)
+
+	// Get paginated records
+	err := query.Preload("User").Preload("Location").
+		Order("check_in_time DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&attendances).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return attendances, total, nil
+}
+
+// GetRecentCheckInsWithDistance returns the user's last n check-ins, newest first,
+// with distances recomputed from the coordinates. Each is flagged when farther from
+// its location than threshold meters, or than the location's radius when threshold is 0.
+func (s *AttendanceService) GetRecentCheckInsWithDistance(userID uint, n int, threshold float64) ([]RecentCheckIn, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	var attendances []model.Attendance
+	if err := s.db.Preload("Location").
+		Where("user_id = ? AND status <> ?", userID, "absent").
+		Order("check_in_time DESC").
+		Limit(n).
+		Find(&attendances).Error; err != nil {
+		return nil, err
+	}
+
+	checkIns := make([]RecentCheckIn, len(attendances))
+	for i, att := range attendances {
+		limit := threshold
+		if limit <= 0 {
+			limit = float64(att.Location.Radius)
+		}
+		distance := utils.CalculateDistance(att.CheckInLatitude, att.CheckInLongitude, att.Location.Latitude, att.Location.Longitude)
+
+		checkIns[i] = RecentCheckIn{
+			AttendanceID:    att.ID,
+			CheckInTime:     att.CheckInTime,
+			LocationID:      att.LocationID,
+			LocationName:    att.Location.Name,
+			Latitude:        att.CheckInLatitude,
+			Longitude:       att.CheckInLongitude,
+			Distance:        distance,
+			Threshold:       limit,
+			BeyondThreshold: distance > limit,
+		}
+		if i+1 < len(attendances) {
+			previous := attendances[i+1]
+			fromPrevious := utils.CalculateDistance(att.CheckInLatitude, att.CheckInLongitude, previous.CheckInLatitude, previous.CheckInLongitude)
+			checkIns[i].DistanceFromPrevious = &fromPrevious
+		}
+	}
+
+	return checkIns, nil
+}
+
+// GetCurrentlyCheckedIn lists everyone with an open attendance today, earliest
+// check-in first, optionally narrowed by location_id and department_id. The list
+// is not paginated since it is used for roll-calls that need every name.
+func (s *AttendanceService) GetCurrentlyCheckedIn(filters map[string]interface{}) ([]PresentUser, error) {
+	present := []PresentUser{}
+
+	today := s.clock.Now().Format("2006-01-02")
+	query := s.db.Table("attendances a").
+		Select("a.id AS attendance_id, a.user_id, u.full_name, u.phone, u.department_id, a.location_id, l.name AS location_name, a.check_in_time").
+		Joins("JOIN users u ON u.id = a.user_id").
+		Joins("JOIN attendance_locations l ON l.id = a.location_id").
+		Where("a.deleted_at IS NULL AND a.check_out_time IS NULL AND DATE(a.check_in_time) = ? AND a.status <> ?", today, "absent")
+
+	if locationID, ok := filters["location_id"].(uint); ok && locationID > 0 {
+		query = query.Where("a.location_id = ?", locationID)
+	}
+	if departmentID, ok := filters["department_id"].(uint); ok && departmentID > 0 {
+		query = query.Where("u.department_id = ?", departmentID)
+	}
+
+	if err := query.Order("a.check_in_time ASC").Scan(&present).Error; err != nil {
+		return nil, err
+	}
+
+	return present, nil
+}
+
+// determineAttendanceStatus determines status based on check-in time and the user's schedule.
+// Check-ins up to CheckInEnd are present, after the midpoint between CheckInEnd and
+// CheckOutStart are half day, and late in between. Schedules with EarlyStatusMinutes
+// mark check-ins more than that many minutes before CheckInStart as early. Without a
+// schedule the default office hours rule applies.
+func (s *AttendanceService) determineAttendanceStatus(checkInTime time.Time, schedule *model.WorkSchedule) string {
+	lastOnTime, halfDayFrom, scheduled := statusCutoffs(schedule)
+	minute := minutesOfDay(checkInTime)
+
+	if scheduled && schedule.EarlyStatusMinutes > 0 {
+		if checkInStart, err := parseTimeOfDay(schedule.CheckInStart); err == nil &&
+			minute < minutesOfDay(checkInStart)-schedule.EarlyStatusMinutes {
+			return "early"
+		}
+	}
+
+	if minute <= lastOnTime {
+		return "present"
+	} else if minute < halfDayFrom {
+		return "late"
+	}
+	return "half_day"
+}
+
+// statusCutoffs returns the last minute of the day a check-in is on time under schedule
+// and the minute from which it is half day, and whether they come from the schedule.
+// Without a schedule, or with times that do not parse, the default office hours apply:
+// on time through 09:59 and half day from noon.
+func statusCutoffs(schedule *model.WorkSchedule) (lastOnTime, halfDayFrom int, scheduled bool) {
+	if schedule != nil {
+		checkInEnd, errEnd := parseTimeOfDay(schedule.CheckInEnd)
+		checkOutStart, errOut := parseTimeOfDay(schedule.CheckOutStart)
+		if errEnd == nil && errOut == nil {
+			lastOnTime = minutesOfDay(checkInEnd)
+			return lastOnTime, (lastOnTime + minutesOfDay(checkOutStart)) / 2, true
+		}
+	}
+	return 9*60 + 59, 12 * 60, false
+}
+
+// minutesOfDay returns the number of minutes since midnight
+func minutesOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+// isEarlyLeave reports whether checking out at checkOutTime is before the schedule's
+// check-out start on the day of checkInTime
+func isEarlyLeave(checkOutTime, checkInTime time.Time, schedule *model.WorkSchedule) bool {
+	checkOutStart, err := parseTimeOfDay(schedule.CheckOutStart)
+	if err != nil {
+		return false
+	}
+	scheduledEnd := time.Date(checkInTime.Year(), checkInTime.Month(), checkInTime.Day(),
+		checkOutStart.Hour(), checkOutStart.Minute(), checkOutStart.Second(), 0, checkInTime.Location())
+	return checkOutTime.Before(scheduledEnd)
+}
+
+// RecalculateStatuses re-derives the status of records checked in under the given schedule
+// between from and to (inclusive) using the schedule's current times. Returns the number
+// of records whose status changed.
+func (s *AttendanceService) RecalculateStatuses(scheduleID uint, from, to time.Time, actorID uint) (int, error) {
+	var schedule model.WorkSchedule
+	if err := s.db.First(&schedule, scheduleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("schedule not found")
+		}
+		return 0, err
+	}
+
+	changed := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var attendances []model.Attendance
+		if err := tx.Model(&model.Attendance{}).
+			Joins("JOIN user_schedules us ON us.user_id = attendances.user_id AND us.schedule_id = ?"+
+				" AND DATE(attendances.check_in_time) >= us.effective_from"+
+				" AND (us.effective_to IS NULL OR DATE(attendances.check_in_time) <= us.effective_to)", scheduleID).
+			Where("DATE(attendances.check_in_time) BETWEEN ? AND ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+			Where("attendances.status IN ?", []string{"present", "early", "late", "half_day"}).
+			Preload("Location").
+			Find(&attendances).Error; err != nil {
+			return err
+		}
+
+		for _, att := range attendances {
+			// Read on the wall clock of the location checked in at, as at check-in
+			status := s.determineAttendanceStatus(att.CheckInTime.In(att.Location.TimeLocation()), &schedule)
+			if status == att.Status {
+				continue
+			}
+			if err := tx.Model(&model.Attendance{}).Where("id = ?", att.ID).Update("status", status).Error; err != nil {
+				return err
+			}
+			changed++
+		}
+
+		return s.auditService.WithTx(tx).Log(actorID, "attendance.recalculate_statuses", "schedule", scheduleID, map[string]interface{}{
+			"date_from": from.Format("2006-01-02"),
+			"date_to":   to.Format("2006-01-02"),
+			"checked":   len(attendances),
+			"changed":   changed,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
+
+// BulkUpdateStatus sets the status of the records selected by req in one transaction,
+// writing an audit entry for every record changed. Records already in the target status
+// are left alone. Returns the number of records changed.
+func (s *AttendanceService) BulkUpdateStatus(req *BulkStatusRequest, actorID uint) (int, error) {
+	if !model.IsValidAttendanceStatus(req.Status) {
+		return 0, &FieldError{Field: "status", Message: "must be one of " + strings.Join(model.AttendanceStatuses, ", ")}
+	}
+	if (len(req.IDs) > 0) == (req.Filter != nil) {
+		return 0, &FieldError{Field: "ids", Message: "provide either ids or filter"}
+	}
+
+	var conditions []func(*gorm.DB) *gorm.DB
+	where := func(query string, args ...interface{}) {
+		conditions = append(conditions, func(db *gorm.DB) *gorm.DB { return db.Where(query, args...) })
+	}
+	if len(req.IDs) > 0 {
+		where("id IN ?", req.IDs)
+	} else {
+		filter := req.Filter
+		if filter.UserID == 0 && filter.LocationID == 0 && filter.Status == "" && filter.DateFrom == "" && filter.DateTo == "" {
+			return 0, &FieldError{Field: "filter", Message: "must set at least one criterion"}
+		}
+		if filter.Status != "" && !model.IsValidAttendanceStatus(filter.Status) {
+			return 0, &FieldError{Field: "filter.status", Message: "must be one of " + strings.Join(model.AttendanceStatuses, ", ")}
+		}
+		for _, date := range []string{filter.DateFrom, filter.DateTo} {
+			if date == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				return 0, &FieldError{Field: "filter", Message: "dates must be in YYYY-MM-DD format"}
+			}
+		}
+
+		if filter.UserID > 0 {
+			where("user_id = ?", filter.UserID)
+		}
+		if filter.LocationID > 0 {
+			where("location_id = ?", filter.LocationID)
+		}
+		if filter.Status != "" {
+			where("status = ?", filter.Status)
+		}
+		if filter.DateFrom != "" {
+			where("DATE(check_in_time) >= ?", filter.DateFrom)
+		}
+		if filter.DateTo != "" {
+			where("DATE(check_in_time) <= ?", filter.DateTo)
+		}
+	}
+
+	changed := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var attendances []model.Attendance
+		if err := tx.Model(&model.Attendance{}).Scopes(conditions...).Order("id ASC").
+			Limit(maxBulkStatusRecords + 1).Find(&attendances).Error; err != nil {
+			return err
+		}
+		if len(attendances) > maxBulkStatusRecords {
+			return &FieldError{Field: "filter", Message: fmt.Sprintf("matches more than %d records, narrow it down", maxBulkStatusRecords)}
+		}
+
+		// Every listed ID must exist, so a typo does not silently shrink the change
+		if len(req.IDs) > 0 {
+			found := make(map[uint]bool, len(attendances))
+			for _, att := range attendances {
+				found[att.ID] = true
+			}
+			var missing []string
+			for _, id := range req.IDs {
+				if !found[id] {
+					missing = append(missing, fmt.Sprint(id))
+				}
+			}
+			if len(missing) > 0 {
+				return &FieldError{Field: "ids", Message: "attendance not found: " + strings.Join(missing, ", ")}
+			}
+		}
+
+		audit := s.auditService.WithTx(tx)
+		for _, att := range attendances {
+			if att.Status == req.Status {
+				continue
+			}
+			if err := tx.Model(&model.Attendance{}).Where("id = ?", att.ID).Update("status", req.Status).Error; err != nil {
+				return err
+			}
+			if err := audit.Log(actorID, "attendance.bulk_status", "attendance", att.ID, map[string]interface{}{
+				"from": att.Status,
+				"to":   req.Status,
+			}); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
+
+// CloseAllAtLocation checks out every open attendance checked in today at the location,
+// in the location's timezone, and flags them as auto checkout. Records checked in after
+// checkOutTime are closed at their check-in time. Returns the number of records closed.
+func (s *AttendanceService) CloseAllAtLocation(locationID uint, checkOutTime *time.Time, actorID uint) (int64, error) {
+	location, err := s.locationService.GetLocationByID(locationID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.clock.Now()
+	at := now
+	if checkOutTime != nil {
+		if checkOutTime.After(now) {
+			return 0, &FieldError{Field: "check_out_time", Message: "must not be in the future"}
+		}
+		at = *checkOutTime
+	}
+
+	localNow := now.In(location.TimeLocation())
+	dayStart := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), 0, 0, 0, 0, localNow.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	var closed int64
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Attendance{}).
+			Where("location_id = ? AND check_out_time IS NULL AND status <> ?", locationID, "absent").
+			Where("check_in_time >= ? AND check_in_time < ?", dayStart, dayEnd).
+			Updates(map[string]interface{}{
+				"check_out_time": gorm.Expr("GREATEST(check_in_time, ?)", at),
+				"auto_checkout":  true,
+				"updated_at":     now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		closed = result.RowsAffected
+
+		return s.auditService.WithTx(tx).Log(actorID, "attendance.close_all", "location", locationID, map[string]interface{}{
+			"check_out_time": at,
+			"closed":         closed,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return closed, nil
 }
@@ -0,0 +1,2946 @@
+package service
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/model"
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestAnomalyReasons(t *testing.T) {
+	tests := []struct {
+		name        string
+		attendance  model.Attendance
+		unscheduled bool
+		want        []string
+	}{
+		{"clean", model.Attendance{}, false, []string{}},
+		{"outside radius", model.Attendance{OutsideRadius: true}, false, []string{"outside_radius"}},
+		{"clock skew", model.Attendance{ClockSkewSuspicious: true}, false, []string{"clock_skew"}},
+		{"unscheduled only", model.Attendance{}, true, []string{"unscheduled"}},
+		{"every flag", model.Attendance{OutsideRadius: true, ClockSkewSuspicious: true}, true, []string{"outside_radius", "clock_skew", "unscheduled"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := anomalyReasons(&tt.attendance, tt.unscheduled); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("anomalyReasons() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// expectAttendanceByID expects GetAttendanceByID to load a clean record of user 7 at location 3
+func expectAttendanceByID(mock sqlmock.Sqlmock, id uint, checkIn time.Time) {
+	mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE "attendances"."id" = \$1 AND "attendances"."deleted_at" IS NULL`).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id", "check_in_time", "status"}).
+			AddRow(id, 7, 3, checkIn, "present"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_attachments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "attendance_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(7, "Dewi"))
+}
+
+func TestReviewAttendance(t *testing.T) {
+	checkIn := time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		unscheduled bool
+		wantErr     string
+	}{
+		{"unscheduled record can be cleared", true, ""},
+		{"clean record is rejected", false, "attendance is not flagged for review"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			svc := newTestAttendanceService(db, nil, checkIn.Add(48*time.Hour))
+
+			expectAttendanceByID(mock, 11, checkIn)
+			unscheduled := sqlmock.NewRows([]string{"id"})
+			if tt.unscheduled {
+				unscheduled.AddRow(11)
+			}
+			mock.ExpectQuery(`SELECT "attendances"."id" FROM "attendances" WHERE attendances.id IN \(\$1\) AND \(NOT EXISTS`).
+				WithArgs(11).
+				WillReturnRows(unscheduled)
+			if tt.wantErr == "" {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "attendances" SET "reviewed"=\$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			attendance, err := svc.ReviewAttendance(11, 1, &ReviewAttendanceRequest{Resolution: "cleared"})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ReviewAttendance() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReviewAttendance() error = %v", err)
+			}
+			if !attendance.Reviewed || attendance.ReviewResolution != "cleared" {
+				t.Errorf("ReviewAttendance() = reviewed %v resolution %q, want cleared", attendance.Reviewed, attendance.ReviewResolution)
+			}
+		})
+	}
+}
+func TestReviewAttendanceOutsideRadius(t *testing.T) {
+	checkIn := time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		found   bool
+		wantErr string
+	}{
+		{"flagged record is confirmed", true, ""},
+		{"unknown record", false, "attendance not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			svc := newTestAttendanceService(db, nil, checkIn.Add(48*time.Hour))
+
+			rows := sqlmock.NewRows([]string{"id", "user_id", "location_id", "check_in_time", "status", "outside_radius", "review_resolution"})
+			if tt.found {
+				rows.AddRow(12, 7, 3, checkIn, "present", true, "cleared")
+				mock.ExpectQuery(`SELECT \* FROM "attendance_attachments"`).WillReturnRows(sqlmock.NewRows([]string{"id", "attendance_id"}))
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectQuery(`SELECT "attendances"."id" FROM "attendances" WHERE attendances.id IN`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "attendances" SET "reviewed"=\$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+				// The audit entry keeps the resolution that was overridden
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+					WithArgs(sqlmock.AnyArg(), "attendance.review", "attendance", 12, jsonContaining(`"previous_resolution":"cleared"`), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE "attendances"."id" = \$1`).
+				WithArgs(12, 1).
+				WillReturnRows(rows)
+
+			attendance, err := svc.ReviewAttendance(12, 1, &ReviewAttendanceRequest{Resolution: "confirmed", Note: "GPS drift"})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ReviewAttendance() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReviewAttendance() error = %v", err)
+			}
+			if attendance.ReviewResolution != "confirmed" || attendance.ReviewNote != "GPS drift" || attendance.ReviewedBy == nil || *attendance.ReviewedBy != 1 {
+				t.Errorf("ReviewAttendance() = %+v, want confirmed by 1 with the note", attendance)
+			}
+		})
+	}
+}
+
+// jsonContaining matches a JSON argument containing fragment
+type jsonContaining string
+
+func (j jsonContaining) Match(v driver.Value) bool {
+	var raw string
+	switch value := v.(type) {
+	case []byte:
+		raw = string(value)
+	case string:
+		raw = value
+	default:
+		return false
+	}
+	return strings.Contains(raw, string(j))
+}
+
+// memStorage is a Storage keeping saved files in memory and track of the files deleted from it
+type memStorage struct {
+	files   map[string]string
+	deleted []string
+}
+
+func (s *memStorage) Save(key string, r io.Reader) (string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	url := "/uploads/" + key
+	if s.files == nil {
+		s.files = make(map[string]string)
+	}
+	s.files[url] = string(content)
+	return url, nil
+}
+
+func (s *memStorage) Open(url string) (io.ReadCloser, error) {
+	content, ok := s.files[url]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (s *memStorage) Delete(url string) error {
+	s.deleted = append(s.deleted, url)
+	return nil
+}
+
+func TestDeleteAttendance(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC))
+
+	expectAttendanceByID(mock, 5, time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "attendances" SET "deleted_at"=\$1 WHERE "attendances"."id" = \$2 AND "attendances"."deleted_at" IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "audit_logs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	if err := svc.DeleteAttendance(5, 1); err != nil {
+		t.Fatalf("DeleteAttendance() error = %v", err)
+	}
+}
+
+func TestGetTrashedAttendances(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC))
+	deletedAt := time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE attendances.deleted_at IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE attendances.deleted_at IS NOT NULL ORDER BY attendances.deleted_at DESC LIMIT \$1 OFFSET \$2`).
+		WithArgs(2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id", "deleted_at"}).
+			AddRow(9, 7, 3, deletedAt))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(7, "Dewi"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+
+	attendances, total, err := svc.GetTrashedAttendances(2, 2)
+	if err != nil {
+		t.Fatalf("GetTrashedAttendances() error = %v", err)
+	}
+	if total != 3 || len(attendances) != 1 {
+		t.Fatalf("GetTrashedAttendances() = %d records of %d, want 1 of 3", len(attendances), total)
+	}
+	if !attendances[0].DeletedAt.Valid || attendances[0].User.FullName != "Dewi" {
+		t.Errorf("GetTrashedAttendances() record = %+v, want deleted record of Dewi", attendances[0])
+	}
+}
+
+func TestRestoreAttendance(t *testing.T) {
+	checkIn := time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		multiSession bool
+		inTrash      bool
+		existing     int
+		wantErr      string
+	}{
+		{"restored", false, true, 0, ""},
+		{"day already has a record", false, true, 1, "user already has attendance on that day"},
+		{"not in trash", false, false, 0, "attendance not found in trash"},
+		{"multi-session allows another record that day", true, true, 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			cfg := &config.Config{Attendance: config.AttendanceConfig{MultiSession: tt.multiSession}}
+			svc := newTestAttendanceService(db, cfg, checkIn.Add(72*time.Hour))
+
+			trashed := sqlmock.NewRows([]string{"id", "user_id", "location_id", "check_in_time", "deleted_at"})
+			if tt.inTrash {
+				trashed.AddRow(5, 7, 3, checkIn, checkIn.Add(24*time.Hour))
+			}
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE deleted_at IS NOT NULL AND "attendances"."id" = \$1 ORDER BY "attendances"."id" LIMIT \$2 FOR UPDATE`).
+				WithArgs(5, 1).
+				WillReturnRows(trashed)
+			if tt.inTrash && !tt.multiSession {
+				mock.ExpectExec(`SELECT id FROM users WHERE id = \$1 FOR UPDATE`).WithArgs(7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) = \$2\) AND "attendances"."deleted_at" IS NULL`).
+					WithArgs(7, "2026-03-07").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.existing))
+			}
+			if tt.wantErr != "" {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec(`UPDATE "attendances" SET "deleted_at"=\$1,"updated_at"=\$2 WHERE "id" = \$3`).
+					WithArgs(nil, sqlmock.AnyArg(), 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+				expectAttendanceByID(mock, 5, checkIn)
+			}
+
+			attendance, err := svc.RestoreAttendance(5, 1)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("RestoreAttendance() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RestoreAttendance() error = %v", err)
+			}
+			if attendance.ID != 5 || attendance.DeletedAt.Valid {
+				t.Errorf("RestoreAttendance() = %+v, want live record 5", attendance)
+			}
+		})
+	}
+}
+
+func TestPurgeTrashedAttendances(t *testing.T) {
+	before := time.Date(2026, 2, 7, 0, 0, 0, 0, time.UTC)
+
+	t.Run("removes records, attachment rows and files", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		store := &memStorage{}
+		svc := newTestAttendanceService(db, nil, before)
+		svc.storage = store
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT "id" FROM "attendances" WHERE deleted_at IS NOT NULL AND deleted_at < \$1 FOR UPDATE`).
+			WithArgs(before).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(6))
+		mock.ExpectQuery(`SELECT \* FROM "attendance_attachments" WHERE attendance_id IN \(\$1,\$2\)`).
+			WithArgs(4, 6).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "attendance_id", "url"}).
+				AddRow(1, 4, "/uploads/attendances/4/a.jpg").
+				AddRow(2, 6, "/uploads/attendances/6/b.pdf"))
+		mock.ExpectExec(`DELETE FROM "attendance_attachments" WHERE attendance_id IN \(\$1,\$2\)`).
+			WithArgs(4, 6).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`DELETE FROM "attendances" WHERE id IN \(\$1,\$2\)`).
+			WithArgs(4, 6).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		purged, err := svc.PurgeTrashedAttendances(before)
+		if err != nil {
+			t.Fatalf("PurgeTrashedAttendances() error = %v", err)
+		}
+		if purged != 2 {
+			t.Errorf("PurgeTrashedAttendances() = %d, want 2", purged)
+		}
+		want := []string{"/uploads/attendances/4/a.jpg", "/uploads/attendances/6/b.pdf"}
+		if !reflect.DeepEqual(store.deleted, want) {
+			t.Errorf("deleted files = %v, want %v", store.deleted, want)
+		}
+	})
+
+	t.Run("empty trash", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		store := &memStorage{}
+		svc := newTestAttendanceService(db, nil, before)
+		svc.storage = store
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT "id" FROM "attendances" WHERE deleted_at IS NOT NULL AND deleted_at < \$1 FOR UPDATE`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		purged, err := svc.PurgeTrashedAttendances(before)
+		if err != nil || purged != 0 || len(store.deleted) != 0 {
+			t.Errorf("PurgeTrashedAttendances() = %d, %v, deleted %v; want nothing purged", purged, err, store.deleted)
+		}
+	})
+}
+
+func TestCheckCanCheckIn(t *testing.T) {
+	now := time.Date(2026, 3, 9, 13, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		multiSession bool
+		limit        int
+		count        int
+		wantLimit    int // 0 when the check-in is allowed
+		wantErr      string
+	}{
+		{"single session, first check-in", false, 1, 0, 0, ""},
+		{"single session, second check-in", false, 1, 1, 0, "already checked in today"},
+		{"below the default limit", true, 1, 0, 0, ""},
+		{"at the default limit", true, 1, 1, 1, "open session limit reached: at most 1 open session(s) allowed, check out first"},
+		{"below a raised limit", true, 3, 2, 0, ""},
+		{"at a raised limit", true, 3, 3, 3, "open session limit reached: at most 3 open session(s) allowed, check out first"},
+		{"beyond a lowered limit", true, 2, 4, 2, "open session limit reached: at most 2 open session(s) allowed, check out first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{Attendance: config.AttendanceConfig{MultiSession: tt.multiSession, MaxOpenSessions: tt.limit}}
+			svc := newTestAttendanceService(db, cfg, now)
+
+			if tt.multiSession {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) = \$2 AND check_out_time IS NULL AND status <> \$3\)`).
+					WithArgs(7, "2026-03-09", "absent").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+			} else {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) = \$2\)`).
+					WithArgs(7, "2026-03-09").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+			}
+
+			err := svc.checkCanCheckIn(db, 7)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkCanCheckIn() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("checkCanCheckIn() error = %v, want %q", err, tt.wantErr)
+			}
+			var limitErr *OpenSessionLimitError
+			if got := errors.As(err, &limitErr); got != (tt.wantLimit > 0) || (got && limitErr.Limit != tt.wantLimit) {
+				t.Errorf("checkCanCheckIn() error = %#v, want limit %d", err, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestCheckInRejectedAtOpenSessionLimit(t *testing.T) {
+	db, mock := newMockDB(t)
+	cfg := &config.Config{Attendance: config.AttendanceConfig{MultiSession: true, MaxOpenSessions: 2}}
+	svc := newTestAttendanceService(db, cfg, time.Date(2026, 3, 9, 13, 0, 0, 0, time.UTC))
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	_, err := svc.CheckIn(7, &CheckInRequest{LocationID: 3})
+	var limitErr *OpenSessionLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != 2 {
+		t.Fatalf("CheckIn() error = %v, want open session limit of 2", err)
+	}
+}
+
+func TestBuildCalendarWithTimeOff(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.Local) }
+	otherLocation := uint(9)
+
+	// Monday to Friday at location 3; the week of 2 March 2026 starts on a Monday
+	userSchedules := []model.UserSchedule{{
+		LocationID:    3,
+		EffectiveFrom: day(1).AddDate(0, -1, 0),
+		Schedule:      model.WorkSchedule{WorkDays: []int64{1, 2, 3, 4, 5}},
+	}}
+	attendances := []model.Attendance{{CheckInTime: day(2).Add(9*time.Hour + 30*time.Minute), Status: "late"}}
+	off := &timeOff{
+		holidays: []model.Holiday{
+			{Date: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), Name: "Nyepi"},
+			{Date: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), Name: "Site closure", LocationID: &otherLocation},
+		},
+		leaves: []model.Leave{
+			{Type: "annual", StartDate: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	now := day(6).Add(10 * time.Hour)
+
+	calendar := buildCalendar(attendances, userSchedules, off, day(2), day(10), now)
+
+	want := map[string]string{
+		"2026-03-02": "late",
+		"2026-03-03": "absent",  // the holiday of another location does not apply
+		"2026-03-04": "holiday", // holidays without a location apply everywhere
+		"2026-03-05": "leave",
+		"2026-03-06": "leave", // today, excused ahead of time
+		"2026-03-07": "off",   // leave does not turn a weekend into a work day
+		"2026-03-08": "off",
+		// 9 March is a future work day without a record and is left out
+	}
+	if !reflect.DeepEqual(calendar, want) {
+		t.Errorf("buildCalendar() = %v, want %v", calendar, want)
+	}
+
+	totals := summarizePeriod(attendances, userSchedules, day(2), day(10), calendar, model.PayrollRounding{})
+	if totals.ScheduledDays != 3 || totals.Late != 1 || totals.Absent != 1 || totals.Holiday != 1 || totals.Leave != 2 {
+		t.Errorf("summarizePeriod() = %+v, want 3 scheduled days, 1 late, 1 absent, 1 holiday and 2 leave", totals)
+	}
+}
+
+func TestDayStatuses(t *testing.T) {
+	at := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, time.Local) }
+
+	// Records come back from the database in no particular order
+	attendances := []model.Attendance{
+		{CheckInTime: at(2, 13), Status: "present"},
+		{CheckInTime: at(2, 9), Status: "late"},
+		{CheckInTime: at(3, 8), Status: "present"},
+		{CheckInTime: at(3, 13), Status: "late"},
+		{CheckInTime: at(4, 0), Status: "absent"},
+		{CheckInTime: at(4, 14), Status: "late"},
+		{CheckInTime: at(5, 0), Status: "absent"},
+		{CheckInTime: at(6, 8), Status: "remote"},
+	}
+
+	want := map[string]string{
+		"2026-03-02": "late",    // the first session decides, a later one does not make up for it
+		"2026-03-03": "present", // nor does a later late session count against it
+		"2026-03-04": "late",    // a session outweighs an absence marked the same day
+		"2026-03-05": "absent",
+		"2026-03-06": "remote",
+	}
+	if got := dayStatuses(attendances); !reflect.DeepEqual(got, want) {
+		t.Errorf("dayStatuses() = %v, want %v", got, want)
+	}
+}
+
+func TestSummarizePeriodMultiSession(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2026, 3, 2, h, 0, 0, 0, time.Local) }
+	out := func(h int) *time.Time { checkOut := at(h); return &checkOut }
+
+	// Two five-hour sessions on a day without a schedule, which counts as an eight-hour shift
+	attendances := []model.Attendance{
+		{CheckInTime: at(7), CheckOutTime: out(12), Status: "present"},
+		{CheckInTime: at(13), CheckOutTime: out(18), Status: "present"},
+	}
+	start := at(0)
+	end := start.AddDate(0, 0, 1)
+	calendar := buildCalendar(attendances, nil, nil, start, end, end)
+
+	totals := summarizePeriod(attendances, nil, start, end, calendar, model.PayrollRounding{})
+	if totals.Present != 1 || totals.TotalHours != 10 || totals.OvertimeHours != 2 {
+		t.Errorf("summarizePeriod() = %+v, want 1 present day, 10 hours and 2 hours overtime", totals)
+	}
+}
+
+func TestTimeOffOn(t *testing.T) {
+	location := uint(3)
+	day := time.Date(2026, 12, 25, 0, 0, 0, 0, time.Local)
+	holidayDate := time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)
+	leave := model.Leave{StartDate: time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 12, 26, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name       string
+		off        *timeOff
+		locationID uint
+		want       string
+	}{
+		{"nothing loaded", nil, 3, ""},
+		{"no time off", &timeOff{}, 3, ""},
+		{"holiday everywhere", &timeOff{holidays: []model.Holiday{{Date: holidayDate}}}, 3, "holiday"},
+		{"holiday everywhere without a location", &timeOff{holidays: []model.Holiday{{Date: holidayDate}}}, 0, "holiday"},
+		{"holiday at the user's location", &timeOff{holidays: []model.Holiday{{Date: holidayDate, LocationID: &location}}}, 3, "holiday"},
+		{"holiday at another location", &timeOff{holidays: []model.Holiday{{Date: holidayDate, LocationID: &location}}}, 4, ""},
+		{"holiday on another day", &timeOff{holidays: []model.Holiday{{Date: holidayDate.AddDate(0, 0, 1)}}}, 3, ""},
+		{"leave", &timeOff{leaves: []model.Leave{leave}}, 3, "leave"},
+		{"holiday during leave", &timeOff{holidays: []model.Holiday{{Date: holidayDate}}, leaves: []model.Leave{leave}}, 3, "holiday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.off.on(day, tt.locationID); got != tt.want {
+				t.Errorf("on() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleOn(t *testing.T) {
+	// Effective dates come back from the date columns as UTC midnight
+	date := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	until := date(10)
+	userSchedules := []model.UserSchedule{
+		{ID: 2, EffectiveFrom: date(11)},
+		{ID: 1, EffectiveFrom: date(2), EffectiveTo: &until},
+	}
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		name string
+		day  time.Time
+		want uint
+	}{
+		{"before the first assignment", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), 0},
+		{"first day, ahead of UTC", time.Date(2026, 3, 2, 0, 0, 0, 0, jakarta), 1},
+		{"last day, behind UTC", time.Date(2026, 3, 10, 0, 0, 0, 0, newYork), 1},
+		{"last day, later in the day", time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), 1},
+		{"next assignment, ahead of UTC", time.Date(2026, 3, 11, 0, 0, 0, 0, jakarta), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got uint
+			if us := scheduleOn(userSchedules, tt.day); us != nil {
+				got = us.ID
+			}
+			if got != tt.want {
+				t.Errorf("scheduleOn(%s) = %d, want %d", tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastEndedDay(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	newYork, _ := time.LoadLocation("America/New_York")
+	now := time.Date(2026, 3, 3, 18, 0, 0, 0, time.UTC) // 4 March 01:00 in Jakarta, 3 March 13:00 in New York
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"ahead of UTC", jakarta, time.Date(2026, 3, 3, 0, 0, 0, 0, jakarta)},
+		{"behind UTC", newYork, time.Date(2026, 3, 2, 0, 0, 0, 0, newYork)},
+		{"UTC", time.UTC, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lastEndedDay(now, tt.loc)
+			if !got.Equal(tt.want) || got.Location() != tt.loc {
+				t.Errorf("lastEndedDay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkAbsences(t *testing.T) {
+	// 4 March 01:00 in Jakarta, so Tuesday 3 March has ended there
+	now := time.Date(2026, 3, 3, 18, 0, 0, 0, time.UTC)
+
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	dayStart := time.Date(2026, 3, 3, 0, 0, 0, 0, jakarta).In(time.Local)
+	dayEnd := time.Date(2026, 3, 4, 0, 0, 0, 0, jakarta).In(time.Local)
+	suspendedAt := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	liftedAt := time.Date(2026, 3, 2, 13, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		holiday        bool
+		leave          bool
+		inactive       bool
+		suspendedAt    *time.Time
+		suspendedUntil *time.Time
+		wantMarked     int
+	}{
+		{"no-show is marked absent", false, false, false, nil, nil, 1},
+		{"user on leave is not marked absent", false, true, false, nil, nil, 0},
+		{"holiday is not marked absent", true, false, false, nil, nil, 0},
+		{"inactive location is not marked absent", false, false, true, nil, nil, 0},
+		{"suspended location is not marked absent", false, false, false, &suspendedAt, nil, 0},
+		{"suspension ended before the day", false, false, false, &suspendedAt, &liftedAt, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			svc := newTestAttendanceService(db, nil, now)
+
+			mock.ExpectQuery(`SELECT "user_schedules"."id".* FROM "user_schedules" JOIN users`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id", "effective_from"}).
+					AddRow(1, 7, 2, 3, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+			mock.ExpectQuery(`SELECT \* FROM "work_schedules"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "work_days"}).AddRow(2, "Office", "{1,2,3,4,5}"))
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "timezone", "is_active", "suspended_at", "suspended_until"}).
+					AddRow(3, "HQ", "Asia/Jakarta", !tt.inactive, tt.suspendedAt, tt.suspendedUntil))
+			// Days the location was closed are skipped before any time off lookup
+			if !tt.inactive && (tt.suspendedAt == nil || tt.suspendedUntil != nil) {
+				holidays := sqlmock.NewRows([]string{"id", "date", "name", "location_id"})
+				if tt.holiday {
+					holidays.AddRow(1, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), "Nyepi", nil)
+				}
+				mock.ExpectQuery(`SELECT \* FROM "holidays" WHERE date >= \$1 AND date < \$2`).
+					WithArgs("2026-03-03", "2026-03-04").
+					WillReturnRows(holidays)
+				leaves := sqlmock.NewRows([]string{"id", "user_id", "type", "start_date", "end_date"})
+				if tt.leave {
+					leaves.AddRow(1, 7, "sick", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC))
+				}
+				mock.ExpectQuery(`SELECT \* FROM "leaves" WHERE user_id = \$1 AND start_date < \$2 AND end_date >= \$3`).
+					WithArgs(7, "2026-03-04", "2026-03-03").
+					WillReturnRows(leaves)
+			}
+
+			if tt.wantMarked > 0 {
+				// The Jakarta day, bounded in server-local time
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE \(user_id = \$1 AND check_in_time >= \$2 AND check_in_time < \$3\)`).
+					WithArgs(7, dayStart, dayEnd).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "attendances"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(30))
+				mock.ExpectCommit()
+			}
+
+			marked, err := svc.MarkAbsences(now)
+			if err != nil {
+				t.Fatalf("MarkAbsences() error = %v", err)
+			}
+			if marked != tt.wantMarked {
+				t.Errorf("MarkAbsences() = %d, want %d", marked, tt.wantMarked)
+			}
+		})
+	}
+}
+
+func TestGetComplianceRateExcludesTimeOff(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local))
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "Budi"))
+	mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(user_id = \$1 AND check_in_time >= \$2 AND check_in_time < \$3\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "check_in_time", "status"}).
+			AddRow(1, 7, time.Date(2026, 3, 2, 8, 0, 0, 0, time.Local), "present").
+			AddRow(2, 7, time.Date(2026, 3, 3, 8, 30, 0, 0, time.Local), "late"))
+	mock.ExpectQuery(`SELECT \* FROM "user_schedules"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id", "effective_from"}).
+			AddRow(1, 7, 2, 3, time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)))
+	mock.ExpectQuery(`SELECT \* FROM "work_schedules"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "work_days"}).AddRow(2, "Office", "{1,2,3,4,5}"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+	mock.ExpectQuery(`SELECT \* FROM "holidays" WHERE date >= \$1 AND date < \$2`).
+		WithArgs("2026-03-02", "2026-03-07").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date", "name", "location_id"}).
+			AddRow(1, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), "Nyepi", nil))
+	mock.ExpectQuery(`SELECT \* FROM "leaves" WHERE user_id = \$1 AND start_date < \$2 AND end_date >= \$3`).
+		WithArgs(7, "2026-03-07", "2026-03-02").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "start_date", "end_date"}).
+			AddRow(1, 7, "annual", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)))
+
+	// Monday 2 March to Friday 6 March: on time, late, a holiday and two days of leave
+	got, err := svc.GetComplianceRate(7, time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local), time.Date(2026, 3, 6, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("GetComplianceRate() error = %v", err)
+	}
+
+	if got.ScheduledDays != 2 || got.OnTime != 1 || got.Late != 1 || got.Absent != 0 {
+		t.Errorf("scheduled/on time/late/absent = %d/%d/%d/%d, want 2/1/1/0", got.ScheduledDays, got.OnTime, got.Late, got.Absent)
+	}
+	if got.HolidayDays != 1 || got.LeaveDays != 2 {
+		t.Errorf("holiday/leave days = %d/%d, want 1/2", got.HolidayDays, got.LeaveDays)
+	}
+	if got.Rate == nil || *got.Rate != 50 {
+		t.Errorf("Rate = %v, want 50", got.Rate)
+	}
+}
+
+func TestGetUserPointsRange(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+	}{
+		{"range reversed", day(5), day(1)},
+		{"range over a year", day(1), day(1).AddDate(1, 0, 2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, day(10))
+
+			// Rejected before the user is looked up
+			_, err := svc.GetUserPoints(7, tt.from, tt.to)
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != "to" {
+				t.Errorf("GetUserPoints() error = %v, want a to field error", err)
+			}
+		})
+	}
+}
+
+func TestGetUserPoints(t *testing.T) {
+	db, mock := newMockDB(t)
+	cfg := &config.Config{Attendance: config.AttendanceConfig{StatusPoints: map[string]int{"late": 1, "half_day": 2, "absent": 3}}}
+	svc := newTestAttendanceService(db, cfg, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))
+
+	at := func(d int) time.Time { return time.Date(2026, 3, d, 8, 0, 0, 0, time.UTC) }
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "Budi"))
+	mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) BETWEEN \$2 AND \$3\)`).
+		WithArgs(7, "2026-03-02", "2026-03-06").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "check_in_time", "status"}).
+			AddRow(1, 7, at(2), "present").
+			AddRow(2, 7, at(3), "late").
+			AddRow(3, 7, at(4), "absent").
+			AddRow(4, 7, at(5), "remote").
+			AddRow(5, 7, at(6), "late"))
+
+	got, err := svc.GetUserPoints(7, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetUserPoints() error = %v", err)
+	}
+
+	if got.Total != 5 {
+		t.Errorf("Total = %d, want 5", got.Total)
+	}
+	if got.ByStatus["late"] != 2 || got.ByStatus["absent"] != 3 || len(got.ByStatus) != 2 {
+		t.Errorf("ByStatus = %v, want late=2 absent=3", got.ByStatus)
+	}
+
+	want := []PointsEntry{
+		{AttendanceID: 2, Date: "2026-03-03", Status: "late", Points: 1, RunningTotal: 1},
+		{AttendanceID: 3, Date: "2026-03-04", Status: "absent", Points: 3, RunningTotal: 4},
+		{AttendanceID: 5, Date: "2026-03-06", Status: "late", Points: 1, RunningTotal: 5},
+	}
+	if len(got.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got.Entries), len(want))
+	}
+	for i := range want {
+		if got.Entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got.Entries[i], want[i])
+		}
+	}
+}
+
+func TestGetOrgAttendanceTrendWeekly(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 12, 10, 0, 0, 0, time.UTC))
+
+	// Counted only up to today, bucketed by the Monday the week starts on
+	mock.ExpectQuery(`WITH scheduled AS`).
+		WithArgs("2026-03-04", "2026-03-12", "week").
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "scheduled_days", "attended_days"}).
+			AddRow("2026-03-02", 6, 5).
+			AddRow("2026-03-09", 4, 2))
+
+	// Wednesday 4 March to Tuesday 17 March
+	got, err := svc.GetOrgAttendanceTrend(time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC), "week")
+	if err != nil {
+		t.Fatalf("GetOrgAttendanceTrend() error = %v", err)
+	}
+
+	rate := func(r float64) *float64 { return &r }
+	want := []AttendanceTrendBucket{
+		{From: "2026-03-04", To: "2026-03-08", ScheduledDays: 6, AttendedDays: 5, Rate: rate(83.33)},
+		{From: "2026-03-09", To: "2026-03-15", ScheduledDays: 4, AttendedDays: 2, Rate: rate(50)},
+		{From: "2026-03-16", To: "2026-03-17"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d buckets, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.From != w.From || g.To != w.To || g.ScheduledDays != w.ScheduledDays || g.AttendedDays != w.AttendedDays {
+			t.Errorf("bucket %d = %+v, want %+v", i, g, w)
+		}
+		if (g.Rate == nil) != (w.Rate == nil) || (g.Rate != nil && *g.Rate != *w.Rate) {
+			t.Errorf("bucket %d rate = %v, want %v", i, g.Rate, w.Rate)
+		}
+	}
+}
+
+func TestGetOrgAttendanceTrendExcludesTimeOff(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 12, 10, 0, 0, 0, time.UTC))
+
+	// Holidays at the assigned location or everywhere, and the user's leave, are not scheduled
+	mock.ExpectQuery(`NOT EXISTS \(SELECT 1 FROM holidays h\s+WHERE h.date = d::date AND \(h.location_id IS NULL OR h.location_id = us.location_id\)\)`+
+		`\s+AND NOT EXISTS \(SELECT 1 FROM leaves lv\s+WHERE lv.user_id = us.user_id AND lv.start_date <= d::date AND lv.end_date >= d::date\)`).
+		WithArgs("2026-03-02", "2026-03-06", "day").
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "scheduled_days", "attended_days"}).
+			AddRow("2026-03-02", 3, 3))
+
+	got, err := svc.GetOrgAttendanceTrend(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC), "day")
+	if err != nil {
+		t.Fatalf("GetOrgAttendanceTrend() error = %v", err)
+	}
+	if len(got) != 5 || got[0].ScheduledDays != 3 || got[0].Rate == nil || *got[0].Rate != 100 {
+		t.Errorf("GetOrgAttendanceTrend() = %+v, want 5 days, the first fully attended", got)
+	}
+}
+
+func TestGetOrgAttendanceTrendBuckets(t *testing.T) {
+	date := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name        string
+		from, to    time.Time
+		granularity string
+		wantRanges  []string
+		wantField   string
+	}{
+		{"week starting on a Sunday", date(3, 1), date(3, 9), "week",
+			[]string{"2026-03-01..2026-03-01", "2026-03-02..2026-03-08", "2026-03-09..2026-03-09"}, ""},
+		{"week within one week", date(3, 3), date(3, 5), "week", []string{"2026-03-03..2026-03-05"}, ""},
+		{"month across a year end", date(12, 15), time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC), "month",
+			[]string{"2026-12-15..2026-12-31", "2027-01-01..2027-01-10"}, ""},
+		{"day", date(3, 1), date(3, 2), "day", []string{"2026-03-01..2026-03-01", "2026-03-02..2026-03-02"}, ""},
+		{"unknown granularity", date(3, 1), date(3, 2), "year", nil, "granularity"},
+		{"to before from", date(3, 2), date(3, 1), "day", nil, "to"},
+		{"too many buckets", date(1, 1), time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC), "day", nil, "to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockDB(t)
+			// Before every range, so nothing is counted and no query runs
+			svc := newTestAttendanceService(db, nil, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+			got, err := svc.GetOrgAttendanceTrend(tt.from, tt.to, tt.granularity)
+			if tt.wantField != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+					t.Errorf("GetOrgAttendanceTrend() error = %v, want field error on %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetOrgAttendanceTrend() error = %v", err)
+			}
+
+			var ranges []string
+			for _, bucket := range got {
+				ranges = append(ranges, bucket.From+".."+bucket.To)
+				if bucket.Rate != nil {
+					t.Errorf("bucket %s has rate %v before it was counted", bucket.From, *bucket.Rate)
+				}
+			}
+			if !reflect.DeepEqual(ranges, tt.wantRanges) {
+				t.Errorf("buckets = %v, want %v", ranges, tt.wantRanges)
+			}
+		})
+	}
+}
+
+func TestGetUserAttendanceByDate(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))
+
+	mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) = \$2\) AND "attendances"."deleted_at" IS NULL ORDER BY check_in_time ASC`).
+		WithArgs(7, "2026-03-02").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "check_in_time", "status"}))
+
+	got, err := svc.GetUserAttendanceByDate(7, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetUserAttendanceByDate() error = %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("GetUserAttendanceByDate() = %v, want an empty list", got)
+	}
+}
+
+// expectCheckInLookups expects the lookups CheckIn makes before creating a record for
+// user 7 checking in at location 3 (100 m radius) on 9 March 2026
+func expectCheckInLookups(mock sqlmock.Sqlmock, remote bool) {
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "radius", "timezone", "is_active"}).
+				AddRow(3, "HQ", -6.2, 106.8, 100, "UTC", true))
+	}
+	mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id = \$1 AND effective_from <= \$2`).
+		WithArgs(7, "2026-03-09", "2026-03-09", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id", "effective_from"}).
+			AddRow(1, 7, 2, 3, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE "work_schedules"."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "check_in_start", "check_in_end", "check_out_start", "remote_allowed"}).
+			AddRow(2, "Schedule", "08:00:00", "09:00:00", "17:00:00", remote))
+}
+
+// expectCheckInLock expects the transaction of a check-in up to the insert: the lock on
+// the user and the repeated check against today's records, of which count are found
+func expectCheckInLock(mock sqlmock.Sqlmock, count int) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT id FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func TestCheckInRemoteSchedule(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC)
+	// About 5 km north of the location, far outside its 100 m radius
+	lat, lon := -6.155, 106.8
+
+	tests := []struct {
+		name    string
+		remote  bool
+		wantErr bool
+	}{
+		{"remote schedule skips geofencing", true, false},
+		{"office schedule is rejected outside the radius", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, now)
+
+			expectCheckInLookups(mock, tt.remote)
+			if !tt.remote {
+				mock.ExpectQuery(`SELECT "id","role" FROM "users"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(7, "employee"))
+			}
+			if !tt.wantErr {
+				expectCheckInLock(mock, 0)
+				mock.ExpectQuery(`INSERT INTO "attendances"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				mock.ExpectCommit()
+			}
+
+			attendance, err := svc.CheckIn(7, &CheckInRequest{LocationID: 3, Latitude: &lat, Longitude: &lon})
+			if tt.wantErr {
+				if err == nil || err.Error() != "you are outside the allowed radius" {
+					t.Errorf("CheckIn() error = %v, want outside the allowed radius", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckIn() error = %v", err)
+			}
+			if attendance.Status != "remote" || attendance.OutsideRadius {
+				t.Errorf("CheckIn() status = %q, outside radius = %v, want remote inside", attendance.Status, attendance.OutsideRadius)
+			}
+		})
+	}
+}
+
+func TestCheckInClosedDay(t *testing.T) {
+	// 2026-03-09 is a Monday and the location only operates Tuesday to Saturday
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC))
+	lat, lon := -6.2, 106.8
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "radius", "timezone", "is_active", "operating_days"}).
+			AddRow(3, "HQ", -6.2, 106.8, 100, "UTC", true, "{2,3,4,5,6}"))
+
+	_, err := svc.CheckIn(7, &CheckInRequest{LocationID: 3, Latitude: &lat, Longitude: &lon})
+	if err == nil || err.Error() != "location is closed today" {
+		t.Errorf("CheckIn() error = %v, want location is closed today", err)
+	}
+	// Nothing is written on a closed day
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheckInRequiresQRToken(t *testing.T) {
+	db, mock := newMockDB(t)
+	cfg := &config.Config{Location: config.LocationConfig{QREnabled: true, QRSecret: "qr-secret", QRTokenTTL: 2 * time.Minute}}
+	svc := newTestAttendanceService(db, cfg, time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC))
+	lat, lon := -6.2, 106.8
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "radius", "timezone", "is_active", "qr_required"}).
+			AddRow(3, "HQ", -6.2, 106.8, 100, "UTC", true, true))
+
+	// Standing inside the radius is not enough without the token shown at the location
+	_, err := svc.CheckIn(7, &CheckInRequest{LocationID: 3, Latitude: &lat, Longitude: &lon})
+	if !errors.Is(err, ErrLocationTokenRequired) {
+		t.Errorf("CheckIn() error = %v, want %v", err, ErrLocationTokenRequired)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetOpenAttendances(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))
+
+	// Only days before the clock's today count as left open, and absences are never open
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE \(check_out_time IS NULL AND DATE\(check_in_time\) < \$1 AND status <> \$2\) AND location_id = \$3 AND DATE\(check_in_time\) >= \$4`).
+		WithArgs("2026-03-10", "absent", uint(3), "2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(check_out_time IS NULL AND DATE\(check_in_time\) < \$1 AND status <> \$2\) AND location_id = \$3 AND DATE\(check_in_time\) >= \$4 .*ORDER BY check_in_time DESC LIMIT \$5`).
+		WithArgs("2026-03-10", "absent", uint(3), "2026-03-01", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id", "check_in_time", "status"}).
+			AddRow(4, 7, 3, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), "present"))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	got, total, err := svc.GetOpenAttendances(map[string]interface{}{"location_id": uint(3), "date_from": "2026-03-01"}, 20, 0)
+	if err != nil {
+		t.Fatalf("GetOpenAttendances() error = %v", err)
+	}
+	if total != 1 || len(got) != 1 || got[0].ID != 4 {
+		t.Errorf("GetOpenAttendances() = %d records of %d, want record 4 of 1", len(got), total)
+	}
+}
+
+func TestCheckOutRecordsDistance(t *testing.T) {
+	now := time.Date(2026, 3, 9, 17, 30, 0, 0, time.UTC)
+	north := func(meters float64) float64 { return -6.2 + meters/6371000*180/math.Pi }
+
+	tests := []struct {
+		name    string
+		status  string
+		meters  float64
+		wantErr bool
+	}{
+		{"inside the radius", "present", 40, false},
+		{"outside the radius", "present", 400, true},
+		{"remote work outside the radius", "remote", 400, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			svc := newTestAttendanceService(db, nil, now)
+
+			attendanceRows := func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"id", "user_id", "location_id", "check_in_time", "status"}).
+					AddRow(4, 7, 3, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), tt.status)
+			}
+			locationRows := func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "radius", "is_active"}).
+					AddRow(3, "HQ", -6.2, 106.8, 100, true)
+			}
+
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) = \$2\)`).
+				WithArgs(7, "2026-03-09", 1).
+				WillReturnRows(attendanceRows())
+			mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).WillReturnRows(locationRows())
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3, 1).
+				WillReturnRows(locationRows())
+
+			if !tt.wantErr {
+				mock.ExpectQuery(`SELECT \* FROM "user_schedules"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				// Save upserts the preloaded relations along with the record
+				mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(`INSERT INTO "attendance_locations" .* ON CONFLICT DO NOTHING`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(`UPDATE "attendances" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE "attendances"."id" = \$1`).WillReturnRows(attendanceRows())
+				mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).WillReturnRows(locationRows())
+			}
+
+			lat, lon := north(tt.meters), 106.8
+			got, err := svc.CheckOut(7, &CheckOutRequest{Latitude: &lat, Longitude: &lon})
+			if tt.wantErr {
+				if err == nil || err.Error() != "you are outside the allowed radius for check-out" {
+					t.Errorf("CheckOut() error = %v, want outside the allowed radius for check-out", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckOut() error = %v", err)
+			}
+			if got.CheckOutDistance == nil || math.Abs(*got.CheckOutDistance-tt.meters) > 0.01 {
+				t.Errorf("CheckOutDistance = %v, want %v", got.CheckOutDistance, tt.meters)
+			}
+		})
+	}
+}
+
+func TestRecalculateStatuses(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))
+	// Checked in at a location in Jakarta, seven hours ahead of UTC
+	at := func(d, h, m int) time.Time { return time.Date(2026, 3, d, h-7, m, 0, 0, time.UTC) }
+
+	mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE "work_schedules"."id" = \$1`).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "check_in_start", "check_in_end", "check_out_start"}).
+			AddRow(2, "Office", "07:00:00", "08:00:00", "16:00:00"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "attendances"."id".* FROM "attendances" JOIN user_schedules us`).
+		WithArgs(2, "2026-03-02", "2026-03-06", "present", "early", "late", "half_day").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "location_id", "check_in_time", "status"}).
+			AddRow(1, 3, at(2, 7, 45), "present"). // still on time
+			AddRow(2, 3, at(3, 8, 30), "present"). // late under the earlier check-in end
+			AddRow(3, 3, at(4, 13, 0), "late"))    // past the midpoint to check-out
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "timezone"}).AddRow(3, "HQ", "Asia/Jakarta"))
+	mock.ExpectExec(`UPDATE "attendances" SET "status"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+		WithArgs("late", sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "attendances" SET "status"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+		WithArgs("half_day", sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+		WithArgs(sqlmock.AnyArg(), "attendance.recalculate_statuses", "schedule", 2, jsonContaining(`"changed":2`), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	changed, err := svc.RecalculateStatuses(2, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC), 1)
+	if err != nil {
+		t.Fatalf("RecalculateStatuses() error = %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("RecalculateStatuses() = %d, want 2", changed)
+	}
+}
+
+func TestGetCountsByLocation(t *testing.T) {
+	tests := []struct {
+		name         string
+		dateFrom     string
+		dateTo       string
+		regionID     uint
+		includeEmpty bool
+		wantSQL      string
+		wantArgs     []driver.Value
+	}{
+		{"open range without empty locations", "", "", 0, false,
+			`LEFT JOIN attendances ON attendances.location_id = attendance_locations.id AND attendances.deleted_at IS NULL AND attendances.status <> \$1 GROUP BY .* HAVING COUNT\(attendances.id\) > 0`,
+			[]driver.Value{"absent"}},
+		{"date range", "2026-03-01", "2026-03-31", 0, false,
+			`AND DATE\(attendances.check_in_time\) >= \$2 AND DATE\(attendances.check_in_time\) <= \$3 GROUP BY .* HAVING`,
+			[]driver.Value{"absent", "2026-03-01", "2026-03-31"}},
+		{"with empty locations", "", "", 0, true,
+			`GROUP BY attendance_locations.id, attendance_locations.name ORDER BY total_check_ins DESC, attendance_locations.name ASC$`,
+			[]driver.Value{"absent"}},
+		{"region with empty locations", "", "", 4, true,
+			`WHERE attendance_locations.region_id = \$2 GROUP BY attendance_locations.id, attendance_locations.name ORDER BY total_check_ins DESC, attendance_locations.name ASC$`,
+			[]driver.Value{"absent", uint(4)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))
+
+			mock.ExpectQuery(tt.wantSQL).
+				WithArgs(tt.wantArgs...).
+				WillReturnRows(sqlmock.NewRows([]string{"location_id", "location_name", "total_check_ins", "unique_users", "average_distance"}).
+					AddRow(3, "HQ", 12, 4, 18.5))
+
+			got, err := svc.GetCountsByLocation(tt.dateFrom, tt.dateTo, tt.regionID, tt.includeEmpty)
+			if err != nil {
+				t.Fatalf("GetCountsByLocation() error = %v", err)
+			}
+			want := LocationAttendanceCount{LocationID: 3, LocationName: "HQ", TotalCheckIns: 12, UniqueUsers: 4, AverageDistance: 18.5}
+			if len(got) != 1 || got[0] != want {
+				t.Errorf("GetCountsByLocation() = %+v, want [%+v]", got, want)
+			}
+		})
+	}
+}
+
+func TestCheckInSoftGeofence(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC)
+	lat, lon := -6.155, 106.8
+	cfg := &config.Config{Attendance: config.AttendanceConfig{SoftGeofenceRoles: []string{"user"}}}
+
+	tests := []struct {
+		name    string
+		role    string
+		wantErr bool
+	}{
+		{"soft role is flagged", "user", false},
+		{"other role is rejected", "admin", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, cfg, now)
+
+			expectCheckInLookups(mock, false)
+			mock.ExpectQuery(`SELECT "id","role" FROM "users" WHERE "users"."id" = \$1`).
+				WithArgs(7, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(7, tt.role))
+			if !tt.wantErr {
+				expectCheckInLock(mock, 0)
+				mock.ExpectQuery(`INSERT INTO "attendances"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				mock.ExpectCommit()
+			}
+
+			attendance, err := svc.CheckIn(7, &CheckInRequest{LocationID: 3, Latitude: &lat, Longitude: &lon})
+			if tt.wantErr {
+				if err == nil || err.Error() != "you are outside the allowed radius" {
+					t.Errorf("CheckIn() error = %v, want outside the allowed radius", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckIn() error = %v", err)
+			}
+			if !attendance.OutsideRadius {
+				t.Error("soft geofence check-in not flagged as outside the radius")
+			}
+		})
+	}
+}
+
+func TestCheckInSuspendedLocation(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC)
+	lat, lon := -6.2, 106.8
+
+	tests := []struct {
+		name    string
+		reason  string
+		until   interface{}
+		wantErr string
+	}{
+		{"indefinitely with a reason", "flooding", nil, "location is temporarily suspended: flooding"},
+		{"until a later day", "", now.Add(24 * time.Hour), "location is temporarily suspended"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, now)
+
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances"`).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "is_active", "suspended_at", "suspended_until", "suspension_reason"}).
+					AddRow(3, "HQ", true, now.Add(-time.Hour), tt.until, tt.reason))
+
+			_, err := svc.CheckIn(7, &CheckInRequest{LocationID: 3, Latitude: &lat, Longitude: &lon})
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("CheckIn() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetDurationHistogram(t *testing.T) {
+	tests := []struct {
+		name          string
+		bucketMinutes int
+		rows          [][2]int64
+		wantField     string
+		want          []DurationBucket
+	}{
+		{"bucket too small", 4, nil, "bucket_minutes", nil},
+		{"bucket too large", 721, nil, "bucket_minutes", nil},
+		{"no completed records", 60, nil, "", []DurationBucket{}},
+		{"gaps filled with zero counts", 60, [][2]int64{{7, 3}, {9, 1}}, "", []DurationBucket{
+			{FromMinutes: 420, ToMinutes: 480, Count: 3},
+			{FromMinutes: 480, ToMinutes: 540, Count: 0},
+			{FromMinutes: 540, ToMinutes: 600, Count: 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, time.Now())
+
+			if tt.wantField == "" {
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+				rows := sqlmock.NewRows([]string{"bucket", "count"})
+				for _, row := range tt.rows {
+					rows.AddRow(row[0], row[1])
+				}
+				// Open and absent records are left out
+				mock.ExpectQuery(`SELECT FLOOR\(EXTRACT\(EPOCH FROM \(check_out_time - check_in_time\)\) / 60 / \$1\)::int AS bucket, COUNT\(\*\) AS count FROM "attendances" WHERE \(location_id = \$2 AND check_out_time IS NOT NULL AND status <> \$3\) AND DATE\(check_in_time\) >= \$4 AND DATE\(check_in_time\) <= \$5 AND "attendances"."deleted_at" IS NULL GROUP BY "bucket" ORDER BY bucket`).
+					WithArgs(tt.bucketMinutes, 3, "absent", "2026-03-01", "2026-03-31").
+					WillReturnRows(rows)
+			}
+
+			buckets, err := svc.GetDurationHistogram(3, "2026-03-01", "2026-03-31", tt.bucketMinutes)
+			if tt.wantField != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+					t.Fatalf("GetDurationHistogram() error = %v, want a %s field error", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDurationHistogram() error = %v", err)
+			}
+			if !reflect.DeepEqual(buckets, tt.want) {
+				t.Errorf("GetDurationHistogram() = %+v, want %+v", buckets, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAttendanceWithNeighbors(t *testing.T) {
+	checkIn := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		previous     []uint
+		next         []uint
+		wantPrevious string
+		wantNext     string
+	}{
+		{"between two records", []uint{10}, []uint{14}, "10", "14"},
+		{"first record", nil, []uint{14}, "<nil>", "14"},
+		{"last record", []uint{10}, nil, "10", "<nil>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			svc := newTestAttendanceService(db, nil, checkIn)
+
+			expectAttendanceByID(mock, 12, checkIn)
+			previous := sqlmock.NewRows([]string{"id"})
+			for _, id := range tt.previous {
+				previous.AddRow(id)
+			}
+			next := sqlmock.NewRows([]string{"id"})
+			for _, id := range tt.next {
+				next.AddRow(id)
+			}
+			// Ties on check-in time are broken by ID so stepping never skips or repeats a record
+			mock.ExpectQuery(`SELECT "id" FROM "attendances" WHERE user_id = \$1 AND \(\(check_in_time < \$2 OR \(check_in_time = \$3 AND id < \$4\)\)\) AND "attendances"."deleted_at" IS NULL ORDER BY check_in_time DESC, id DESC LIMIT \$5`).
+				WithArgs(7, checkIn, checkIn, 12, 1).
+				WillReturnRows(previous)
+			mock.ExpectQuery(`SELECT "id" FROM "attendances" WHERE user_id = \$1 AND \(\(check_in_time > \$2 OR \(check_in_time = \$3 AND id > \$4\)\)\) AND "attendances"."deleted_at" IS NULL ORDER BY check_in_time ASC, id ASC LIMIT \$5`).
+				WithArgs(7, checkIn, checkIn, 12, 1).
+				WillReturnRows(next)
+
+			attendance, previousID, nextID, err := svc.GetAttendanceWithNeighbors(12)
+			if err != nil {
+				t.Fatalf("GetAttendanceWithNeighbors() error = %v", err)
+			}
+			if attendance.ID != 12 {
+				t.Errorf("attendance ID = %d, want 12", attendance.ID)
+			}
+			format := func(id *uint) string {
+				if id == nil {
+					return "<nil>"
+				}
+				return fmt.Sprint(*id)
+			}
+			if format(previousID) != tt.wantPrevious || format(nextID) != tt.wantNext {
+				t.Errorf("neighbors = (%s, %s), want (%s, %s)", format(previousID), format(nextID), tt.wantPrevious, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestCheckInRequestCoordinates(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"equator and prime meridian", `{"location_id":3,"latitude":0,"longitude":0}`, false},
+		{"regular coordinates", `{"location_id":3,"latitude":-6.2,"longitude":106.8}`, false},
+		{"missing latitude", `{"location_id":3,"longitude":106.8}`, true},
+		{"missing longitude", `{"location_id":3,"latitude":-6.2}`, true},
+		{"null latitude", `{"location_id":3,"latitude":null,"longitude":106.8}`, true},
+		{"latitude out of range", `{"location_id":3,"latitude":90.5,"longitude":106.8}`, true},
+		{"longitude out of range", `{"location_id":3,"latitude":-6.2,"longitude":-180.5}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checkIn CheckInRequest
+			if err := binding.JSON.BindBody([]byte(tt.body), &checkIn); (err != nil) != tt.wantErr {
+				t.Errorf("bind CheckInRequest error = %v, want error %v", err, tt.wantErr)
+			}
+
+			// Check-out shares the coordinate rules
+			var checkOut CheckOutRequest
+			if err := binding.JSON.BindBody([]byte(tt.body), &checkOut); (err != nil) != tt.wantErr {
+				t.Errorf("bind CheckOutRequest error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetUserAttendanceHistoryStatusFilter(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, time.Now())
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE user_id = \$1 AND status = \$2 AND "attendances"."deleted_at" IS NULL`).
+		WithArgs(7, "late").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE user_id = \$1 AND status = \$2 AND "attendances"."deleted_at" IS NULL ORDER BY check_in_time DESC LIMIT \$3`).
+		WithArgs(7, "late", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id", "status"}).AddRow(12, 7, 3, "late"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_attachments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "attendance_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+
+	attendances, total, err := svc.GetUserAttendanceHistory(7, map[string]interface{}{"status": "late"}, 10, 0)
+	if err != nil {
+		t.Fatalf("GetUserAttendanceHistory() error = %v", err)
+	}
+	if total != 1 || len(attendances) != 1 || attendances[0].Status != "late" {
+		t.Errorf("GetUserAttendanceHistory() = %d records of %d, want the one late record", len(attendances), total)
+	}
+}
+
+func TestGetWeeklySummary(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.Local) }
+	now := day(12).Add(12 * time.Hour) // Thursday
+
+	tests := []struct {
+		name      string
+		weekStart time.Time
+	}{
+		{"from the Monday", day(9)},
+		{"from midweek", day(11).Add(15 * time.Hour)},
+		{"from the Sunday", day(15)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			svc := newTestAttendanceService(db, nil, now)
+
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(user_id = \$1 AND check_in_time >= \$2 AND check_in_time < \$3\)`).
+				WithArgs(7, day(9), day(16)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "check_in_time", "check_out_time", "status"}).
+					AddRow(1, 7, day(9).Add(8*time.Hour), day(9).Add(18*time.Hour+30*time.Minute), "present").
+					AddRow(2, 7, day(10).Add(9*time.Hour+30*time.Minute), day(10).Add(17*time.Hour), "late"))
+			mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id = \$1 AND effective_from < \$2`).
+				WithArgs(7, day(16), day(9)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id", "effective_from"}).
+					AddRow(1, 7, 1, 3, day(1)))
+			mock.ExpectQuery(`SELECT \* FROM "work_schedules"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "check_in_start", "check_out_start", "work_days"}).
+					AddRow(1, "08:00:00", "17:00:00", "{1,2,3,4,5}"))
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+			mock.ExpectQuery(`SELECT \* FROM "holidays" WHERE date >= \$1 AND date < \$2`).
+				WithArgs("2026-03-09", "2026-03-16").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "date"}))
+			mock.ExpectQuery(`SELECT \* FROM "leaves"`).
+				WithArgs(7, "2026-03-16", "2026-03-09").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+
+			summary, err := svc.GetWeeklySummary(7, tt.weekStart)
+			if err != nil {
+				t.Fatalf("GetWeeklySummary() error = %v", err)
+			}
+			if summary.WeekStart != "2026-03-09" || summary.WeekEnd != "2026-03-15" {
+				t.Errorf("week = %s to %s, want 2026-03-09 to 2026-03-15", summary.WeekStart, summary.WeekEnd)
+			}
+			// Wednesday passed without a record; Thursday is today and Friday is still to come
+			want := PeriodTotals{ScheduledDays: 5, Present: 1, Late: 1, Absent: 1, TotalHours: 18, OvertimeHours: 1.5}
+			if summary.PeriodTotals != want {
+				t.Errorf("totals = %+v, want %+v", summary.PeriodTotals, want)
+			}
+		})
+	}
+}
+
+func TestAddAttachment(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32)
+
+	tests := []struct {
+		name     string
+		userID   uint
+		isAdmin  bool
+		content  string
+		size     int64
+		existing int
+		wantErr  error
+	}{
+		{"owner uploads a photo", 7, false, png, 40, 0, nil},
+		{"admin uploads for the owner", 1, true, png, 40, 4, nil},
+		{"another user", 8, false, png, 40, 0, ErrAttachmentForbidden},
+		{"file too large", 7, false, png, 2048, 0, ErrAttachmentTooLarge},
+		{"attachment limit reached", 7, false, png, 40, 5, ErrAttachmentLimit},
+		{"content type not accepted", 7, false, "plain text notes", 16, 0, ErrAttachmentUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{}
+			cfg.Attendance.MaxAttachments = 5
+			cfg.Attendance.AttachmentMaxSize = 1024
+			cfg.Attendance.AttachmentTypes = []string{"image/png", "image/jpeg"}
+			svc := newTestAttendanceService(db, cfg, time.Now())
+			storage := &memStorage{}
+			svc.storage = storage
+
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE "attendances"."id" = \$1`).
+				WithArgs(12, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(12, 7))
+			if tt.wantErr == nil || tt.wantErr == ErrAttachmentLimit {
+				// The limit is counted under the owner's lock, so concurrent uploads cannot pass it
+				mock.ExpectBegin()
+				mock.ExpectExec(`SELECT id FROM users WHERE id = \$1 FOR UPDATE`).
+					WithArgs(7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "attendance_attachments" WHERE attendance_id = \$1`).
+					WithArgs(12).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.existing))
+			}
+			if tt.wantErr == nil {
+				mock.ExpectQuery(`INSERT INTO "attendance_attachments"`).
+					WithArgs(12, "photo", "", sqlmock.AnyArg(), "image/png", tt.size, tt.userID, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			} else if tt.wantErr == ErrAttachmentLimit {
+				mock.ExpectRollback()
+			}
+
+			attachment, err := svc.AddAttachment(12, tt.userID, tt.isAdmin, &AddAttachmentRequest{}, strings.NewReader(tt.content), tt.size)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddAttachment() error = %v, want %v", err, tt.wantErr)
+			}
+			// The file written for a rejected upload is removed again
+			if wantDeleted := tt.wantErr == ErrAttachmentLimit; (len(storage.deleted) == 1) != wantDeleted {
+				t.Errorf("deleted files = %v, want one deleted: %v", storage.deleted, wantDeleted)
+			}
+			if tt.wantErr == nil && (!strings.HasPrefix(attachment.URL, "/uploads/attendances/12/") || !strings.HasSuffix(attachment.URL, ".png")) {
+				t.Errorf("URL = %q, want a .png under attendances/12/", attachment.URL)
+			}
+		})
+	}
+}
+
+func TestAddAttachmentQuota(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32)
+
+	insertErr := errors.New("insert failed")
+
+	tests := []struct {
+		name      string
+		used      int64
+		insertErr error
+		wantErr   error
+	}{
+		{"under the quota", 900, nil, nil},
+		{"up to the quota", 960, nil, nil},
+		{"over the quota", 980, nil, ErrStorageQuotaExceeded},
+		{"insert fails", 900, insertErr, insertErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{}
+			cfg.Attendance.MaxAttachments = 5
+			cfg.Attendance.AttachmentMaxSize = 1024
+			cfg.Attendance.AttachmentTypes = []string{"image/png"}
+			cfg.Attendance.AttachmentQuota = 1000
+			svc := newTestAttendanceService(db, cfg, time.Now())
+			storage := &memStorage{}
+			svc.storage = storage
+
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE "attendances"."id" = \$1`).
+				WithArgs(12, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(12, 7))
+			mock.ExpectBegin()
+			// Charged to the record owner, also when an admin uploads
+			mock.ExpectExec(`SELECT id FROM users WHERE id = \$1 FOR UPDATE`).
+				WithArgs(7).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "attendance_attachments" WHERE attendance_id = \$1`).
+				WithArgs(12).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			mock.ExpectQuery(`SELECT COUNT\(attendance_attachments.id\) AS count, COALESCE\(SUM\(attendance_attachments.size\), 0\) AS bytes FROM "attendance_attachments" JOIN attendances ON attendances.id = attendance_attachments.attendance_id WHERE attendances.user_id = \$1`).
+				WithArgs(7).
+				WillReturnRows(sqlmock.NewRows([]string{"count", "bytes"}).AddRow(3, tt.used))
+			switch {
+			case tt.insertErr != nil:
+				mock.ExpectQuery(`INSERT INTO "attendance_attachments"`).WillReturnError(tt.insertErr)
+				mock.ExpectRollback()
+			case tt.wantErr == nil:
+				mock.ExpectQuery(`INSERT INTO "attendance_attachments"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			default:
+				mock.ExpectRollback()
+			}
+
+			_, err := svc.AddAttachment(12, 1, true, &AddAttachmentRequest{}, strings.NewReader(png), 40)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddAttachment() error = %v, want %v", err, tt.wantErr)
+			}
+			// A file that did not make it into a record is removed again
+			if wantDeleted := tt.wantErr != nil; (len(storage.deleted) == 1) != wantDeleted {
+				t.Errorf("deleted files = %v, want one deleted: %v", storage.deleted, wantDeleted)
+			}
+		})
+	}
+}
+
+func TestOpenAttachment(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  uint
+		isAdmin bool
+		found   bool
+		wantErr string
+	}{
+		{"owner", 7, false, true, ""},
+		{"admin", 1, true, true, ""},
+		{"another user", 8, false, true, ErrAttachmentHidden.Error()},
+		{"attachment of another record", 7, false, false, "attachment not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, time.Now())
+			storage := &memStorage{files: map[string]string{"/uploads/attendances/12/a.png": "photo"}}
+			svc.storage = storage
+
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE "attendances"."id" = \$1`).
+				WithArgs(12, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(12, 7))
+			if tt.wantErr != ErrAttachmentHidden.Error() {
+				rows := sqlmock.NewRows([]string{"id", "attendance_id", "url", "content_type"})
+				if tt.found {
+					rows.AddRow(3, 12, "/uploads/attendances/12/a.png", "image/png")
+				}
+				mock.ExpectQuery(`SELECT \* FROM "attendance_attachments" WHERE id = \$1 AND attendance_id = \$2`).
+					WithArgs(3, 12, 1).
+					WillReturnRows(rows)
+			}
+
+			attachment, content, err := svc.OpenAttachment(12, 3, tt.userID, tt.isAdmin)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("OpenAttachment() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OpenAttachment() error = %v", err)
+			}
+			defer content.Close()
+			if body, _ := io.ReadAll(content); string(body) != "photo" || attachment.ContentType != "image/png" {
+				t.Errorf("OpenAttachment() = %q as %s, want the stored photo", body, attachment.ContentType)
+			}
+		})
+	}
+}
+
+func TestGetStorageUsage(t *testing.T) {
+	tests := []struct {
+		name          string
+		quota         int64
+		used          int64
+		wantQuota     string
+		wantRemaining string
+	}{
+		{"without a quota", 0, 900, "null", "null"},
+		{"within the quota", 1000, 900, "1000", "100"},
+		{"over a lowered quota", 500, 900, "500", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{}
+			cfg.Attendance.AttachmentQuota = tt.quota
+			svc := newTestAttendanceService(db, cfg, time.Now())
+
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+				WithArgs(7, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			mock.ExpectQuery(`FROM "attendance_attachments" JOIN attendances`).
+				WithArgs(7).
+				WillReturnRows(sqlmock.NewRows([]string{"count", "bytes"}).AddRow(3, tt.used))
+
+			usage, err := svc.GetStorageUsage(7)
+			if err != nil {
+				t.Fatalf("GetStorageUsage() error = %v", err)
+			}
+			quota, _ := json.Marshal(usage.QuotaBytes)
+			remaining, _ := json.Marshal(usage.RemainingBytes)
+			if usage.Attachments != 3 || usage.UsedBytes != tt.used || string(quota) != tt.wantQuota || string(remaining) != tt.wantRemaining {
+				t.Errorf("GetStorageUsage() = %d attachments, %d bytes, quota %s, remaining %s, want 3, %d, %s, %s",
+					usage.Attachments, usage.UsedBytes, quota, remaining, tt.used, tt.wantQuota, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestGetAllAttendancesMinDistance(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, time.Now())
+
+	// Combined with the other filters
+	where := `WHERE location_id = \$1 AND distance_from_location > \$2 AND DATE\(check_in_time\) >= \$3 AND "attendances"."deleted_at" IS NULL`
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" `+where).
+		WithArgs(3, 250.0, "2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "attendances" `+where+` ORDER BY check_in_time DESC LIMIT \$4`).
+		WithArgs(3, 250.0, "2026-03-01", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id", "distance_from_location"}).AddRow(12, 7, 3, 412.5))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(7, "Dewi"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+
+	filters := map[string]interface{}{"location_id": uint(3), "min_distance": 250.0, "date_from": "2026-03-01"}
+	attendances, total, err := svc.GetAllAttendances(filters, 20, 0)
+	if err != nil {
+		t.Fatalf("GetAllAttendances() error = %v", err)
+	}
+	if total != 1 || len(attendances) != 1 || attendances[0].DistanceFromLocation != 412.5 {
+		t.Errorf("GetAllAttendances() = %d records of %d, want the one 412.5 m away", len(attendances), total)
+	}
+}
+
+func TestIsEarlyLeave(t *testing.T) {
+	checkIn := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	schedule := &model.WorkSchedule{CheckOutStart: "17:00:00"}
+
+	tests := []struct {
+		name     string
+		checkOut time.Time
+		schedule *model.WorkSchedule
+		want     bool
+	}{
+		{"before check-out start", time.Date(2026, 3, 9, 16, 59, 0, 0, time.UTC), schedule, true},
+		{"at check-out start", time.Date(2026, 3, 9, 17, 0, 0, 0, time.UTC), schedule, false},
+		{"after midnight", time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC), schedule, false},
+		{"unparsable schedule", time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC), &model.WorkSchedule{CheckOutStart: "5pm"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEarlyLeave(tt.checkOut, checkIn, tt.schedule); got != tt.want {
+				t.Errorf("isEarlyLeave() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOutRequiresNoteOnEarlyLeave(t *testing.T) {
+	now := time.Date(2026, 3, 9, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		notes   string
+		wantErr bool
+	}{
+		{"without a note", "", true},
+		{"blank note", "   ", true},
+		{"with a note", "doctor's appointment", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			svc := newTestAttendanceService(db, nil, now)
+
+			attendanceRows := func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"id", "user_id", "location_id", "check_in_time", "status"}).
+					AddRow(4, 7, 3, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), "present")
+			}
+			locationRows := func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "radius", "is_active"}).
+					AddRow(3, "HQ", -6.2, 106.8, 100, true)
+			}
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) = \$2\)`).
+				WithArgs(7, "2026-03-09", 1).
+				WillReturnRows(attendanceRows())
+			mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).WillReturnRows(locationRows())
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3, 1).
+				WillReturnRows(locationRows())
+			mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id = \$1 AND effective_from <= \$2`).
+				WithArgs(7, "2026-03-09", "2026-03-09", 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id"}).AddRow(1, 7, 2, 3))
+			mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE "work_schedules"."id" = \$1`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "check_in_start", "check_out_start", "require_note_on_early_leave"}).
+					AddRow(2, "08:00:00", "17:00:00", true))
+
+			if !tt.wantErr {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(`INSERT INTO "attendance_locations" .* ON CONFLICT DO NOTHING`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(`UPDATE "attendances" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE "attendances"."id" = \$1`).WillReturnRows(attendanceRows())
+				mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).WillReturnRows(locationRows())
+			}
+
+			lat, lon := -6.2, 106.8
+			got, err := svc.CheckOut(7, &CheckOutRequest{Latitude: &lat, Longitude: &lon, Notes: tt.notes})
+			if tt.wantErr {
+				if !errors.Is(err, ErrEarlyLeaveNoteMissing) {
+					t.Errorf("CheckOut() error = %v, want %v", err, ErrEarlyLeaveNoteMissing)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckOut() error = %v", err)
+			}
+			if got.CheckOutTime == nil || !got.CheckOutTime.Equal(now) {
+				t.Errorf("CheckOutTime = %v, want %v", got.CheckOutTime, now)
+			}
+		})
+	}
+}
+
+// expectRecomputeUserStats expects the lookups and the write of one RecomputeUserStats call
+// for user 7 on 11 March 2026, with records from 2 March and no schedule. rows and leaves
+// build the attendance and leave rows, once per period loaded.
+func expectRecomputeUserStats(mock sqlmock.Sqlmock, rows, leaves func() *sqlmock.Rows) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.Local) }
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(`SELECT MIN\(check_in_time\) FROM "attendances" WHERE user_id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(day(2).Add(8 * time.Hour)))
+	// The streak looks back to the first record, the counts at the current month
+	for _, period := range [][2]time.Time{{day(2), day(12)}, {day(1), time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)}} {
+		mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(user_id = \$1 AND check_in_time >= \$2 AND check_in_time < \$3\)`).
+			WithArgs(7, period[0], period[1]).
+			WillReturnRows(rows())
+		mock.ExpectQuery(`SELECT \* FROM "user_schedules"`).
+			WithArgs(7, period[1], period[0]).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(`SELECT \* FROM "holidays"`).
+			WithArgs(period[0].Format("2006-01-02"), period[1].Format("2006-01-02")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(`SELECT \* FROM "leaves"`).
+			WithArgs(7, period[1].Format("2006-01-02"), period[0].Format("2006-01-02")).
+			WillReturnRows(leaves())
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "user_attendance_stats" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestRecomputeUserStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.Local) }
+	now := day(11).Add(12 * time.Hour) // Wednesday
+
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, now)
+
+	// Without a schedule Monday to Friday are work days. Missing Wednesday 4 March
+	// breaks the streak; the weekend of 7 and 8 March does not.
+	attendanceRows := func() *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "check_in_time", "check_out_time", "status"}).
+			AddRow(1, 7, day(2).Add(8*time.Hour), day(2).Add(16*time.Hour), "present").
+			AddRow(2, 7, day(3).Add(9*time.Hour), nil, "late")
+		for i, d := range []int{5, 6, 9, 10, 11} {
+			rows.AddRow(3+i, 7, day(d).Add(8*time.Hour), nil, "present")
+		}
+		return rows
+	}
+	noLeave := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"id"}) }
+	expectRecomputeUserStats(mock, attendanceRows, noLeave)
+
+	stats, err := svc.RecomputeUserStats(7)
+	if err != nil {
+		t.Fatalf("RecomputeUserStats() error = %v", err)
+	}
+	if stats.CurrentStreak != 5 || stats.LongestStreak != 5 {
+		t.Errorf("streaks = (%d, %d), want (5, 5)", stats.CurrentStreak, stats.LongestStreak)
+	}
+	if stats.Month != "2026-03" || stats.MonthPresent != 6 || stats.MonthLate != 1 || stats.MonthAbsent != 1 || stats.MonthHours != 8 {
+		t.Errorf("month stats = %+v, want 6 present, 1 late, 1 absent and 8 hours in 2026-03", stats)
+	}
+}
+
+func TestUserAttendanceStatsAfterEdit(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.Local) }
+	now := day(11).Add(12 * time.Hour) // Wednesday
+
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, now)
+
+	// Wednesday 4 March is annual leave, so it neither breaks the streak nor counts as absent
+	leave := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "user_id", "type", "start_date", "end_date"}).
+			AddRow(1, 7, "annual", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC))
+	}
+	attendanceRows := func(status3 string) func() *sqlmock.Rows {
+		return func() *sqlmock.Rows {
+			rows := sqlmock.NewRows([]string{"id", "user_id", "check_in_time", "status"}).
+				AddRow(2, 7, day(3).Add(9*time.Hour), status3)
+			for i, d := range []int{2, 5, 6, 9, 10, 11} {
+				rows.AddRow(3+i, 7, day(d).Add(8*time.Hour), "present")
+			}
+			return rows
+		}
+	}
+	columns := []string{"user_id", "current_streak", "longest_streak", "month", "month_present", "month_late", "month_absent", "computed_at"}
+
+	// The cache was last computed yesterday, so reading it recomputes
+	mock.ExpectQuery(`SELECT \* FROM "user_attendance_stats" WHERE user_id = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 4, 4, "2026-03", 5, 1, 0, now.AddDate(0, 0, -1)))
+	expectRecomputeUserStats(mock, attendanceRows("late"), leave)
+
+	stats, err := svc.GetUserAttendanceStats(7)
+	if err != nil {
+		t.Fatalf("GetUserAttendanceStats() error = %v", err)
+	}
+	if stats.CurrentStreak != 7 || stats.MonthPresent != 6 || stats.MonthLate != 1 || stats.MonthAbsent != 0 {
+		t.Errorf("stats before the edit = %+v, want a streak of 7, 6 present, 1 late and none absent", stats)
+	}
+
+	// An admin marks 3 March absent; the recompute picks it up and breaks the streak there
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	expectRecomputeUserStats(mock, attendanceRows("absent"), leave)
+
+	stats, err = svc.RecomputeUserStats(7)
+	if err != nil {
+		t.Fatalf("RecomputeUserStats() error = %v", err)
+	}
+	if stats.CurrentStreak != 5 || stats.LongestStreak != 5 || stats.MonthPresent != 6 || stats.MonthLate != 0 || stats.MonthAbsent != 1 {
+		t.Errorf("stats after the edit = %+v, want streaks of 5, 6 present, none late and 1 absent", stats)
+	}
+
+	// Computed today, the cache is served as stored
+	mock.ExpectQuery(`SELECT \* FROM "user_attendance_stats" WHERE user_id = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 5, 5, "2026-03", 6, 0, 1, now))
+
+	stats, err = svc.GetUserAttendanceStats(7)
+	if err != nil {
+		t.Fatalf("GetUserAttendanceStats() error = %v", err)
+	}
+	if stats.CurrentStreak != 5 || stats.MonthAbsent != 1 {
+		t.Errorf("cached stats = %+v, want the recomputed values", stats)
+	}
+}
+
+func TestGetUserAttendanceHistoryForAdmin(t *testing.T) {
+	t.Run("unknown user", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		svc := newTestAttendanceService(db, nil, time.Now())
+
+		mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+			WithArgs(9, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, _, err := svc.GetUserAttendanceHistoryForAdmin(9, nil, 10, 0)
+		if err == nil || err.Error() != "user not found" {
+			t.Errorf("GetUserAttendanceHistoryForAdmin() error = %v, want user not found", err)
+		}
+	})
+
+	t.Run("date range of another user", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		mock.MatchExpectationsInOrder(false)
+		svc := newTestAttendanceService(db, nil, time.Now())
+
+		where := `WHERE user_id = \$1 AND DATE\(check_in_time\) >= \$2 AND DATE\(check_in_time\) <= \$3 AND "attendances"."deleted_at" IS NULL`
+		mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+			WithArgs(9, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" `+where).
+			WithArgs(9, "2026-03-01", "2026-03-31").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`SELECT \* FROM "attendances" `+where+` ORDER BY check_in_time DESC LIMIT \$4`).
+			WithArgs(9, "2026-03-01", "2026-03-31", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		filters := map[string]interface{}{"date_from": "2026-03-01", "date_to": "2026-03-31"}
+		attendances, total, err := svc.GetUserAttendanceHistoryForAdmin(9, filters, 10, 0)
+		if err != nil {
+			t.Fatalf("GetUserAttendanceHistoryForAdmin() error = %v", err)
+		}
+		if total != 0 || len(attendances) != 0 {
+			t.Errorf("GetUserAttendanceHistoryForAdmin() = %d records of %d, want none", len(attendances), total)
+		}
+	})
+}
+
+func TestCheckInRechecksUnderLock(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC))
+	lat, lon := -6.2, 106.8
+
+	// A concurrent check-in is inserted after the first check, so the second one finds it
+	expectCheckInLookups(mock, false)
+	expectCheckInLock(mock, 1)
+	mock.ExpectRollback()
+
+	_, err := svc.CheckIn(7, &CheckInRequest{LocationID: 3, Latitude: &lat, Longitude: &lon})
+	if err == nil || err.Error() != "already checked in today" {
+		t.Fatalf("CheckIn() error = %v, want already checked in today", err)
+	}
+}
+
+func TestCheckInClockSkew(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 30, 0, 0, time.UTC)
+	lat, lon := -6.2, 106.8
+	at := func(offset time.Duration) *time.Time {
+		clientTime := now.Add(offset)
+		return &clientTime
+	}
+
+	tests := []struct {
+		name       string
+		maxSkew    time.Duration
+		clientTime *time.Time
+		want       bool
+	}{
+		{"no client time", 5 * time.Minute, nil, false},
+		{"within the skew", 5 * time.Minute, at(4 * time.Minute), false},
+		{"at the skew", 5 * time.Minute, at(-5 * time.Minute), false},
+		{"device clock ahead", 5 * time.Minute, at(2 * time.Hour), true},
+		{"device clock behind", 5 * time.Minute, at(-6 * time.Minute), true},
+		{"detection disabled", 0, at(2 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{Attendance: config.AttendanceConfig{MaxClientClockSkew: tt.maxSkew}}
+			svc := newTestAttendanceService(db, cfg, now)
+
+			expectCheckInLookups(mock, false)
+			expectCheckInLock(mock, 0)
+			mock.ExpectQuery(`INSERT INTO "attendances"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+			mock.ExpectCommit()
+
+			// Suspicious device clocks are flagged for review, not rejected
+			attendance, err := svc.CheckIn(7, &CheckInRequest{LocationID: 3, Latitude: &lat, Longitude: &lon, ClientTime: tt.clientTime})
+			if err != nil {
+				t.Fatalf("CheckIn() error = %v", err)
+			}
+			if attendance.ClockSkewSuspicious != tt.want {
+				t.Errorf("ClockSkewSuspicious = %v, want %v", attendance.ClockSkewSuspicious, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartWork(t *testing.T) {
+	now := time.Date(2026, 3, 9, 9, 30, 0, 0, time.UTC)
+	arrivedAt := time.Date(2026, 3, 9, 8, 40, 0, 0, time.UTC)
+
+	windowStart, windowEnd := "07:00:00", "09:00:00"
+
+	tests := []struct {
+		name        string
+		arrival     bool
+		suspendedAt interface{}
+		windowEnd   interface{}
+		wantErr     string
+	}{
+		{"without an arrival", false, nil, nil, "no arrival recorded today"},
+		{"after an arrival", true, nil, nil, ""},
+		// The arrival was accepted, but the location no longer takes check-ins
+		{"location suspended since the arrival", true, now.Add(-30 * time.Minute), nil, "location is temporarily suspended"},
+		{"check-in window closed since the arrival", true, nil, windowEnd, "check-in at this location is only accepted between 07:00:00 and 09:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, now)
+
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) = \$2\)`).
+				WithArgs(7, "2026-03-09").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			arrivals := sqlmock.NewRows([]string{"id", "user_id", "location_id", "arrived_at", "latitude", "longitude", "distance_from_location", "notes"})
+			if tt.arrival {
+				arrivals.AddRow(5, 7, 3, arrivedAt, -6.2, 106.8, 12.5, "gate B")
+			}
+			mock.ExpectQuery(`SELECT \* FROM "attendance_arrivals" WHERE user_id = \$1 AND DATE\(arrived_at\) = \$2 ORDER BY arrived_at DESC`).
+				WithArgs(7, "2026-03-09", 1).
+				WillReturnRows(arrivals)
+
+			if tt.arrival {
+				var start interface{}
+				if tt.windowEnd != nil {
+					start = windowStart
+				}
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "timezone", "is_active", "suspended_at", "check_in_window_start", "check_in_window_end"}).
+						AddRow(3, "HQ", "UTC", true, tt.suspendedAt, start, tt.windowEnd))
+			}
+			if tt.arrival && tt.wantErr == "" {
+				mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id = \$1 AND effective_from <= \$2`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "effective_from"}).
+						AddRow(1, 7, 2, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+				mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE "work_schedules"."id" = \$1`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "check_in_start", "check_in_end", "check_out_start"}).
+						AddRow(2, "Schedule", "08:00:00", "09:00:00", "17:00:00"))
+				expectCheckInLock(mock, 0)
+				// Checked in when work starts, late, while the arrival time is kept
+				mock.ExpectQuery(`INSERT INTO "attendances"`).
+					WithArgs(7, 3, now, arrivedAt, nil, -6.2, 106.8, sqlmock.AnyArg(), sqlmock.AnyArg(), 12.5, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+						sqlmock.AnyArg(), sqlmock.AnyArg(), "late", "gate B", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+				mock.ExpectExec(`DELETE FROM "attendance_arrivals" WHERE "attendance_arrivals"."id" = \$1`).
+					WithArgs(5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE "attendances"."id" = \$1`).
+					WithArgs(11, 11, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id", "check_in_time", "arrival_time", "status"}).
+						AddRow(11, 7, 3, now, arrivedAt, "late"))
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+				mock.ExpectQuery(`SELECT \* FROM "users"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(7, "Dewi"))
+			}
+
+			attendance, err := svc.StartWork(7)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("StartWork() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StartWork() error = %v", err)
+			}
+			if attendance.Status != "late" || !attendance.CheckInTime.Equal(now) {
+				t.Errorf("StartWork() = %s at %v, want late at %v", attendance.Status, attendance.CheckInTime, now)
+			}
+			if attendance.ArrivalTime == nil || !attendance.ArrivalTime.Equal(arrivedAt) {
+				t.Errorf("ArrivalTime = %v, want %v", attendance.ArrivalTime, arrivedAt)
+			}
+		})
+	}
+}
+
+func TestArriveRejectsSecondArrival(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 45, 0, 0, time.UTC)
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, now)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_arrivals" WHERE user_id = \$1 AND DATE\(arrived_at\) = \$2`).
+		WithArgs(7, "2026-03-09", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id", "arrived_at"}).AddRow(5, 7, 3, now.Add(-time.Hour)))
+
+	lat, lon := -6.2, 106.8
+	if _, err := svc.Arrive(7, &CheckInRequest{LocationID: 3, Latitude: &lat, Longitude: &lon}); err == nil || err.Error() != "arrival already recorded today" {
+		t.Errorf("Arrive() error = %v, want arrival already recorded today", err)
+	}
+}
+
+func TestGetAllAttendancesScheduleFilter(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, time.Now())
+
+	// Matched against the assignment in effect on each record's date
+	where := `WHERE \(EXISTS \(SELECT 1 FROM user_schedules us\s+WHERE us.user_id = attendances.user_id AND us.schedule_id = \$1\s+` +
+		`AND us.effective_from <= DATE\(attendances.check_in_time\)\s+` +
+		`AND \(us.effective_to IS NULL OR us.effective_to >= DATE\(attendances.check_in_time\)\)\)\) AND DATE\(check_in_time\) >= \$2 AND "attendances"."deleted_at" IS NULL`
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" `+where).
+		WithArgs(2, "2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "attendances" `+where+` ORDER BY check_in_time DESC LIMIT \$3`).
+		WithArgs(2, "2026-03-01", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id"}).AddRow(12, 7, 3))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(7, "Dewi"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+
+	attendances, total, err := svc.GetAllAttendances(map[string]interface{}{"schedule_id": uint(2), "date_from": "2026-03-01"}, 20, 0)
+	if err != nil {
+		t.Fatalf("GetAllAttendances() error = %v", err)
+	}
+	if total != 1 || len(attendances) != 1 || attendances[0].ID != 12 {
+		t.Errorf("GetAllAttendances() = %d records of %d, want record 12 of 1", len(attendances), total)
+	}
+}
+
+func TestGetAllAttendancesRegionFilter(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := newTestAttendanceService(db, nil, time.Now())
+
+	// Scoped through the location of each record
+	where := `WHERE location_id IN \(SELECT id FROM attendance_locations WHERE region_id = \$1\) AND "attendances"."deleted_at" IS NULL`
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" ` + where).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "attendances" `+where+` ORDER BY check_in_time DESC LIMIT \$2`).
+		WithArgs(4, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id"}).AddRow(12, 7, 3))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(7, "Dewi"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+
+	attendances, total, err := svc.GetAllAttendances(map[string]interface{}{"region_id": uint(4)}, 20, 0)
+	if err != nil {
+		t.Fatalf("GetAllAttendances() error = %v", err)
+	}
+	if total != 1 || len(attendances) != 1 || attendances[0].ID != 12 {
+		t.Errorf("GetAllAttendances() = %d records of %d, want record 12 of 1", len(attendances), total)
+	}
+}
+
+func TestMinutesUntilLate(t *testing.T) {
+	monday := func(h, m int) time.Time { return time.Date(2026, 3, 9, h, m, 30, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		schedule bool
+		timezone string
+		want     string // "null" when no countdown applies
+	}{
+		{"without a schedule", monday(8, 0), false, "", "null"},
+		{"before the cutoff", monday(8, 48), true, "UTC", "12"},
+		// The whole check_in_end minute is still on time
+		{"within the cutoff minute", monday(9, 0), true, "UTC", "0"},
+		{"past the cutoff", monday(9, 20), true, "UTC", "-20"},
+		{"on a day off", time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC), true, "UTC", "null"},
+		// 08:48 in Jakarta, seven hours ahead of UTC
+		{"in the location's timezone", monday(1, 48), true, "Asia/Jakarta", "12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, tt.now)
+
+			rows := sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id", "effective_from"})
+			if tt.schedule {
+				rows.AddRow(1, 7, 2, 3, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+			}
+			mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id = \$1 AND effective_from <= \$2`).
+				WillReturnRows(rows)
+			if tt.schedule {
+				mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE "work_schedules"."id" = \$1`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "check_in_start", "check_in_end", "check_out_start", "work_days"}).
+						AddRow(2, "Schedule", "08:00:00", "09:00:00", "17:00:00", "{1,2,3,4,5}"))
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "timezone"}).AddRow(3, "HQ", tt.timezone))
+			}
+
+			got, err := svc.minutesUntilLate(7, tt.now)
+			if err != nil {
+				t.Fatalf("minutesUntilLate() error = %v", err)
+			}
+			if encoded, _ := json.Marshal(got); string(encoded) != tt.want {
+				t.Errorf("minutesUntilLate() = %s, want %s", encoded, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCurrentlyCheckedIn(t *testing.T) {
+	now := time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC)
+	base := `SELECT a.id AS attendance_id, a.user_id, u.full_name, u.phone, u.department_id, a.location_id, l.name AS location_name, a.check_in_time FROM attendances a ` +
+		`JOIN users u ON u.id = a.user_id JOIN attendance_locations l ON l.id = a.location_id WHERE `
+	// Soft-deleted records are excluded even though the raw table skips GORM's scope
+	open := `a.deleted_at IS NULL AND a.check_out_time IS NULL AND DATE\(a.check_in_time\) = \$1 AND a.status <> \$2`
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		query   string
+		args    []driver.Value
+	}{
+		{"unfiltered", map[string]interface{}{}, base + open + ` ORDER BY a.check_in_time ASC`, []driver.Value{"2026-03-09", "absent"}},
+		{
+			"by location and department",
+			map[string]interface{}{"location_id": uint(3), "department_id": uint(4)},
+			base + `\(` + open + `\) AND a.location_id = \$3 AND u.department_id = \$4 ORDER BY a.check_in_time ASC`,
+			[]driver.Value{"2026-03-09", "absent", 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, now)
+
+			mock.ExpectQuery(tt.query).
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"attendance_id", "user_id", "full_name", "phone", "department_id", "location_id", "location_name", "check_in_time"}).
+					AddRow(12, 7, "Dewi", "0812", 4, 3, "HQ", now.Add(-2*time.Hour)))
+
+			present, err := svc.GetCurrentlyCheckedIn(tt.filters)
+			if err != nil {
+				t.Fatalf("GetCurrentlyCheckedIn() error = %v", err)
+			}
+			if len(present) != 1 || present[0].AttendanceID != 12 || present[0].LocationName != "HQ" || present[0].DepartmentID == nil || *present[0].DepartmentID != 4 {
+				t.Errorf("GetCurrentlyCheckedIn() = %+v, want Dewi at HQ", present)
+			}
+		})
+	}
+}
+
+func TestDetermineAttendanceStatus(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 9, h, m, 0, 0, time.UTC) }
+	schedule := &model.WorkSchedule{CheckInStart: "08:00:00", CheckInEnd: "09:00:00", CheckOutStart: "17:00:00"}
+	withEarly := &model.WorkSchedule{CheckInStart: "08:00:00", CheckInEnd: "09:00:00", CheckOutStart: "17:00:00", EarlyStatusMinutes: 30}
+
+	tests := []struct {
+		name     string
+		checkIn  time.Time
+		schedule *model.WorkSchedule
+		want     string
+	}{
+		{"well before start without early status", at(6, 0), schedule, "present"},
+		{"more than the early minutes before start", at(7, 29), withEarly, "early"},
+		{"exactly the early minutes before start", at(7, 30), withEarly, "present"},
+		{"within the check-in window", at(8, 30), withEarly, "present"},
+		{"in the check-in end minute", at(9, 0), schedule, "present"},
+		{"after check-in end", at(9, 1), schedule, "late"},
+		{"past the midpoint to check-out", at(13, 0), schedule, "half_day"},
+		{"default hours, on time", at(9, 59), nil, "present"},
+		{"default hours, late", at(10, 0), nil, "late"},
+		{"default hours, half day", at(12, 0), nil, "half_day"},
+	}
+
+	svc := &AttendanceService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := svc.determineAttendanceStatus(tt.checkIn, tt.schedule); got != tt.want {
+				t.Errorf("determineAttendanceStatus(%s) = %q, want %q", tt.checkIn.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRestGap(t *testing.T) {
+	now := time.Date(2026, 3, 9, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rest    time.Duration // since the last check-out, 0 when there is none
+		wantErr string
+	}{
+		{"never checked out", 0, ""},
+		{"rested long enough", 9 * time.Hour, ""},
+		{"exactly the minimum rest", 8 * time.Hour, ""},
+		{"one second past the minimum rest", 8*time.Hour + time.Second, ""},
+		{"one second short of the minimum rest", 8*time.Hour - time.Second, "a rest of 480 minutes is required after checking out, try again in 1 minutes"},
+		// The minutes left are rounded up
+		{"too soon", 7*time.Hour + 30*time.Second, "a rest of 480 minutes is required after checking out, try again in 60 minutes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, now)
+
+			rows := sqlmock.NewRows([]string{"id", "check_out_time"})
+			if tt.rest > 0 {
+				rows.AddRow(12, now.Add(-tt.rest))
+			}
+			mock.ExpectQuery(`SELECT "id","check_out_time" FROM "attendances" WHERE \(user_id = \$1 AND check_out_time IS NOT NULL\) AND "attendances"."deleted_at" IS NULL ORDER BY check_out_time DESC`).
+				WithArgs(7, 1).
+				WillReturnRows(rows)
+			if tt.wantErr != "" {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+					WithArgs(7, "attendance.check_in_rejected", "attendance", 12, jsonContaining(`"min_rest_minutes":480`), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			err := svc.checkRestGap(7, 480, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkRestGap() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("checkRestGap() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetRecentCheckInsWithDistance(t *testing.T) {
+	// Meters due north of the location
+	north := func(meters float64) float64 { return -6.2 + meters/6371000*180/math.Pi }
+
+	tests := []struct {
+		name          string
+		threshold     float64
+		wantThreshold float64
+		wantBeyond    []bool
+	}{
+		{"location radius by default", 0, 100, []bool{true, false}},
+		{"explicit threshold", 500, 500, []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, time.Now())
+
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+				WithArgs(7, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			mock.ExpectQuery(`SELECT \* FROM "attendances" WHERE \(user_id = \$1 AND status <> \$2\) AND "attendances"."deleted_at" IS NULL ORDER BY check_in_time DESC LIMIT \$3`).
+				WithArgs(7, "absent", 2).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "location_id", "check_in_time", "check_in_latitude", "check_in_longitude", "status"}).
+					AddRow(12, 7, 3, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), north(300), 106.8, "present").
+					AddRow(11, 7, 3, time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC), north(50), 106.8, "present"))
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "radius"}).AddRow(3, "HQ", -6.2, 106.8, 100))
+
+			checkIns, err := svc.GetRecentCheckInsWithDistance(7, 2, tt.threshold)
+			if err != nil {
+				t.Fatalf("GetRecentCheckInsWithDistance() error = %v", err)
+			}
+			if len(checkIns) != 2 {
+				t.Fatalf("got %d check-ins, want 2", len(checkIns))
+			}
+			for i, checkIn := range checkIns {
+				if checkIn.Threshold != tt.wantThreshold || checkIn.BeyondThreshold != tt.wantBeyond[i] {
+					t.Errorf("check-in %d = %.0f m against %.0f m (beyond %v), want %.0f m (beyond %v)",
+						checkIn.AttendanceID, checkIn.Distance, checkIn.Threshold, checkIn.BeyondThreshold, tt.wantThreshold, tt.wantBeyond[i])
+				}
+			}
+			// Only the newest has an older check-in to compare with
+			if d := checkIns[0].DistanceFromPrevious; d == nil || math.Abs(*d-250) > 1 {
+				t.Errorf("DistanceFromPrevious = %v, want about 250 m", d)
+			}
+			if checkIns[1].DistanceFromPrevious != nil {
+				t.Errorf("oldest DistanceFromPrevious = %v, want nil", *checkIns[1].DistanceFromPrevious)
+			}
+		})
+	}
+}
+
+// sameInstant matches a time argument at the same instant regardless of its zone
+type sameInstant time.Time
+
+func (s sameInstant) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	return ok && t.Equal(time.Time(s))
+}
+
+func TestCloseAllAtLocation(t *testing.T) {
+	// 03:00 on 10 March in Jakarta
+	now := time.Date(2026, 3, 9, 20, 0, 0, 0, time.UTC)
+	earlier := now.Add(-2 * time.Hour)
+	later := now.Add(time.Minute)
+
+	tests := []struct {
+		name         string
+		checkOutTime *time.Time
+		wantAt       time.Time
+		wantErr      bool
+	}{
+		{"now by default", nil, now, false},
+		{"an earlier time", &earlier, earlier, false},
+		{"a future time", &later, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, now)
+
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "timezone"}).AddRow(3, "HQ", "Asia/Jakarta"))
+			if !tt.wantErr {
+				// Today is the location's day, from Jakarta midnight
+				dayStart := time.Date(2026, 3, 9, 17, 0, 0, 0, time.UTC)
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "attendances" SET "auto_checkout"=\$1,"check_out_time"=GREATEST\(check_in_time, \$2\),"updated_at"=\$3 `+
+					`WHERE \(location_id = \$4 AND check_out_time IS NULL AND status <> \$5\) AND \(check_in_time >= \$6 AND check_in_time < \$7\) AND "attendances"."deleted_at" IS NULL`).
+					WithArgs(true, sameInstant(tt.wantAt), sameInstant(now), 3, "absent", sameInstant(dayStart), sameInstant(dayStart.AddDate(0, 0, 1))).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+					WithArgs(1, "attendance.close_all", "location", 3, jsonContaining(`"closed":4`), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			closed, err := svc.CloseAllAtLocation(3, tt.checkOutTime, 1)
+			if tt.wantErr {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != "check_out_time" {
+					t.Errorf("CloseAllAtLocation() error = %v, want a field error on check_out_time", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CloseAllAtLocation() error = %v", err)
+			}
+			if closed != 4 {
+				t.Errorf("CloseAllAtLocation() = %d, want 4", closed)
+			}
+		})
+	}
+}
+
+func TestUpdateAdminNotes(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Now())
+
+	expectAttendanceByID(mock, 12, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC))
+	// Only the notes column is written, never the preloaded user or location
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "attendances" SET "admin_notes"=\$1,"updated_at"=\$2 WHERE id = \$3 AND "attendances"."deleted_at" IS NULL`).
+		WithArgs("verify with HR", sqlmock.AnyArg(), 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+		WithArgs(1, "attendance.admin_notes", "attendance", 12, jsonContaining(`"admin_notes":"verify with HR"`), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	attendance, err := svc.UpdateAdminNotes(12, 1, &UpdateAdminNotesRequest{AdminNotes: "verify with HR"})
+	if err != nil {
+		t.Fatalf("UpdateAdminNotes() error = %v", err)
+	}
+	if attendance.AdminNotes != "verify with HR" {
+		t.Errorf("AdminNotes = %q, want %q", attendance.AdminNotes, "verify with HR")
+	}
+}
+
+func TestPreviewCheckInStatus(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2026, 3, 9, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name            string
+		now             time.Time
+		schedule        bool
+		remote          bool
+		timezone        string
+		checkedIn       int
+		wantStatus      string
+		wantMinutesLate int
+	}{
+		{"on time", at(8, 30), true, false, "UTC", 0, "present", 0},
+		{"last on-time minute", at(9, 0), true, false, "UTC", 0, "present", 0},
+		{"late", at(9, 20), true, false, "UTC", 0, "late", 20},
+		{"half day", at(13, 30), true, false, "UTC", 0, "half_day", 270},
+		{"remote schedule", at(13, 30), true, true, "UTC", 0, "remote", 0},
+		{"without a schedule", at(10, 15), false, false, "", 0, "late", 16},
+		{"already checked in", at(8, 30), true, false, "UTC", 1, "present", 0},
+		// 09:20 in Jakarta, seven hours ahead of UTC
+		{"in the location's timezone", at(2, 20), true, false, "Asia/Jakarta", 0, "late", 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, tt.now)
+
+			userSchedules := sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id", "effective_from"})
+			if tt.schedule {
+				userSchedules.AddRow(1, 7, 2, 3, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+			}
+			mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id = \$1 AND effective_from <= \$2`).
+				WithArgs(7, "2026-03-09", "2026-03-09", 1).
+				WillReturnRows(userSchedules)
+			if tt.schedule {
+				mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE "work_schedules"."id" = \$1`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "check_in_start", "check_in_end", "check_out_start", "remote_allowed"}).
+						AddRow(2, "Schedule", "08:00:00", "09:00:00", "17:00:00", tt.remote))
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "timezone"}).AddRow(3, "HQ", tt.timezone))
+			}
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE \(user_id = \$1 AND DATE\(check_in_time\) = \$2\)`).
+				WithArgs(7, "2026-03-09").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.checkedIn))
+
+			// Only reads: no expectation is set for a write
+			preview, err := svc.PreviewCheckInStatus(7)
+			if err != nil {
+				t.Fatalf("PreviewCheckInStatus() error = %v", err)
+			}
+			if preview.Status != tt.wantStatus || preview.MinutesLate != tt.wantMinutesLate {
+				t.Errorf("PreviewCheckInStatus() = %s, %d minutes late, want %s, %d", preview.Status, preview.MinutesLate, tt.wantStatus, tt.wantMinutesLate)
+			}
+			if preview.HasSchedule != tt.schedule || preview.AlreadyCheckedIn != (tt.checkedIn > 0) || !preview.At.Equal(tt.now) {
+				t.Errorf("PreviewCheckInStatus() = %+v", preview)
+			}
+		})
+	}
+}
+
+func TestStatusCutoffs(t *testing.T) {
+	tests := []struct {
+		name            string
+		schedule        *model.WorkSchedule
+		wantLastOnTime  int
+		wantHalfDayFrom int
+		wantScheduled   bool
+	}{
+		{"schedule", &model.WorkSchedule{CheckInEnd: "09:00:00", CheckOutStart: "17:00:00"}, 9 * 60, 13 * 60, true},
+		{"without a schedule", nil, 9*60 + 59, 12 * 60, false},
+		// determineAttendanceStatus falls back to office hours, so the preview must too
+		{"schedule without a check-out start", &model.WorkSchedule{CheckInEnd: "08:30:00"}, 9*60 + 59, 12 * 60, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lastOnTime, halfDayFrom, scheduled := statusCutoffs(tt.schedule)
+			if lastOnTime != tt.wantLastOnTime || halfDayFrom != tt.wantHalfDayFrom || scheduled != tt.wantScheduled {
+				t.Errorf("statusCutoffs() = %d, %d, %v, want %d, %d, %v",
+					lastOnTime, halfDayFrom, scheduled, tt.wantLastOnTime, tt.wantHalfDayFrom, tt.wantScheduled)
+			}
+		})
+	}
+}
+
+func TestBulkUpdateStatus(t *testing.T) {
+	columns := []string{"id", "user_id", "location_id", "status"}
+	tooMany := make([][]driver.Value, maxBulkStatusRecords+1)
+	for i := range tooMany {
+		tooMany[i] = []driver.Value{i + 1, 7, 3, "late"}
+	}
+
+	tests := []struct {
+		name        string
+		req         BulkStatusRequest
+		wantField   string
+		wantSQL     string
+		wantArgs    []driver.Value
+		records     [][]driver.Value
+		wantChanged []uint
+	}{
+		{"invalid target status", BulkStatusRequest{IDs: []uint{1}, Status: "vacation"}, "status", "", nil, nil, nil},
+		{"neither ids nor filter", BulkStatusRequest{Status: "present"}, "ids", "", nil, nil, nil},
+		{"both ids and filter", BulkStatusRequest{IDs: []uint{1}, Filter: &BulkStatusFilter{UserID: 7}, Status: "present"}, "ids", "", nil, nil, nil},
+		{"empty filter", BulkStatusRequest{Filter: &BulkStatusFilter{}, Status: "present"}, "filter", "", nil, nil, nil},
+		{"invalid filter date", BulkStatusRequest{Filter: &BulkStatusFilter{DateFrom: "03/01/2026"}, Status: "present"}, "filter", "", nil, nil, nil},
+		{"unknown id", BulkStatusRequest{IDs: []uint{11, 12}, Status: "present"}, "ids",
+			`WHERE id IN \(\$1,\$2\)`, []driver.Value{11, 12},
+			[][]driver.Value{{11, 7, 3, "late"}}, nil},
+		{"by ids", BulkStatusRequest{IDs: []uint{11, 12}, Status: "present"}, "",
+			`WHERE id IN \(\$1,\$2\)`, []driver.Value{11, 12},
+			// Record 12 is already present and left alone
+			[][]driver.Value{{11, 7, 3, "late"}, {12, 8, 3, "present"}}, []uint{11}},
+		{"by filter", BulkStatusRequest{Filter: &BulkStatusFilter{LocationID: 3, Status: "late", DateFrom: "2026-03-01", DateTo: "2026-03-31"}, Status: "present"}, "",
+			`WHERE location_id = \$1 AND status = \$2 AND DATE\(check_in_time\) >= \$3 AND DATE\(check_in_time\) <= \$4`,
+			[]driver.Value{3, "late", "2026-03-01", "2026-03-31"},
+			[][]driver.Value{{11, 7, 3, "late"}, {13, 9, 3, "late"}}, []uint{11, 13}},
+		// Nothing is changed when the filter matches more than the cap
+		{"filter matching too many", BulkStatusRequest{Filter: &BulkStatusFilter{Status: "late"}, Status: "present"}, "filter",
+			`WHERE status = \$1`, []driver.Value{"late"},
+			tooMany, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))
+
+			if tt.wantSQL != "" {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows(columns)
+				for _, record := range tt.records {
+					rows.AddRow(record...)
+				}
+				mock.ExpectQuery(`SELECT \* FROM "attendances" ` + tt.wantSQL + ` AND "attendances"."deleted_at" IS NULL ORDER BY id ASC LIMIT \$\d+`).
+					WithArgs(append(tt.wantArgs, maxBulkStatusRecords+1)...).
+					WillReturnRows(rows)
+				for _, id := range tt.wantChanged {
+					mock.ExpectExec(`UPDATE "attendances" SET "status"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+						WithArgs("present", sqlmock.AnyArg(), id).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+						WithArgs(1, "attendance.bulk_status", "attendance", id, jsonContaining(`"to":"present"`), sqlmock.AnyArg()).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+				}
+				if tt.wantField != "" {
+					mock.ExpectRollback()
+				} else {
+					mock.ExpectCommit()
+				}
+			}
+
+			changed, err := svc.BulkUpdateStatus(&tt.req, 1)
+			if tt.wantField != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+					t.Fatalf("BulkUpdateStatus() error = %v, want a %s field error", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BulkUpdateStatus() error = %v", err)
+			}
+			if changed != len(tt.wantChanged) {
+				t.Errorf("BulkUpdateStatus() = %d, want %d", changed, len(tt.wantChanged))
+			}
+		})
+	}
+}
+
+func TestAttendanceSchedules(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, day(10))
+
+	// User 7 moved from Office to Branch on 4 March; user 8 has no schedule
+	mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id IN \(\$1,\$2\) ORDER BY effective_from DESC`).
+		WithArgs(7, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "effective_from", "effective_to"}).
+			AddRow(2, 7, 2, day(4), nil).
+			AddRow(1, 7, 1, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), day(3)))
+	mock.ExpectQuery(`SELECT \* FROM "work_schedules"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "check_in_end", "check_out_start"}).
+			AddRow(1, "Office", "09:00:00", "17:00:00").
+			AddRow(2, "Branch", "08:00:00", "16:00:00"))
+
+	schedules, err := svc.AttendanceSchedules([]model.Attendance{
+		{ID: 11, UserID: 7, CheckInTime: day(3).Add(9 * time.Hour)},
+		{ID: 12, UserID: 7, CheckInTime: day(4).Add(9 * time.Hour)},
+		{ID: 13, UserID: 8, CheckInTime: day(4).Add(9 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("AttendanceSchedules() error = %v", err)
+	}
+
+	want := map[uint]string{11: "Office", 12: "Branch"}
+	if len(schedules) != len(want) {
+		t.Fatalf("AttendanceSchedules() = %d schedules, want %d", len(schedules), len(want))
+	}
+	for id, name := range want {
+		if schedules[id] == nil || schedules[id].Name != name {
+			t.Errorf("schedule of record %d = %v, want %s", id, schedules[id], name)
+		}
+	}
+}
+
+func TestAttendanceSchedulesLookupFailure(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Now())
+
+	mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id IN \(\$1\)`).
+		WillReturnError(errors.New("connection reset"))
+
+	// Reported rather than treated as no schedule, which would pass for a record without one
+	if _, err := svc.AttendanceSchedules([]model.Attendance{{ID: 11, UserID: 7, CheckInTime: time.Now()}}); err == nil {
+		t.Error("AttendanceSchedules() error = nil, want the lookup error")
+	}
+}
+
+func TestGetDashboardCounts(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := newTestAttendanceService(db, nil, time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC))
+
+	// Reviewed records drop out of the suspicious count but still count as check-ins
+	mock.ExpectQuery(`COUNT\(\*\) FILTER \(WHERE NOT attendances.reviewed AND \(attendances.outside_radius OR attendances.clock_skew_suspicious OR NOT EXISTS .*\)\) AS suspicious FROM "attendances" WHERE \(DATE\(attendances.check_in_time\) = \$1 AND attendances.status <> \$2\) AND "attendances"."deleted_at" IS NULL`).
+		WithArgs("2026-03-09", "absent").
+		WillReturnRows(sqlmock.NewRows([]string{"check_ins", "late", "open", "suspicious"}).AddRow(12, 3, 5, 2))
+
+	counts, err := svc.GetDashboardCounts(time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetDashboardCounts() error = %v", err)
+	}
+	want := DashboardCounts{Date: "2026-03-09", CheckIns: 12, Late: 3, Open: 5, Suspicious: 2}
+	if *counts != want {
+		t.Errorf("GetDashboardCounts() = %+v, want %+v", *counts, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetDailyCounts(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		filters   map[string]interface{}
+		wantField string
+		wantSQL   string
+		wantArgs  []driver.Value
+	}{
+		{"range reversed", day(5), day(1), nil, "date_to", "", nil},
+		{"range over a year", day(1), day(1).AddDate(1, 0, 2), nil, "date_to", "", nil},
+		{"whole organization", day(1), day(5), nil, "",
+			`FROM "attendances" WHERE \(DATE\(attendances.check_in_time\) BETWEEN \$1 AND \$2\) AND attendances.status <> \$3 AND "attendances"."deleted_at" IS NULL GROUP BY DATE\(attendances.check_in_time\)`,
+			[]driver.Value{"2026-03-01", "2026-03-05", "absent"}},
+		{"by location", day(1), day(5), map[string]interface{}{"location_id": uint(3)}, "",
+			`AND attendances.location_id = \$4 AND "attendances"."deleted_at" IS NULL GROUP BY`,
+			[]driver.Value{"2026-03-01", "2026-03-05", "absent", uint(3)}},
+		{"by department", day(1), day(5), map[string]interface{}{"department_id": uint(2)}, "",
+			`FROM "attendances" JOIN users ON users.id = attendances.user_id WHERE .* AND users.department_id = \$4`,
+			[]driver.Value{"2026-03-01", "2026-03-05", "absent", uint(2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := newTestAttendanceService(db, nil, day(10))
+
+			if tt.wantSQL != "" {
+				mock.ExpectQuery(`SELECT DATE\(attendances.check_in_time\) AS day, COUNT\(\*\) AS count .*` + tt.wantSQL).
+					WithArgs(tt.wantArgs...).
+					WillReturnRows(sqlmock.NewRows([]string{"day", "count"}).
+						AddRow(day(2), 4).
+						AddRow(day(4), 6))
+			}
+
+			counts, err := svc.GetDailyCounts(tt.from, tt.to, tt.filters)
+			if tt.wantField != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+					t.Fatalf("GetDailyCounts() error = %v, want a %s field error", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDailyCounts() error = %v", err)
+			}
+			// Days without check-ins are filled with zero
+			want := map[string]int64{"2026-03-01": 0, "2026-03-02": 4, "2026-03-03": 0, "2026-03-04": 6, "2026-03-05": 0}
+			if len(counts) != len(want) {
+				t.Fatalf("GetDailyCounts() = %v, want %v", counts, want)
+			}
+			for date, count := range want {
+				if got, ok := counts[date]; !ok || got != count {
+					t.Errorf("counts[%s] = %d, want %d", date, got, count)
+				}
+			}
+		})
+	}
+}
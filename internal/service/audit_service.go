@@ -0,0 +1,98 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/attendance/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// WithTx returns an AuditService that writes within the given transaction
+func (s *AuditService) WithTx(tx *gorm.DB) *AuditService {
+	return &AuditService{db: tx}
+}
+
+// Log records an action performed by actorID on an entity
+func (s *AuditService) Log(actorID uint, action, entityType string, entityID uint, details interface{}) error {
+	return s.create(&actorID, action, entityType, entityID, details)
+}
+
+// LogSystem records an action performed by a background job rather than a user
+func (s *AuditService) LogSystem(action, entityType string, entityID uint, details interface{}) error {
+	return s.create(nil, action, entityType, entityID, details)
+}
+
+// GetAuditLogs retrieves audit log entries, newest first. Supported filters are
+// entity_type, entity_id, actor_id, action, and date_from/date_to (time.Time,
+// inclusive days).
+func (s *AuditService) GetAuditLogs(filters map[string]interface{}, limit, offset int) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	query := s.db.Model(&model.AuditLog{})
+
+	// Apply filters
+	if entityType, ok := filters["entity_type"].(string); ok && entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID, ok := filters["entity_id"].(uint); ok && entityID > 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if actorID, ok := filters["actor_id"].(uint); ok && actorID > 0 {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if action, ok := filters["action"].(string); ok && action != "" {
+		query = query.Where("action = ?", action)
+	}
+	// Compare created_at directly rather than DATE(created_at) so the indexes apply
+	if dateFrom, ok := filters["date_from"].(time.Time); ok {
+		query = query.Where("created_at >= ?", dateFrom)
+	}
+	if dateTo, ok := filters["date_to"].(time.Time); ok {
+		query = query.Where("created_at < ?", dateTo.AddDate(0, 0, 1))
+	}
+
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated records
+	err := query.Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// create writes a single audit log entry
+func (s *AuditService) create(actorID *uint, action, entityType string, entityID uint, details interface{}) error {
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	entry := model.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Details:    raw,
+	}
+
+	return s.db.Create(&entry).Error
+}
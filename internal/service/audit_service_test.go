@@ -0,0 +1,47 @@
+package service
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetAuditLogs(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := NewAuditService(db)
+
+	dateFrom := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	dateTo := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	// date_to covers the whole day, and created_at is compared directly so the indexes apply
+	where := `WHERE entity_type = \$1 AND entity_id = \$2 AND actor_id = \$3 AND action = \$4 AND created_at >= \$5 AND created_at < \$6`
+	args := []driver.Value{"user", 7, 1, "user.deactivated", dateFrom, dateTo.AddDate(0, 0, 1)}
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "audit_logs" ` + where).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	mock.ExpectQuery(`SELECT \* FROM "audit_logs" ` + where + ` ORDER BY created_at DESC, id DESC LIMIT \$7 OFFSET \$8`).
+		WithArgs(append(args, 20, 20)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "action", "entity_type", "entity_id"}).AddRow(5, 1, "user.deactivated", "user", 7))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(1, "Admin"))
+
+	filters := map[string]interface{}{
+		"entity_type": "user",
+		"entity_id":   uint(7),
+		"actor_id":    uint(1),
+		"action":      "user.deactivated",
+		"date_from":   dateFrom,
+		"date_to":     dateTo,
+	}
+	logs, total, err := svc.GetAuditLogs(filters, 20, 20)
+	if err != nil {
+		t.Fatalf("GetAuditLogs() error = %v", err)
+	}
+	if total != 21 || len(logs) != 1 || logs[0].Actor == nil || logs[0].Actor.FullName != "Admin" {
+		t.Errorf("GetAuditLogs() = %d entries of %d, want entry 5 by Admin of 21", len(logs), total)
+	}
+}
@@ -2,6 +2,9 @@ package service
 
 import (
 	"errors"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/attendance/backend/internal/config"
 	"github.com/attendance/backend/internal/model"
@@ -10,36 +13,53 @@ import (
 )
 
 var (
-	ErrEmailAlreadyExists = errors.New("email already exists")
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserInactive       = errors.New("user account is inactive")
+	ErrEmailAlreadyExists     = errors.New("email already exists")
+	ErrPhoneAlreadyExists     = errors.New("phone already exists")
+	ErrEmployeeIDExists       = errors.New("employee id already exists")
+	ErrInvalidCredentials     = errors.New("invalid email or password")
+	ErrIdentifierRequired     = errors.New("identifier is required")
+	ErrAmbiguousIdentifier    = errors.New("identifier matches more than one account, log in with email instead")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrUserInactive           = errors.New("user account is inactive")
+	ErrRegistrationClosed     = errors.New("registration is closed")
+	ErrTokenRevoked           = errors.New("token has been revoked")
+	ErrDeviceMismatch         = errors.New("token is bound to a different device")
+	ErrSessionExpired         = errors.New("session has expired, please log in again")
+	ErrPasswordChangeRequired = errors.New("password must be changed before continuing")
 )
 
 type AuthService struct {
-	db     *gorm.DB
-	config *config.Config
+	db             *gorm.DB
+	config         *config.Config
+	keys           *jwt.Keys
+	webhookService *WebhookService
 }
 
-func NewAuthService(db *gorm.DB, cfg *config.Config) *AuthService {
+func NewAuthService(db *gorm.DB, cfg *config.Config, keys *jwt.Keys, webhookService *WebhookService) *AuthService {
 	return &AuthService{
-		db:     db,
-		config: cfg,
+		db:             db,
+		config:         cfg,
+		keys:           keys,
+		webhookService: webhookService,
 	}
 }
 
 // RegisterRequest represents registration request
 type RegisterRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	FullName string `json:"full_name" binding:"required"`
-	Phone    string `json:"phone"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=6"`
+	FullName   string `json:"full_name" binding:"required"`
+	Phone      string `json:"phone"`
+	EmployeeID string `json:"employee_id" binding:"omitempty,max=50"`
 }
 
-// LoginRequest represents login request
+// LoginRequest represents login request.
+// Identifier accepts either an email or a phone number; Email is still
+// accepted on its own for older clients.
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Identifier string `json:"identifier"`
+	Email      string `json:"email" binding:"omitempty,email"`
+	Password   string `json:"password" binding:"required"`
 }
 
 // AuthResponse represents authentication response
@@ -47,23 +67,59 @@ type AuthResponse struct {
 	User         model.UserResponse `json:"user"`
 	AccessToken  string             `json:"access_token"`
 	RefreshToken string             `json:"refresh_token"`
+	// MustChangePassword tells the client to route straight to the change-password
+	// screen; the tokens stay valid so the user can call it
+	MustChangePassword bool `json:"must_change_password"`
+}
+
+// MeResponse represents the authenticated user with effective permissions
+type MeResponse struct {
+	model.UserResponse
+	Permissions    []string   `json:"permissions"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
 }
 
 // Register creates a new user account
-func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
+func (s *AuthService) Register(req *RegisterRequest, fingerprint string) (*AuthResponse, error) {
+	if !s.config.Auth.RegistrationEnabled {
+		return nil, ErrRegistrationClosed
+	}
+	if err := validateRequiredRegistrationFields(req, s.config.Auth.RegistrationRequired); err != nil {
+		return nil, err
+	}
+
 	// Check if email already exists
-	var existingUser model.User
-	if err := s.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+	email := normalizeEmail(req.Email)
+	if taken, err := isEmailTaken(s.db, email, 0); err != nil {
+		return nil, err
+	} else if taken {
 		return nil, ErrEmailAlreadyExists
 	}
 
+	// Check if phone already exists
+	phone := strings.TrimSpace(req.Phone)
+	if taken, err := isPhoneTaken(s.db, phone, 0); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrPhoneAlreadyExists
+	}
+
+	// Check if employee ID already exists
+	employeeID := strings.TrimSpace(req.EmployeeID)
+	if taken, err := isEmployeeIDTaken(s.db, employeeID, 0); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrEmployeeIDExists
+	}
+
 	// Create new user
 	user := model.User{
-		Email:    req.Email,
-		FullName: req.FullName,
-		Phone:    req.Phone,
-		Role:     "user",
-		IsActive: true,
+		Email:      email,
+		FullName:   req.FullName,
+		Phone:      phone,
+		EmployeeID: employeeID,
+		Role:       "user",
+		IsActive:   true,
 	}
 
 	// Hash password
@@ -73,15 +129,29 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 
 	// Save to database
 	if err := s.db.Create(&user).Error; err != nil {
+		// A concurrent registration may have taken the employee ID since the check above
+		if isUniqueViolation(err, employeeIDIndex) {
+			return nil, ErrEmployeeIDExists
+		}
 		return nil, err
 	}
 
+	// A misconfigured default schedule must not block registration
+	if err := assignDefaultSchedule(s.db, s.config.Schedule, user.ID, time.Now()); err != nil {
+		log.Printf("user %d: failed to assign default schedule: %v", user.ID, err)
+	}
+
+	s.webhookService.Dispatch(model.WebhookEventUserCreated, user.ToResponse())
+
 	// Generate tokens
 	tokens, err := jwt.GenerateTokenPair(
 		user.ID,
 		user.Email,
 		user.Role,
-		s.config.JWT.Secret,
+		user.TokenVersion,
+		s.bindFingerprint(fingerprint),
+		time.Now(),
+		s.keys,
 		s.config.JWT.Expiration,
 		s.config.JWT.RefreshExpiration,
 	)
@@ -90,22 +160,56 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 	}
 
 	return &AuthResponse{
-		User:         user.ToResponse(),
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
+		User:               user.ToResponse(),
+		AccessToken:        tokens.AccessToken,
+		RefreshToken:       tokens.RefreshToken,
+		MustChangePassword: user.MustChangePassword,
 	}, nil
 }
 
+// IsEmailAvailable reports whether an email can still be used to register
+func (s *AuthService) IsEmailAvailable(email string) (bool, error) {
+	if !s.config.Auth.RegistrationEnabled {
+		return false, ErrRegistrationClosed
+	}
+
+	taken, err := isEmailTaken(s.db, normalizeEmail(email), 0)
+	if err != nil {
+		return false, err
+	}
+
+	return !taken, nil
+}
+
 // Login authenticates a user
-func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
-	// Find user by email
-	var user model.User
-	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrInvalidCredentials
-		}
+func (s *AuthService) Login(req *LoginRequest, fingerprint string) (*AuthResponse, error) {
+	identifier := strings.TrimSpace(req.Identifier)
+	if identifier == "" {
+		identifier = req.Email
+	}
+	if identifier == "" {
+		return nil, ErrIdentifierRequired
+	}
+
+	// Find user by email or phone
+	var users []model.User
+	if err := s.db.Where("LOWER(email) = ? OR phone = ?", normalizeEmail(identifier), identifier).Limit(2).Find(&users).Error; err != nil {
 		return nil, err
 	}
+	if len(users) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	if len(users) > 1 {
+		// Only a caller holding the password of one of the accounts learns that the
+		// identifier is shared; anyone else gets the same answer as for a wrong password
+		for _, user := range users {
+			if user.CheckPassword(req.Password) {
+				return nil, ErrAmbiguousIdentifier
+			}
+		}
+		return nil, ErrInvalidCredentials
+	}
+	user := users[0]
 
 	// Check if user is active
 	if !user.IsActive {
@@ -117,12 +221,22 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 		return nil, ErrInvalidCredentials
 	}
 
+	// Record the login without touching updated_at
+	now := time.Now()
+	if err := s.db.Model(&user).UpdateColumn("last_login_at", now).Error; err != nil {
+		return nil, err
+	}
+	user.LastLoginAt = &now
+
 	// Generate tokens
 	tokens, err := jwt.GenerateTokenPair(
 		user.ID,
 		user.Email,
 		user.Role,
-		s.config.JWT.Secret,
+		user.TokenVersion,
+		s.bindFingerprint(fingerprint),
+		now,
+		s.keys,
 		s.config.JWT.Expiration,
 		s.config.JWT.RefreshExpiration,
 	)
@@ -131,9 +245,10 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 	}
 
 	return &AuthResponse{
-		User:         user.ToResponse(),
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
+		User:               user.ToResponse(),
+		AccessToken:        tokens.AccessToken,
+		RefreshToken:       tokens.RefreshToken,
+		MustChangePassword: user.MustChangePassword,
 	}, nil
 }
 
@@ -149,10 +264,56 @@ func (s *AuthService) GetUserByID(userID uint) (*model.User, error) {
 	return &user, nil
 }
 
+// GetMe returns the user with role permissions and the current token expiry
+func (s *AuthService) GetMe(userID uint, tokenExpiresAt *time.Time) (*MeResponse, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MeResponse{
+		UserResponse:   user.ToResponse(),
+		Permissions:    user.Permissions(),
+		TokenExpiresAt: tokenExpiresAt,
+	}, nil
+}
+
+// ValidateTokenVersion ensures the token's user still exists, is active and
+// the token was not revoked by a token version bump. A valid token of a user who
+// must change their password returns ErrPasswordChangeRequired.
+func (s *AuthService) ValidateTokenVersion(userID uint, tokenVersion int) error {
+	var user model.User
+	if err := s.db.Select("id", "is_active", "token_version", "must_change_password").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if !user.IsActive {
+		return ErrUserInactive
+	}
+
+	if user.TokenVersion != tokenVersion {
+		return ErrTokenRevoked
+	}
+
+	if user.MustChangePassword {
+		return ErrPasswordChangeRequired
+	}
+
+	return nil
+}
+
+// ValidateToken validates and parses a token issued by this service
+func (s *AuthService) ValidateToken(token string) (*jwt.Claims, error) {
+	return jwt.ValidateToken(token, s.keys)
+}
+
 // RefreshToken generates new access token from refresh token
-func (s *AuthService) RefreshToken(refreshToken string) (*jwt.TokenPair, error) {
+func (s *AuthService) RefreshToken(refreshToken, fingerprint string) (*jwt.TokenPair, error) {
 	// Validate refresh token
-	claims, err := jwt.ValidateToken(refreshToken, s.config.JWT.Secret)
+	claims, err := s.ValidateToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
@@ -167,13 +328,83 @@ func (s *AuthService) RefreshToken(refreshToken string) (*jwt.TokenPair, error)
 		return nil, ErrUserInactive
 	}
 
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, ErrTokenRevoked
+	}
+
+	if err := s.VerifyFingerprint(claims.Fingerprint, fingerprint); err != nil {
+		return nil, err
+	}
+
+	// Tokens issued before auth_time existed count from their own issue time
+	var authTime time.Time
+	if claims.AuthTime != nil {
+		authTime = claims.AuthTime.Time
+	} else if claims.IssuedAt != nil {
+		authTime = claims.IssuedAt.Time
+	}
+
+	// Refreshing never extends a session beyond its absolute lifetime
+	accessExp, refreshExp := s.config.JWT.Expiration, s.config.JWT.RefreshExpiration
+	if maxLifetime := s.config.JWT.SessionMaxLifetime; maxLifetime > 0 {
+		remaining := time.Until(authTime.Add(maxLifetime))
+		if remaining <= 0 {
+			return nil, ErrSessionExpired
+		}
+		accessExp, refreshExp = min(accessExp, remaining), min(refreshExp, remaining)
+	}
+
 	// Generate new token pair
 	return jwt.GenerateTokenPair(
 		user.ID,
 		user.Email,
 		user.Role,
-		s.config.JWT.Secret,
-		s.config.JWT.Expiration,
-		s.config.JWT.RefreshExpiration,
+		user.TokenVersion,
+		s.bindFingerprint(fingerprint),
+		authTime,
+		s.keys,
+		accessExp,
+		refreshExp,
 	)
 }
+
+// VerifyFingerprint rejects a token bound to a device other than the one presenting it.
+// Tokens issued without a fingerprint, or while device binding is disabled, always pass.
+func (s *AuthService) VerifyFingerprint(tokenFingerprint, requestFingerprint string) error {
+	if !s.config.Auth.DeviceBindingEnabled || tokenFingerprint == "" {
+		return nil
+	}
+	if tokenFingerprint != requestFingerprint {
+		return ErrDeviceMismatch
+	}
+	return nil
+}
+
+// TokenExpiryHeaderEnabled reports whether authenticated responses carry the
+// remaining token lifetime in X-Token-Expires-In
+func (s *AuthService) TokenExpiryHeaderEnabled() bool {
+	return s.config.Auth.TokenExpiryHeader
+}
+
+// validateRequiredRegistrationFields reports the first field listed in required
+// (AUTH_REGISTRATION_REQUIRED_FIELDS) that the registration request leaves empty
+func validateRequiredRegistrationFields(req *RegisterRequest, required []string) error {
+	values := map[string]string{
+		"phone":       req.Phone,
+		"employee_id": req.EmployeeID,
+	}
+	for _, field := range required {
+		if strings.TrimSpace(values[field]) == "" {
+			return &FieldError{Field: field, Message: "is required"}
+		}
+	}
+	return nil
+}
+
+// bindFingerprint returns the fingerprint to embed in new tokens, empty when device binding is disabled
+func (s *AuthService) bindFingerprint(fingerprint string) string {
+	if !s.config.Auth.DeviceBindingEnabled {
+		return ""
+	}
+	return fingerprint
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/model"
+	"github.com/attendance/backend/pkg/jwt"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestValidateRequiredRegistrationFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       RegisterRequest
+		required  []string
+		wantField string
+	}{
+		{"nothing required", RegisterRequest{}, nil, ""},
+		{"phone required and given", RegisterRequest{Phone: "+628123456789"}, []string{"phone"}, ""},
+		{"phone required and missing", RegisterRequest{EmployeeID: "E-1"}, []string{"phone"}, "phone"},
+		{"phone required and blank", RegisterRequest{Phone: "   "}, []string{"phone"}, "phone"},
+		{"phone optional and missing", RegisterRequest{EmployeeID: "E-1"}, []string{"employee_id"}, ""},
+		{"first missing field is reported", RegisterRequest{}, []string{"employee_id", "phone"}, "employee_id"},
+		{"both required and given", RegisterRequest{Phone: "+628123456789", EmployeeID: "E-1"}, []string{"phone", "employee_id"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRequiredRegistrationFields(&tt.req, tt.required)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("validateRequiredRegistrationFields() error = %v, want nil", err)
+				}
+				return
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+				t.Errorf("validateRequiredRegistrationFields() error = %v, want field error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsMissingRequiredField(t *testing.T) {
+	db, _ := newMockDB(t)
+	cfg := &config.Config{Auth: config.AuthConfig{RegistrationEnabled: true, RegistrationRequired: []string{"phone"}}}
+	svc := NewAuthService(db, cfg, nil, nil)
+
+	// Rejected before any lookup, so no queries are expected
+	_, err := svc.Register(&RegisterRequest{Email: "budi@example.com", Password: "secret1", FullName: "Budi"}, "")
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "phone" {
+		t.Errorf("Register() error = %v, want field error on phone", err)
+	}
+}
+
+func TestIsEmailAvailable(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		count     int
+		want      bool
+		wantError error
+	}{
+		{"unused email", true, 0, true, nil},
+		{"registered email", true, 1, false, nil},
+		{"registration closed", false, 0, false, ErrRegistrationClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewAuthService(db, &config.Config{Auth: config.AuthConfig{RegistrationEnabled: tt.enabled}}, nil, nil)
+
+			if tt.enabled {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE LOWER\(email\) = \$1 AND id != \$2`).
+					WithArgs("budi@example.com", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+			}
+
+			got, err := svc.IsEmailAvailable("Budi@Example.com")
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("IsEmailAvailable() error = %v, want %v", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("IsEmailAvailable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMe(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewAuthService(db, &config.Config{}, nil, nil)
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role", "is_active"}).AddRow(7, "budi@example.com", "admin", true))
+
+	expiresAt := time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC)
+	me, err := svc.GetMe(7, &expiresAt)
+	if err != nil {
+		t.Fatalf("GetMe() error = %v", err)
+	}
+	if me.Role != "admin" || len(me.Permissions) != len(model.RolePermissions["admin"]) {
+		t.Errorf("GetMe() role %q permissions %v, want admin permissions", me.Role, me.Permissions)
+	}
+	if me.TokenExpiresAt == nil || !me.TokenExpiresAt.Equal(expiresAt) {
+		t.Errorf("TokenExpiresAt = %v, want %v", me.TokenExpiresAt, expiresAt)
+	}
+}
+
+func TestLoginByIdentifier(t *testing.T) {
+	var hashed model.User
+	if err := hashed.HashPassword("secret1"); err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	columns := []string{"id", "email", "phone", "password_hash", "role", "is_active"}
+
+	tests := []struct {
+		name       string
+		req        LoginRequest
+		lookup     string
+		users      [][]driver.Value
+		wantErr    error
+		wantUserID uint
+	}{
+		{"by email", LoginRequest{Identifier: "budi@example.com", Password: "secret1"}, "budi@example.com",
+			[][]driver.Value{{7, "budi@example.com", "0812", hashed.PasswordHash, "user", true}}, nil, 7},
+		{"by phone", LoginRequest{Identifier: " 0812 ", Password: "secret1"}, "0812",
+			[][]driver.Value{{7, "budi@example.com", "0812", hashed.PasswordHash, "user", true}}, nil, 7},
+		{"legacy email field", LoginRequest{Email: "budi@example.com", Password: "secret1"}, "budi@example.com",
+			[][]driver.Value{{7, "budi@example.com", "0812", hashed.PasswordHash, "user", true}}, nil, 7},
+		{"missing identifier", LoginRequest{Password: "secret1"}, "", nil, ErrIdentifierRequired, 0},
+		{"unknown identifier", LoginRequest{Identifier: "0899", Password: "secret1"}, "0899", nil, ErrInvalidCredentials, 0},
+		{"phone shared with another account's email", LoginRequest{Identifier: "0812", Password: "secret1"}, "0812",
+			[][]driver.Value{{7, "budi@example.com", "0812", hashed.PasswordHash, "user", true}, {8, "0812", "", hashed.PasswordHash, "user", true}},
+			ErrAmbiguousIdentifier, 0},
+		{"shared identifier with a wrong password", LoginRequest{Identifier: "0812", Password: "secret2"}, "0812",
+			[][]driver.Value{{7, "budi@example.com", "0812", hashed.PasswordHash, "user", true}, {8, "0812", "", hashed.PasswordHash, "user", true}},
+			ErrInvalidCredentials, 0},
+		{"inactive user", LoginRequest{Identifier: "0812", Password: "secret1"}, "0812",
+			[][]driver.Value{{7, "budi@example.com", "0812", hashed.PasswordHash, "user", false}}, ErrUserInactive, 0},
+		{"wrong password", LoginRequest{Identifier: "0812", Password: "secret2"}, "0812",
+			[][]driver.Value{{7, "budi@example.com", "0812", hashed.PasswordHash, "user", true}}, ErrInvalidCredentials, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{JWT: config.JWTConfig{Expiration: time.Hour, RefreshExpiration: 24 * time.Hour}}
+			svc := NewAuthService(db, cfg, jwt.NewHMACKeys("test-secret"), nil)
+
+			if tt.lookup != "" {
+				rows := sqlmock.NewRows(columns)
+				for _, user := range tt.users {
+					rows.AddRow(user...)
+				}
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE LOWER\(email\) = \$1 OR phone = \$2 LIMIT \$3`).
+					WithArgs(tt.lookup, tt.lookup, 2).
+					WillReturnRows(rows)
+			}
+			if tt.wantUserID != 0 {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "users" SET "last_login_at"=\$1 WHERE "id" = \$2`).
+					WithArgs(sqlmock.AnyArg(), tt.wantUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			resp, err := svc.Login(&tt.req, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantUserID != 0 && (resp.User.ID != tt.wantUserID || resp.AccessToken == "") {
+				t.Errorf("Login() user %d token %q, want user %d with a token", resp.User.ID, resp.AccessToken, tt.wantUserID)
+			}
+		})
+	}
+}
+
+func TestLoginMustChangePassword(t *testing.T) {
+	var hashed model.User
+	if err := hashed.HashPassword("secret1"); err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		mustChange bool
+	}{
+		{"regular login", false},
+		{"after a password reset", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{JWT: config.JWTConfig{Expiration: time.Hour, RefreshExpiration: 24 * time.Hour}}
+			svc := NewAuthService(db, cfg, jwt.NewHMACKeys("test-secret"), nil)
+
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE LOWER\(email\) = \$1 OR phone = \$2 LIMIT \$3`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "email", "password_hash", "role", "is_active", "must_change_password"}).
+					AddRow(7, "budi@example.com", hashed.PasswordHash, "user", true, tt.mustChange))
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "users" SET "last_login_at"=\$1 WHERE "id" = \$2`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			resp, err := svc.Login(&LoginRequest{Identifier: "budi@example.com", Password: "secret1"}, "")
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			// Tokens are still issued so the user can call the change endpoint
+			if resp.MustChangePassword != tt.mustChange || resp.AccessToken == "" {
+				t.Errorf("Login() must_change_password = %v with token %q, want %v with a token", resp.MustChangePassword, resp.AccessToken, tt.mustChange)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsTakenPhone(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewAuthService(db, &config.Config{Auth: config.AuthConfig{RegistrationEnabled: true}}, nil, nil)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE LOWER\(email\) = \$1 AND id != \$2`).
+		WithArgs("budi@example.com", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE phone = \$1 AND id != \$2`).
+		WithArgs("0812", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	_, err := svc.Register(&RegisterRequest{Email: "budi@example.com", Password: "secret1", FullName: "Budi", Phone: " 0812 "}, "")
+	if !errors.Is(err, ErrPhoneAlreadyExists) {
+		t.Errorf("Register() error = %v, want %v", err, ErrPhoneAlreadyExists)
+	}
+}
+
+func TestRegisterEmployeeIDTakenConcurrently(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewAuthService(db, &config.Config{Auth: config.AuthConfig{RegistrationEnabled: true}}, nil, nil)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE LOWER\(email\) = \$1 AND id != \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE employee_id = \$1 AND id != \$2`).
+		WithArgs("EMP-7", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	// Another registration takes the employee ID between the check and the insert
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users"`).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_users_employee_id"})
+	mock.ExpectRollback()
+
+	_, err := svc.Register(&RegisterRequest{Email: "budi@example.com", Password: "secret1", FullName: "Budi", EmployeeID: "EMP-7"}, "")
+	if !errors.Is(err, ErrEmployeeIDExists) {
+		t.Errorf("Register() error = %v, want %v", err, ErrEmployeeIDExists)
+	}
+}
+
+func TestRegisterRejectsEmailInOtherCase(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewAuthService(db, &config.Config{Auth: config.AuthConfig{RegistrationEnabled: true}}, nil, nil)
+
+	// The lookup uses the normalized address, so a differently-cased duplicate is caught
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE LOWER\(email\) = \$1 AND id != \$2`).
+		WithArgs("budi@example.com", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	_, err := svc.Register(&RegisterRequest{Email: " Budi@Example.com ", Password: "secret1", FullName: "Budi"}, "")
+	if !errors.Is(err, ErrEmailAlreadyExists) {
+		t.Errorf("Register() error = %v, want %v", err, ErrEmailAlreadyExists)
+	}
+}
+
+func TestRegisterWhenClosed(t *testing.T) {
+	// Rejected before any lookup, so admin-created accounts are the only way in
+	db, _ := newMockDB(t)
+	svc := NewAuthService(db, &config.Config{Auth: config.AuthConfig{RegistrationEnabled: false}}, nil, nil)
+
+	_, err := svc.Register(&RegisterRequest{Email: "budi@example.com", Password: "secret1", FullName: "Budi"}, "")
+	if !errors.Is(err, ErrRegistrationClosed) {
+		t.Errorf("Register() error = %v, want %v", err, ErrRegistrationClosed)
+	}
+}
+
+func TestVerifyFingerprint(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		token   string
+		request string
+		wantErr error
+	}{
+		{"binding disabled", false, "abc", "def", nil},
+		{"token without fingerprint", true, "", "def", nil},
+		{"same device", true, "abc", "abc", nil},
+		{"different device", true, "abc", "def", ErrDeviceMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewAuthService(nil, &config.Config{Auth: config.AuthConfig{DeviceBindingEnabled: tt.enabled}}, nil, nil)
+			if err := svc.VerifyFingerprint(tt.token, tt.request); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyFingerprint() error = %v, want %v", err, tt.wantErr)
+			}
+			if bound := svc.bindFingerprint("abc"); (bound != "") != tt.enabled {
+				t.Errorf("bindFingerprint() = %q with binding enabled %v", bound, tt.enabled)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenSessionLifetime(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxLifetime time.Duration
+		loggedInAgo time.Duration
+		wantErr     error
+		// wantRefreshTTL is the longest the new refresh token may live
+		wantRefreshTTL time.Duration
+	}{
+		{"disabled", 0, 30 * 24 * time.Hour, nil, 24 * time.Hour},
+		{"well within the lifetime", 7 * 24 * time.Hour, time.Hour, nil, 24 * time.Hour},
+		{"capped at the lifetime", 7 * 24 * time.Hour, 7*24*time.Hour - 2*time.Hour, nil, 2 * time.Hour},
+		{"beyond the lifetime", 7 * 24 * time.Hour, 8 * 24 * time.Hour, ErrSessionExpired, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			keys := jwt.NewHMACKeys("test-secret")
+			cfg := &config.Config{JWT: config.JWTConfig{
+				Expiration:         time.Hour,
+				RefreshExpiration:  24 * time.Hour,
+				SessionMaxLifetime: tt.maxLifetime,
+			}}
+			svc := NewAuthService(db, cfg, keys, nil)
+
+			authTime := time.Now().Add(-tt.loggedInAgo).Truncate(time.Second)
+			refreshToken, err := jwt.GenerateToken(7, "budi@example.com", "user", 0, "", authTime, keys, 24*time.Hour)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+				WithArgs(7, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role", "is_active", "token_version"}).
+					AddRow(7, "budi@example.com", "user", true, 0))
+
+			pair, err := svc.RefreshToken(refreshToken, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			claims, err := jwt.ValidateToken(pair.RefreshToken, keys)
+			if err != nil {
+				t.Fatalf("ValidateToken() error = %v", err)
+			}
+			// Refreshing keeps the original login time
+			if claims.AuthTime == nil || !claims.AuthTime.Time.Equal(authTime) {
+				t.Errorf("auth_time = %v, want %v", claims.AuthTime, authTime)
+			}
+			if ttl := time.Until(claims.ExpiresAt.Time); ttl > tt.wantRefreshTTL || ttl < tt.wantRefreshTTL-time.Minute {
+				t.Errorf("refresh token expires in %v, want about %v", ttl, tt.wantRefreshTTL)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/attendance/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type DepartmentService struct {
+	db *gorm.DB
+}
+
+func NewDepartmentService(db *gorm.DB) *DepartmentService {
+	return &DepartmentService{db: db}
+}
+
+// CreateDepartmentRequest represents create department request
+type CreateDepartmentRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateDepartment creates a new department
+func (s *DepartmentService) CreateDepartment(req *CreateDepartmentRequest) (*model.Department, error) {
+	name := strings.TrimSpace(req.Name)
+
+	var count int64
+	if err := s.db.Model(&model.Department{}).Where("LOWER(name) = LOWER(?)", name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("department already exists")
+	}
+
+	department := model.Department{Name: name}
+	if err := s.db.Create(&department).Error; err != nil {
+		return nil, err
+	}
+
+	return &department, nil
+}
+
+// GetDepartmentByID retrieves department by ID
+func (s *DepartmentService) GetDepartmentByID(id uint) (*model.Department, error) {
+	return getDepartmentByID(s.db, id)
+}
+
+// GetAllDepartments retrieves all departments ordered by name
+func (s *DepartmentService) GetAllDepartments() ([]model.Department, error) {
+	var departments []model.Department
+	if err := s.db.Order("name ASC").Find(&departments).Error; err != nil {
+		return nil, err
+	}
+	return departments, nil
+}
+
+// getDepartmentByID retrieves a department, reporting a missing one as "department not found"
+func getDepartmentByID(db *gorm.DB, id uint) (*model.Department, error) {
+	var department model.Department
+	if err := db.First(&department, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("department not found")
+		}
+		return nil, err
+	}
+	return &department, nil
+}
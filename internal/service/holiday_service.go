@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/attendance/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type HolidayService struct {
+	db *gorm.DB
+}
+
+func NewHolidayService(db *gorm.DB) *HolidayService {
+	return &HolidayService{db: db}
+}
+
+// CreateHolidayRequest represents create holiday request
+type CreateHolidayRequest struct {
+	Date       string `json:"date" binding:"required"` // "2025-12-25"
+	Name       string `json:"name" binding:"required"`
+	LocationID *uint  `json:"location_id"` // omit for a holiday at every location
+}
+
+// CreateHoliday creates a holiday. A date holds one holiday for every location and one
+// per location.
+func (s *HolidayService) CreateHoliday(req *CreateHolidayRequest) (*model.Holiday, error) {
+	date, err := parseDate(req.Date)
+	if err != nil {
+		return nil, &FieldError{Field: "date", Message: "invalid date, expected format YYYY-MM-DD"}
+	}
+	if req.LocationID != nil {
+		if err := s.db.First(&model.AttendanceLocation{}, *req.LocationID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &FieldError{Field: "location_id", Message: "location not found"}
+			}
+			return nil, err
+		}
+	}
+
+	var count int64
+	query := s.db.Model(&model.Holiday{}).Where("date = ?", req.Date)
+	if req.LocationID != nil {
+		query = query.Where("location_id = ?", *req.LocationID)
+	} else {
+		query = query.Where("location_id IS NULL")
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("holiday already exists")
+	}
+
+	holiday := model.Holiday{
+		Date:       date,
+		Name:       strings.TrimSpace(req.Name),
+		LocationID: req.LocationID,
+	}
+	if err := s.db.Create(&holiday).Error; err != nil {
+		return nil, err
+	}
+
+	return &holiday, nil
+}
+
+// GetHolidays retrieves holidays ordered by date, limited to a year when year is set
+// and to the ones observed at a location when locationID is set
+func (s *HolidayService) GetHolidays(year int, locationID *uint) ([]model.Holiday, error) {
+	query := s.db.Model(&model.Holiday{})
+	if year > 0 {
+		query = query.Where("EXTRACT(YEAR FROM date) = ?", year)
+	}
+	if locationID != nil {
+		query = query.Where("location_id IS NULL OR location_id = ?", *locationID)
+	}
+
+	var holidays []model.Holiday
+	if err := query.Order("date ASC").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// DeleteHoliday deletes a holiday
+func (s *HolidayService) DeleteHoliday(id uint) error {
+	result := s.db.Delete(&model.Holiday{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("holiday not found")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateHoliday(t *testing.T) {
+	location := uint(3)
+
+	tests := []struct {
+		name       string
+		locationID *uint
+		existing   int
+		wantErr    string
+	}{
+		{"every location", nil, 0, ""},
+		{"one location", &location, 0, ""},
+		{"date already has a holiday", nil, 1, "holiday already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewHolidayService(db)
+
+			if tt.locationID != nil {
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "holidays" WHERE date = \$1 AND location_id = \$2`).
+					WithArgs("2026-12-25", 3).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.existing))
+			} else {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "holidays" WHERE date = \$1 AND location_id IS NULL`).
+					WithArgs("2026-12-25").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.existing))
+			}
+			if tt.wantErr == "" {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "holidays"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectCommit()
+			}
+
+			holiday, err := svc.CreateHoliday(&CreateHolidayRequest{Date: "2026-12-25", Name: " Christmas ", LocationID: tt.locationID})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("CreateHoliday() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateHoliday() error = %v", err)
+			}
+			if holiday.ID != 2 || holiday.Name != "Christmas" {
+				t.Errorf("CreateHoliday() = %+v", holiday)
+			}
+		})
+	}
+}
+
+func TestCreateHolidayInvalidDate(t *testing.T) {
+	_, err := NewHolidayService(nil).CreateHoliday(&CreateHolidayRequest{Date: "25-12-2026", Name: "Christmas"})
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "date" {
+		t.Errorf("CreateHoliday() error = %v, want field error on date", err)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/attendance/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type LeaveService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+func NewLeaveService(db *gorm.DB, auditService *AuditService) *LeaveService {
+	return &LeaveService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// CreateLeaveRequest represents the request to grant a user leave (Admin)
+type CreateLeaveRequest struct {
+	UserID    uint   `json:"user_id" binding:"required"`
+	Type      string `json:"type" binding:"required"`       // one of model.LeaveTypes
+	StartDate string `json:"start_date" binding:"required"` // "2025-01-01"
+	EndDate   string `json:"end_date" binding:"required"`   // inclusive
+	Reason    string `json:"reason"`
+}
+
+// CreateLeave grants a user leave. Leave of one user cannot overlap.
+func (s *LeaveService) CreateLeave(req *CreateLeaveRequest, actorID uint) (*model.Leave, error) {
+	if !model.IsValidLeaveType(req.Type) {
+		return nil, &FieldError{Field: "type", Message: fmt.Sprintf("must be one of %s", strings.Join(model.LeaveTypes, ", "))}
+	}
+	startDate, err := parseDate(req.StartDate)
+	if err != nil {
+		return nil, &FieldError{Field: "start_date", Message: "invalid date, expected format YYYY-MM-DD"}
+	}
+	endDate, err := parseDate(req.EndDate)
+	if err != nil {
+		return nil, &FieldError{Field: "end_date", Message: "invalid date, expected format YYYY-MM-DD"}
+	}
+	if endDate.Before(startDate) {
+		return nil, &FieldError{Field: "end_date", Message: "must not be before start_date"}
+	}
+
+	if err := s.db.First(&model.User{}, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	leave := model.Leave{
+		UserID:    req.UserID,
+		Type:      req.Type,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedBy: &actorID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the user serializes concurrent grants against the overlap check
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", req.UserID).Error; err != nil {
+			return err
+		}
+
+		var overlapping int64
+		if err := tx.Model(&model.Leave{}).
+			Where("user_id = ? AND start_date <= ? AND end_date >= ?", req.UserID, req.EndDate, req.StartDate).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return errors.New("leave overlaps existing leave")
+		}
+
+		if err := tx.Create(&leave).Error; err != nil {
+			return err
+		}
+
+		return s.auditService.WithTx(tx).Log(actorID, "leave.create", "user", req.UserID, map[string]interface{}{
+			"leave_id":   leave.ID,
+			"type":       leave.Type,
+			"start_date": req.StartDate,
+			"end_date":   req.EndDate,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &leave, nil
+}
+
+// GetLeaves retrieves leave, latest first (Admin). Date filters select leave overlapping
+// the range.
+func (s *LeaveService) GetLeaves(filters map[string]interface{}, limit, offset int) ([]model.Leave, int64, error) {
+	query := s.db.Model(&model.Leave{})
+	if userID, ok := filters["user_id"].(uint); ok && userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if leaveType, ok := filters["type"].(string); ok && leaveType != "" {
+		query = query.Where("type = ?", leaveType)
+	}
+	if dateFrom, ok := filters["date_from"].(string); ok && dateFrom != "" {
+		query = query.Where("end_date >= ?", dateFrom)
+	}
+	if dateTo, ok := filters["date_to"].(string); ok && dateTo != "" {
+		query = query.Where("start_date <= ?", dateTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var leaves []model.Leave
+	if err := query.Order("start_date DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&leaves).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return leaves, total, nil
+}
+
+// DeleteLeave withdraws leave (Admin). Days it covered count as work days again, and
+// past ones without a record as absences.
+func (s *LeaveService) DeleteLeave(id, actorID uint) error {
+	var leave model.Leave
+	if err := s.db.First(&leave, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("leave not found")
+		}
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&leave).Error; err != nil {
+			return err
+		}
+
+		return s.auditService.WithTx(tx).Log(actorID, "leave.delete", "user", leave.UserID, map[string]interface{}{
+			"leave_id":   leave.ID,
+			"type":       leave.Type,
+			"start_date": leave.StartDate.Format("2006-01-02"),
+			"end_date":   leave.EndDate.Format("2006-01-02"),
+		})
+	})
+}
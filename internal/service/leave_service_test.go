@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateLeaveValidation(t *testing.T) {
+	svc := NewLeaveService(nil, nil)
+
+	tests := []struct {
+		name      string
+		req       CreateLeaveRequest
+		wantField string
+	}{
+		{"unknown type", CreateLeaveRequest{UserID: 7, Type: "vacation", StartDate: "2026-03-02", EndDate: "2026-03-03"}, "type"},
+		{"invalid start date", CreateLeaveRequest{UserID: 7, Type: "annual", StartDate: "02/03/2026", EndDate: "2026-03-03"}, "start_date"},
+		{"invalid end date", CreateLeaveRequest{UserID: 7, Type: "annual", StartDate: "2026-03-02", EndDate: "2026-02-30"}, "end_date"},
+		{"end before start", CreateLeaveRequest{UserID: 7, Type: "sick", StartDate: "2026-03-03", EndDate: "2026-03-02"}, "end_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateLeave(&tt.req, 1)
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+				t.Errorf("CreateLeave() error = %v, want field error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestCreateLeave(t *testing.T) {
+	tests := []struct {
+		name        string
+		overlapping int
+		wantErr     string
+	}{
+		{"granted", 0, ""},
+		{"overlaps existing leave", 1, "leave overlaps existing leave"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewLeaveService(db, NewAuditService(db))
+
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).WithArgs(7, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			mock.ExpectBegin()
+			mock.ExpectExec(`SELECT id FROM users WHERE id = \$1 FOR UPDATE`).WithArgs(7).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "leaves" WHERE user_id = \$1 AND start_date <= \$2 AND end_date >= \$3`).
+				WithArgs(7, "2026-03-06", "2026-03-02").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.overlapping))
+			if tt.wantErr != "" {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectQuery(`INSERT INTO "leaves"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			leave, err := svc.CreateLeave(&CreateLeaveRequest{UserID: 7, Type: "annual", StartDate: "2026-03-02", EndDate: "2026-03-06", Reason: " trip "}, 1)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("CreateLeave() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateLeave() error = %v", err)
+			}
+			if leave.ID != 4 || leave.Reason != "trip" || leave.EndDate.Format("2006-01-02") != "2026-03-06" {
+				t.Errorf("CreateLeave() = %+v", leave)
+			}
+		})
+	}
+}
@@ -1,62 +1,190 @@
 package service
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/attendance/backend/internal/config"
 	"github.com/attendance/backend/internal/model"
 	"github.com/attendance/backend/internal/utils"
+	"github.com/attendance/backend/pkg/timezone"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrLocationNotFound      = errors.New("location not found")
+	ErrLocationInactive      = errors.New("location is not active")
+	ErrLocationTokenRequired = errors.New("this location requires scanning its QR code to check in")
+	ErrLocationTokenInvalid  = errors.New("invalid location token")
+	ErrLocationTokenExpired  = errors.New("location token has expired, scan the QR code again")
+	ErrQRCheckInDisabled     = errors.New("QR check-in is not enabled")
+)
+
+// locationCreateLock is the advisory lock key that serializes location creation while
+// the duplicate check is enabled, so two concurrent requests cannot both pass it
+const locationCreateLock = 7301
+
+// LocationConflictError is returned when a new location is closer than
+// LOCATION_DUPLICATE_MIN_DISTANCE to an existing active one
+type LocationConflictError struct {
+	Location model.AttendanceLocation
+	Distance float64 // in meters
+}
+
+func (e *LocationConflictError) Error() string {
+	return fmt.Sprintf("location is %.0f meters from active location %q", e.Distance, e.Location.Name)
+}
+
 type LocationService struct {
-	db *gorm.DB
+	db               *gorm.DB
+	config           *config.Config
+	timezoneResolver timezone.Resolver // nil leaves omitted timezones empty
+
+	// Active locations served to the nearby search, refreshed after LOCATION_NEARBY_CACHE_TTL
+	cacheMu        sync.Mutex
+	activeCache    []model.AttendanceLocation
+	activeCachedAt time.Time
 }
 
-func NewLocationService(db *gorm.DB) *LocationService {
-	return &LocationService{db: db}
+func NewLocationService(db *gorm.DB, cfg *config.Config, timezoneResolver timezone.Resolver) *LocationService {
+	return &LocationService{
+		db:               db,
+		config:           cfg,
+		timezoneResolver: timezoneResolver,
+	}
 }
 
 // CreateLocationRequest represents create location request
 type CreateLocationRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Latitude    float64 `json:"latitude" binding:"required"`
-	Longitude   float64 `json:"longitude" binding:"required"`
-	Radius      int     `json:"radius" binding:"required,min=1"`
+	Name           string  `json:"name" binding:"required"`
+	Description    string  `json:"description"`
+	Latitude       float64 `json:"latitude" binding:"required"`
+	Longitude      float64 `json:"longitude" binding:"required"`
+	Radius         int     `json:"radius" binding:"omitempty,min=1"`                    // defaults to LOCATION_DEFAULT_RADIUS
+	CheckOutRadius *int    `json:"check_out_radius" binding:"omitempty,min=1"`          // defaults to radius
+	OperatingDays  []int   `json:"operating_days" binding:"omitempty,dive,min=1,max=7"` // empty means every day
+	Timezone       string  `json:"timezone"`                                            // e.g. "Asia/Jakarta", resolved from the coordinates when enabled
+	RegionID       *uint   `json:"region_id"`
+	QRRequired     bool    `json:"qr_required"`
+
+	CheckInWindowStart string `json:"check_in_window_start"` // "05:00:00", set together with the end
+	CheckInWindowEnd   string `json:"check_in_window_end"`   // earlier than the start for windows crossing midnight
 }
 
 // UpdateLocationRequest represents update location request
 type UpdateLocationRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Latitude    float64 `json:"latitude"`
-	Longitude   float64 `json:"longitude"`
-	Radius      int     `json:"radius" binding:"min=1"`
-	IsActive    *bool   `json:"is_active"`
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	Radius         int     `json:"radius" binding:"omitempty,min=1"`
+	CheckOutRadius *int    `json:"check_out_radius" binding:"omitempty,min=0"` // 0 falls back to radius
+	IsActive       *bool   `json:"is_active"`
+	OperatingDays  []int   `json:"operating_days" binding:"omitempty,dive,min=1,max=7"`
+	Timezone       *string `json:"timezone"`
+	RegionID       *uint   `json:"region_id"` // 0 removes the location from its region
+	QRRequired     *bool   `json:"qr_required"`
+
+	CheckInWindowStart *string `json:"check_in_window_start"` // "" together with an empty end removes the window
+	CheckInWindowEnd   *string `json:"check_in_window_end"`
+}
+
+// SuspendLocationRequest represents suspend location request
+type SuspendLocationRequest struct {
+	Reason         string `json:"reason"`
+	SuspendedUntil string `json:"suspended_until"` // "2025-01-31" (optional, inclusive)
 }
 
 // GetNearbyLocationsRequest represents nearby locations request
 type GetNearbyLocationsRequest struct {
-	Latitude  float64 `form:"latitude" binding:"required"`
-	Longitude float64 `form:"longitude" binding:"required"`
-	RadiusKm  float64 `form:"radius_km" binding:"required,min=0.1,max=50"` // max 50km
+	Latitude       float64 `form:"latitude" binding:"required"`
+	Longitude      float64 `form:"longitude" binding:"required"`
+	RadiusKm       float64 `form:"radius_km" binding:"required,min=0.1"` // capped by LOCATION_NEARBY_MAX_RADIUS_KM
+	Fresh          bool    `form:"fresh"`                                // bypass the location cache
+	IncludeNearest bool    `form:"include_nearest"`                      // return the closest location when none is within radius_km
+}
+
+// NearbyLocation is a location with its distance from the user
+type NearbyLocation struct {
+	model.AttendanceLocation
+	Distance      float64 `json:"distance"`       // in meters
+	OutsideSearch bool    `json:"outside_search"` // beyond radius_km, returned by include_nearest
 }
 
 // CreateLocation creates a new attendance location
 func (s *LocationService) CreateLocation(req *CreateLocationRequest, createdBy uint) (*model.AttendanceLocation, error) {
+	if err := validateTimezone(req.Timezone); err != nil {
+		return nil, err
+	}
+	if err := validateOperatingDays(req.OperatingDays); err != nil {
+		return nil, err
+	}
+	windowStart, windowEnd, err := checkInWindow(req.CheckInWindowStart, req.CheckInWindowEnd)
+	if err != nil {
+		return nil, err
+	}
+	if req.RegionID != nil {
+		if err := s.validateRegion(*req.RegionID); err != nil {
+			return nil, err
+		}
+	}
+	if req.QRRequired && !s.config.Location.QREnabled {
+		return nil, &FieldError{Field: "qr_required", Message: "QR check-in is not enabled"}
+	}
+
+	radius := req.Radius
+	if radius == 0 {
+		radius = s.config.Location.DefaultRadius
+	}
+
+	timezoneName := req.Timezone
+	if timezoneName == "" {
+		timezoneName = s.resolveTimezone(req.Latitude, req.Longitude)
+	}
+
 	location := model.AttendanceLocation{
-		Name:        req.Name,
-		Description: req.Description,
-		Latitude:    req.Latitude,
-		Longitude:   req.Longitude,
-		Radius:      req.Radius,
-		IsActive:    true,
-		CreatedBy:   &createdBy,
+		Name:               req.Name,
+		Description:        req.Description,
+		Latitude:           req.Latitude,
+		Longitude:          req.Longitude,
+		Radius:             radius,
+		CheckOutRadius:     req.CheckOutRadius,
+		IsActive:           true,
+		OperatingDays:      toInt64Array(req.OperatingDays),
+		Timezone:           timezoneName,
+		RegionID:           req.RegionID,
+		QRRequired:         req.QRRequired,
+		CheckInWindowStart: windowStart,
+		CheckInWindowEnd:   windowEnd,
+		CreatedBy:          &createdBy,
 	}
 
-	if err := s.db.Create(&location).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if s.config.Location.DuplicateCheckEnabled {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", locationCreateLock).Error; err != nil {
+				return err
+			}
+			if err := checkDuplicateLocation(tx, req.Latitude, req.Longitude, s.config.Location.DuplicateMinDistance); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&location).Error
+	})
+	if err != nil {
 		return nil, err
 	}
+	s.invalidateActiveLocations()
 
 	// Load creator info
 	s.db.Preload("Creator").First(&location, location.ID)
@@ -69,7 +197,7 @@ func (s *LocationService) GetLocationByID(id uint) (*model.AttendanceLocation, e
 	var location model.AttendanceLocation
 	if err := s.db.Preload("Creator").First(&location, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("location not found")
+			return nil, ErrLocationNotFound
 		}
 		return nil, err
 	}
@@ -92,26 +220,84 @@ func (s *LocationService) GetAllLocations(isActive *bool) ([]model.AttendanceLoc
 	return locations, nil
 }
 
-// GetNearbyLocations retrieves locations near user's current position
-func (s *LocationService) GetNearbyLocations(req *GetNearbyLocationsRequest) ([]model.AttendanceLocation, error) {
-	var allLocations []model.AttendanceLocation
+// GetNearbyLocations retrieves locations near user's current position, closest first.
+// With IncludeNearest, the single closest location is returned when none is within the radius.
+func (s *LocationService) GetNearbyLocations(req *GetNearbyLocationsRequest) ([]NearbyLocation, error) {
+	if maxRadius := s.config.Location.NearbyMaxRadiusKm; maxRadius > 0 && req.RadiusKm > maxRadius {
+		return nil, &FieldError{Field: "radius_km", Message: fmt.Sprintf("must not exceed %g km", maxRadius)}
+	}
 
-	// Get all active locations
-	if err := s.db.Where("is_active = ?", true).Find(&allLocations).Error; err != nil {
+	// Get all active, non-suspended locations
+	allLocations, err := s.activeLocations(req.Fresh)
+	if err != nil {
 		return nil, err
 	}
 
-	// Filter locations within radius
-	var nearbyLocations []model.AttendanceLocation
+	// Filter locations within radius, remembering the closest one overall
+	nearbyLocations := []NearbyLocation{}
+	var nearest *NearbyLocation
 	for _, loc := range allLocations {
+		location := NearbyLocation{
+			AttendanceLocation: loc,
+			Distance:           utils.CalculateDistance(req.Latitude, req.Longitude, loc.Latitude, loc.Longitude),
+		}
 		if utils.IsWithinRadius(req.Latitude, req.Longitude, loc.Latitude, loc.Longitude, req.RadiusKm) {
-			nearbyLocations = append(nearbyLocations, loc)
+			nearbyLocations = append(nearbyLocations, location)
+		}
+		if nearest == nil || location.Distance < nearest.Distance {
+			nearest = &location
 		}
 	}
 
+	if len(nearbyLocations) == 0 && req.IncludeNearest && nearest != nil {
+		nearest.OutsideSearch = true
+		return []NearbyLocation{*nearest}, nil
+	}
+
+	// Closest first, capped to the configured maximum
+	sort.Slice(nearbyLocations, func(i, j int) bool {
+		return nearbyLocations[i].Distance < nearbyLocations[j].Distance
+	})
+	if maxResults := s.config.Location.NearbyMaxResults; maxResults > 0 && len(nearbyLocations) > maxResults {
+		nearbyLocations = nearbyLocations[:maxResults]
+	}
+
 	return nearbyLocations, nil
 }
 
+// activeLocations returns all active, non-suspended locations, from the cache unless
+// fresh is set or the cache is older than LOCATION_NEARBY_CACHE_TTL. The returned
+// slice is shared and must not be modified.
+func (s *LocationService) activeLocations(fresh bool) ([]model.AttendanceLocation, error) {
+	ttl := s.config.Location.NearbyCacheTTL
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if !fresh && ttl > 0 && s.activeCache != nil && time.Since(s.activeCachedAt) < ttl {
+		return s.activeCache, nil
+	}
+
+	var locations []model.AttendanceLocation
+	if err := s.db.Where("is_active = ? AND suspended_at IS NULL", true).Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 {
+		s.activeCache = locations
+		s.activeCachedAt = time.Now()
+	}
+	return locations, nil
+}
+
+// invalidateActiveLocations drops the cached active locations after a change on this instance.
+// Other instances pick the change up once their cache expires.
+func (s *LocationService) invalidateActiveLocations() {
+	s.cacheMu.Lock()
+	s.activeCache = nil
+	s.cacheMu.Unlock()
+}
+
 // UpdateLocation updates location information
 func (s *LocationService) UpdateLocation(id uint, req *UpdateLocationRequest) (*model.AttendanceLocation, error) {
 	location, err := s.GetLocationByID(id)
@@ -135,17 +321,132 @@ func (s *LocationService) UpdateLocation(id uint, req *UpdateLocationRequest) (*
 	if req.Radius > 0 {
 		location.Radius = req.Radius
 	}
+	if req.CheckOutRadius != nil {
+		if *req.CheckOutRadius == 0 {
+			location.CheckOutRadius = nil
+		} else {
+			location.CheckOutRadius = req.CheckOutRadius
+		}
+	}
 	if req.IsActive != nil {
 		location.IsActive = *req.IsActive
 	}
+	if req.OperatingDays != nil {
+		if err := validateOperatingDays(req.OperatingDays); err != nil {
+			return nil, err
+		}
+		location.OperatingDays = toInt64Array(req.OperatingDays)
+	}
+	if req.Timezone != nil {
+		if err := validateTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+		location.Timezone = *req.Timezone
+	}
+	if req.RegionID != nil {
+		if *req.RegionID == 0 {
+			location.RegionID = nil
+		} else {
+			if err := s.validateRegion(*req.RegionID); err != nil {
+				return nil, err
+			}
+			location.RegionID = req.RegionID
+		}
+	}
+	if req.QRRequired != nil {
+		if *req.QRRequired && !s.config.Location.QREnabled {
+			return nil, &FieldError{Field: "qr_required", Message: "QR check-in is not enabled"}
+		}
+		location.QRRequired = *req.QRRequired
+	}
+	if req.CheckInWindowStart != nil || req.CheckInWindowEnd != nil {
+		start, end := "", ""
+		if location.CheckInWindowStart != nil && location.CheckInWindowEnd != nil {
+			start, end = *location.CheckInWindowStart, *location.CheckInWindowEnd
+		}
+		if req.CheckInWindowStart != nil {
+			start = *req.CheckInWindowStart
+		}
+		if req.CheckInWindowEnd != nil {
+			end = *req.CheckInWindowEnd
+		}
+		windowStart, windowEnd, err := checkInWindow(start, end)
+		if err != nil {
+			return nil, err
+		}
+		location.CheckInWindowStart = windowStart
+		location.CheckInWindowEnd = windowEnd
+	}
 
 	if err := s.db.Save(&location).Error; err != nil {
 		return nil, err
 	}
+	s.invalidateActiveLocations()
 
 	return location, nil
 }
 
+// GenerateCheckInToken issues a token for the location's QR code, valid for
+// LOCATION_QR_TOKEN_TTL from now. Displays are expected to fetch a new one as it expires.
+func (s *LocationService) GenerateCheckInToken(locationID uint, now time.Time) (string, time.Time, error) {
+	if !s.config.Location.QREnabled {
+		return "", time.Time{}, ErrQRCheckInDisabled
+	}
+	if _, err := s.GetLocationByID(locationID); err != nil {
+		return "", time.Time{}, err
+	}
+
+	payload := fmt.Sprintf("%d.%d", locationID, now.Unix())
+	return payload + "." + s.signCheckInToken(payload), now.Add(s.config.Location.QRTokenTTL), nil
+}
+
+// ValidateCheckInToken checks that token was issued for locationID and is still fresh.
+// With QR check-in disabled no token is accepted, so locations requiring one stay closed.
+func (s *LocationService) ValidateCheckInToken(locationID uint, token string, now time.Time) error {
+	if !s.config.Location.QREnabled {
+		return ErrQRCheckInDisabled
+	}
+	if token == "" {
+		return ErrLocationTokenRequired
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrLocationTokenInvalid
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.signCheckInToken(payload))) {
+		return ErrLocationTokenInvalid
+	}
+
+	tokenLocationID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil || uint(tokenLocationID) != locationID {
+		return ErrLocationTokenInvalid
+	}
+	issuedUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ErrLocationTokenInvalid
+	}
+
+	// Tolerate a little clock drift between the node that issued the token and this one
+	issuedAt := time.Unix(issuedUnix, 0)
+	if issuedAt.After(now.Add(30 * time.Second)) {
+		return ErrLocationTokenInvalid
+	}
+	if now.Sub(issuedAt) > s.config.Location.QRTokenTTL {
+		return ErrLocationTokenExpired
+	}
+
+	return nil
+}
+
+// signCheckInToken returns the hex encoded HMAC-SHA256 of payload
+func (s *LocationService) signCheckInToken(payload string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.Location.QRSecret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // DeleteLocation deletes a location
 func (s *LocationService) DeleteLocation(id uint) error {
 	// Check if location exists
@@ -157,26 +458,260 @@ func (s *LocationService) DeleteLocation(id uint) error {
 	if err := s.db.Delete(&model.AttendanceLocation{}, id).Error; err != nil {
 		return err
 	}
+	s.invalidateActiveLocations()
 
 	return nil
 }
 
+// SuspendLocation temporarily takes a location offline without deleting it
+func (s *LocationService) SuspendLocation(id uint, req *SuspendLocationRequest) (*model.AttendanceLocation, error) {
+	location, err := s.GetLocationByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var suspendedUntil *time.Time
+	if req.SuspendedUntil != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.SuspendedUntil, location.TimeLocation())
+		if err != nil {
+			return nil, errors.New("invalid suspended_until date format")
+		}
+		// Suspension lasts through the given day. The TIMESTAMP column keeps the wall
+		// clock as written, so the location's midnight is stored in server time.
+		until := date.AddDate(0, 0, 1).In(time.Local)
+		if !until.After(time.Now()) {
+			return nil, errors.New("suspended_until must not be in the past")
+		}
+		suspendedUntil = &until
+	}
+
+	now := time.Now()
+	location.SuspendedAt = &now
+	location.SuspendedUntil = suspendedUntil
+	location.SuspensionReason = req.Reason
+
+	if err := s.db.Save(location).Error; err != nil {
+		return nil, err
+	}
+	s.invalidateActiveLocations()
+
+	return location, nil
+}
+
+// ActivateLocation lifts a location suspension
+func (s *LocationService) ActivateLocation(id uint) (*model.AttendanceLocation, error) {
+	location, err := s.GetLocationByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	location.SuspendedAt = nil
+	location.SuspendedUntil = nil
+	location.SuspensionReason = ""
+
+	if err := s.db.Save(location).Error; err != nil {
+		return nil, err
+	}
+	s.invalidateActiveLocations()
+
+	return location, nil
+}
+
+// ReactivateExpiredSuspensions lifts suspensions whose end has passed and returns how many were lifted
+func (s *LocationService) ReactivateExpiredSuspensions(now time.Time) (int64, error) {
+	result := s.db.Model(&model.AttendanceLocation{}).
+		Where("suspended_at IS NOT NULL AND suspended_until IS NOT NULL AND suspended_until <= ?", now).
+		Updates(map[string]interface{}{
+			"suspended_at":      nil,
+			"suspended_until":   nil,
+			"suspension_reason": "",
+		})
+	if result.RowsAffected > 0 {
+		s.invalidateActiveLocations()
+	}
+	return result.RowsAffected, result.Error
+}
+
+// StartSuspensionExpiry runs ReactivateExpiredSuspensions every interval until ctx is cancelled
+func (s *LocationService) StartSuspensionExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if reactivated, err := s.ReactivateExpiredSuspensions(time.Now()); err != nil {
+			log.Printf("location suspension job: %v", err)
+		} else if reactivated > 0 {
+			log.Printf("location suspension job: reactivated %d locations", reactivated)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDuplicateLocation returns a LocationConflictError for the nearest active location
+// closer than minDistance meters to the given coordinates
+func checkDuplicateLocation(db *gorm.DB, latitude, longitude, minDistance float64) error {
+	var locations []model.AttendanceLocation
+	if err := db.Where("is_active = ?", true).Find(&locations).Error; err != nil {
+		return err
+	}
+
+	var conflict *LocationConflictError
+	for _, location := range locations {
+		distance := utils.CalculateDistance(latitude, longitude, location.Latitude, location.Longitude)
+		if distance < minDistance && (conflict == nil || distance < conflict.Distance) {
+			conflict = &LocationConflictError{Location: location, Distance: distance}
+		}
+	}
+	if conflict != nil {
+		return conflict
+	}
+	return nil
+}
+
+// validateTimezone ensures tz is empty or a known IANA timezone name
+func validateTimezone(tz string) error {
+	if tz == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return errors.New("invalid timezone")
+	}
+	return nil
+}
+
+// resolveTimezone looks up the timezone for a new location without one. Failures are
+// logged and leave the timezone empty, which means server local time.
+func (s *LocationService) resolveTimezone(latitude, longitude float64) string {
+	if s.timezoneResolver == nil {
+		return ""
+	}
+	tz, err := s.timezoneResolver.Resolve(latitude, longitude)
+	if err == nil {
+		err = validateTimezone(tz)
+	}
+	if err != nil {
+		log.Printf("location: no timezone resolved for %f,%f: %v", latitude, longitude, err)
+		return ""
+	}
+	return tz
+}
+
+// validateRegion ensures a location is assigned to an existing region
+func (s *LocationService) validateRegion(regionID uint) error {
+	if _, err := getRegionByID(s.db, regionID); err != nil {
+		if err.Error() == "region not found" {
+			return &FieldError{Field: "region_id", Message: "region not found"}
+		}
+		return err
+	}
+	return nil
+}
+
+// validateOperatingDays rejects days outside 1-7 and days listed more than once
+func validateOperatingDays(days []int) error {
+	seen := make(map[int]bool, len(days))
+	for _, day := range days {
+		if day < 1 || day > 7 {
+			return &FieldError{Field: "operating_days", Message: fmt.Sprintf("day %d is out of range, expected 1 (Monday) to 7 (Sunday)", day)}
+		}
+		if seen[day] {
+			return &FieldError{Field: "operating_days", Message: fmt.Sprintf("day %d is listed more than once", day)}
+		}
+		seen[day] = true
+	}
+	return nil
+}
+
+// checkInWindow validates a check-in window and normalizes both ends to HH:MM:SS.
+// Both ends empty means no window; nil values are returned in that case.
+// An end before the start is an overnight window, e.g. 22:00:00 to 06:00:00.
+func checkInWindow(start, end string) (*string, *string, error) {
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	if start == "" && end == "" {
+		return nil, nil, nil
+	}
+	if start == "" {
+		return nil, nil, &FieldError{Field: "check_in_window_start", Message: "required when check_in_window_end is set"}
+	}
+	if end == "" {
+		return nil, nil, &FieldError{Field: "check_in_window_end", Message: "required when check_in_window_start is set"}
+	}
+
+	startTime, err := parseTimeOfDay(start)
+	if err != nil {
+		return nil, nil, &FieldError{Field: "check_in_window_start", Message: "invalid time, expected format HH:MM:SS between 00:00:00 and 23:59:59"}
+	}
+	endTime, err := parseTimeOfDay(end)
+	if err != nil {
+		return nil, nil, &FieldError{Field: "check_in_window_end", Message: "invalid time, expected format HH:MM:SS between 00:00:00 and 23:59:59"}
+	}
+	if startTime.Equal(endTime) {
+		return nil, nil, &FieldError{Field: "check_in_window_end", Message: "must differ from check_in_window_start; leave both empty to accept check-ins all day"}
+	}
+
+	normalizedStart, normalizedEnd := startTime.Format("15:04:05"), endTime.Format("15:04:05")
+	return &normalizedStart, &normalizedEnd, nil
+}
+
+// toInt64Array converts []int to pq.Int64Array
+func toInt64Array(values []int) pq.Int64Array {
+	result := make(pq.Int64Array, len(values))
+	for i, v := range values {
+		result[i] = int64(v)
+	}
+	return result
+}
+
 // ValidateLocationForAttendance validates if user can check-in at location
 func (s *LocationService) ValidateLocationForAttendance(locationID uint, userLat, userLon float64) (bool, float64, error) {
+	isValid, _, distance, err := s.ValidateLocationWithGrace(locationID, userLat, userLon, 0)
+	return isValid, distance, err
+}
+
+// ValidateLocationForCheckOut validates a check-out position against the
+// location's check-out radius, which may be more lenient than the check-in radius
+func (s *LocationService) ValidateLocationForCheckOut(locationID uint, userLat, userLon float64) (bool, float64, error) {
 	location, err := s.GetLocationByID(locationID)
 	if err != nil {
 		return false, 0, err
 	}
 
 	if !location.IsActive {
-		return false, 0, errors.New("location is not active")
+		return false, 0, ErrLocationInactive
 	}
 
 	isValid, distance := utils.ValidateLocation(
 		userLat, userLon,
 		location.Latitude, location.Longitude,
-		float64(location.Radius),
+		float64(location.EffectiveCheckOutRadius()),
 	)
 
 	return isValid, distance, nil
 }
+
+// ValidateLocationWithGrace validates location like ValidateLocationForAttendance
+// and additionally reports whether the user is inside the grace band beyond the radius
+func (s *LocationService) ValidateLocationWithGrace(locationID uint, userLat, userLon, grace float64) (bool, bool, float64, error) {
+	location, err := s.GetLocationByID(locationID)
+	if err != nil {
+		return false, false, 0, err
+	}
+
+	if !location.IsActive {
+		return false, false, 0, ErrLocationInactive
+	}
+
+	isValid, inGrace, distance := utils.ValidateLocationWithGrace(
+		userLat, userLon,
+		location.Latitude, location.Longitude,
+		float64(location.Radius),
+		grace,
+	)
+
+	return isValid, inGrace, distance, nil
+}
@@ -0,0 +1,621 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/pkg/timezone"
+)
+
+func TestValidateCheckInToken(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	cfg := &config.Config{Location: config.LocationConfig{QREnabled: true, QRSecret: "qr-secret", QRTokenTTL: 2 * time.Minute}}
+	svc := NewLocationService(nil, cfg, nil)
+
+	token := func(locationID uint, issuedAt time.Time) string {
+		payload := fmt.Sprintf("%d.%d", locationID, issuedAt.Unix())
+		return payload + "." + svc.signCheckInToken(payload)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", token(3, now.Add(-time.Minute)), nil},
+		{"valid at the end of the window", token(3, now.Add(-2*time.Minute)), nil},
+		{"expired", token(3, now.Add(-2*time.Minute-time.Second)), ErrLocationTokenExpired},
+		{"wrong location", token(4, now), ErrLocationTokenInvalid},
+		{"issued in the future", token(3, now.Add(time.Minute)), ErrLocationTokenInvalid},
+		{"tampered signature", token(3, now) + "0", ErrLocationTokenInvalid},
+		{"malformed", "3.abc", ErrLocationTokenInvalid},
+		{"missing", "", ErrLocationTokenRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.ValidateCheckInToken(3, tt.token, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateCheckInToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckInTokenSignedWithQRSecret(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	issuer := NewLocationService(nil, &config.Config{Location: config.LocationConfig{QREnabled: true, QRSecret: "old-secret", QRTokenTTL: time.Minute}}, nil)
+	payload := fmt.Sprintf("3.%d", now.Unix())
+	token := payload + "." + issuer.signCheckInToken(payload)
+
+	validator := NewLocationService(nil, &config.Config{Location: config.LocationConfig{QREnabled: true, QRSecret: "new-secret", QRTokenTTL: time.Minute}}, nil)
+	if err := validator.ValidateCheckInToken(3, token, now); !errors.Is(err, ErrLocationTokenInvalid) {
+		t.Errorf("ValidateCheckInToken() with another secret error = %v, want %v", err, ErrLocationTokenInvalid)
+	}
+
+	disabled := NewLocationService(nil, &config.Config{Location: config.LocationConfig{QRSecret: "old-secret", QRTokenTTL: time.Minute}}, nil)
+	if err := disabled.ValidateCheckInToken(3, token, now); !errors.Is(err, ErrQRCheckInDisabled) {
+		t.Errorf("ValidateCheckInToken() with QR disabled error = %v, want %v", err, ErrQRCheckInDisabled)
+	}
+	if _, _, err := disabled.GenerateCheckInToken(3, now); !errors.Is(err, ErrQRCheckInDisabled) {
+		t.Errorf("GenerateCheckInToken() with QR disabled error = %v, want %v", err, ErrQRCheckInDisabled)
+	}
+}
+
+func TestCreateLocationQRRequiresQREnabled(t *testing.T) {
+	svc := NewLocationService(nil, &config.Config{}, nil)
+
+	_, err := svc.CreateLocation(&CreateLocationRequest{Name: "HQ", Latitude: -6.2, Longitude: 106.8, QRRequired: true}, 1)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "qr_required" {
+		t.Errorf("CreateLocation() error = %v, want qr_required field error", err)
+	}
+}
+
+func TestValidateLocationWithGrace(t *testing.T) {
+	// Meters due north of the location, which has a 100 m radius
+	north := func(meters float64) float64 { return -6.2 + meters/6371000*180/math.Pi }
+
+	tests := []struct {
+		name        string
+		meters      float64
+		active      bool
+		missing     bool
+		wantValid   bool
+		wantInGrace bool
+		wantErr     error
+	}{
+		{"inside the radius", 60, true, false, true, false, nil},
+		{"inside the grace band", 115, true, false, false, true, nil},
+		{"beyond the grace band", 130, true, false, false, false, nil},
+		{"inactive location", 60, false, false, false, false, ErrLocationInactive},
+		{"unknown location", 60, true, true, false, false, ErrLocationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewLocationService(db, &config.Config{}, nil)
+
+			rows := sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "radius", "is_active"})
+			if !tt.missing {
+				rows.AddRow(3, "HQ", -6.2, 106.8, 100, tt.active)
+			}
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3, 1).
+				WillReturnRows(rows)
+
+			valid, inGrace, _, err := svc.ValidateLocationWithGrace(3, north(tt.meters), 106.8, 20)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateLocationWithGrace() error = %v, want %v", err, tt.wantErr)
+			}
+			if valid != tt.wantValid || inGrace != tt.wantInGrace {
+				t.Errorf("ValidateLocationWithGrace() = (%v, %v), want (%v, %v)", valid, inGrace, tt.wantValid, tt.wantInGrace)
+			}
+		})
+	}
+}
+
+func TestValidateLocationForCheckOut(t *testing.T) {
+	// Meters due north of the location, which has a 100 m check-in radius
+	north := func(meters float64) float64 { return -6.2 + meters/6371000*180/math.Pi }
+
+	tests := []struct {
+		name           string
+		meters         float64
+		checkOutRadius interface{}
+		active         bool
+		wantValid      bool
+		wantErr        error
+	}{
+		{"check-in radius without a check-out radius", 150, nil, true, false, nil},
+		{"inside the check-out radius", 150, 250, true, true, nil},
+		{"beyond the check-out radius", 300, 250, true, false, nil},
+		{"inactive location", 60, 250, false, false, ErrLocationInactive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewLocationService(db, &config.Config{}, nil)
+
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "latitude", "longitude", "radius", "check_out_radius", "is_active"}).
+					AddRow(3, "HQ", -6.2, 106.8, 100, tt.checkOutRadius, tt.active))
+
+			valid, _, err := svc.ValidateLocationForCheckOut(3, north(tt.meters), 106.8)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateLocationForCheckOut() error = %v, want %v", err, tt.wantErr)
+			}
+			if valid != tt.wantValid {
+				t.Errorf("ValidateLocationForCheckOut() = %v, want %v", valid, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestCreateLocationDefaultRadius(t *testing.T) {
+	tests := []struct {
+		name   string
+		radius int
+		want   int
+	}{
+		{"radius omitted", 0, 75},
+		{"radius given", 30, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewLocationService(db, &config.Config{Location: config.LocationConfig{DefaultRadius: 75}}, nil)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO "attendance_locations"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectCommit()
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_by"}).AddRow(3, 1))
+			mock.ExpectQuery(`SELECT \* FROM "users"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+			location, err := svc.CreateLocation(&CreateLocationRequest{
+				Name:      "HQ",
+				Latitude:  -6.2,
+				Longitude: 106.8,
+				Radius:    tt.radius,
+				Timezone:  "Asia/Jakarta",
+			}, 1)
+			if err != nil {
+				t.Fatalf("CreateLocation() error = %v", err)
+			}
+			if location.Radius != tt.want {
+				t.Errorf("Radius = %d, want %d", location.Radius, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateLocationDuplicateCheck(t *testing.T) {
+	// Meters due north of HQ
+	north := func(m float64) float64 { return -6.2 + m/6371000*180/math.Pi }
+
+	tests := []struct {
+		name         string
+		enabled      bool
+		latitude     float64
+		wantConflict uint
+		wantDistance float64
+	}{
+		{"disabled", false, -6.2, 0, 0},
+		{"coincident", true, -6.2, 2, 0},
+		{"nearest of two within the distance", true, north(30), 4, 10},
+		{"far apart", true, north(500), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{}
+			cfg.Location.DuplicateCheckEnabled = tt.enabled
+			cfg.Location.DuplicateMinDistance = 50
+			svc := NewLocationService(db, cfg, nil)
+
+			mock.ExpectBegin()
+			if tt.enabled {
+				// Serializes concurrent creations so both cannot pass the check
+				mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+					WithArgs(locationCreateLock).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE is_active = \$1`).
+					WithArgs(true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "latitude", "longitude"}).
+						AddRow(2, "HQ", -6.2, 106.8).
+						AddRow(4, "HQ annex", north(40), 106.8))
+			}
+			if tt.wantConflict != 0 {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectQuery(`INSERT INTO "attendance_locations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_by"}).AddRow(9, 1))
+				mock.ExpectQuery(`SELECT \* FROM "users"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			}
+
+			_, err := svc.CreateLocation(&CreateLocationRequest{
+				Name:      "New site",
+				Latitude:  tt.latitude,
+				Longitude: 106.8,
+				Radius:    100,
+				Timezone:  "Asia/Jakarta",
+			}, 1)
+			if tt.wantConflict == 0 {
+				if err != nil {
+					t.Fatalf("CreateLocation() error = %v", err)
+				}
+				return
+			}
+			var conflict *LocationConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("CreateLocation() error = %v, want a location conflict", err)
+			}
+			if conflict.Location.ID != tt.wantConflict || math.Abs(conflict.Distance-tt.wantDistance) > 0.5 {
+				t.Errorf("conflict with location %d at %.1f m, want %d at %.0f m", conflict.Location.ID, conflict.Distance, tt.wantConflict, tt.wantDistance)
+			}
+		})
+	}
+}
+
+func TestValidateOperatingDays(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    []int
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"weekdays", []int{1, 2, 3, 4, 5}, false},
+		{"Sunday", []int{7}, false},
+		{"zero", []int{0, 1}, true},
+		{"eight", []int{8}, true},
+		{"duplicate", []int{1, 2, 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOperatingDays(tt.days)
+			var fieldErr *FieldError
+			if tt.wantErr && (!errors.As(err, &fieldErr) || fieldErr.Field != "operating_days") {
+				t.Errorf("validateOperatingDays() error = %v, want field error on operating_days", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validateOperatingDays() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestCreateLocationRejectsInvalidSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       CreateLocationRequest
+		wantField string
+	}{
+		{"duplicate operating day", CreateLocationRequest{OperatingDays: []int{1, 1}}, "operating_days"},
+		{"operating day out of range", CreateLocationRequest{OperatingDays: []int{0}}, "operating_days"},
+		{"window end out of range", CreateLocationRequest{CheckInWindowStart: "06:00", CheckInWindowEnd: "25:00"}, "check_in_window_end"},
+		{"window with equal ends", CreateLocationRequest{CheckInWindowStart: "06:00", CheckInWindowEnd: "06:00"}, "check_in_window_end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Rejected before anything is written
+			db, _ := newMockDB(t)
+			svc := NewLocationService(db, &config.Config{}, nil)
+
+			req := tt.req
+			req.Name, req.Latitude, req.Longitude, req.Radius = "HQ", -6.2, 106.8, 100
+			_, err := svc.CreateLocation(&req, 1)
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+				t.Errorf("CreateLocation() error = %v, want a field error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestSuspendLocation(t *testing.T) {
+	tests := []struct {
+		name           string
+		req            SuspendLocationRequest
+		wantErr        string
+		wantUntilLocal string
+	}{
+		{"indefinitely", SuspendLocationRequest{Reason: "renovation"}, "", ""},
+		{"through a day", SuspendLocationRequest{SuspendedUntil: "2099-01-31"}, "", "2099-02-01 00:00"},
+		{"invalid date", SuspendLocationRequest{SuspendedUntil: "31-01-2099"}, "invalid suspended_until date format", ""},
+		{"past date", SuspendLocationRequest{SuspendedUntil: "2020-01-31"}, "suspended_until must not be in the past", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewLocationService(db, &config.Config{}, nil)
+
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "timezone", "is_active"}).AddRow(3, "HQ", "Asia/Jakarta", true))
+			if tt.wantErr == "" {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "attendance_locations" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			location, err := svc.SuspendLocation(3, &tt.req)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("SuspendLocation() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SuspendLocation() error = %v", err)
+			}
+			if !location.IsSuspended() || location.SuspensionReason != tt.req.Reason {
+				t.Errorf("SuspendLocation() suspended %v reason %q, want suspended with %q", location.IsSuspended(), location.SuspensionReason, tt.req.Reason)
+			}
+			gotUntil := ""
+			if location.SuspendedUntil != nil {
+				if location.SuspendedUntil.Location() != time.Local {
+					t.Errorf("SuspendedUntil saved in %v, want server time", location.SuspendedUntil.Location())
+				}
+				gotUntil = location.SuspendedUntil.In(location.TimeLocation()).Format("2006-01-02 15:04")
+			}
+			if gotUntil != tt.wantUntilLocal {
+				t.Errorf("SuspendedUntil = %q, want %q", gotUntil, tt.wantUntilLocal)
+			}
+		})
+	}
+}
+
+func TestReactivateExpiredSuspensions(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewLocationService(db, &config.Config{}, nil)
+	now := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "attendance_locations" SET "suspended_at"=\$1,"suspended_until"=\$2,"suspension_reason"=\$3,"updated_at"=\$4 WHERE suspended_at IS NOT NULL AND suspended_until IS NOT NULL AND suspended_until <= \$5`).
+		WithArgs(nil, nil, "", sqlmock.AnyArg(), now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	reactivated, err := svc.ReactivateExpiredSuspensions(now)
+	if err != nil {
+		t.Fatalf("ReactivateExpiredSuspensions() error = %v", err)
+	}
+	if reactivated != 2 {
+		t.Errorf("ReactivateExpiredSuspensions() = %d, want 2", reactivated)
+	}
+}
+
+func TestGetNearbyLocations(t *testing.T) {
+	// Kilometers due north of the search point
+	north := func(km float64) float64 { return -6.2 + km*1000/6371000*180/math.Pi }
+
+	tests := []struct {
+		name           string
+		radiusKm       float64
+		includeNearest bool
+		wantField      string
+		wantIDs        []uint
+		wantOutside    bool
+	}{
+		{"radius over the cap", 10.5, false, "radius_km", nil, false},
+		{"closest first, capped to max results", 10, false, "", []uint{2, 3}, false},
+		{"only locations within the radius", 2, false, "", []uint{2}, false},
+		{"nothing within the radius", 0.5, false, "", []uint{}, false},
+		{"nearest when nothing is within the radius", 0.5, true, "", []uint{2}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{}
+			cfg.Location.NearbyMaxRadiusKm = 10
+			cfg.Location.NearbyMaxResults = 2
+			svc := NewLocationService(db, cfg, nil)
+
+			if tt.wantField == "" {
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE is_active = \$1 AND suspended_at IS NULL`).
+					WithArgs(true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "latitude", "longitude", "is_active"}).
+						AddRow(1, north(8), 106.8, true).
+						AddRow(2, north(1), 106.8, true).
+						AddRow(3, north(4), 106.8, true))
+			}
+
+			locations, err := svc.GetNearbyLocations(&GetNearbyLocationsRequest{
+				Latitude:       -6.2,
+				Longitude:      106.8,
+				RadiusKm:       tt.radiusKm,
+				IncludeNearest: tt.includeNearest,
+			})
+			if tt.wantField != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+					t.Fatalf("GetNearbyLocations() error = %v, want a %s field error", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetNearbyLocations() error = %v", err)
+			}
+
+			ids := []uint{}
+			for _, location := range locations {
+				ids = append(ids, location.ID)
+				if location.OutsideSearch != tt.wantOutside {
+					t.Errorf("location %d OutsideSearch = %v, want %v", location.ID, location.OutsideSearch, tt.wantOutside)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("GetNearbyLocations() ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestGetNearbyLocationsCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		secondFresh bool
+		wantQueries int
+	}{
+		{"cached within the TTL", time.Minute, false, 1},
+		{"fresh bypasses the cache", time.Minute, true, 2},
+		{"caching disabled", 0, false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{}
+			cfg.Location.NearbyCacheTTL = tt.ttl
+			svc := NewLocationService(db, cfg, nil)
+
+			for i := 0; i < tt.wantQueries; i++ {
+				mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE is_active = \$1 AND suspended_at IS NULL`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "latitude", "longitude", "is_active"}).AddRow(1, -6.2, 106.8, true))
+			}
+
+			req := &GetNearbyLocationsRequest{Latitude: -6.2, Longitude: 106.8, RadiusKm: 1}
+			if _, err := svc.GetNearbyLocations(req); err != nil {
+				t.Fatalf("first GetNearbyLocations() error = %v", err)
+			}
+			req.Fresh = tt.secondFresh
+			locations, err := svc.GetNearbyLocations(req)
+			if err != nil {
+				t.Fatalf("second GetNearbyLocations() error = %v", err)
+			}
+			if len(locations) != 1 {
+				t.Errorf("second GetNearbyLocations() returned %d locations, want 1", len(locations))
+			}
+		})
+	}
+}
+
+func TestActiveLocationsCacheInvalidatedOnChange(t *testing.T) {
+	db, mock := newMockDB(t)
+	cfg := &config.Config{}
+	cfg.Location.NearbyCacheTTL = time.Hour
+	svc := NewLocationService(db, cfg, nil)
+
+	activeQuery := `SELECT \* FROM "attendance_locations" WHERE is_active = \$1 AND suspended_at IS NULL`
+	mock.ExpectQuery(activeQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "latitude", "longitude", "is_active"}).AddRow(3, -6.2, 106.8, true))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "is_active"}).AddRow(3, "HQ", true))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "attendance_locations" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// The suspended location is gone from the next search without waiting for the TTL
+	mock.ExpectQuery(activeQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	req := &GetNearbyLocationsRequest{Latitude: -6.2, Longitude: 106.8, RadiusKm: 1}
+	if _, err := svc.GetNearbyLocations(req); err != nil {
+		t.Fatalf("GetNearbyLocations() error = %v", err)
+	}
+	if _, err := svc.SuspendLocation(3, &SuspendLocationRequest{Reason: "flooding"}); err != nil {
+		t.Fatalf("SuspendLocation() error = %v", err)
+	}
+	locations, err := svc.GetNearbyLocations(req)
+	if err != nil {
+		t.Fatalf("GetNearbyLocations() error = %v", err)
+	}
+	if len(locations) != 0 {
+		t.Errorf("GetNearbyLocations() after suspension = %d locations, want 0", len(locations))
+	}
+}
+
+func TestCheckInWindow(t *testing.T) {
+	tests := []struct {
+		name               string
+		start, end         string
+		wantStart, wantEnd string
+		wantField          string
+	}{
+		{name: "both empty", start: " ", end: ""},
+		{name: "normalized", start: "05:00", end: " 11:30:15 ", wantStart: "05:00:00", wantEnd: "11:30:15"},
+		{name: "overnight", start: "22:00", end: "02:00", wantStart: "22:00:00", wantEnd: "02:00:00"},
+		{name: "missing start", end: "11:00", wantField: "check_in_window_start"},
+		{name: "missing end", start: "05:00", wantField: "check_in_window_end"},
+		{name: "invalid start", start: "25:00", end: "11:00", wantField: "check_in_window_start"},
+		{name: "invalid end", start: "05:00", end: "noon", wantField: "check_in_window_end"},
+		{name: "equal ends", start: "05:00", end: "05:00:00", wantField: "check_in_window_end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := checkInWindow(tt.start, tt.end)
+			if tt.wantField != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+					t.Fatalf("err = %v, want a field error on %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantStart == "" {
+				if start != nil || end != nil {
+					t.Errorf("window = %v, %v, want none", start, end)
+				}
+				return
+			}
+			if start == nil || end == nil || *start != tt.wantStart || *end != tt.wantEnd {
+				t.Errorf("window = %v, %v, want %s to %s", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+// stubResolver resolves every coordinate to zone, or fails with err
+type stubResolver struct {
+	zone string
+	err  error
+}
+
+func (r stubResolver) Resolve(latitude, longitude float64) (string, error) {
+	return r.zone, r.err
+}
+
+func TestResolveTimezone(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver timezone.Resolver
+		want     string
+	}{
+		{"disabled", nil, ""},
+		{"resolved", stubResolver{zone: "Asia/Makassar"}, "Asia/Makassar"},
+		{"not found", stubResolver{err: timezone.ErrNotFound}, ""},
+		{"unknown zone", stubResolver{zone: "Asia/Atlantis"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewLocationService(nil, &config.Config{}, tt.resolver)
+			if got := svc.resolveTimezone(-8.65, 115.22); got != tt.want {
+				t.Errorf("resolveTimezone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/pkg/clock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB returns a GORM handle on the Postgres dialect backed by sqlmock. Every
+// expectation must be met by the end of the test.
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet database expectations: %v", err)
+		}
+		sqlDB.Close()
+	})
+	return db, mock
+}
+
+// newTestAttendanceService returns an AttendanceService on db whose clock is fixed at now
+func newTestAttendanceService(db *gorm.DB, cfg *config.Config, now time.Time) *AttendanceService {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	return NewAttendanceService(db, NewLocationService(db, cfg, nil), NewAuditService(db), nil, clock.Fixed(now), cfg)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/attendance/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type RegionService struct {
+	db *gorm.DB
+}
+
+func NewRegionService(db *gorm.DB) *RegionService {
+	return &RegionService{db: db}
+}
+
+// CreateRegionRequest represents create region request
+type CreateRegionRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpdateRegionRequest represents update region request
+type UpdateRegionRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateRegion creates a new region
+func (s *RegionService) CreateRegion(req *CreateRegionRequest) (*model.Region, error) {
+	name, err := regionName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureNameAvailable(name, 0); err != nil {
+		return nil, err
+	}
+
+	region := model.Region{Name: name}
+	if err := s.db.Create(&region).Error; err != nil {
+		if isUniqueViolation(err, "") {
+			return nil, errors.New("region already exists")
+		}
+		return nil, err
+	}
+
+	return &region, nil
+}
+
+// GetRegionByID retrieves region by ID
+func (s *RegionService) GetRegionByID(id uint) (*model.Region, error) {
+	return getRegionByID(s.db, id)
+}
+
+// GetAllRegions retrieves all regions ordered by name
+func (s *RegionService) GetAllRegions() ([]model.Region, error) {
+	var regions []model.Region
+	if err := s.db.Order("name ASC").Find(&regions).Error; err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
+
+// UpdateRegion renames a region
+func (s *RegionService) UpdateRegion(id uint, req *UpdateRegionRequest) (*model.Region, error) {
+	region, err := getRegionByID(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := regionName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureNameAvailable(name, id); err != nil {
+		return nil, err
+	}
+
+	region.Name = name
+	if err := s.db.Save(region).Error; err != nil {
+		if isUniqueViolation(err, "") {
+			return nil, errors.New("region already exists")
+		}
+		return nil, err
+	}
+
+	return region, nil
+}
+
+// DeleteRegion deletes a region. Regions that still have locations are kept;
+// their locations must be moved or unassigned first.
+func (s *RegionService) DeleteRegion(id uint) error {
+	if _, err := getRegionByID(s.db, id); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.AttendanceLocation{}).Where("region_id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("region still has locations")
+	}
+
+	return s.db.Delete(&model.Region{}, id).Error
+}
+
+// regionName trims a requested region name, rejecting one that is blank
+func regionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", &FieldError{Field: "name", Message: "must not be blank"}
+	}
+	return name, nil
+}
+
+// ensureNameAvailable rejects a name already used by a region other than excludeID, ignoring case
+func (s *RegionService) ensureNameAvailable(name string, excludeID uint) error {
+	var count int64
+	if err := s.db.Model(&model.Region{}).Where("LOWER(name) = LOWER(?) AND id != ?", name, excludeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("region already exists")
+	}
+	return nil
+}
+
+// getRegionByID retrieves a region, reporting a missing one as "region not found"
+func getRegionByID(db *gorm.DB, id uint) (*model.Region, error) {
+	var region model.Region
+	if err := db.First(&region, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("region not found")
+		}
+		return nil, err
+	}
+	return &region, nil
+}
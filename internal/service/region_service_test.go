@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestCreateRegion(t *testing.T) {
+	tests := []struct {
+		name      string
+		reqName   string
+		taken     int
+		insertErr error
+		wantErr   string
+	}{
+		{"blank name", "   ", 0, nil, "name: must not be blank"},
+		{"name taken", "jakarta", 1, nil, "region already exists"},
+		// Created by a concurrent request after the check, caught by the unique index
+		{"name taken concurrently", "Jakarta", 0, &pgconn.PgError{Code: "23505", ConstraintName: "idx_regions_name_lower"}, "region already exists"},
+		{"name trimmed", "  Jakarta ", 0, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewRegionService(db)
+			blank := strings.TrimSpace(tt.reqName) == ""
+
+			if !blank {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "regions" WHERE LOWER\(name\) = LOWER\(\$1\) AND id != \$2`).
+					WithArgs(strings.TrimSpace(tt.reqName), 0).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.taken))
+			}
+			if !blank && tt.taken == 0 {
+				mock.ExpectBegin()
+				insert := mock.ExpectQuery(`INSERT INTO "regions"`).WithArgs("Jakarta", sqlmock.AnyArg(), sqlmock.AnyArg())
+				if tt.insertErr != nil {
+					insert.WillReturnError(tt.insertErr)
+					mock.ExpectRollback()
+				} else {
+					insert.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+					mock.ExpectCommit()
+				}
+			}
+
+			region, err := svc.CreateRegion(&CreateRegionRequest{Name: tt.reqName})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("CreateRegion() error = %v, want %q", err, tt.wantErr)
+				}
+				var fieldErr *FieldError
+				if blank && !errors.As(err, &fieldErr) {
+					t.Errorf("CreateRegion() error = %T, want a field error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateRegion() error = %v", err)
+			}
+			if region.Name != "Jakarta" {
+				t.Errorf("CreateRegion() name = %q, want Jakarta", region.Name)
+			}
+		})
+	}
+}
+
+func TestDeleteRegion(t *testing.T) {
+	tests := []struct {
+		name      string
+		exists    bool
+		locations int
+		wantErr   string
+	}{
+		{"unknown region", false, 0, "region not found"},
+		{"region with locations", true, 2, "region still has locations"},
+		{"empty region", true, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewRegionService(db)
+
+			regions := sqlmock.NewRows([]string{"id", "name"})
+			if tt.exists {
+				regions.AddRow(4, "Jakarta")
+			}
+			mock.ExpectQuery(`SELECT \* FROM "regions" WHERE "regions"."id" = \$1`).
+				WithArgs(4, 1).
+				WillReturnRows(regions)
+			if tt.exists {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "attendance_locations" WHERE region_id = \$1`).
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.locations))
+			}
+			if tt.wantErr == "" {
+				mock.ExpectBegin()
+				mock.ExpectExec(`DELETE FROM "regions" WHERE "regions"."id" = \$1`).
+					WithArgs(4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			err := svc.DeleteRegion(4)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("DeleteRegion() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("DeleteRegion() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,37 +2,61 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/attendance/backend/internal/config"
 	"github.com/attendance/backend/internal/model"
+	"github.com/attendance/backend/pkg/clock"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
+// FieldError describes a validation failure on a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
 type ScheduleService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	clock  clock.Clock
+	config *config.Config
 }
 
-func NewScheduleService(db *gorm.DB) *ScheduleService {
-	return &ScheduleService{db: db}
+func NewScheduleService(db *gorm.DB, clk clock.Clock, cfg *config.Config) *ScheduleService {
+	return &ScheduleService{db: db, clock: clk, config: cfg}
 }
 
 // CreateScheduleRequest represents create schedule request
 type CreateScheduleRequest struct {
-	Name          string `json:"name" binding:"required"`
-	CheckInStart  string `json:"check_in_start" binding:"required"`  // "08:00:00"
-	CheckInEnd    string `json:"check_in_end" binding:"required"`    // "09:00:00"
-	CheckOutStart string `json:"check_out_start" binding:"required"` // "17:00:00"
-	WorkDays      []int  `json:"work_days" binding:"required"`       // [1,2,3,4,5]
+	Name                    string `json:"name" binding:"required"`
+	CheckInStart            string `json:"check_in_start" binding:"required"`  // "08:00:00"
+	CheckInEnd              string `json:"check_in_end" binding:"required"`    // "09:00:00"
+	CheckOutStart           string `json:"check_out_start" binding:"required"` // "17:00:00"
+	WorkDays                []int  `json:"work_days" binding:"required"`       // [1,2,3,4,5]
+	RemoteAllowed           bool   `json:"remote_allowed"`
+	RequireNoteOnEarlyLeave bool   `json:"require_note_on_early_leave"`
+	EarlyStatusMinutes      int    `json:"early_status_minutes" binding:"min=0"` // 0 disables the "early" status
+	MinRestMinutes          int    `json:"min_rest_minutes" binding:"min=0"`     // 0 disables the rest gap
 }
 
 // UpdateScheduleRequest represents update schedule request
 type UpdateScheduleRequest struct {
-	Name          string `json:"name"`
-	CheckInStart  string `json:"check_in_start"`
-	CheckInEnd    string `json:"check_in_end"`
-	CheckOutStart string `json:"check_out_start"`
-	WorkDays      []int  `json:"work_days"`
+	Name                    string `json:"name"`
+	CheckInStart            string `json:"check_in_start"`
+	CheckInEnd              string `json:"check_in_end"`
+	CheckOutStart           string `json:"check_out_start"`
+	WorkDays                []int  `json:"work_days"`
+	RemoteAllowed           *bool  `json:"remote_allowed"`
+	RequireNoteOnEarlyLeave *bool  `json:"require_note_on_early_leave"`
+	EarlyStatusMinutes      *int   `json:"early_status_minutes" binding:"omitempty,min=0"`
+	MinRestMinutes          *int   `json:"min_rest_minutes" binding:"omitempty,min=0"`
 }
 
 // AssignScheduleRequest represents assign schedule to user request
@@ -44,8 +68,48 @@ type AssignScheduleRequest struct {
 	EffectiveTo   string `json:"effective_to"`                      // "2025-12-31" (optional)
 }
 
-// CreateSchedule creates a new work schedule
-func (s *ScheduleService) CreateSchedule(req *CreateScheduleRequest) (*model.WorkSchedule, error) {
+// LocationSchedule is a schedule operating at a location with the number of
+// users currently assigned to it there
+type LocationSchedule struct {
+	Schedule      model.ScheduleResponse `json:"schedule"`
+	AssignedUsers int64                  `json:"assigned_users"`
+}
+
+// PlannedDay is one day of a user's schedule resolved from their assignments. Times are
+// in the timezone of the assigned location and only set on work days.
+type PlannedDay struct {
+	Date             string     `json:"date"` // YYYY-MM-DD
+	Type             string     `json:"type"` // 'work', 'off', 'holiday' or 'leave'
+	ScheduleID       *uint      `json:"schedule_id"`
+	ScheduleName     string     `json:"schedule_name,omitempty"`
+	LocationID       *uint      `json:"location_id"`
+	HolidayName      string     `json:"holiday_name,omitempty"` // set on holidays
+	LeaveType        string     `json:"leave_type,omitempty"`   // set on leave
+	ExpectedCheckIn  *time.Time `json:"expected_check_in"`      // check_in_start of the schedule
+	CheckInDeadline  *time.Time `json:"check_in_deadline"`      // check_in_end, later check-ins are late
+	ExpectedCheckOut *time.Time `json:"expected_check_out"`     // check_out_start, the next day for overnight schedules
+}
+
+// CreateSchedule creates a new work schedule. Names are unique regardless of case;
+// with returnExisting a schedule of the same name is returned instead of an error.
+// The bool result reports whether a schedule was created.
+func (s *ScheduleService) CreateSchedule(req *CreateScheduleRequest, returnExisting bool) (*model.WorkSchedule, bool, error) {
+	if err := validateScheduleTimes(req.CheckInStart, req.CheckInEnd, req.CheckOutStart); err != nil {
+		return nil, false, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	existing, err := s.findScheduleByName(name, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		if returnExisting {
+			return existing, false, nil
+		}
+		return nil, false, errors.New("schedule name already exists")
+	}
+
 	// Convert []int to pq.Int64Array
 	workDays := make(pq.Int64Array, len(req.WorkDays))
 	for i, day := range req.WorkDays {
@@ -53,17 +117,47 @@ func (s *ScheduleService) CreateSchedule(req *CreateScheduleRequest) (*model.Wor
 	}
 
 	schedule := model.WorkSchedule{
-		Name:          req.Name,
-		CheckInStart:  req.CheckInStart,
-		CheckInEnd:    req.CheckInEnd,
-		CheckOutStart: req.CheckOutStart,
-		WorkDays:      workDays,
+		Name:                    name,
+		CheckInStart:            req.CheckInStart,
+		CheckInEnd:              req.CheckInEnd,
+		CheckOutStart:           req.CheckOutStart,
+		WorkDays:                workDays,
+		RemoteAllowed:           req.RemoteAllowed,
+		RequireNoteOnEarlyLeave: req.RequireNoteOnEarlyLeave,
+		EarlyStatusMinutes:      req.EarlyStatusMinutes,
+		MinRestMinutes:          req.MinRestMinutes,
 	}
 
 	if err := s.db.Create(&schedule).Error; err != nil {
-		return nil, err
+		if !isUniqueViolation(err, scheduleNameIndex) {
+			return nil, false, err
+		}
+		// Another request created a schedule of the same name since the lookup above
+		if returnExisting {
+			if existing, err := s.findScheduleByName(name, 0); err != nil || existing != nil {
+				return existing, false, err
+			}
+		}
+		return nil, false, errors.New("schedule name already exists")
 	}
 
+	return &schedule, true, nil
+}
+
+// scheduleNameIndex is the unique index on LOWER(work_schedules.name)
+const scheduleNameIndex = "idx_work_schedules_name_lower"
+
+// findScheduleByName returns the schedule named name (case-insensitive) other than
+// excludeID, or nil when there is none
+func (s *ScheduleService) findScheduleByName(name string, excludeID uint) (*model.WorkSchedule, error) {
+	var schedule model.WorkSchedule
+	err := s.db.Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID).First(&schedule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
 	return &schedule, nil
 }
 
@@ -90,14 +184,25 @@ func (s *ScheduleService) GetAllSchedules() ([]model.WorkSchedule, error) {
 
 // UpdateSchedule updates schedule information
 func (s *ScheduleService) UpdateSchedule(id uint, req *UpdateScheduleRequest) (*model.WorkSchedule, error) {
+	if err := validateScheduleTimes(req.CheckInStart, req.CheckInEnd, req.CheckOutStart); err != nil {
+		return nil, err
+	}
+
 	schedule, err := s.GetScheduleByID(id)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update fields
-	if req.Name != "" {
-		schedule.Name = req.Name
+	if name := strings.TrimSpace(req.Name); name != "" {
+		existing, err := s.findScheduleByName(name, schedule.ID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, errors.New("schedule name already exists")
+		}
+		schedule.Name = name
 	}
 	if req.CheckInStart != "" {
 		schedule.CheckInStart = req.CheckInStart
@@ -115,8 +220,23 @@ func (s *ScheduleService) UpdateSchedule(id uint, req *UpdateScheduleRequest) (*
 		}
 		schedule.WorkDays = workDays
 	}
+	if req.RemoteAllowed != nil {
+		schedule.RemoteAllowed = *req.RemoteAllowed
+	}
+	if req.RequireNoteOnEarlyLeave != nil {
+		schedule.RequireNoteOnEarlyLeave = *req.RequireNoteOnEarlyLeave
+	}
+	if req.EarlyStatusMinutes != nil {
+		schedule.EarlyStatusMinutes = *req.EarlyStatusMinutes
+	}
+	if req.MinRestMinutes != nil {
+		schedule.MinRestMinutes = *req.MinRestMinutes
+	}
 
 	if err := s.db.Save(&schedule).Error; err != nil {
+		if isUniqueViolation(err, scheduleNameIndex) {
+			return nil, errors.New("schedule name already exists")
+		}
 		return nil, err
 	}
 
@@ -137,7 +257,7 @@ func (s *ScheduleService) DeleteSchedule(id uint) error {
 }
 
 // AssignScheduleToUser assigns a work schedule to a user
-func (s *ScheduleService) AssignScheduleToUser(req *AssignScheduleRequest) (*model.UserSchedule, error) {
+func (s *ScheduleService) AssignScheduleToUser(req *AssignScheduleRequest, createdBy uint) (*model.UserSchedule, error) {
 	// Validate schedule exists
 	if _, err := s.GetScheduleByID(req.ScheduleID); err != nil {
 		return nil, errors.New("schedule not found")
@@ -146,7 +266,7 @@ func (s *ScheduleService) AssignScheduleToUser(req *AssignScheduleRequest) (*mod
 	// Parse dates
 	effectiveFrom, err := parseDate(req.EffectiveFrom)
 	if err != nil {
-		return nil, errors.New("invalid effective_from date format")
+		return nil, &FieldError{Field: "effective_from", Message: "invalid date, expected format YYYY-MM-DD"}
 	}
 
 	var effectiveTo *string
@@ -159,38 +279,342 @@ func (s *ScheduleService) AssignScheduleToUser(req *AssignScheduleRequest) (*mod
 		ScheduleID:    req.ScheduleID,
 		LocationID:    req.LocationID,
 		EffectiveFrom: effectiveFrom,
+		CreatedBy:     &createdBy,
 	}
 
 	if effectiveTo != nil {
 		parsed, err := parseDate(*effectiveTo)
 		if err != nil {
-			return nil, errors.New("invalid effective_to date format")
+			return nil, &FieldError{Field: "effective_to", Message: "invalid date, expected format YYYY-MM-DD"}
 		}
 		userSchedule.EffectiveTo = &parsed
 	}
 
+	if err := s.validateAssignmentDates(userSchedule.EffectiveFrom, userSchedule.EffectiveTo); err != nil {
+		return nil, err
+	}
+
 	if err := s.db.Create(&userSchedule).Error; err != nil {
 		return nil, err
 	}
 
 	// Load relations
-	s.db.Preload("User").Preload("Schedule").Preload("Location").First(&userSchedule, userSchedule.ID)
+	s.db.Preload("User").Preload("Schedule").Preload("Location").Preload("Creator").First(&userSchedule, userSchedule.ID)
 
 	return &userSchedule, nil
 }
 
 // GetUserSchedules retrieves schedules assigned to a user
-func (s *ScheduleService) GetUserSchedules(userID uint) ([]model.UserSchedule, error) {
+func (s *ScheduleService) GetUserSchedules(userID uint, limit, offset int) ([]model.UserSchedule, int64, error) {
 	var userSchedules []model.UserSchedule
-	if err := s.db.Preload("Schedule").Preload("Location").
+	var total int64
+
+	// Count total
+	if err := s.db.Model(&model.UserSchedule{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated records
+	err := s.db.Preload("Schedule").Preload("Location").
 		Where("user_id = ?", userID).
+		Order("effective_from DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&userSchedules).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return userSchedules, total, nil
+}
+
+// GetUserScheduleHistory retrieves all schedule assignments of a user, including ended ones
+func (s *ScheduleService) GetUserScheduleHistory(userID uint, limit, offset int) ([]model.UserSchedule, int64, error) {
+	var userSchedules []model.UserSchedule
+	var total int64
+
+	// Count total
+	s.db.Model(&model.UserSchedule{}).Where("user_id = ?", userID).Count(&total)
+
+	// Get paginated records
+	err := s.db.Preload("Schedule").Preload("Location").Preload("Creator").
+		Where("user_id = ?", userID).
+		Order("effective_from DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&userSchedules).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return userSchedules, total, nil
+}
+
+// GetAssignmentsOnDate retrieves every schedule assignment effective on the given date,
+// optionally limited to one location (locationID 0 means all locations)
+func (s *ScheduleService) GetAssignmentsOnDate(date time.Time, locationID uint, limit, offset int) ([]model.UserSchedule, int64, error) {
+	var userSchedules []model.UserSchedule
+	var total int64
+
+	day := date.Format("2006-01-02")
+	query := s.db.Model(&model.UserSchedule{}).
+		Where("effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", day, day)
+	if locationID > 0 {
+		query = query.Where("location_id = ?", locationID)
+	}
+
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated records
+	err := query.Preload("User").Preload("Schedule").Preload("Location").
+		Order("location_id ASC, user_id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&userSchedules).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return userSchedules, total, nil
+}
+
+// GetSchedulesByLocation retrieves the distinct schedules with an assignment at the
+// location effective today, ordered by name, each with its number of assigned users
+func (s *ScheduleService) GetSchedulesByLocation(locationID uint, limit, offset int) ([]LocationSchedule, int64, error) {
+	var location model.AttendanceLocation
+	if err := s.db.First(&location, locationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrLocationNotFound
+		}
+		return nil, 0, err
+	}
+
+	today := s.clock.Now().Format("2006-01-02")
+	active := func() *gorm.DB {
+		return s.db.Table("user_schedules us").
+			Where("us.location_id = ? AND us.effective_from <= ? AND (us.effective_to IS NULL OR us.effective_to >= ?)",
+				locationID, today, today)
+	}
+
+	// Count total
+	var total int64
+	if err := active().Distinct("us.schedule_id").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		ScheduleID    uint
+		AssignedUsers int64
+	}
+	err := active().
+		Select("us.schedule_id, COUNT(DISTINCT us.user_id) AS assigned_users").
+		Joins("JOIN work_schedules ws ON ws.id = us.schedule_id").
+		Group("us.schedule_id, ws.name").
+		Order("ws.name ASC, us.schedule_id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ScheduleID
+	}
+
+	var schedules []model.WorkSchedule
+	if len(ids) > 0 {
+		if err := s.db.Where("id IN ?", ids).Find(&schedules).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+	byID := make(map[uint]model.WorkSchedule, len(schedules))
+	for _, schedule := range schedules {
+		byID[schedule.ID] = schedule
+	}
+
+	result := make([]LocationSchedule, len(rows))
+	for i, row := range rows {
+		schedule := byID[row.ScheduleID]
+		result[i] = LocationSchedule{
+			Schedule:      schedule.ToResponse(),
+			AssignedUsers: row.AssignedUsers,
+		}
+	}
+
+	return result, total, nil
+}
+
+// ResolveUserSchedule lists every day from from to to (inclusive, at most 366 days) with
+// what the user is expected to do. Each day follows the assignment effective on it, so a
+// range spanning an assignment change switches schedule on that day. A day is off when no
+// assignment covers it, it is not one of the schedule's work days, or the assigned
+// location does not operate on it. A work day that is a holiday at the assigned location
+// or falls in the user's leave is planned as such instead, without times.
+func (s *ScheduleService) ResolveUserSchedule(userID uint, from, to time.Time) ([]PlannedDay, error) {
+	if to.Before(from) {
+		return nil, &FieldError{Field: "to", Message: "must not be before from"}
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return nil, &FieldError{Field: "to", Message: "range must not exceed 366 days"}
+	}
+
+	var userSchedules []model.UserSchedule
+	if err := s.db.Preload("Schedule").Preload("Location").
+		Where("user_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)",
+			userID, to.Format("2006-01-02"), from.Format("2006-01-02")).
+		Order("effective_from DESC").
 		Find(&userSchedules).Error; err != nil {
 		return nil, err
 	}
-	return userSchedules, nil
+	off, err := loadTimeOff(s.db, userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	days := []PlannedDay{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		planned := PlannedDay{Date: day.Format("2006-01-02"), Type: "off"}
+
+		us := scheduleOn(userSchedules, day)
+		if us == nil {
+			days = append(days, planned)
+			continue
+		}
+		planned.ScheduleID = &us.ScheduleID
+		planned.ScheduleName = us.Schedule.Name
+		planned.LocationID = &us.LocationID
+
+		if !isScheduledWorkDay(userSchedules, day) || !us.Location.IsOpenOnDate(day) {
+			days = append(days, planned)
+			continue
+		}
+
+		if holiday := off.holidayOn(day, us.LocationID); holiday != nil {
+			planned.Type = "holiday"
+			planned.HolidayName = holiday.Name
+		} else if leave := off.leaveOn(day); leave != nil {
+			planned.Type = "leave"
+			planned.LeaveType = leave.Type
+		} else {
+			planned.Type = "work"
+
+			local := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, us.Location.TimeLocation())
+			if start, err := parseTimeOfDay(us.Schedule.CheckInStart); err == nil {
+				checkIn := time.Date(local.Year(), local.Month(), local.Day(), start.Hour(), start.Minute(), start.Second(), 0, local.Location())
+				planned.ExpectedCheckIn = &checkIn
+			}
+			if deadline, checkOut, ok := us.Schedule.ScheduledTimes(local); ok {
+				planned.CheckInDeadline = &deadline
+				planned.ExpectedCheckOut = &checkOut
+			}
+		}
+
+		days = append(days, planned)
+	}
+
+	return days, nil
+}
+
+// validateAssignmentDates rejects an end before the start and effective dates
+// outside the configured horizon around today, which usually are typos
+func (s *ScheduleService) validateAssignmentDates(from time.Time, to *time.Time) error {
+	if to != nil && to.Before(from) {
+		return &FieldError{Field: "effective_to", Message: "must not be before effective_from"}
+	}
+
+	today, _ := parseDate(s.clock.Now().Format("2006-01-02"))
+	cfg := s.config.Schedule
+	for _, date := range []struct {
+		field string
+		value *time.Time
+	}{
+		{"effective_from", &from},
+		{"effective_to", to},
+	} {
+		if date.value == nil {
+			continue
+		}
+		if cfg.AssignmentMaxFutureDays > 0 && date.value.After(today.AddDate(0, 0, cfg.AssignmentMaxFutureDays)) {
+			return &FieldError{Field: date.field, Message: fmt.Sprintf("must not be more than %d days in the future", cfg.AssignmentMaxFutureDays)}
+		}
+		if cfg.AssignmentMaxPastDays > 0 && date.value.Before(today.AddDate(0, 0, -cfg.AssignmentMaxPastDays)) {
+			return &FieldError{Field: date.field, Message: fmt.Sprintf("must not be more than %d days in the past", cfg.AssignmentMaxPastDays)}
+		}
+	}
+
+	return nil
+}
+
+// assignDefaultSchedule gives a new user the configured default schedule, open-ended
+// from the day of from. Nothing is assigned when no default is configured or the
+// user already has an assignment covering that day, so assignments never overlap.
+func assignDefaultSchedule(db *gorm.DB, cfg config.ScheduleConfig, userID uint, from time.Time) error {
+	if cfg.DefaultScheduleID == 0 || cfg.DefaultLocationID == 0 {
+		return nil
+	}
+
+	effectiveFrom := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	date := effectiveFrom.Format("2006-01-02")
+
+	var existing int64
+	err := db.Model(&model.UserSchedule{}).
+		Where("user_id = ? AND (effective_to IS NULL OR effective_to >= ?)", userID, date).
+		Count(&existing).Error
+	if err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	return db.Create(&model.UserSchedule{
+		UserID:        userID,
+		ScheduleID:    cfg.DefaultScheduleID,
+		LocationID:    cfg.DefaultLocationID,
+		EffectiveFrom: effectiveFrom,
+	}).Error
 }
 
 // Helper function to parse date
 func parseDate(dateStr string) (time.Time, error) {
 	return time.Parse("2006-01-02", dateStr)
 }
+
+// parseTimeOfDay parses a schedule time in "HH:MM:SS" (or "HH:MM") format
+func parseTimeOfDay(value string) (time.Time, error) {
+	if t, err := time.Parse("15:04:05", value); err == nil {
+		return t, nil
+	}
+	return time.Parse("15:04", value)
+}
+
+// validateScheduleTimes checks every non-empty schedule time field and reports the first malformed one
+func validateScheduleTimes(checkInStart, checkInEnd, checkOutStart string) error {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"check_in_start", checkInStart},
+		{"check_in_end", checkInEnd},
+		{"check_out_start", checkOutStart},
+	}
+
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		if _, err := parseTimeOfDay(f.value); err != nil {
+			return &FieldError{Field: f.name, Message: "invalid time, expected format HH:MM:SS"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/pkg/clock"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestResolveUserSchedule(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	svc := NewScheduleService(db, clock.System(), &config.Config{})
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	// Office at HQ until Tuesday 3 March, then Branch from Wednesday 4 March
+	mock.ExpectQuery(`SELECT \* FROM "user_schedules" WHERE user_id = \$1 AND effective_from <= \$2`).
+		WithArgs(7, "2026-03-06", "2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id", "effective_from", "effective_to"}).
+			AddRow(2, 7, 2, 4, day(4), nil).
+			AddRow(1, 7, 1, 3, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), day(3)))
+	mock.ExpectQuery(`SELECT \* FROM "work_schedules"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "check_in_start", "check_in_end", "check_out_start", "work_days"}).
+			AddRow(1, "Office", "08:00:00", "09:00:00", "17:00:00", "{1,2,3,4,5}").
+			AddRow(2, "Branch", "07:00:00", "08:00:00", "16:00:00", "{1,2,3,4,5}"))
+	mock.ExpectQuery(`SELECT \* FROM "attendance_locations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ").AddRow(4, "Branch"))
+	mock.ExpectQuery(`SELECT \* FROM "holidays" WHERE date >= \$1 AND date < \$2`).
+		WithArgs("2026-03-01", "2026-03-07").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date", "name", "location_id"}).
+			AddRow(1, day(2), "HQ anniversary", 3).
+			AddRow(2, day(4), "HQ open day", 3).
+			AddRow(3, day(5), "Nyepi", nil))
+	mock.ExpectQuery(`SELECT \* FROM "leaves" WHERE user_id = \$1 AND start_date < \$2 AND end_date >= \$3`).
+		WithArgs(7, "2026-03-07", "2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "start_date", "end_date"}).
+			AddRow(1, 7, "sick", day(5), day(6)))
+
+	days, err := svc.ResolveUserSchedule(7, day(1), day(6))
+	if err != nil {
+		t.Fatalf("ResolveUserSchedule() error = %v", err)
+	}
+
+	want := []struct {
+		date     string
+		typ      string
+		schedule string
+		holiday  string
+		leave    string
+	}{
+		{"2026-03-01", "off", "Office", "", ""},
+		{"2026-03-02", "holiday", "Office", "HQ anniversary", ""},
+		{"2026-03-03", "work", "Office", "", ""},
+		{"2026-03-04", "work", "Branch", "", ""}, // the HQ holiday does not apply at Branch
+		{"2026-03-05", "holiday", "Branch", "Nyepi", ""},
+		{"2026-03-06", "leave", "Branch", "", "sick"},
+	}
+	if len(days) != len(want) {
+		t.Fatalf("got %d days, want %d", len(days), len(want))
+	}
+	for i, w := range want {
+		got := days[i]
+		if got.Date != w.date || got.Type != w.typ || got.ScheduleName != w.schedule || got.HolidayName != w.holiday || got.LeaveType != w.leave {
+			t.Errorf("day %d = {%s %s %s %q %q}, want {%s %s %s %q %q}", i,
+				got.Date, got.Type, got.ScheduleName, got.HolidayName, got.LeaveType,
+				w.date, w.typ, w.schedule, w.holiday, w.leave)
+		}
+		if hasTimes := got.ExpectedCheckIn != nil; hasTimes != (w.typ == "work") {
+			t.Errorf("%s: expected check-in set = %v, want %v", w.date, hasTimes, w.typ == "work")
+		}
+	}
+}
+
+func TestValidateScheduleTimes(t *testing.T) {
+	tests := []struct {
+		name                                    string
+		checkInStart, checkInEnd, checkOutStart string
+		wantField                               string
+	}{
+		{"all valid", "08:00:00", "09:00:00", "17:00:00", ""},
+		{"without seconds", "08:00", "09:00", "17:00", ""},
+		{"empty fields are skipped", "", "09:00:00", "", ""},
+		{"malformed check-in start", "8am", "09:00:00", "17:00:00", "check_in_start"},
+		{"out of range check-in end", "08:00:00", "25:00:00", "17:00:00", "check_in_end"},
+		{"malformed check-out start", "08:00:00", "09:00:00", "17.00", "check_out_start"},
+		{"first malformed field is reported", "x", "y", "z", "check_in_start"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateScheduleTimes(tt.checkInStart, tt.checkInEnd, tt.checkOutStart)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("validateScheduleTimes() error = %v, want nil", err)
+				}
+				return
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+				t.Errorf("validateScheduleTimes() error = %v, want field error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestGetAssignmentsOnDate(t *testing.T) {
+	date := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		locationID uint
+		wantWhere  string
+		wantArgs   []driver.Value
+	}{
+		{"all locations", 0, `WHERE effective_from <= \$1 AND \(effective_to IS NULL OR effective_to >= \$2\)$`, []driver.Value{"2025-03-10", "2025-03-10"}},
+		{"one location", 3, `WHERE \(effective_from <= \$1 AND \(effective_to IS NULL OR effective_to >= \$2\)\) AND location_id = \$3$`, []driver.Value{"2025-03-10", "2025-03-10", 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.MatchExpectationsInOrder(false)
+			svc := NewScheduleService(db, clock.System(), &config.Config{})
+
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "user_schedules" ` + tt.wantWhere).
+				WithArgs(tt.wantArgs...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery(`SELECT \* FROM "user_schedules" ` + strings.TrimSuffix(tt.wantWhere, "$") + ` ORDER BY location_id ASC, user_id ASC LIMIT \$\d+$`).
+				WithArgs(append(tt.wantArgs, 20)...).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_id", "location_id"}).AddRow(1, 7, 2, 3))
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(7, "Budi"))
+			mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE "work_schedules"."id" = \$1`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Office"))
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "HQ"))
+
+			assignments, total, err := svc.GetAssignmentsOnDate(date, tt.locationID, 20, 0)
+			if err != nil {
+				t.Fatalf("GetAssignmentsOnDate() error = %v", err)
+			}
+			if total != 1 || len(assignments) != 1 {
+				t.Fatalf("GetAssignmentsOnDate() = %d assignments of %d, want 1 of 1", len(assignments), total)
+			}
+			if got := assignments[0]; got.User.FullName != "Budi" || got.Schedule.Name != "Office" || got.Location.Name != "HQ" {
+				t.Errorf("relations = (%q, %q, %q), want (Budi, Office, HQ)", got.User.FullName, got.Schedule.Name, got.Location.Name)
+			}
+		})
+	}
+}
+
+func TestCreateScheduleUniqueName(t *testing.T) {
+	tests := []struct {
+		name           string
+		existing       bool
+		raced          bool // created by another request between the lookup and the insert
+		returnExisting bool
+		wantErr        string
+		wantCreated    bool
+		wantID         uint
+	}{
+		{"new name", false, false, false, "", true, 5},
+		{"name taken in another case", true, false, false, "schedule name already exists", false, 0},
+		{"name taken, returning the existing one", true, false, true, "", false, 2},
+		{"name taken concurrently", false, true, false, "schedule name already exists", false, 0},
+		{"name taken concurrently, returning the existing one", false, true, true, "", false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewScheduleService(db, clock.System(), &config.Config{})
+
+			rows := sqlmock.NewRows([]string{"id", "name"})
+			if tt.existing {
+				rows.AddRow(2, "morning shift")
+			}
+			mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE LOWER\(name\) = LOWER\(\$1\) AND id <> \$2`).
+				WithArgs("Morning Shift", 0, 1).
+				WillReturnRows(rows)
+			if tt.wantCreated {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "work_schedules"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				mock.ExpectCommit()
+			}
+			if tt.raced {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "work_schedules"`).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_work_schedules_name_lower"})
+				mock.ExpectRollback()
+				if tt.returnExisting {
+					mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE LOWER\(name\) = LOWER\(\$1\) AND id <> \$2`).
+						WithArgs("Morning Shift", 0, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "morning shift"))
+				}
+			}
+
+			req := &CreateScheduleRequest{
+				Name:          " Morning Shift ",
+				CheckInStart:  "08:00:00",
+				CheckInEnd:    "09:00:00",
+				CheckOutStart: "17:00:00",
+				WorkDays:      []int{1, 2, 3, 4, 5},
+			}
+			schedule, created, err := svc.CreateSchedule(req, tt.returnExisting)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("CreateSchedule() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateSchedule() error = %v", err)
+			}
+			if created != tt.wantCreated || schedule.ID != tt.wantID {
+				t.Errorf("CreateSchedule() = schedule %d created %v, want schedule %d created %v", schedule.ID, created, tt.wantID, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestAssignDefaultSchedule(t *testing.T) {
+	registeredAt := time.Date(2026, 3, 9, 14, 25, 0, 0, time.UTC)
+	configured := config.ScheduleConfig{DefaultScheduleID: 2, DefaultLocationID: 3}
+
+	tests := []struct {
+		name       string
+		cfg        config.ScheduleConfig
+		existing   int // -1 when no lookup is expected
+		wantInsert bool
+	}{
+		{"not configured", config.ScheduleConfig{}, -1, false},
+		{"schedule without a location", config.ScheduleConfig{DefaultScheduleID: 2}, -1, false},
+		{"already assigned", configured, 1, false},
+		{"first assignment", configured, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+
+			if tt.existing >= 0 {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "user_schedules" WHERE user_id = \$1 AND \(effective_to IS NULL OR effective_to >= \$2\)`).
+					WithArgs(7, "2026-03-09").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.existing))
+			}
+			if tt.wantInsert {
+				// Open-ended from the start of the day the user was created
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "user_schedules" \("user_id","schedule_id","location_id","effective_from","effective_to","created_by","created_at","updated_at"\)`).
+					WithArgs(7, 2, 3, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			if err := assignDefaultSchedule(db, tt.cfg, 7, registeredAt); err != nil {
+				t.Fatalf("assignDefaultSchedule() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateAssignmentDates(t *testing.T) {
+	now := time.Date(2026, 3, 9, 15, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time { return time.Date(2026, 3, 9+offset, 0, 0, 0, 0, time.UTC) }
+	dayPtr := func(offset int) *time.Time { d := day(offset); return &d }
+	bounded := config.ScheduleConfig{AssignmentMaxFutureDays: 730, AssignmentMaxPastDays: 365}
+
+	tests := []struct {
+		name      string
+		cfg       config.ScheduleConfig
+		from      time.Time
+		to        *time.Time
+		wantField string
+	}{
+		{"open-ended from today", bounded, day(0), nil, ""},
+		{"single day", bounded, day(3), dayPtr(3), ""},
+		{"end before start", bounded, day(3), dayPtr(2), "effective_to"},
+		{"at the future bound", bounded, day(730), nil, ""},
+		{"beyond the future bound", bounded, day(731), nil, "effective_from"},
+		{"end beyond the future bound", bounded, day(0), dayPtr(731), "effective_to"},
+		{"at the past bound", bounded, day(-365), nil, ""},
+		{"beyond the past bound", bounded, day(-366), nil, "effective_from"},
+		{"bounds disabled", config.ScheduleConfig{}, day(-5000), dayPtr(5000), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewScheduleService(nil, clock.Fixed(now), &config.Config{Schedule: tt.cfg})
+			err := svc.validateAssignmentDates(tt.from, tt.to)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("validateAssignmentDates() error = %v", err)
+				}
+				return
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+				t.Errorf("validateAssignmentDates() error = %v, want a field error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestGetSchedulesByLocation(t *testing.T) {
+	// Just before midnight, so a test reading the real clock could see another day
+	now := time.Date(2026, 3, 9, 23, 59, 59, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		found   bool
+		wantErr error
+	}{
+		{"unknown location", false, ErrLocationNotFound},
+		{"schedules in name order", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewScheduleService(db, clock.Fixed(now), &config.Config{})
+
+			rows := sqlmock.NewRows([]string{"id", "name"})
+			if tt.found {
+				rows.AddRow(3, "HQ")
+			}
+			mock.ExpectQuery(`SELECT \* FROM "attendance_locations" WHERE "attendance_locations"."id" = \$1`).
+				WithArgs(3, 1).
+				WillReturnRows(rows)
+
+			if tt.found {
+				// Only assignments effective today count
+				active := `WHERE us.location_id = \$1 AND us.effective_from <= \$2 AND \(us.effective_to IS NULL OR us.effective_to >= \$3\)`
+				today := "2026-03-09"
+				mock.ExpectQuery(`SELECT COUNT\(DISTINCT\("us"."schedule_id"\)\) FROM user_schedules us `+active).
+					WithArgs(3, today, today).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectQuery(`SELECT us.schedule_id, COUNT\(DISTINCT us.user_id\) AS assigned_users FROM user_schedules us JOIN work_schedules ws ON ws.id = us.schedule_id `+active+
+					` GROUP BY us.schedule_id, ws.name ORDER BY ws.name ASC, us.schedule_id ASC LIMIT \$4`).
+					WithArgs(3, today, today, 20).
+					WillReturnRows(sqlmock.NewRows([]string{"schedule_id", "assigned_users"}).AddRow(5, 4).AddRow(2, 11))
+				mock.ExpectQuery(`SELECT \* FROM "work_schedules" WHERE id IN \(\$1,\$2\)`).
+					WithArgs(5, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Office").AddRow(5, "Early"))
+			}
+
+			schedules, total, err := svc.GetSchedulesByLocation(3, 20, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetSchedulesByLocation() error = %v, want %v", err, tt.wantErr)
+			}
+			if !tt.found {
+				return
+			}
+			if total != 2 || len(schedules) != 2 {
+				t.Fatalf("GetSchedulesByLocation() = %d schedules of %d, want 2 of 2", len(schedules), total)
+			}
+			if schedules[0].Schedule.Name != "Early" || schedules[0].AssignedUsers != 4 || schedules[1].Schedule.Name != "Office" || schedules[1].AssignedUsers != 11 {
+				t.Errorf("GetSchedulesByLocation() = %+v, want Early (4) then Office (11)", schedules)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/attendance/backend/pkg/pdf"
+)
+
+// SummaryRenderer turns a monthly summary into a printable document.
+// The PDF renderer is used in production; tests can plug in their own.
+type SummaryRenderer interface {
+	RenderMonthlySummary(summary *MonthlySummary) ([]byte, error)
+}
+
+// PDFSummaryRenderer renders a monthly summary as a one page A4 sheet
+type PDFSummaryRenderer struct{}
+
+func NewPDFSummaryRenderer() *PDFSummaryRenderer {
+	return &PDFSummaryRenderer{}
+}
+
+// RenderMonthlySummary lists every day of the month with its status followed by the totals.
+// Days without a status (future work days) are shown as "-".
+func (r *PDFSummaryRenderer) RenderMonthlySummary(summary *MonthlySummary) ([]byte, error) {
+	const (
+		left       = 56.0
+		lineHeight = 16.0
+	)
+
+	doc := pdf.New()
+	doc.AddPage()
+
+	start := time.Date(summary.Year, time.Month(summary.Month), 1, 0, 0, 0, 0, time.Local)
+	y := pdf.PageHeight - 64
+
+	doc.Text(left, y, 16, true, "Monthly Attendance Sheet")
+	y -= 22
+	doc.Text(left, y, 11, false, fmt.Sprintf("%s (ID %d) - %s", summary.UserName, summary.UserID, start.Format("January 2006")))
+	y -= 28
+
+	doc.Text(left, y, 10, true, "Date")
+	doc.Text(left+100, y, 10, true, "Day")
+	doc.Text(left+180, y, 10, true, "Status")
+	y -= 6
+	doc.Line(left, y, pdf.PageWidth-left, y)
+	y -= lineHeight
+
+	for day := start; day.Month() == start.Month(); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		status, ok := summary.Days[date]
+		if !ok {
+			status = "-"
+		}
+		doc.Text(left, y, 10, false, date)
+		doc.Text(left+100, y, 10, false, day.Format("Mon"))
+		doc.Text(left+180, y, 10, false, status)
+		y -= lineHeight
+	}
+
+	y -= 6
+	doc.Line(left, y+lineHeight-4, pdf.PageWidth-left, y+lineHeight-4)
+	totals := []struct {
+		label string
+		value string
+	}{
+		{"Scheduled days", fmt.Sprint(summary.ScheduledDays)},
+		{"Present", fmt.Sprint(summary.Present)},
+		{"Early", fmt.Sprint(summary.Early)},
+		{"Late", fmt.Sprint(summary.Late)},
+		{"Half day", fmt.Sprint(summary.HalfDay)},
+		{"Remote", fmt.Sprint(summary.Remote)},
+		{"Absent", fmt.Sprint(summary.Absent)},
+		{"Holiday", fmt.Sprint(summary.Holiday)},
+		{"Leave", fmt.Sprint(summary.Leave)},
+		{"Total hours", fmt.Sprintf("%.2f", summary.TotalHours)},
+		{"Overtime hours", fmt.Sprintf("%.2f", summary.OvertimeHours)},
+	}
+	for _, total := range totals {
+		doc.Text(left, y, 10, true, total.label)
+		doc.Text(left+180, y, 10, false, total.value)
+		y -= lineHeight
+	}
+
+	return doc.Bytes(), nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPDFSummaryRendererRenderMonthlySummary(t *testing.T) {
+	summary := &MonthlySummary{
+		UserID:   7,
+		UserName: "Budi (HQ)",
+		Year:     2026,
+		Month:    2,
+		Days:     map[string]string{"2026-02-02": "late", "2026-02-03": "present"},
+		PeriodTotals: PeriodTotals{
+			ScheduledDays: 20,
+			Present:       1,
+			Late:          1,
+			TotalHours:    16.5,
+			OvertimeHours: 0.25,
+		},
+	}
+
+	out, err := NewPDFSummaryRenderer().RenderMonthlySummary(summary)
+	if err != nil {
+		t.Fatalf("RenderMonthlySummary() error = %v", err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-")) {
+		t.Fatalf("output is not a PDF: %q", out[:16])
+	}
+
+	for _, want := range []string{
+		`(Budi \(HQ\) \(ID 7\) - February 2026)`,
+		"(2026-02-01)", "(2026-02-28)", // every day of the month
+		"(late)", "(present)",
+		"(-)", // days without a status
+		"(16.50)", "(0.25)",
+	} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("PDF lacks %q", want)
+		}
+	}
+	if bytes.Contains(out, []byte("(2026-03-01)")) {
+		t.Errorf("PDF lists a day of the next month")
+	}
+}
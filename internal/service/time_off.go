@@ -0,0 +1,68 @@
+package service
+
+import (
+	"time"
+
+	"github.com/attendance/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// timeOff holds the holidays and a user's leave within a period, for deciding which
+// scheduled work days the user is excused from
+type timeOff struct {
+	holidays []model.Holiday
+	leaves   []model.Leave
+}
+
+// loadTimeOff loads the holidays in [start, end) and the user's leave overlapping it
+func loadTimeOff(db *gorm.DB, userID uint, start, end time.Time) (*timeOff, error) {
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+
+	var off timeOff
+	if err := db.Where("date >= ? AND date < ?", from, to).Find(&off.holidays).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ? AND start_date < ? AND end_date >= ?", userID, to, from).
+		Find(&off.leaves).Error; err != nil {
+		return nil, err
+	}
+	return &off, nil
+}
+
+// on returns "holiday" or "leave" when the user is excused on day at locationID (0 when
+// they have no assigned location), or "" when they are not. A holiday wins over leave.
+func (t *timeOff) on(day time.Time, locationID uint) string {
+	if t.holidayOn(day, locationID) != nil {
+		return "holiday"
+	}
+	if t.leaveOn(day) != nil {
+		return "leave"
+	}
+	return ""
+}
+
+// holidayOn returns the holiday on day at locationID, or nil when there is none
+func (t *timeOff) holidayOn(day time.Time, locationID uint) *model.Holiday {
+	if t == nil {
+		return nil
+	}
+	for i := range t.holidays {
+		if t.holidays[i].AppliesTo(day, locationID) {
+			return &t.holidays[i]
+		}
+	}
+	return nil
+}
+
+// leaveOn returns the user's leave covering day, or nil when they are not on leave
+func (t *timeOff) leaveOn(day time.Time) *model.Leave {
+	if t == nil {
+		return nil
+	}
+	for i := range t.leaves {
+		if t.leaves[i].Covers(day) {
+			return &t.leaves[i]
+		}
+	}
+	return nil
+}
@@ -1,37 +1,65 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
 
+	"github.com/attendance/backend/internal/config"
 	"github.com/attendance/backend/internal/model"
+	"github.com/attendance/backend/pkg/clock"
 	"gorm.io/gorm"
 )
 
+// ErrPasswordReused is returned when a new password matches a recent one
+var ErrPasswordReused = errors.New("password was used recently")
+
 type UserService struct {
-	db *gorm.DB
+	db             *gorm.DB
+	webhookService *WebhookService
+	auditService   *AuditService
+	clock          clock.Clock
+	config         *config.Config
 }
 
-func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(db *gorm.DB, webhookService *WebhookService, auditService *AuditService, clk clock.Clock, cfg *config.Config) *UserService {
+	return &UserService{
+		db:             db,
+		webhookService: webhookService,
+		auditService:   auditService,
+		clock:          clk,
+		config:         cfg,
+	}
 }
 
 // CreateUserRequest represents the request to create a user
 type CreateUserRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	FullName string `json:"full_name" binding:"required"`
-	Phone    string `json:"phone"`
-	Role     string `json:"role" binding:"required,oneof=admin user"`
+	Email        string `json:"email" binding:"required,email"`
+	Password     string `json:"password" binding:"required,min=6"`
+	FullName     string `json:"full_name" binding:"required"`
+	Phone        string `json:"phone"`
+	EmployeeID   string `json:"employee_id" binding:"omitempty,max=50"`
+	Role         string `json:"role" binding:"required,oneof=admin user"`
+	DepartmentID *uint  `json:"department_id"`
 }
 
 // UpdateUserRequest represents the request to update a user
 type UpdateUserRequest struct {
-	Email    string `json:"email" binding:"omitempty,email"`
-	FullName string `json:"full_name"`
-	Phone    string `json:"phone"`
-	Role     string `json:"role" binding:"omitempty,oneof=admin user"`
-	IsActive *bool  `json:"is_active"`
+	Email        string `json:"email" binding:"omitempty,email"`
+	FullName     string `json:"full_name"`
+	Phone        string `json:"phone"`
+	EmployeeID   string `json:"employee_id" binding:"omitempty,max=50"`
+	Role         string `json:"role" binding:"omitempty,oneof=admin user"`
+	IsActive     *bool  `json:"is_active"`
+	DepartmentID *uint  `json:"department_id"` // 0 removes the user from their department
+
+	DeactivationReason string `json:"deactivation_reason" binding:"max=255"` // recorded when is_active turns false
+	ReactivationNote   string `json:"reactivation_note" binding:"max=500"`   // audit note when is_active turns true
 }
 
 // ChangePasswordRequest represents the request to change user password
@@ -52,11 +80,61 @@ type UpdateMyPasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
 
-// GetAllUsers retrieves all users
-func (s *UserService) GetAllUsers() ([]model.User, error) {
+// BulkUserIDsRequest represents a request targeting several users at once
+type BulkUserIDsRequest struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1,max=500"`
+	Reason  string `json:"reason" binding:"max=255"` // deactivation reason, when deactivating
+}
+
+// BatchUsersRequest represents a request to fetch several users at once
+type BatchUsersRequest struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1,max=100"`
+}
+
+// BulkUserResult reports the outcome of a bulk operation for a single user
+type BulkUserResult struct {
+	UserID uint   `json:"user_id"`
+	Status string `json:"status"` // 'deactivated', 'already_inactive', 'not_found' or 'skipped'
+	Error  string `json:"error,omitempty"`
+}
+
+// GetUsersByIDs fetches the given users with a single query. Users are returned in
+// the order requested, duplicates once; IDs without a user are returned separately.
+func (s *UserService) GetUsersByIDs(userIDs []uint) ([]model.User, []uint, error) {
+	var users []model.User
+	if err := s.db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+
+	usersByID := make(map[uint]model.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+
+	found := make([]model.User, 0, len(users))
+	notFound := []uint{}
+	seen := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if user, ok := usersByID[id]; ok {
+			found = append(found, user)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+
+	return found, notFound, nil
+}
+
+// GetAllUsers retrieves all users matching filters (search, role, is_active)
+func (s *UserService) GetAllUsers(filters map[string]interface{}) ([]model.User, error) {
 	var users []model.User
 
-	result := s.db.Order("created_at DESC").Find(&users)
+	result := filterUsers(s.db, filters).Order("created_at DESC").Find(&users)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -64,6 +142,43 @@ func (s *UserService) GetAllUsers() ([]model.User, error) {
 	return users, nil
 }
 
+// ExportUsers calls fn for every user matching filters, oldest first, reading the
+// rows one at a time so memory use does not grow with the number of users
+func (s *UserService) ExportUsers(filters map[string]interface{}, fn func(user *model.User) error) error {
+	rows, err := filterUsers(s.db.Model(&model.User{}), filters).Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user model.User
+		if err := s.db.ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// filterUsers applies the user list filters to query
+func filterUsers(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	if search, ok := filters["search"].(string); ok && search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("email ILIKE ? OR full_name ILIKE ? OR phone ILIKE ?", pattern, pattern, pattern)
+	}
+	if role, ok := filters["role"].(string); ok && role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if isActive, ok := filters["is_active"].(bool); ok {
+		query = query.Where("is_active = ?", isActive)
+	}
+	return query
+}
+
 // GetUserByID retrieves a user by ID
 func (s *UserService) GetUserByID(userID uint) (*model.User, error) {
 	var user model.User
@@ -83,7 +198,7 @@ func (s *UserService) GetUserByID(userID uint) (*model.User, error) {
 func (s *UserService) GetUserByEmail(email string) (*model.User, error) {
 	var user model.User
 
-	result := s.db.Where("email = ?", email).First(&user)
+	result := s.db.Where("LOWER(email) = ?", normalizeEmail(email)).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, errors.New("user not found")
@@ -97,21 +212,45 @@ func (s *UserService) GetUserByEmail(email string) (*model.User, error) {
 // CreateUser creates a new user
 func (s *UserService) CreateUser(req *CreateUserRequest) (*model.User, error) {
 	// Check if email already exists
-	var existingUser model.User
-	result := s.db.Where("email = ?", req.Email).First(&existingUser)
-	if result.Error == nil {
+	email := normalizeEmail(req.Email)
+	if taken, err := isEmailTaken(s.db, email, 0); err != nil {
+		return nil, err
+	} else if taken {
 		return nil, errors.New("email already exists")
-	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return nil, result.Error
+	}
+
+	// Check if phone already exists
+	phone := strings.TrimSpace(req.Phone)
+	if taken, err := isPhoneTaken(s.db, phone, 0); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrPhoneAlreadyExists
+	}
+
+	// Check if employee ID already exists
+	employeeID := strings.TrimSpace(req.EmployeeID)
+	if taken, err := isEmployeeIDTaken(s.db, employeeID, 0); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrEmployeeIDExists
+	}
+
+	// Check if department exists
+	if req.DepartmentID != nil {
+		if _, err := getDepartmentByID(s.db, *req.DepartmentID); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create new user
 	user := &model.User{
-		Email:    req.Email,
-		FullName: req.FullName,
-		Phone:    req.Phone,
-		Role:     req.Role,
-		IsActive: true,
+		Email:        email,
+		FullName:     req.FullName,
+		Phone:        phone,
+		EmployeeID:   employeeID,
+		Role:         req.Role,
+		IsActive:     true,
+		DepartmentID: req.DepartmentID,
 	}
 
 	// Hash password
@@ -121,14 +260,25 @@ func (s *UserService) CreateUser(req *CreateUserRequest) (*model.User, error) {
 
 	// Save to database
 	if err := s.db.Create(user).Error; err != nil {
+		// A concurrent request may have taken the employee ID since the check above
+		if isUniqueViolation(err, employeeIDIndex) {
+			return nil, ErrEmployeeIDExists
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	if err := assignDefaultSchedule(s.db, s.config.Schedule, user.ID, s.clock.Now()); err != nil {
+		log.Printf("user %d: failed to assign default schedule: %v", user.ID, err)
+	}
+
+	s.webhookService.Dispatch(model.WebhookEventUserCreated, user.ToResponse())
+
 	return user, nil
 }
 
-// UpdateUser updates an existing user
-func (s *UserService) UpdateUser(userID uint, req *UpdateUserRequest) (*model.User, error) {
+// UpdateUser updates an existing user. Deactivating records the reason and time;
+// reactivating clears them and logs the previous reason with the reactivation note.
+func (s *UserService) UpdateUser(userID uint, req *UpdateUserRequest, actorID uint) (*model.User, error) {
 	// Get user
 	user, err := s.GetUserByID(userID)
 	if err != nil {
@@ -136,34 +286,93 @@ func (s *UserService) UpdateUser(userID uint, req *UpdateUserRequest) (*model.Us
 	}
 
 	// Check if email is being changed and already exists
-	if req.Email != "" && req.Email != user.Email {
-		var existingUser model.User
-		result := s.db.Where("email = ? AND id != ?", req.Email, userID).First(&existingUser)
-		if result.Error == nil {
+	if email := normalizeEmail(req.Email); email != "" && email != user.Email {
+		if taken, err := isEmailTaken(s.db, email, userID); err != nil {
+			return nil, err
+		} else if taken {
 			return nil, errors.New("email already exists")
-		} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, result.Error
 		}
-		user.Email = req.Email
+		user.Email = email
 	}
 
 	// Update fields
 	if req.FullName != "" {
 		user.FullName = req.FullName
 	}
-	if req.Phone != "" {
-		user.Phone = req.Phone
+	if phone := strings.TrimSpace(req.Phone); phone != "" && phone != user.Phone {
+		if taken, err := isPhoneTaken(s.db, phone, userID); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, ErrPhoneAlreadyExists
+		}
+		user.Phone = phone
+	}
+	if employeeID := strings.TrimSpace(req.EmployeeID); employeeID != "" && employeeID != user.EmployeeID {
+		if taken, err := isEmployeeIDTaken(s.db, employeeID, userID); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, ErrEmployeeIDExists
+		}
+		user.EmployeeID = employeeID
 	}
 	if req.Role != "" {
 		user.Role = req.Role
 	}
+	if req.DepartmentID != nil {
+		if *req.DepartmentID == 0 {
+			user.DepartmentID = nil
+		} else {
+			if _, err := getDepartmentByID(s.db, *req.DepartmentID); err != nil {
+				return nil, err
+			}
+			user.DepartmentID = req.DepartmentID
+		}
+	}
+	deactivated, reactivated := false, false
+	var auditDetails map[string]interface{}
 	if req.IsActive != nil {
-		user.IsActive = *req.IsActive
+		deactivated = user.IsActive && !*req.IsActive
+		reactivated = !user.IsActive && *req.IsActive
+		switch {
+		case deactivated:
+			deactivate(user, strings.TrimSpace(req.DeactivationReason), s.clock.Now())
+			auditDetails = map[string]interface{}{"reason": user.DeactivationReason}
+		case reactivated:
+			auditDetails = map[string]interface{}{
+				"previous_reason": user.DeactivationReason,
+				"deactivated_at":  user.DeactivatedAt,
+				"note":            strings.TrimSpace(req.ReactivationNote),
+			}
+			now := s.clock.Now()
+			user.IsActive = true
+			user.DeactivationReason = ""
+			user.DeactivatedAt = nil
+			user.ReactivatedAt = &now
+		}
 	}
 
 	// Save changes
-	if err := s.db.Save(user).Error; err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			if isUniqueViolation(err, employeeIDIndex) {
+				return ErrEmployeeIDExists
+			}
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		if deactivated {
+			return s.auditService.WithTx(tx).Log(actorID, "user.deactivated", "user", user.ID, auditDetails)
+		}
+		if reactivated {
+			return s.auditService.WithTx(tx).Log(actorID, "user.reactivated", "user", user.ID, auditDetails)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deactivated {
+		s.webhookService.Dispatch(model.WebhookEventUserDeactivated, user.ToResponse())
 	}
 
 	return user, nil
@@ -191,9 +400,159 @@ func (s *UserService) DeleteUser(userID uint) error {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
+	s.webhookService.Dispatch(model.WebhookEventUserDeleted, user.ToResponse())
+
 	return nil
 }
 
+// BulkDeactivateUsers deactivates several users in one transaction, keeping at least one
+// active admin. Unknown IDs and the acting admin are skipped and reported per ID.
+func (s *UserService) BulkDeactivateUsers(userIDs []uint, reason string, actorID uint) ([]BulkUserResult, error) {
+	results := make([]BulkUserResult, 0, len(userIDs))
+	var deactivatedUsers []model.User
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var users []model.User
+		if err := tx.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return err
+		}
+		usersByID := make(map[uint]*model.User, len(users))
+		for i := range users {
+			usersByID[users[i].ID] = &users[i]
+		}
+
+		var activeAdmins int64
+		if err := tx.Model(&model.User{}).Where("role = ? AND is_active = ?", "admin", true).Count(&activeAdmins).Error; err != nil {
+			return err
+		}
+
+		seen := make(map[uint]bool, len(userIDs))
+		for _, id := range userIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			user, ok := usersByID[id]
+			switch {
+			case !ok:
+				results = append(results, BulkUserResult{UserID: id, Status: "not_found", Error: "user not found"})
+				continue
+			case id == actorID:
+				results = append(results, BulkUserResult{UserID: id, Status: "skipped", Error: "cannot deactivate your own account"})
+				continue
+			case !user.IsActive:
+				results = append(results, BulkUserResult{UserID: id, Status: "already_inactive"})
+				continue
+			case user.Role == "admin" && activeAdmins <= 1:
+				results = append(results, BulkUserResult{UserID: id, Status: "skipped", Error: "cannot deactivate the last active admin"})
+				continue
+			}
+
+			deactivate(user, strings.TrimSpace(reason), s.clock.Now())
+			if err := tx.Model(user).Select("IsActive", "TokenVersion", "DeactivationReason", "DeactivatedAt").Updates(user).Error; err != nil {
+				return fmt.Errorf("failed to deactivate user %d: %w", id, err)
+			}
+			if user.Role == "admin" {
+				activeAdmins--
+			}
+
+			results = append(results, BulkUserResult{UserID: id, Status: "deactivated"})
+			deactivatedUsers = append(deactivatedUsers, *user)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range deactivatedUsers {
+		s.webhookService.Dispatch(model.WebhookEventUserDeactivated, user.ToResponse())
+	}
+
+	return results, nil
+}
+
+// deactivate marks the user inactive with the given reason and revokes their issued tokens
+func deactivate(user *model.User, reason string, at time.Time) {
+	user.IsActive = false
+	user.TokenVersion++
+	user.DeactivationReason = reason
+	user.DeactivatedAt = &at
+}
+
+// DeactivateInactiveUsers deactivates non-admin users whose last login or reactivation
+// (or account creation, if neither happened) is older than maxInactive. Users with an email
+// in exemptEmails are skipped. Returns the number of users deactivated.
+func (s *UserService) DeactivateInactiveUsers(now time.Time, maxInactive time.Duration, exemptEmails []string) (int, error) {
+	cutoff := now.Add(-maxInactive)
+
+	query := s.db.Where("is_active = ? AND role <> ?", true, "admin").
+		Where("COALESCE(GREATEST(last_login_at, reactivated_at), created_at) < ?", cutoff)
+	if len(exemptEmails) > 0 {
+		query = query.Where("email NOT IN ?", exemptEmails)
+	}
+
+	var users []model.User
+	if err := query.Find(&users).Error; err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		audit := s.auditService.WithTx(tx)
+		for i := range users {
+			user := &users[i]
+			deactivate(user, "inactivity", now)
+			if err := tx.Model(user).Select("IsActive", "TokenVersion", "DeactivationReason", "DeactivatedAt").Updates(user).Error; err != nil {
+				return fmt.Errorf("failed to deactivate user %d: %w", user.ID, err)
+			}
+
+			details := map[string]interface{}{
+				"last_login_at":   user.LastLoginAt,
+				"reactivated_at":  user.ReactivatedAt,
+				"inactivity_days": int(maxInactive.Hours() / 24),
+			}
+			if err := audit.LogSystem("user.auto_deactivated", "user", user.ID, details); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, user := range users {
+		s.webhookService.Dispatch(model.WebhookEventUserDeactivated, user.ToResponse())
+	}
+
+	return len(users), nil
+}
+
+// StartInactivityDeactivation runs DeactivateInactiveUsers every interval until ctx is cancelled
+func (s *UserService) StartInactivityDeactivation(ctx context.Context, interval, maxInactive time.Duration, exemptEmails []string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if deactivated, err := s.DeactivateInactiveUsers(s.clock.Now(), maxInactive, exemptEmails); err != nil {
+			log.Printf("inactivity job: %v", err)
+		} else if deactivated > 0 {
+			log.Printf("inactivity job: deactivated %d users", deactivated)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // ChangeUserPassword changes a user's password
 func (s *UserService) ChangeUserPassword(userID uint, req *ChangePasswordRequest) error {
 	// Get user
@@ -202,36 +561,232 @@ func (s *UserService) ChangeUserPassword(userID uint, req *ChangePasswordRequest
 		return err
 	}
 
+	historySize := s.config.Auth.PasswordHistorySize
+	if err := checkPasswordReuse(s.db, user, req.NewPassword, historySize); err != nil {
+		return err
+	}
+
 	// Hash new password
 	if err := user.HashPassword(req.NewPassword); err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	// Save changes
-	if err := s.db.Save(user).Error; err != nil {
-		return fmt.Errorf("failed to change password: %w", err)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return fmt.Errorf("failed to change password: %w", err)
+		}
+		return recordPassword(tx, user.ID, user.PasswordHash, historySize)
+	})
+}
+
+// ResetUserPassword replaces a user's password with a random temporary one that must be
+// changed on next use, revoking their issued tokens. Unlike ChangeUserPassword the admin
+// never chooses the password; it is returned once and not stored in plain text.
+func (s *UserService) ResetUserPassword(userID, actorID uint) (string, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	password, err := generateTemporaryPassword(12)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	if err := user.HashPassword(password); err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.MustChangePassword = true
+	user.TokenVersion++
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Select("PasswordHash", "MustChangePassword", "TokenVersion").Updates(user).Error; err != nil {
+			return fmt.Errorf("failed to reset password: %w", err)
+		}
+		if err := recordPassword(tx, user.ID, user.PasswordHash, s.config.Auth.PasswordHistorySize); err != nil {
+			return err
+		}
+		return s.auditService.WithTx(tx).Log(actorID, "user.password_reset", "user", user.ID, nil)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
+
+// MergeUsersRequest represents the account a duplicate user is merged into
+type MergeUsersRequest struct {
+	TargetID uint `json:"target_id" binding:"required"`
 }
 
-// GetUserStats returns user statistics
-func (s *UserService) GetUserStats() (map[string]interface{}, error) {
+// MergeUsersResult reports how many records moved from the source to the target user
+type MergeUsersResult struct {
+	SourceID          uint  `json:"source_id"`
+	TargetID          uint  `json:"target_id"`
+	Attendances       int64 `json:"attendances"`
+	PendingArrivals   int64 `json:"pending_arrivals"`
+	ScheduleHistory   int64 `json:"schedule_history"`
+	Leaves            int64 `json:"leaves"`
+	AuditLogs         int64 `json:"audit_logs"`
+	TargetAttendances int64 `json:"target_attendances"` // total after the merge
+}
+
+// MergeUsers moves the attendance, schedule assignments, leave and audit references of a
+// duplicate account (source) to the canonical one (target) and deactivates the source,
+// all in one transaction. Merging is refused when both have attendance on the same day,
+// since a user has at most one record per day (multi-session mode allows several), and
+// when an assignment of the source overlaps one of the target's, since a user's
+// assignments never overlap.
+func (s *UserService) MergeUsers(sourceID, targetID, actorID uint) (*MergeUsersResult, error) {
+	if sourceID == targetID {
+		return nil, errors.New("cannot merge a user into itself")
+	}
+
+	source, err := s.GetUserByID(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.GetUserByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if source.Role == "admin" && target.Role != "admin" {
+		return nil, errors.New("cannot merge an admin into a non-admin user")
+	}
+
+	result := &MergeUsersResult{SourceID: sourceID, TargetID: targetID}
+	wasActive := source.IsActive
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if !s.config.Attendance.MultiSession {
+			var overlapping int64
+			if err := tx.Model(&model.Attendance{}).
+				Where("user_id = ?", sourceID).
+				Where("DATE(check_in_time) IN (?)", tx.Model(&model.Attendance{}).Select("DATE(check_in_time)").Where("user_id = ?", targetID)).
+				Count(&overlapping).Error; err != nil {
+				return err
+			}
+			if overlapping > 0 {
+				return fmt.Errorf("both users have attendance on %d of the same days", overlapping)
+			}
+		}
+
+		// An open-ended assignment runs on forever, so a missing effective_to never ends first
+		var overlappingSchedules int64
+		if err := tx.Model(&model.UserSchedule{}).
+			Where("user_id = ?", sourceID).
+			Where(`EXISTS (SELECT 1 FROM user_schedules ts WHERE ts.user_id = ?
+				AND ts.effective_from <= COALESCE(user_schedules.effective_to, ts.effective_from)
+				AND user_schedules.effective_from <= COALESCE(ts.effective_to, user_schedules.effective_from))`, targetID).
+			Count(&overlappingSchedules).Error; err != nil {
+			return err
+		}
+		if overlappingSchedules > 0 {
+			return fmt.Errorf("both users have schedule assignments covering the same days (%d overlapping)", overlappingSchedules)
+		}
+
+		// Leave runs through its end date, so ranges sharing a single day overlap
+		var overlappingLeaves int64
+		if err := tx.Model(&model.Leave{}).
+			Where("user_id = ?", sourceID).
+			Where(`EXISTS (SELECT 1 FROM leaves tl WHERE tl.user_id = ?
+				AND tl.start_date <= leaves.end_date AND leaves.start_date <= tl.end_date)`, targetID).
+			Count(&overlappingLeaves).Error; err != nil {
+			return err
+		}
+		if overlappingLeaves > 0 {
+			return fmt.Errorf("both users have leave covering the same days (%d overlapping)", overlappingLeaves)
+		}
+
+		moves := []struct {
+			table interface{}
+			where string
+			args  []interface{}
+			set   map[string]interface{}
+			count *int64
+		}{
+			{&model.Attendance{}, "user_id = ?", []interface{}{sourceID}, map[string]interface{}{"user_id": targetID}, &result.Attendances},
+			{&model.AttendanceArrival{}, "user_id = ?", []interface{}{sourceID}, map[string]interface{}{"user_id": targetID}, &result.PendingArrivals},
+			{&model.UserSchedule{}, "user_id = ?", []interface{}{sourceID}, map[string]interface{}{"user_id": targetID}, &result.ScheduleHistory},
+			{&model.Leave{}, "user_id = ?", []interface{}{sourceID}, map[string]interface{}{"user_id": targetID}, &result.Leaves},
+			{&model.AuditLog{}, "entity_type = ? AND entity_id = ?", []interface{}{"user", sourceID}, map[string]interface{}{"entity_id": targetID}, &result.AuditLogs},
+		}
+		for _, move := range moves {
+			// Unscoped so records of the source that sit in the trash move along too
+			update := tx.Unscoped().Model(move.table).Where(move.where, move.args...).Updates(move.set)
+			if update.Error != nil {
+				return update.Error
+			}
+			*move.count = update.RowsAffected
+		}
+
+		// Actions the duplicate account performed itself
+		actions := tx.Model(&model.AuditLog{}).Where("actor_id = ?", sourceID).Update("actor_id", targetID)
+		if actions.Error != nil {
+			return actions.Error
+		}
+		result.AuditLogs += actions.RowsAffected
+
+		// Cached aggregates of both users are stale now; they are rebuilt on demand
+		if err := tx.Where("user_id IN ?", []uint{sourceID, targetID}).Delete(&model.UserAttendanceStats{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.Attendance{}).Where("user_id = ?", targetID).Count(&result.TargetAttendances).Error; err != nil {
+			return err
+		}
+
+		if source.IsActive {
+			deactivate(source, fmt.Sprintf("merged into user %d", targetID), s.clock.Now())
+			if err := tx.Model(source).Select("IsActive", "TokenVersion", "DeactivationReason", "DeactivatedAt").Updates(source).Error; err != nil {
+				return err
+			}
+		}
+
+		return s.auditService.WithTx(tx).Log(actorID, "user.merged", "user", targetID, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if wasActive {
+		s.webhookService.Dispatch(model.WebhookEventUserDeactivated, source.ToResponse())
+	}
+
+	return result, nil
+}
+
+// GetUserStats returns user statistics, scoped to a department when departmentID is set
+func (s *UserService) GetUserStats(departmentID *uint) (map[string]interface{}, error) {
 	var totalUsers int64
 	var activeUsers int64
 	var adminUsers int64
 	var regularUsers int64
 
-	s.db.Model(&model.User{}).Count(&totalUsers)
-	s.db.Model(&model.User{}).Where("is_active = ?", true).Count(&activeUsers)
-	s.db.Model(&model.User{}).Where("role = ?", "admin").Count(&adminUsers)
-	s.db.Model(&model.User{}).Where("role = ?", "user").Count(&regularUsers)
+	users := func() *gorm.DB {
+		query := s.db.Model(&model.User{})
+		if departmentID != nil {
+			query = query.Where("department_id = ?", *departmentID)
+		}
+		return query
+	}
+
+	if departmentID != nil {
+		if _, err := getDepartmentByID(s.db, *departmentID); err != nil {
+			return nil, err
+		}
+	}
+
+	users().Count(&totalUsers)
+	users().Where("is_active = ?", true).Count(&activeUsers)
+	users().Where("role = ?", "admin").Count(&adminUsers)
+	users().Where("role = ?", "user").Count(&regularUsers)
 
 	stats := map[string]interface{}{
-		"total_users":   totalUsers,
-		"active_users":  activeUsers,
-		"admin_users":   adminUsers,
-		"regular_users": regularUsers,
+		"total_users":    totalUsers,
+		"active_users":   activeUsers,
+		"admin_users":    adminUsers,
+		"regular_users":  regularUsers,
 		"inactive_users": totalUsers - activeUsers,
 	}
 
@@ -247,23 +802,26 @@ func (s *UserService) UpdateMyProfile(userID uint, req *UpdateMyProfileRequest)
 	}
 
 	// Check if email is being changed and already exists
-	if req.Email != "" && req.Email != user.Email {
-		var existingUser model.User
-		result := s.db.Where("email = ? AND id != ?", req.Email, userID).First(&existingUser)
-		if result.Error == nil {
+	if email := normalizeEmail(req.Email); email != "" && email != user.Email {
+		if taken, err := isEmailTaken(s.db, email, userID); err != nil {
+			return nil, err
+		} else if taken {
 			return nil, errors.New("email already exists")
-		} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, result.Error
 		}
-		user.Email = req.Email
+		user.Email = email
 	}
 
 	// Update fields
 	if req.FullName != "" {
 		user.FullName = req.FullName
 	}
-	if req.Phone != "" {
-		user.Phone = req.Phone
+	if phone := strings.TrimSpace(req.Phone); phone != "" && phone != user.Phone {
+		if taken, err := isPhoneTaken(s.db, phone, userID); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, ErrPhoneAlreadyExists
+		}
+		user.Phone = phone
 	}
 
 	// Save changes
@@ -287,15 +845,137 @@ func (s *UserService) UpdateMyPassword(userID uint, req *UpdateMyPasswordRequest
 		return errors.New("old password is incorrect")
 	}
 
+	historySize := s.config.Auth.PasswordHistorySize
+	if err := checkPasswordReuse(s.db, user, req.NewPassword, historySize); err != nil {
+		return err
+	}
+
 	// Hash new password
 	if err := user.HashPassword(req.NewPassword); err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
+	user.MustChangePassword = false
 
 	// Save changes
-	if err := s.db.Save(user).Error; err != nil {
-		return fmt.Errorf("failed to update password: %w", err)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+		return recordPassword(tx, user.ID, user.PasswordHash, historySize)
+	})
+}
+
+// checkPasswordReuse rejects password when it matches one of the user's last n
+// passwords: the current one and those kept in the password history. n of 0 disables it.
+func checkPasswordReuse(db *gorm.DB, user *model.User, password string, n int) error {
+	if n <= 0 {
+		return nil
 	}
 
+	var history []model.PasswordHistory
+	if err := db.Where("user_id = ?", user.ID).Order("created_at DESC, id DESC").Limit(n).Find(&history).Error; err != nil {
+		return err
+	}
+
+	// The current password is normally the newest history entry, but accounts
+	// created before the history existed only have it on the user
+	hashes := []string{user.PasswordHash}
+	for _, entry := range history {
+		if entry.PasswordHash != user.PasswordHash {
+			hashes = append(hashes, entry.PasswordHash)
+		}
+	}
+	if len(hashes) > n {
+		hashes = hashes[:n]
+	}
+
+	for _, hash := range hashes {
+		previous := model.User{PasswordHash: hash}
+		if previous.CheckPassword(password) {
+			return fmt.Errorf("%w, it must differ from the last %d", ErrPasswordReused, n)
+		}
+	}
 	return nil
 }
+
+// recordPassword adds hash to the user's password history and prunes all but the newest n entries
+func recordPassword(db *gorm.DB, userID uint, hash string, n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	if err := db.Create(&model.PasswordHistory{UserID: userID, PasswordHash: hash}).Error; err != nil {
+		return fmt.Errorf("failed to record password history: %w", err)
+	}
+
+	newest := db.Model(&model.PasswordHistory{}).Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(n)
+	return db.Where("user_id = ? AND id NOT IN (?)", userID, newest).Delete(&model.PasswordHistory{}).Error
+}
+
+// normalizeEmail returns email in the form it is stored and compared in: trimmed
+// and lower-cased, so one address cannot be registered twice in different cases
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// isEmailTaken reports whether email, normalized, belongs to a user other than excludeID.
+// Stored emails are compared lower-cased too, which covers those written before
+// emails were normalized.
+func isEmailTaken(db *gorm.DB, email string, excludeID uint) (bool, error) {
+	var count int64
+	if err := db.Model(&model.User{}).Where("LOWER(email) = ? AND id != ?", email, excludeID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// isPhoneTaken reports whether phone belongs to a user other than excludeID.
+// An empty phone is never taken.
+func isPhoneTaken(db *gorm.DB, phone string, excludeID uint) (bool, error) {
+	if phone == "" {
+		return false, nil
+	}
+
+	var count int64
+	if err := db.Model(&model.User{}).Where("phone = ? AND id != ?", phone, excludeID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// employeeIDIndex is the partial unique index on users.employee_id
+const employeeIDIndex = "idx_users_employee_id"
+
+// isEmployeeIDTaken reports whether employeeID belongs to a user other than excludeID.
+// An empty employee ID is never taken.
+func isEmployeeIDTaken(db *gorm.DB, employeeID string, excludeID uint) (bool, error) {
+	if employeeID == "" {
+		return false, nil
+	}
+
+	var count int64
+	if err := db.Model(&model.User{}).Where("employee_id = ? AND id != ?", employeeID, excludeID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// temporaryPasswordAlphabet leaves out characters that are easy to confuse when read aloud
+const temporaryPasswordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+
+// generateTemporaryPassword returns a random password of the given length
+func generateTemporaryPassword(length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(temporaryPasswordAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = temporaryPasswordAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,523 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/config"
+	"github.com/attendance/backend/internal/model"
+	"github.com/attendance/backend/pkg/clock"
+)
+
+func TestMergeUsersMovesTrashedAttendance(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.System(), &config.Config{})
+
+	users := []string{"id", "role", "is_active"}
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).WithArgs(8, 1).
+		WillReturnRows(sqlmock.NewRows(users).AddRow(8, "user", false))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows(users).AddRow(7, "user", true))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "user_schedules"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "leaves"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	// No deleted_at condition: trashed records of the source move as well
+	mock.ExpectExec(`UPDATE "attendances" SET "user_id"=\$1,"updated_at"=\$2 WHERE user_id = \$3$`).
+		WithArgs(7, sqlmock.AnyArg(), 8).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE "attendance_arrivals"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "user_schedules"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "leaves"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "audit_logs" SET "entity_id"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "audit_logs" SET "actor_id"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "user_attendance_stats"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE user_id = \$1 AND "attendances"."deleted_at" IS NULL`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(`INSERT INTO "audit_logs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	result, err := svc.MergeUsers(8, 7, 1)
+	if err != nil {
+		t.Fatalf("MergeUsers() error = %v", err)
+	}
+	if result.Attendances != 3 || result.TargetAttendances != 2 {
+		t.Errorf("MergeUsers() = %+v, want 3 moved and 2 live on the target", result)
+	}
+}
+
+func TestBulkDeactivateUsers(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.System(), &config.Config{})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE id IN \(\$1,\$2,\$3,\$4,\$5,\$6\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "is_active", "token_version"}).
+			AddRow(1, "admin", true, 0).
+			AddRow(2, "user", true, 4).
+			AddRow(3, "user", false, 0).
+			AddRow(4, "admin", true, 0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE role = \$1 AND is_active = \$2`).
+		WithArgs("admin", true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	// Only user 2 is written, with its token version bumped to revoke issued tokens
+	mock.ExpectExec(`UPDATE "users" SET "is_active"=\$1,"deactivation_reason"=\$2,"deactivated_at"=\$3,"token_version"=\$4`).
+		WithArgs(false, "offboarded", sqlmock.AnyArg(), 5, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "webhooks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	results, err := svc.BulkDeactivateUsers([]uint{2, 3, 4, 1, 9, 2}, " offboarded ", 1)
+	if err != nil {
+		t.Fatalf("BulkDeactivateUsers() error = %v", err)
+	}
+
+	want := []BulkUserResult{
+		{UserID: 2, Status: "deactivated"},
+		{UserID: 3, Status: "already_inactive"},
+		{UserID: 4, Status: "skipped", Error: "cannot deactivate the last active admin"},
+		{UserID: 1, Status: "skipped", Error: "cannot deactivate your own account"},
+		{UserID: 9, Status: "not_found", Error: "user not found"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("BulkDeactivateUsers() = %+v, want %+v", results, want)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, results[i], want[i])
+		}
+	}
+}
+
+func TestDeactivateInactiveUsers(t *testing.T) {
+	now := time.Date(2026, 3, 9, 2, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-90 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		exempt   []string
+		stale    []uint
+		wantTail string
+	}{
+		{"stale user", nil, []uint{7}, `< \$3$`},
+		{"exempt emails", []string{"ops@example.com"}, []uint{7}, `< \$3 AND email NOT IN \(\$4\)$`},
+		{"no stale users", nil, nil, `< \$3$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.System(), &config.Config{})
+
+			args := []driver.Value{true, "admin", cutoff}
+			for _, email := range tt.exempt {
+				args = append(args, email)
+			}
+			rows := sqlmock.NewRows([]string{"id", "role", "is_active", "token_version"})
+			for _, id := range tt.stale {
+				rows.AddRow(id, "user", true, 2)
+			}
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE \(is_active = \$1 AND role <> \$2\) AND COALESCE\(GREATEST\(last_login_at, reactivated_at\), created_at\) ` + tt.wantTail).WithArgs(args...).WillReturnRows(rows)
+			for _, id := range tt.stale {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "users" SET "is_active"=\$1,"deactivation_reason"=\$2,"deactivated_at"=\$3,"token_version"=\$4`).
+					WithArgs(false, "inactivity", now, 3, sqlmock.AnyArg(), id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+					WithArgs(nil, "user.auto_deactivated", "user", id, jsonContaining(`"inactivity_days":90`), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`SELECT \* FROM "webhooks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			}
+
+			deactivated, err := svc.DeactivateInactiveUsers(now, 90*24*time.Hour, tt.exempt)
+			if err != nil {
+				t.Fatalf("DeactivateInactiveUsers() error = %v", err)
+			}
+			if deactivated != len(tt.stale) {
+				t.Errorf("DeactivateInactiveUsers() = %d, want %d", deactivated, len(tt.stale))
+			}
+		})
+	}
+}
+
+func TestResetUserPassword(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.System(), &config.Config{})
+
+	var hash capturedString
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "is_active", "token_version"}).AddRow(7, "user", true, 2))
+	mock.ExpectBegin()
+	// The new password must be changed on next use and issued tokens are revoked
+	mock.ExpectExec(`UPDATE "users" SET "password_hash"=\$1,"token_version"=\$2,"must_change_password"=\$3,"updated_at"=\$4 WHERE "id" = \$5`).
+		WithArgs(&hash, 3, true, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+		WithArgs(1, "user.password_reset", "user", 7, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	password, err := svc.ResetUserPassword(7, 1)
+	if err != nil {
+		t.Fatalf("ResetUserPassword() error = %v", err)
+	}
+	if len(password) != 12 || strings.Trim(password, temporaryPasswordAlphabet) != "" {
+		t.Errorf("ResetUserPassword() = %q, want 12 characters from the temporary alphabet", password)
+	}
+	stored := model.User{PasswordHash: string(hash)}
+	if !stored.CheckPassword(password) {
+		t.Errorf("stored hash does not match the returned password")
+	}
+}
+
+// capturedString matches any string argument and keeps its value
+type capturedString string
+
+func (c *capturedString) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	*c = capturedString(s)
+	return ok
+}
+
+func TestGetUserStats(t *testing.T) {
+	department := uint(4)
+
+	tests := []struct {
+		name         string
+		departmentID *uint
+		where        string
+		args         []driver.Value
+		missing      bool
+	}{
+		{"all users", nil, `WHERE `, nil, false},
+		{"one department", &department, `WHERE department_id = \$1 AND `, []driver.Value{4}, false},
+		{"unknown department", &department, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.System(), &config.Config{})
+
+			if tt.departmentID != nil {
+				rows := sqlmock.NewRows([]string{"id", "name"})
+				if !tt.missing {
+					rows.AddRow(4, "Engineering")
+				}
+				mock.ExpectQuery(`SELECT \* FROM "departments" WHERE "departments"."id" = \$1`).
+					WithArgs(4, 1).
+					WillReturnRows(rows)
+			}
+			if !tt.missing {
+				count := func(n int) *sqlmock.Rows { return sqlmock.NewRows([]string{"count"}).AddRow(n) }
+				total := `SELECT count\(\*\) FROM "users"`
+				if tt.departmentID != nil {
+					total += ` WHERE department_id = \$1`
+				}
+				mock.ExpectQuery(total + `$`).WithArgs(tt.args...).WillReturnRows(count(10))
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "users" ` + tt.where + `is_active = `).WillReturnRows(count(8))
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "users" ` + tt.where + `role = `).WillReturnRows(count(1))
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "users" ` + tt.where + `role = `).WillReturnRows(count(9))
+			}
+
+			stats, err := svc.GetUserStats(tt.departmentID)
+			if tt.missing {
+				if err == nil || err.Error() != "department not found" {
+					t.Fatalf("GetUserStats() error = %v, want department not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetUserStats() error = %v", err)
+			}
+			want := map[string]int64{"total_users": 10, "active_users": 8, "admin_users": 1, "regular_users": 9, "inactive_users": 2}
+			for key, value := range want {
+				if stats[key] != value {
+					t.Errorf("%s = %v, want %d", key, stats[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateUserActivation(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	deactivatedAt := time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)
+	active, inactive := true, false
+
+	tests := []struct {
+		name        string
+		wasActive   bool
+		req         UpdateUserRequest
+		wantAction  string
+		wantDetails string
+		wantReason  string
+		wantVersion int
+	}{
+		{"deactivate with a reason", true, UpdateUserRequest{IsActive: &inactive, DeactivationReason: " resigned "},
+			"user.deactivated", `"reason":"resigned"`, "resigned", 3},
+		{"reactivate with a note", false, UpdateUserRequest{IsActive: &active, ReactivationNote: "rehired"},
+			"user.reactivated", `"note":"rehired","previous_reason":"on leave"`, "", 2},
+		{"already active", true, UpdateUserRequest{IsActive: &active}, "", "", "", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.Fixed(now), &config.Config{})
+
+			rows := sqlmock.NewRows([]string{"id", "role", "is_active", "token_version", "deactivation_reason", "deactivated_at"})
+			if tt.wasActive {
+				rows.AddRow(7, "user", true, 2, "", nil)
+			} else {
+				rows.AddRow(7, "user", false, 2, "on leave", deactivatedAt)
+			}
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).WithArgs(7, 1).WillReturnRows(rows)
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "users" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+			if tt.wantAction != "" {
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+					WithArgs(1, tt.wantAction, "user", 7, jsonContaining(tt.wantDetails), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			}
+			mock.ExpectCommit()
+			if tt.wantAction == "user.deactivated" {
+				mock.ExpectQuery(`SELECT \* FROM "webhooks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			}
+
+			user, err := svc.UpdateUser(7, &tt.req, 1)
+			if err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+			if user.DeactivationReason != tt.wantReason || (user.DeactivatedAt != nil) != !user.IsActive {
+				t.Errorf("deactivation = (%q, %v), want (%q, set only while inactive)", user.DeactivationReason, user.DeactivatedAt, tt.wantReason)
+			}
+			// Deactivating revokes the user's issued tokens
+			if user.TokenVersion != tt.wantVersion {
+				t.Errorf("TokenVersion = %d, want %d", user.TokenVersion, tt.wantVersion)
+			}
+			// Reactivating restarts the inactivity clock, so the stale last login does not count
+			if reactivated := tt.wantAction == "user.reactivated"; reactivated != (user.ReactivatedAt != nil && user.ReactivatedAt.Equal(now)) {
+				t.Errorf("ReactivatedAt = %v, want set to now only on reactivation", user.ReactivatedAt)
+			}
+		})
+	}
+}
+
+func TestGetUsersByIDs(t *testing.T) {
+	db, mock := newMockDB(t)
+	svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.System(), &config.Config{})
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE id IN \(\$1,\$2,\$3,\$4,\$5\)`).
+		WithArgs(9, 7, 4, 7, 12).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).AddRow(4, "Ayu").AddRow(7, "Budi").AddRow(9, "Citra"))
+
+	users, notFound, err := svc.GetUsersByIDs([]uint{9, 7, 4, 7, 12})
+	if err != nil {
+		t.Fatalf("GetUsersByIDs() error = %v", err)
+	}
+
+	// In the requested order, duplicates once
+	var ids []uint
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	if fmt.Sprint(ids) != "[9 7 4]" {
+		t.Errorf("found ids = %v, want [9 7 4]", ids)
+	}
+	if fmt.Sprint(notFound) != "[12]" {
+		t.Errorf("notFound = %v, want [12]", notFound)
+	}
+}
+
+func TestMergeUsers(t *testing.T) {
+	tests := []struct {
+		name                 string
+		multiSession         bool
+		sourceID             uint
+		sourceRole           string
+		targetRole           string
+		overlapping          int
+		overlappingSchedules int
+		overlappingLeaves    int
+		wantErr              string
+	}{
+		{"into itself", false, 7, "", "", 0, 0, 0, "cannot merge a user into itself"},
+		{"admin into a non-admin", false, 9, "admin", "employee", 0, 0, 0, "cannot merge an admin into a non-admin user"},
+		{"attendance on the same days", false, 9, "employee", "employee", 2, 0, 0, "both users have attendance on 2 of the same days"},
+		{"overlapping schedule assignments", false, 9, "employee", "employee", 0, 1, 0, "both users have schedule assignments covering the same days (1 overlapping)"},
+		{"overlapping leave", false, 9, "employee", "employee", 0, 0, 2, "both users have leave covering the same days (2 overlapping)"},
+		{"moves everything", false, 9, "employee", "admin", 0, 0, 0, ""},
+		{"multi-session moves attendance on the same days", true, 9, "employee", "admin", 0, 0, 0, ""},
+	}
+
+	now := time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			cfg := &config.Config{Attendance: config.AttendanceConfig{MultiSession: tt.multiSession}}
+			svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.Fixed(now), cfg)
+
+			if tt.sourceID != 7 {
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).WithArgs(9, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "role", "is_active", "token_version"}).AddRow(9, tt.sourceRole, true, 1))
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).WithArgs(7, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "role", "is_active"}).AddRow(7, tt.targetRole, true))
+			}
+			if tt.sourceRole == "employee" {
+				mock.ExpectBegin()
+			}
+			if tt.sourceRole == "employee" && !tt.multiSession {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE user_id = \$1 AND DATE\(check_in_time\) IN \(SELECT DATE\(check_in_time\) FROM "attendances" WHERE user_id = \$2`).
+					WithArgs(9, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.overlapping))
+			}
+			if tt.sourceRole == "employee" && tt.overlapping == 0 {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "user_schedules" WHERE user_id = \$1 AND EXISTS \(SELECT 1 FROM user_schedules ts WHERE ts.user_id = \$2`).
+					WithArgs(9, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.overlappingSchedules))
+			}
+			if tt.sourceRole == "employee" && tt.overlapping == 0 && tt.overlappingSchedules == 0 {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "leaves" WHERE user_id = \$1 AND \(EXISTS \(SELECT 1 FROM leaves tl WHERE tl.user_id = \$2\s+`+
+					`AND tl.start_date <= leaves.end_date AND leaves.start_date <= tl.end_date\)`).
+					WithArgs(9, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.overlappingLeaves))
+			}
+			if tt.overlapping > 0 || tt.overlappingSchedules > 0 || tt.overlappingLeaves > 0 {
+				mock.ExpectRollback()
+			}
+			if tt.wantErr == "" {
+				// Trashed records move along, so no deleted_at condition
+				mock.ExpectExec(`UPDATE "attendances" SET "user_id"=\$1,"updated_at"=\$2 WHERE user_id = \$3$`).
+					WithArgs(7, sqlmock.AnyArg(), 9).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(`UPDATE "attendance_arrivals" SET "user_id"=\$1 WHERE user_id = \$2`).
+					WithArgs(7, 9).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE "user_schedules" SET "user_id"=\$1,"updated_at"=\$2 WHERE user_id = \$3`).
+					WithArgs(7, sqlmock.AnyArg(), 9).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(`UPDATE "leaves" SET "user_id"=\$1,"updated_at"=\$2 WHERE user_id = \$3`).
+					WithArgs(7, sqlmock.AnyArg(), 9).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE "audit_logs" SET "entity_id"=\$1 WHERE entity_type = \$2 AND entity_id = \$3`).
+					WithArgs(7, "user", 9).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE "audit_logs" SET "actor_id"=\$1 WHERE actor_id = \$2`).
+					WithArgs(7, 9).WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec(`DELETE FROM "user_attendance_stats" WHERE user_id IN \(\$1,\$2\)`).
+					WithArgs(9, 7).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "attendances" WHERE user_id = \$1`).
+					WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(8))
+				mock.ExpectExec(`UPDATE "users" SET "is_active"=\$1,"deactivation_reason"=\$2,"deactivated_at"=\$3,"token_version"=\$4,"updated_at"=\$5 WHERE "id" = \$6`).
+					WithArgs(false, "merged into user 7", now, 2, sqlmock.AnyArg(), 9).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+					WithArgs(1, "user.merged", "user", 7, jsonContaining(`"attendances":3,"pending_arrivals":1,"schedule_history":2,"leaves":1,"audit_logs":5,"target_attendances":8`), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`SELECT \* FROM "webhooks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			}
+
+			result, err := svc.MergeUsers(tt.sourceID, 7, 1)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("MergeUsers() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeUsers() error = %v", err)
+			}
+			if result.Attendances != 3 || result.Leaves != 1 || result.AuditLogs != 5 || result.TargetAttendances != 8 {
+				t.Errorf("MergeUsers() = %+v, want 3 attendances, 1 leave, 5 audit logs and 8 in total", result)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordReuse(t *testing.T) {
+	hashes := map[string]string{}
+	for _, password := range []string{"current", "previous", "oldest"} {
+		user := model.User{}
+		if err := user.HashPassword(password); err != nil {
+			t.Fatalf("HashPassword() error = %v", err)
+		}
+		hashes[password] = user.PasswordHash
+	}
+
+	tests := []struct {
+		name     string
+		n        int
+		history  []string // newest first, as the history query returns them
+		password string
+		wantErr  bool
+	}{
+		{"disabled", 0, nil, "current", false},
+		{"current password", 3, []string{"current", "previous", "oldest"}, "current", true},
+		{"recent password", 3, []string{"current", "previous", "oldest"}, "oldest", true},
+		{"older than the history size", 2, []string{"current", "previous", "oldest"}, "oldest", false},
+		{"new password", 3, []string{"current", "previous", "oldest"}, "fresh", false},
+		{"current password without history", 3, nil, "current", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			if tt.n > 0 {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "password_hash"})
+				for i, password := range tt.history {
+					rows.AddRow(len(tt.history)-i, 7, hashes[password])
+				}
+				mock.ExpectQuery(`SELECT \* FROM "password_history" WHERE user_id = \$1 ORDER BY created_at DESC, id DESC LIMIT \$2`).
+					WithArgs(7, tt.n).
+					WillReturnRows(rows)
+			}
+
+			user := &model.User{ID: 7, PasswordHash: hashes["current"]}
+			err := checkPasswordReuse(db, user, tt.password, tt.n)
+			if tt.wantErr != errors.Is(err, ErrPasswordReused) {
+				t.Errorf("checkPasswordReuse() error = %v, want reuse error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkPasswordReuse() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateMyPasswordRejectsReuse(t *testing.T) {
+	db, mock := newMockDB(t)
+	cfg := &config.Config{}
+	cfg.Auth.PasswordHistorySize = 3
+	svc := NewUserService(db, NewWebhookService(db), NewAuditService(db), clock.System(), cfg)
+
+	user := model.User{}
+	if err := user.HashPassword("current"); err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	previous := model.User{}
+	if err := previous.HashPassword("previous"); err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "is_active"}).AddRow(7, user.PasswordHash, true))
+	mock.ExpectQuery(`SELECT \* FROM "password_history"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "password_hash"}).
+			AddRow(2, 7, user.PasswordHash).
+			AddRow(1, 7, previous.PasswordHash))
+
+	// Rejected before anything is written
+	err := svc.UpdateMyPassword(7, &UpdateMyPasswordRequest{OldPassword: "current", NewPassword: "previous"})
+	if !errors.Is(err, ErrPasswordReused) {
+		t.Errorf("UpdateMyPassword() error = %v, want ErrPasswordReused", err)
+	}
+}
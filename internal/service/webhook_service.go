@@ -0,0 +1,339 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/attendance/backend/internal/model"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// ErrDeliveryInProgress is returned when replaying a delivery that is still being sent
+var ErrDeliveryInProgress = errors.New("webhook delivery is still in progress")
+
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CreateWebhookRequest represents create webhook request
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Secret string   `json:"secret"` // generated when empty
+	Events []string `json:"events" binding:"required,min=1"`
+}
+
+// UpdateWebhookRequest represents update webhook request
+type UpdateWebhookRequest struct {
+	URL      string   `json:"url" binding:"omitempty,url"`
+	Events   []string `json:"events"`
+	IsActive *bool    `json:"is_active"`
+}
+
+// CreateWebhook registers a new webhook subscription
+func (s *WebhookService) CreateWebhook(req *CreateWebhookRequest, createdBy uint) (*model.Webhook, error) {
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	webhook := model.Webhook{
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    pq.StringArray(req.Events),
+		IsActive:  true,
+		CreatedBy: &createdBy,
+	}
+
+	if err := s.db.Create(&webhook).Error; err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// GetWebhookByID retrieves webhook by ID
+func (s *WebhookService) GetWebhookByID(id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := s.db.First(&webhook, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook not found")
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetAllWebhooks retrieves all webhooks
+func (s *WebhookService) GetAllWebhooks() ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := s.db.Order("created_at DESC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook updates webhook information
+func (s *WebhookService) UpdateWebhook(id uint, req *UpdateWebhookRequest) (*model.Webhook, error) {
+	webhook, err := s.GetWebhookByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != "" {
+		webhook.URL = req.URL
+	}
+	if len(req.Events) > 0 {
+		if err := validateWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+		webhook.Events = pq.StringArray(req.Events)
+	}
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+
+	if err := s.db.Save(webhook).Error; err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook
+func (s *WebhookService) DeleteWebhook(id uint) error {
+	if _, err := s.GetWebhookByID(id); err != nil {
+		return err
+	}
+
+	return s.db.Delete(&model.Webhook{}, id).Error
+}
+
+// Dispatch delivers an event to every active webhook subscribed to it.
+// Deliveries run in the background so callers are never blocked by slow receivers.
+func (s *WebhookService) Dispatch(event string, data interface{}) {
+	var webhooks []model.Webhook
+	if err := s.db.Where("is_active = ?", true).Find(&webhooks).Error; err != nil {
+		log.Printf("webhook: failed to load subscribers for %s: %v", event, err)
+		return
+	}
+
+	payload := model.WebhookPayload{
+		Event:     event,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("webhook: failed to encode %s payload: %v", event, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+
+		webhookID := webhook.ID
+		delivery := &model.WebhookDelivery{
+			WebhookID: &webhookID,
+			Event:     event,
+			URL:       webhook.URL,
+			Payload:   body,
+			Status:    model.WebhookDeliveryPending,
+		}
+		// Sent even when it could not be recorded, deliver then has no row to update
+		if err := s.db.Create(delivery).Error; err != nil {
+			log.Printf("webhook %d: failed to record delivery of %s: %v", webhook.ID, event, err)
+		}
+
+		go s.deliver(webhook, delivery)
+	}
+}
+
+// GetDeliveries retrieves webhook deliveries, newest first, optionally filtered
+// by status and webhook_id
+func (s *WebhookService) GetDeliveries(filters map[string]interface{}, limit, offset int) ([]model.WebhookDelivery, int64, error) {
+	var deliveries []model.WebhookDelivery
+	var total int64
+
+	query := s.db.Model(&model.WebhookDelivery{})
+
+	// Apply filters
+	if status, ok := filters["status"].(string); ok && status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if webhookID, ok := filters["webhook_id"].(uint); ok && webhookID > 0 {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated records
+	err := query.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// ReplayDelivery resends a recorded delivery with its original payload. It is
+// sent to the webhook's current URL and signed with its current secret, and
+// runs synchronously so the caller sees the outcome. A delivery still pending is
+// being sent and cannot be replayed until it finishes or outlasts the client timeout.
+func (s *WebhookService) ReplayDelivery(id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := s.db.First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook delivery not found")
+		}
+		return nil, err
+	}
+
+	if delivery.WebhookID == nil {
+		return nil, errors.New("webhook not found")
+	}
+	webhook, err := s.GetWebhookByID(*delivery.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Marking it pending claims the delivery, so neither the background send
+	// nor another replay records its outcome over this one
+	result := s.db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND (status <> ? OR updated_at < ?)", delivery.ID, model.WebhookDeliveryPending, time.Now().Add(-s.client.Timeout)).
+		Updates(map[string]interface{}{"status": model.WebhookDeliveryPending, "url": webhook.URL})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrDeliveryInProgress
+	}
+
+	delivery.URL = webhook.URL
+	delivery.Status = model.WebhookDeliveryPending
+	s.deliver(*webhook, &delivery)
+
+	return &delivery, nil
+}
+
+// deliver sends a signed payload to a single webhook and records the attempt on delivery
+func (s *WebhookService) deliver(webhook model.Webhook, delivery *model.WebhookDelivery) {
+	statusCode, err := s.send(webhook, delivery.Event, delivery.Payload)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.StatusCode = statusCode
+	if err != nil {
+		log.Printf("webhook %d: delivery of %s failed: %v", webhook.ID, delivery.Event, err)
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = err.Error()
+	} else {
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.LastError = ""
+	}
+
+	if delivery.ID == 0 {
+		return
+	}
+	if err := s.db.Model(&model.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"status_code":     delivery.StatusCode,
+		"attempts":        delivery.Attempts,
+		"last_error":      delivery.LastError,
+		"last_attempt_at": delivery.LastAttemptAt,
+	}).Error; err != nil {
+		log.Printf("webhook %d: failed to record delivery of %s: %v", webhook.ID, delivery.Event, err)
+	}
+}
+
+// send posts body to the webhook, returning the response status code when one was received
+func (s *WebhookService) send(webhook model.Webhook, event string, body []byte) (*int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhookPayload(webhook.Secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	statusCode := resp.StatusCode
+	if statusCode >= 300 {
+		return &statusCode, fmt.Errorf("returned status %d", statusCode)
+	}
+	return &statusCode, nil
+}
+
+// signWebhookPayload returns the hex encoded HMAC-SHA256 of body
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateWebhookSecret returns a random 32 byte hex secret
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validateWebhookEvents ensures every event is a known webhook event
+func validateWebhookEvents(events []string) error {
+	for _, event := range events {
+		known := false
+		for _, e := range model.WebhookEvents {
+			if e == event {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown webhook event: %s", event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/attendance/backend/internal/model"
+)
+
+func TestValidateWebhookEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"all known", []string{model.WebhookEventUserCreated, model.WebhookEventUserDeleted}, false},
+		{"unknown", []string{model.WebhookEventUserCreated, "user.renamed"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWebhookEvents(tt.events); (err != nil) != tt.wantErr {
+				t.Errorf("validateWebhookEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantErr    bool
+		wantStatus int
+	}{
+		{"accepted", http.StatusNoContent, false, http.StatusNoContent},
+		{"rejected", http.StatusInternalServerError, true, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`{"event":"user.created"}`)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ := io.ReadAll(r.Body)
+				mac := hmac.New(sha256.New, []byte("whsec"))
+				mac.Write(received)
+				if got, want := r.Header.Get("X-Webhook-Signature"), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+					t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+				}
+				if got := r.Header.Get("X-Webhook-Event"); got != model.WebhookEventUserCreated {
+					t.Errorf("X-Webhook-Event = %q, want %q", got, model.WebhookEventUserCreated)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			svc := NewWebhookService(nil)
+			status, err := svc.send(model.Webhook{URL: server.URL, Secret: "whsec"}, model.WebhookEventUserCreated, body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status == nil || *status != tt.wantStatus {
+				t.Errorf("send() status = %v, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReplayDelivery(t *testing.T) {
+	payload := `{"event":"user.created","data":{"id":7}}`
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		// Signed with the webhook's current secret
+		if got, want := r.Header.Get("X-Webhook-Signature"), "sha256="+signWebhookPayload("rotated", body); got != want {
+			t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		found     bool
+		webhookID interface{}
+		inFlight  bool
+		wantErr   string
+	}{
+		{"unknown delivery", false, nil, false, "webhook delivery not found"},
+		{"webhook deleted", true, nil, false, "webhook not found"},
+		// The background send or another replay holds it
+		{"still being sent", true, 2, true, "webhook delivery is still in progress"},
+		{"resent to the current URL", true, 2, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			svc := NewWebhookService(db)
+			received = ""
+
+			rows := sqlmock.NewRows([]string{"id", "webhook_id", "event", "url", "payload", "status", "attempts", "created_at"})
+			if tt.found {
+				rows.AddRow(9, tt.webhookID, model.WebhookEventUserCreated, "https://old.example.com/hook", []byte(payload), model.WebhookDeliveryFailed, 1, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC))
+			}
+			mock.ExpectQuery(`SELECT \* FROM "webhook_deliveries" WHERE "webhook_deliveries"."id" = \$1`).
+				WithArgs(9, 1).
+				WillReturnRows(rows)
+			if tt.webhookID != nil {
+				mock.ExpectQuery(`SELECT \* FROM "webhooks" WHERE "webhooks"."id" = \$1`).
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret", "events", "is_active"}).
+						AddRow(2, server.URL, "rotated", "{user.created}", true))
+				claimed := int64(1)
+				if tt.inFlight {
+					claimed = 0
+				}
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "webhook_deliveries" SET "status"=\$1,"url"=\$2,"updated_at"=\$3 WHERE id = \$4 AND \(status <> \$5 OR updated_at < \$6\)`).
+					WithArgs(model.WebhookDeliveryPending, server.URL, sqlmock.AnyArg(), 9, model.WebhookDeliveryPending, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, claimed))
+				mock.ExpectCommit()
+			}
+			if tt.wantErr == "" {
+				// Only the outcome is written, keyed on the recorded delivery
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "webhook_deliveries" SET "attempts"=\$1,"last_attempt_at"=\$2,"last_error"=\$3,"status"=\$4,"status_code"=\$5,"updated_at"=\$6 WHERE id = \$7`).
+					WithArgs(2, sqlmock.AnyArg(), "", model.WebhookDeliverySucceeded, http.StatusOK, sqlmock.AnyArg(), 9).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			delivery, err := svc.ReplayDelivery(9)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ReplayDelivery() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReplayDelivery() error = %v", err)
+			}
+			if received != payload {
+				t.Errorf("received %q, want the stored payload %q", received, payload)
+			}
+			if delivery.URL != server.URL || delivery.Status != model.WebhookDeliverySucceeded || delivery.Attempts != 2 {
+				t.Errorf("ReplayDelivery() = %s to %s after %d attempts, want succeeded to %s after 2", delivery.Status, delivery.URL, delivery.Attempts, server.URL)
+			}
+			if delivery.StatusCode == nil || *delivery.StatusCode != http.StatusOK || delivery.LastError != "" {
+				t.Errorf("ReplayDelivery() status code = %v, last error %q", delivery.StatusCode, delivery.LastError)
+			}
+		})
+	}
+}
+
+func TestDeliverUnrecorded(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Without a recorded delivery there is no row to update, so the database is never touched
+	svc := NewWebhookService(nil)
+	svc.deliver(model.Webhook{URL: server.URL, Secret: "whsec"}, &model.WebhookDelivery{Event: model.WebhookEventUserCreated, Payload: []byte(`{}`)})
+	if calls != 1 {
+		t.Errorf("webhook called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// DeviceFingerprint identifies the device sending r. An explicit X-Device-ID header
+// is preferred over the User-Agent, which changes on browser updates.
+func DeviceFingerprint(r *http.Request) string {
+	source := r.Header.Get("X-Device-ID")
+	if source == "" {
+		source = r.UserAgent()
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeviceFingerprint(t *testing.T) {
+	type device struct{ userAgent, deviceID string }
+	fingerprint := func(d device) string {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", d.userAgent)
+		if d.deviceID != "" {
+			r.Header.Set("X-Device-ID", d.deviceID)
+		}
+		return DeviceFingerprint(r)
+	}
+
+	tests := []struct {
+		name     string
+		a, b     device
+		wantSame bool
+	}{
+		{"same user agent", device{"Firefox/120", ""}, device{"Firefox/120", ""}, true},
+		{"different user agent", device{"Firefox/120", ""}, device{"Firefox/121", ""}, false},
+		{"device ID survives a browser update", device{"Firefox/120", "device-1"}, device{"Firefox/121", "device-1"}, true},
+		{"different device IDs", device{"Firefox/120", "device-1"}, device{"Firefox/120", "device-2"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := fingerprint(tt.a), fingerprint(tt.b)
+			if len(a) != 64 {
+				t.Errorf("DeviceFingerprint() = %q, want a hex SHA-256", a)
+			}
+			if (a == b) != tt.wantSame {
+				t.Errorf("fingerprints equal = %v, want %v", a == b, tt.wantSame)
+			}
+		})
+	}
+}
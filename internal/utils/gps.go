@@ -25,7 +25,18 @@ func CalculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
 // ValidateLocation checks if user is within the allowed radius
 func ValidateLocation(userLat, userLon, locationLat, locationLon, radius float64) (bool, float64) {
 	distance := CalculateDistance(userLat, userLon, locationLat, locationLon)
-	return distance <= radius, distance
+	return roundMeters(distance) <= radius, distance
+}
+
+// ValidateLocationWithGrace checks if user is within the allowed radius and,
+// if not, whether they are still inside the outer grace band
+func ValidateLocationWithGrace(userLat, userLon, locationLat, locationLon, radius, grace float64) (bool, bool, float64) {
+	distance := CalculateDistance(userLat, userLon, locationLat, locationLon)
+	rounded := roundMeters(distance)
+	if rounded <= radius {
+		return true, false, distance
+	}
+	return false, grace > 0 && rounded <= radius+grace, distance
 }
 
 // toRadians converts degrees to radians
@@ -36,5 +47,11 @@ func toRadians(degrees float64) float64 {
 // GetNearbyLocations returns locations within specified radius (in kilometers)
 func IsWithinRadius(userLat, userLon, locationLat, locationLon, radiusKm float64) bool {
 	distance := CalculateDistance(userLat, userLon, locationLat, locationLon)
-	return distance <= (radiusKm * 1000) // Convert km to meters
+	return roundMeters(distance) <= math.Round(radiusKm*1000) // Convert km to meters
+}
+
+// roundMeters rounds a distance to whole meters so boundary comparisons
+// are not affected by floating point noise
+func roundMeters(distance float64) float64 {
+	return math.Round(distance)
 }
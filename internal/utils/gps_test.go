@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+// northOf returns the latitude the given number of meters due north of lat
+func northOf(lat, meters float64) float64 {
+	return lat + meters/earthRadius*180/math.Pi
+}
+
+func TestCalculateDistance(t *testing.T) {
+	got := CalculateDistance(-6.2, 106.8, northOf(-6.2, 250), 106.8)
+	if math.Abs(got-250) > 1e-6 {
+		t.Errorf("CalculateDistance() = %v, want 250", got)
+	}
+	if got := CalculateDistance(-6.2, 106.8, -6.2, 106.8); got != 0 {
+		t.Errorf("CalculateDistance() of the same point = %v, want 0", got)
+	}
+}
+
+func TestValidateLocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		meters float64
+		radius float64
+		want   bool
+	}{
+		{"well inside", 40, 100, true},
+		{"exactly on the radius", 100, 100, true},
+		{"float noise above the radius", 100.0000001, 100, true},
+		{"rounds down onto the radius", 100.49, 100, true},
+		{"rounds up past the radius", 100.51, 100, false},
+		{"a meter outside", 101, 100, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, distance := ValidateLocation(northOf(-6.2, tt.meters), 106.8, -6.2, 106.8, tt.radius)
+			if ok != tt.want {
+				t.Errorf("ValidateLocation() at %.7fm = %v, want %v", distance, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateLocationWithGrace(t *testing.T) {
+	tests := []struct {
+		name        string
+		meters      float64
+		grace       float64
+		wantInside  bool
+		wantInGrace bool
+	}{
+		{"inside the radius", 100, 20, true, false},
+		{"inside the grace band", 110, 20, false, true},
+		{"on the outer edge of the grace band", 120, 20, false, true},
+		{"past the grace band", 121, 20, false, false},
+		{"no grace configured", 101, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inside, inGrace, _ := ValidateLocationWithGrace(northOf(-6.2, tt.meters), 106.8, -6.2, 106.8, 100, tt.grace)
+			if inside != tt.wantInside || inGrace != tt.wantInGrace {
+				t.Errorf("ValidateLocationWithGrace() = (%v, %v), want (%v, %v)", inside, inGrace, tt.wantInside, tt.wantInGrace)
+			}
+		})
+	}
+}
+
+func TestIsWithinRadius(t *testing.T) {
+	tests := []struct {
+		name     string
+		meters   float64
+		radiusKm float64
+		want     bool
+	}{
+		{"inside", 900, 1, true},
+		{"exactly on the radius", 1000, 1, true},
+		{"fractional kilometers on the radius", 300, 0.3, true},
+		{"outside", 1001, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsWithinRadius(northOf(-6.2, tt.meters), 106.8, -6.2, 106.8, tt.radiusKm); got != tt.want {
+				t.Errorf("IsWithinRadius() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
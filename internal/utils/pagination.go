@@ -0,0 +1,10 @@
+package utils
+
+// TotalPages returns the number of pages needed to show total items, limit per page.
+// A non-positive limit yields 0 instead of dividing by zero.
+func TotalPages(total int64, limit int) int {
+	if limit <= 0 || total <= 0 {
+		return 0
+	}
+	return int((total + int64(limit) - 1) / int64(limit))
+}
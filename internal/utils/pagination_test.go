@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestTotalPages(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int64
+		limit int
+		want  int
+	}{
+		{"no items", 0, 10, 0},
+		{"zero limit", 25, 0, 0},
+		{"negative limit", 25, -5, 0},
+		{"fewer items than the limit", 3, 10, 1},
+		{"exactly one page", 10, 10, 1},
+		{"exactly divisible", 30, 10, 3},
+		{"partial last page", 31, 10, 4},
+		{"one per page", 7, 1, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TotalPages(tt.total, tt.limit); got != tt.want {
+				t.Errorf("TotalPages(%d, %d) = %d, want %d", tt.total, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
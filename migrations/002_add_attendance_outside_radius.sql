@@ -0,0 +1,4 @@
+-- Flag check-ins recorded within the grace band beyond the location radius
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS outside_radius BOOLEAN DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_attendances_outside_radius ON attendances(outside_radius) WHERE outside_radius = true;
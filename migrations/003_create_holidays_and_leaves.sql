@@ -0,0 +1,33 @@
+-- Holidays apply to every location, or to one location when location_id is set
+CREATE TABLE IF NOT EXISTS holidays (
+    id SERIAL PRIMARY KEY,
+    date DATE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    location_id INTEGER REFERENCES attendance_locations(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holidays_date_location ON holidays(date, COALESCE(location_id, 0));
+
+CREATE TRIGGER update_holidays_updated_at BEFORE UPDATE ON holidays
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Leave granted to a user for a range of days (inclusive)
+CREATE TABLE IF NOT EXISTS leaves (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL, -- 'annual', 'sick', 'unpaid' or 'other'
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaves_user_dates ON leaves(user_id, start_date, end_date);
+
+CREATE TRIGGER update_leaves_updated_at BEFORE UPDATE ON leaves
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,6 @@
+-- Track who created a schedule assignment and when it was last changed
+ALTER TABLE user_schedules ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE user_schedules ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+CREATE TRIGGER update_user_schedules_updated_at BEFORE UPDATE ON user_schedules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
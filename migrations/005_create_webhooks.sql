@@ -0,0 +1,16 @@
+-- Create webhooks table
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(255) NOT NULL, -- used to sign deliveries (HMAC-SHA256)
+    events TEXT[] NOT NULL, -- e.g., {user.created,user.deleted}
+    is_active BOOLEAN DEFAULT true,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_active ON webhooks(is_active);
+
+CREATE TRIGGER update_webhooks_updated_at BEFORE UPDATE ON webhooks
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
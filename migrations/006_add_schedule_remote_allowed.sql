@@ -0,0 +1,2 @@
+-- Allow schedules to permit remote ("work from anywhere") check-ins without geofencing
+ALTER TABLE work_schedules ADD COLUMN IF NOT EXISTS remote_allowed BOOLEAN DEFAULT false;
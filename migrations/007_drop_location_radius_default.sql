@@ -0,0 +1,3 @@
+-- The default radius comes from LOCATION_DEFAULT_RADIUS and is applied when a
+-- location is created, so the column no longer carries one that could drift from it
+ALTER TABLE attendance_locations ALTER COLUMN radius DROP DEFAULT;
@@ -0,0 +1,2 @@
+-- Days a location accepts check-ins (1=Monday, 7=Sunday); NULL or empty means every day
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS operating_days INTEGER[];
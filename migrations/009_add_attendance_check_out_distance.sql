@@ -0,0 +1,2 @@
+-- Distance from the location at check-out, in meters
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS check_out_distance DECIMAL(10, 2);
@@ -0,0 +1,19 @@
+-- Create audit_logs table
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id SERIAL PRIMARY KEY,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(100) NOT NULL, -- e.g., 'attendance.review'
+    entity_type VARCHAR(50) NOT NULL, -- e.g., 'attendance'
+    entity_id INTEGER NOT NULL,
+    details JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs(entity_type, entity_id);
+
+-- Review of flagged attendances
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS reviewed BOOLEAN DEFAULT false;
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS review_resolution VARCHAR(20); -- 'confirmed' or 'cleared'
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS review_note TEXT;
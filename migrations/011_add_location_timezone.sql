@@ -0,0 +1,2 @@
+-- IANA timezone of a location (e.g., 'Asia/Jakarta'); NULL or empty means server local time
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);
@@ -0,0 +1,4 @@
+-- Temporary location suspension (e.g., renovation) without deactivating or deleting it
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP;
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMP; -- NULL means until manually activated
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS suspension_reason TEXT;
@@ -0,0 +1,2 @@
+-- Bumped to revoke every token issued to a user (e.g., on deactivation)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Phone numbers are optional but must be unique when set, since they can be used to log in
+UPDATE users SET phone = NULL WHERE phone = '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone ON users(phone) WHERE phone IS NOT NULL AND phone <> '';
@@ -0,0 +1,2 @@
+-- Used to auto-deactivate accounts that have not logged in for a while
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP;
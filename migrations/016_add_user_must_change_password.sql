@@ -0,0 +1,2 @@
+-- Set when an admin resets a user's password to a temporary one
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT false;
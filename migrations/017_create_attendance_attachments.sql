@@ -0,0 +1,14 @@
+-- Create attendance_attachments table (proof-of-work photos and documents)
+CREATE TABLE IF NOT EXISTS attendance_attachments (
+    id SERIAL PRIMARY KEY,
+    attendance_id INTEGER NOT NULL REFERENCES attendances(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL DEFAULT 'photo', -- 'photo' or 'document'
+    caption TEXT,
+    url VARCHAR(500) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0, -- in bytes
+    uploaded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attendance_attachments_attendance ON attendance_attachments(attendance_id);
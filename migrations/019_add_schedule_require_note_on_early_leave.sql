@@ -0,0 +1,2 @@
+-- Let schedules require a note when checking out before check_out_start
+ALTER TABLE work_schedules ADD COLUMN IF NOT EXISTS require_note_on_early_leave BOOLEAN DEFAULT false;
@@ -0,0 +1,14 @@
+-- Cached per-user attendance aggregates, rebuilt from attendances on demand
+CREATE TABLE IF NOT EXISTS user_attendance_stats (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    current_streak INTEGER NOT NULL DEFAULT 0,
+    longest_streak INTEGER NOT NULL DEFAULT 0,
+    month VARCHAR(7) NOT NULL, -- "2025-03"
+    month_present INTEGER NOT NULL DEFAULT 0,
+    month_late INTEGER NOT NULL DEFAULT 0,
+    month_half_day INTEGER NOT NULL DEFAULT 0,
+    month_remote INTEGER NOT NULL DEFAULT 0,
+    month_absent INTEGER NOT NULL DEFAULT 0,
+    month_hours DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    computed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Locations can require a token from an on-site QR code for check-in
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS qr_required BOOLEAN DEFAULT false;
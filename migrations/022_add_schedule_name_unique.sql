@@ -0,0 +1,9 @@
+-- Schedule names are unique regardless of case.
+-- Existing duplicates are renamed rather than removed, since assignments still refer to
+-- them: the oldest schedule keeps the name and the others get their id appended.
+UPDATE work_schedules ws SET name = ws.name || ' (' || ws.id || ')'
+WHERE EXISTS (
+    SELECT 1 FROM work_schedules older
+    WHERE LOWER(older.name) = LOWER(ws.name) AND older.id < ws.id
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_work_schedules_name_lower ON work_schedules (LOWER(name));
@@ -0,0 +1,3 @@
+-- Record why and when an account was deactivated
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivation_reason VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP;
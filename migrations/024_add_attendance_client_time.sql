@@ -0,0 +1,3 @@
+-- Device clock reported at check-in and whether it was suspiciously far from server time
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS client_time TIMESTAMP;
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS clock_skew_suspicious BOOLEAN DEFAULT false;
@@ -0,0 +1,3 @@
+-- Optional daily window in which a location accepts check-ins (may cross midnight)
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS check_in_window_start TIME;
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS check_in_window_end TIME;
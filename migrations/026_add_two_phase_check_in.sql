@@ -0,0 +1,20 @@
+-- Two-phase check-in: presence is recorded on arrival, the official check-in time when work starts
+CREATE TABLE IF NOT EXISTS attendance_arrivals (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES attendance_locations(id) ON DELETE CASCADE,
+    arrived_at TIMESTAMP NOT NULL,
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    distance_from_location DECIMAL(10, 2),
+    outside_radius BOOLEAN DEFAULT false,
+    client_time TIMESTAMP,
+    clock_skew_suspicious BOOLEAN DEFAULT false,
+    notes TEXT,
+    photo_url TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attendance_arrivals_user_arrived ON attendance_arrivals(user_id, arrived_at);
+
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS arrival_time TIMESTAMP;
@@ -0,0 +1,2 @@
+-- Optional separate radius for check-out, NULL means the check-in radius applies
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS check_out_radius INTEGER;
@@ -0,0 +1,2 @@
+-- Check-ins more than this many minutes before check_in_start get the "early" status, 0 disables
+ALTER TABLE work_schedules ADD COLUMN IF NOT EXISTS early_status_minutes INTEGER DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Minimum rest between a check-out and the next check-in, 0 disables
+ALTER TABLE work_schedules ADD COLUMN IF NOT EXISTS min_rest_minutes INTEGER DEFAULT 0;
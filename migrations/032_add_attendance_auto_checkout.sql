@@ -0,0 +1,2 @@
+-- Marks attendances checked out by an admin action instead of by the user
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS auto_checkout BOOLEAN DEFAULT false;
@@ -0,0 +1,2 @@
+-- Notes only admins can see, kept apart from the user-visible notes
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS admin_notes TEXT;
@@ -0,0 +1,14 @@
+-- Create regions table for grouping locations in reports
+CREATE TABLE IF NOT EXISTS regions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_regions_updated_at BEFORE UPDATE ON regions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Locations optionally belong to one region
+ALTER TABLE attendance_locations ADD COLUMN IF NOT EXISTS region_id INTEGER REFERENCES regions(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_attendance_locations_region ON attendance_locations(region_id);
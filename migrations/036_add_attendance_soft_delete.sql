@@ -0,0 +1,3 @@
+-- Deleted attendance stays in the trash until restored or purged
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_attendances_deleted_at ON attendances(deleted_at);
@@ -0,0 +1,3 @@
+-- Optional employee ID, unique when set, like phone numbers
+ALTER TABLE users ADD COLUMN IF NOT EXISTS employee_id VARCHAR(50);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_employee_id ON users(employee_id) WHERE employee_id IS NOT NULL AND employee_id <> '';
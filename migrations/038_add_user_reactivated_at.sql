@@ -0,0 +1,2 @@
+-- Restart the inactivity clock when an admin reactivates an account
+ALTER TABLE users ADD COLUMN IF NOT EXISTS reactivated_at TIMESTAMP;
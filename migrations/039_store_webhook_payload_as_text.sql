@@ -0,0 +1,2 @@
+-- Keep the delivered body byte for byte, JSONB re-serializes it and replays would no longer match the original signature
+ALTER TABLE webhook_deliveries ALTER COLUMN payload TYPE TEXT USING payload::text;
@@ -0,0 +1,2 @@
+-- Region names are unique ignoring case, so concurrent requests cannot both pass the service check
+CREATE UNIQUE INDEX IF NOT EXISTS idx_regions_name_lower ON regions(LOWER(name));
@@ -0,0 +1,28 @@
+package clock
+
+import "time"
+
+// Clock is the source of the current time. Services take a Clock instead of
+// calling time.Now directly so every node decides with the same time source
+// and tests can pin the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// System returns a Clock backed by the local system clock
+func System() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed is a Clock that always returns the same instant
+type Fixed time.Time
+
+func (f Fixed) Now() time.Time {
+	return time.Time(f)
+}
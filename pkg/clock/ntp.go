@@ -0,0 +1,88 @@
+package clock
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+)
+
+// ntpEpochOffset is the number of seconds between 1900-01-01 (NTP) and 1970-01-01 (Unix)
+const ntpEpochOffset = 2208988800
+
+// NTPOffset queries server (host or host:port) once over SNTP and returns how far
+// the NTP time is ahead of c. A negative offset means c runs fast.
+func NTPOffset(c Clock, server string, timeout time.Duration) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, err
+	}
+
+	// LI = 0, version = 4, mode = 3 (client)
+	request := make([]byte, 48)
+	request[0] = 0x23
+
+	sent := c.Now()
+	if _, err := conn.Write(request); err != nil {
+		return 0, err
+	}
+
+	response := make([]byte, 48)
+	n, err := conn.Read(response)
+	if err != nil {
+		return 0, err
+	}
+	received := c.Now()
+	if n < 48 {
+		return 0, errors.New("short NTP response")
+	}
+	if mode := response[0] & 0x07; mode != 4 {
+		return 0, fmt.Errorf("unexpected NTP mode %d", mode)
+	}
+
+	serverReceive := ntpTime(response[32:40])
+	serverTransmit := ntpTime(response[40:48])
+
+	// Standard SNTP offset: ((t2 - t1) + (t3 - t4)) / 2
+	return (serverReceive.Sub(sent) + serverTransmit.Sub(received)) / 2, nil
+}
+
+// ntpTime decodes a 64 bit NTP timestamp
+func ntpTime(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	fraction := int64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(seconds, fraction*int64(time.Second)>>32)
+}
+
+// StartSkewMonitor compares c against server every interval until ctx is cancelled,
+// logging whenever the offset exceeds maxSkew. It only reports; the clock is not adjusted.
+func StartSkewMonitor(ctx context.Context, c Clock, server string, interval, maxSkew time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		offset, err := NTPOffset(c, server, 5*time.Second)
+		if err != nil {
+			log.Printf("clock skew check: %v", err)
+		} else if offset > maxSkew || offset < -maxSkew {
+			log.Printf("clock skew check: local clock is off by %s from %s (max %s)", -offset, server, maxSkew)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
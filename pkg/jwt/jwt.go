@@ -13,9 +13,13 @@ var (
 )
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID       uint   `json:"user_id"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	TokenVersion int    `json:"token_version"`
+	Fingerprint  string `json:"fingerprint,omitempty"` // device binding, empty when not bound
+	// AuthTime is when the user logged in with credentials; refreshed tokens keep it
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -25,29 +29,32 @@ type TokenPair struct {
 }
 
 // GenerateToken generates JWT access token
-func GenerateToken(userID uint, email, role, secret string, expiration time.Duration) (string, error) {
+func GenerateToken(userID uint, email, role string, tokenVersion int, fingerprint string, authTime time.Time, keys *Keys, expiration time.Duration) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:       userID,
+		Email:        email,
+		Role:         role,
+		TokenVersion: tokenVersion,
+		Fingerprint:  fingerprint,
+		AuthTime:     jwt.NewNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	token := jwt.NewWithClaims(keys.signingMethod(), claims)
+	return token.SignedString(keys.signingKey())
 }
 
 // GenerateTokenPair generates both access and refresh tokens
-func GenerateTokenPair(userID uint, email, role, secret string, accessExp, refreshExp time.Duration) (*TokenPair, error) {
-	accessToken, err := GenerateToken(userID, email, role, secret, accessExp)
+func GenerateTokenPair(userID uint, email, role string, tokenVersion int, fingerprint string, authTime time.Time, keys *Keys, accessExp, refreshExp time.Duration) (*TokenPair, error) {
+	accessToken, err := GenerateToken(userID, email, role, tokenVersion, fingerprint, authTime, keys, accessExp)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := GenerateToken(userID, email, role, secret, refreshExp)
+	refreshToken, err := GenerateToken(userID, email, role, tokenVersion, fingerprint, authTime, keys, refreshExp)
 	if err != nil {
 		return nil, err
 	}
@@ -58,14 +65,12 @@ func GenerateTokenPair(userID uint, email, role, secret string, accessExp, refre
 	}, nil
 }
 
-// ValidateToken validates and parses JWT token
-func ValidateToken(tokenString, secret string) (*Claims, error) {
+// ValidateToken validates and parses JWT token.
+// Only tokens signed with the algorithm of keys are accepted.
+func ValidateToken(tokenString string, keys *Keys) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(secret), nil
-	})
+		return keys.verificationKey(), nil
+	}, jwt.WithValidMethods([]string{keys.Algorithm()}))
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
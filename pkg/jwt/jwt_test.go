@@ -0,0 +1,137 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// rsaKeyPEM returns a fresh RSA key pair, PEM encoded
+func rsaKeyPEM(t *testing.T) (privateKeyPEM, publicKeyPEM []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+}
+
+func TestValidateToken(t *testing.T) {
+	privateKeyPEM, publicKeyPEM := rsaKeyPEM(t)
+	rsaKeys, err := NewRSAKeys(privateKeyPEM, nil)
+	if err != nil {
+		t.Fatalf("NewRSAKeys() error = %v", err)
+	}
+	// Verifies with the configured public key rather than the one derived from the private key
+	verifierKeys, err := NewRSAKeys(privateKeyPEM, publicKeyPEM)
+	if err != nil {
+		t.Fatalf("NewRSAKeys() with public key error = %v", err)
+	}
+	otherPrivatePEM, _ := rsaKeyPEM(t)
+	otherKeys, err := NewRSAKeys(otherPrivatePEM, nil)
+	if err != nil {
+		t.Fatalf("NewRSAKeys() error = %v", err)
+	}
+	if _, err := NewRSAKeys(otherPrivatePEM, publicKeyPEM); err == nil {
+		t.Fatal("NewRSAKeys() with a mismatched public key error = nil, want an error")
+	}
+	hmacKeys := NewHMACKeys("test-secret")
+
+	tests := []struct {
+		name       string
+		signWith   *Keys
+		verifyWith *Keys
+		expiration time.Duration
+		wantErr    error
+	}{
+		{"HS256", hmacKeys, hmacKeys, time.Hour, nil},
+		{"RS256", rsaKeys, rsaKeys, time.Hour, nil},
+		{"RS256 verified with the public key", rsaKeys, verifierKeys, time.Hour, nil},
+		{"RS256 signed by another key", otherKeys, rsaKeys, time.Hour, ErrInvalidToken},
+		{"HS256 token against RS256 keys", hmacKeys, rsaKeys, time.Hour, ErrInvalidToken},
+		{"RS256 token against HS256 keys", rsaKeys, hmacKeys, time.Hour, ErrInvalidToken},
+		{"wrong HMAC secret", hmacKeys, NewHMACKeys("other-secret"), time.Hour, ErrInvalidToken},
+		{"expired", rsaKeys, rsaKeys, -time.Minute, ErrExpiredToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authTime := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+			token, err := GenerateToken(7, "budi@example.com", "user", 3, "device", authTime, tt.signWith, tt.expiration)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+
+			claims, err := ValidateToken(token, tt.verifyWith)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if claims.UserID != 7 || claims.Role != "user" || claims.TokenVersion != 3 || claims.Fingerprint != "device" {
+				t.Errorf("claims = %+v, want user 7 with version 3 bound to device", claims)
+			}
+			if claims.AuthTime == nil || !claims.AuthTime.Equal(authTime) {
+				t.Errorf("AuthTime = %v, want %v", claims.AuthTime, authTime)
+			}
+		})
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	privateKeyPEM, publicKeyPEM := rsaKeyPEM(t)
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	for path, content := range map[string][]byte{privatePath: privateKeyPEM, publicPath: publicKeyPEM, invalidPath: []byte("not a key")} {
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		algorithm     string
+		secret        string
+		privateKey    string
+		publicKey     string
+		wantAlgorithm string
+		wantErr       bool
+	}{
+		{"default is HS256", "", "secret", "", "", AlgorithmHS256, false},
+		{"HS256 without a secret", AlgorithmHS256, "", "", "", "", true},
+		{"RS256 with a private key", AlgorithmRS256, "", privatePath, "", AlgorithmRS256, false},
+		{"RS256 with both keys", AlgorithmRS256, "", privatePath, publicPath, AlgorithmRS256, false},
+		{"RS256 without a private key", AlgorithmRS256, "secret", "", "", "", true},
+		{"RS256 with a missing file", AlgorithmRS256, "", filepath.Join(dir, "missing.pem"), "", "", true},
+		{"RS256 with an invalid private key", AlgorithmRS256, "", invalidPath, "", "", true},
+		{"RS256 with an invalid public key", AlgorithmRS256, "", privatePath, invalidPath, "", true},
+		{"unsupported algorithm", "ES256", "secret", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := LoadKeys(tt.algorithm, tt.secret, tt.privateKey, tt.publicKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadKeys() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && keys.Algorithm() != tt.wantAlgorithm {
+				t.Errorf("Algorithm() = %q, want %q", keys.Algorithm(), tt.wantAlgorithm)
+			}
+		})
+	}
+}
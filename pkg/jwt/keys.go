@@ -0,0 +1,117 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// Keys holds the key material used to sign and verify tokens with one algorithm
+type Keys struct {
+	algorithm  string
+	secret     []byte
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+}
+
+// NewHMACKeys returns keys signing and verifying with HS256 and a shared secret
+func NewHMACKeys(secret string) *Keys {
+	return &Keys{
+		algorithm: AlgorithmHS256,
+		secret:    []byte(secret),
+	}
+}
+
+// NewRSAKeys returns keys signing with an RSA private key and verifying with its
+// public key, both PEM encoded. When publicKeyPEM is empty the public key is taken
+// from the private key; otherwise it must belong to the private key.
+func NewRSAKeys(privateKeyPEM, publicKeyPEM []byte) (*Keys, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RSA private key: %w", err)
+	}
+
+	publicKey := &privateKey.PublicKey
+	if len(publicKeyPEM) > 0 {
+		if publicKey, err = jwt.ParseRSAPublicKeyFromPEM(publicKeyPEM); err != nil {
+			return nil, fmt.Errorf("invalid RSA public key: %w", err)
+		}
+		// A mismatched pair would sign tokens that this server then rejects
+		if !privateKey.PublicKey.Equal(publicKey) {
+			return nil, fmt.Errorf("RSA public key does not match the private key")
+		}
+	}
+
+	return &Keys{
+		algorithm:  AlgorithmRS256,
+		privateKey: privateKey,
+		publicKey:  publicKey,
+	}, nil
+}
+
+// LoadKeys builds keys for the given algorithm, reading RSA keys from PEM files
+func LoadKeys(algorithm, secret, privateKeyPath, publicKeyPath string) (*Keys, error) {
+	switch algorithm {
+	case "", AlgorithmHS256:
+		if secret == "" {
+			return nil, fmt.Errorf("JWT secret is required for %s", AlgorithmHS256)
+		}
+		return NewHMACKeys(secret), nil
+
+	case AlgorithmRS256:
+		if privateKeyPath == "" {
+			return nil, fmt.Errorf("private key path is required for %s", AlgorithmRS256)
+		}
+		privateKeyPEM, err := os.ReadFile(privateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+
+		var publicKeyPEM []byte
+		if publicKeyPath != "" {
+			if publicKeyPEM, err = os.ReadFile(publicKeyPath); err != nil {
+				return nil, fmt.Errorf("failed to read public key: %w", err)
+			}
+		}
+		return NewRSAKeys(privateKeyPEM, publicKeyPEM)
+
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm: %s", algorithm)
+	}
+}
+
+// Algorithm returns the signing algorithm name, e.g. "RS256"
+func (k *Keys) Algorithm() string {
+	return k.algorithm
+}
+
+// signingMethod returns the jwt signing method for the algorithm
+func (k *Keys) signingMethod() jwt.SigningMethod {
+	if k.algorithm == AlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodHS256
+}
+
+// signingKey returns the key used to sign tokens
+func (k *Keys) signingKey() interface{} {
+	if k.algorithm == AlgorithmRS256 {
+		return k.privateKey
+	}
+	return k.secret
+}
+
+// verificationKey returns the key used to verify token signatures
+func (k *Keys) verificationKey() interface{} {
+	if k.algorithm == AlgorithmRS256 {
+		return k.publicKey
+	}
+	return k.secret
+}
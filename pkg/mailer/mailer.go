@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+)
+
+// Message represents a plain-text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// SMTPMailer sends messages through an SMTP server
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer creates a mailer for the given SMTP server
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send delivers msg through the SMTP server
+func (m *SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		m.from, strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+
+	return smtp.SendMail(m.host+":"+m.port, auth, m.from, msg.To, []byte(body))
+}
+
+// LogMailer writes messages to the log instead of sending them.
+// Used when no SMTP server is configured.
+type LogMailer struct{}
+
+// Send logs msg
+func (LogMailer) Send(msg Message) error {
+	log.Printf("mailer: to=%s subject=%q (SMTP not configured, not sent)", strings.Join(msg.To, ","), msg.Subject)
+	return nil
+}
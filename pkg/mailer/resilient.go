@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueueFull   = errors.New("mail queue is full")
+	ErrCircuitOpen = errors.New("mail circuit breaker is open")
+)
+
+// Options configures a ResilientMailer
+type Options struct {
+	MaxRetries       int           // attempts after the first failure
+	RetryBaseDelay   time.Duration // doubled after every failed attempt
+	QueueSize        int
+	BreakerThreshold int           // consecutive failures that open the circuit
+	BreakerCooldown  time.Duration // how long the circuit stays open
+}
+
+// ResilientMailer queues messages and delivers them from a background worker,
+// retrying with exponential backoff behind a circuit breaker so a flaky
+// provider never blocks request handling.
+type ResilientMailer struct {
+	next    Mailer
+	options Options
+	queue   chan Message
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// NewResilientMailer wraps next with queueing, retries and a circuit breaker
+func NewResilientMailer(next Mailer, options Options) *ResilientMailer {
+	if options.QueueSize < 1 {
+		options.QueueSize = 1
+	}
+	return &ResilientMailer{
+		next:    next,
+		options: options,
+		queue:   make(chan Message, options.QueueSize),
+	}
+}
+
+// Send enqueues msg for background delivery without blocking
+func (m *ResilientMailer) Send(msg Message) error {
+	select {
+	case m.queue <- msg:
+		return nil
+	default:
+		logUndeliverable(msg, ErrQueueFull)
+		return ErrQueueFull
+	}
+}
+
+// Start delivers queued messages until ctx is cancelled
+func (m *ResilientMailer) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			// Whatever is still queued cannot be delivered anymore
+			for {
+				select {
+				case msg := <-m.queue:
+					logUndeliverable(msg, ctx.Err())
+				default:
+					return
+				}
+			}
+		case msg := <-m.queue:
+			m.deliver(ctx, msg)
+		}
+	}
+}
+
+// deliver sends msg, retrying with exponential backoff
+func (m *ResilientMailer) deliver(ctx context.Context, msg Message) {
+	delay := m.options.RetryBaseDelay
+	var err error
+
+	for attempt := 0; attempt <= m.options.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				logUndeliverable(msg, ctx.Err())
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		if err = m.attempt(msg); err == nil {
+			return
+		}
+	}
+
+	logUndeliverable(msg, err)
+}
+
+// attempt sends msg once, honouring and updating the circuit breaker
+func (m *ResilientMailer) attempt(msg Message) error {
+	m.mu.Lock()
+	if time.Now().Before(m.openUntil) {
+		m.mu.Unlock()
+		return ErrCircuitOpen
+	}
+	m.mu.Unlock()
+
+	err := m.next.Send(msg)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.failures++
+		if m.options.BreakerThreshold > 0 && m.failures >= m.options.BreakerThreshold {
+			m.openUntil = time.Now().Add(m.options.BreakerCooldown)
+			m.failures = 0
+		}
+		return err
+	}
+	m.failures = 0
+	return nil
+}
+
+// logUndeliverable records a message that was dropped
+func logUndeliverable(msg Message, err error) {
+	log.Printf("mailer: undeliverable mail to=%s subject=%q: %v", strings.Join(msg.To, ","), msg.Subject, err)
+}
@@ -0,0 +1,119 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyMailer fails its first failures sends
+type flakyMailer struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (m *flakyMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.calls <= m.failures {
+		return errors.New("provider unavailable")
+	}
+	return nil
+}
+
+func (m *flakyMailer) callCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+func TestResilientMailerDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		maxRetries int
+		wantCalls  int
+	}{
+		{"first attempt succeeds", 0, 3, 1},
+		{"succeeds on a retry", 2, 3, 3},
+		{"gives up after the retries", 10, 2, 3},
+		{"no retries", 10, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &flakyMailer{failures: tt.failures}
+			m := NewResilientMailer(next, Options{MaxRetries: tt.maxRetries, RetryBaseDelay: time.Millisecond})
+
+			m.deliver(context.Background(), Message{To: []string{"budi@example.com"}, Subject: "Hi"})
+			if got := next.callCount(); got != tt.wantCalls {
+				t.Errorf("sends = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestResilientMailerCircuitBreaker(t *testing.T) {
+	next := &flakyMailer{failures: 2}
+	m := NewResilientMailer(next, Options{BreakerThreshold: 2, BreakerCooldown: 50 * time.Millisecond})
+	msg := Message{To: []string{"budi@example.com"}}
+
+	for i := 0; i < 2; i++ {
+		if err := m.attempt(msg); err == nil {
+			t.Fatalf("attempt %d succeeded, want the provider error", i+1)
+		}
+	}
+	if err := m.attempt(msg); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("attempt with the circuit open error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if got := next.callCount(); got != 2 {
+		t.Errorf("sends while open = %d, want 2", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := m.attempt(msg); err != nil {
+		t.Errorf("attempt after the cooldown error = %v, want nil", err)
+	}
+}
+
+func TestResilientMailerSendQueueFull(t *testing.T) {
+	m := NewResilientMailer(&flakyMailer{}, Options{QueueSize: 1})
+
+	if err := m.Send(Message{Subject: "first"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := m.Send(Message{Subject: "second"}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Send() on a full queue error = %v, want %v", err, ErrQueueFull)
+	}
+}
+
+func TestResilientMailerStart(t *testing.T) {
+	next := &flakyMailer{}
+	m := NewResilientMailer(next, Options{QueueSize: 4})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Start(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		if err := m.Send(Message{Subject: "hello"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for next.callCount() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := next.callCount(); got != 3 {
+		t.Errorf("sends = %d, want 3", got)
+	}
+}
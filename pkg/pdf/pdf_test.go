@@ -0,0 +1,85 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Budi Santoso", "Budi Santoso"},
+		{"Overtime (hours)", `Overtime \(hours\)`},
+		{`C:\reports`, `C:\\reports`},
+		{"Café\n", "Caf??"},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentBytes(t *testing.T) {
+	tests := []struct {
+		name      string
+		build     func(d *Document)
+		wantPages int
+		wantText  []string
+	}{
+		{"empty document gets a blank page", func(d *Document) {}, 1, nil},
+		{"text without AddPage", func(d *Document) { d.Text(10, 20, 12, false, "hello") }, 1, []string{"BT /F1 12.00 Tf 10.00 20.00 Td (hello) Tj ET"}},
+		{"two pages", func(d *Document) {
+			d.AddPage()
+			d.Text(10, 20, 12, true, "first")
+			d.AddPage()
+			d.Line(0, 0, 100, 0)
+		}, 2, []string{"/F2 12.00 Tf", "0.00 0.00 m 100.00 0.00 l S"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := New()
+			tt.build(doc)
+			out := doc.Bytes()
+
+			if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+				t.Fatalf("output is not framed as a PDF: %q", out)
+			}
+			if want := fmt.Sprintf("/Count %d", tt.wantPages); !bytes.Contains(out, []byte(want)) {
+				t.Errorf("output lacks %q", want)
+			}
+			for _, text := range tt.wantText {
+				if !bytes.Contains(out, []byte(text)) {
+					t.Errorf("output lacks %q", text)
+				}
+			}
+
+			// Every cross-reference entry must point at the start of its object
+			objects := 4 + 2*tt.wantPages
+			xref := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllSubmatch(out, -1)
+			if len(xref) != objects {
+				t.Fatalf("xref has %d entries, want %d", len(xref), objects)
+			}
+			for i, entry := range xref {
+				offset, _ := strconv.Atoi(string(entry[1]))
+				if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+					t.Errorf("xref entry %d points at %q, want %q", i+1, out[offset:offset+len(want)], want)
+				}
+			}
+			startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+			if startxref == nil {
+				t.Fatalf("output lacks startxref")
+			}
+			if offset, _ := strconv.Atoi(string(startxref[1])); !bytes.HasPrefix(out[offset:], []byte("xref\n")) {
+				t.Errorf("startxref %d does not point at the xref table", offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage persists uploaded files
+type Storage interface {
+	// Save writes the content of r under key and returns the URL identifying it
+	Save(key string, r io.Reader) (string, error)
+	// Open reads the file stored under url
+	Open(url string) (io.ReadCloser, error)
+	// Delete removes the file served from url; deleting a missing file is not an error
+	Delete(url string) error
+}
+
+// LocalStorage stores files on the local filesystem below a root directory
+type LocalStorage struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStorage creates a storage writing to root and naming files below baseURL
+func NewLocalStorage(root, baseURL string) *LocalStorage {
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Save writes the content of r to root/key
+func (s *LocalStorage) Save(key string, r io.Reader) (string, error) {
+	key = filepath.ToSlash(filepath.Clean("/" + key))[1:]
+	if key == "" {
+		return "", fmt.Errorf("storage: empty key")
+	}
+
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("storage: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("storage: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("storage: %w", err)
+	}
+
+	return s.baseURL + "/" + key, nil
+}
+
+// Open reads the file that Save stored under url
+func (s *LocalStorage) Open(url string) (io.ReadCloser, error) {
+	path, err := s.path(url)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("storage: %w", err)
+	}
+	return f, nil
+}
+
+// Delete removes the file that Save stored under url
+func (s *LocalStorage) Delete(url string) error {
+	path, err := s.path(url)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("storage: %w", err)
+	}
+	return nil
+}
+
+// path returns where the file Save stored under url lives below root
+func (s *LocalStorage) path(url string) (string, error) {
+	key, ok := strings.CutPrefix(url, s.baseURL+"/")
+	if !ok {
+		return "", fmt.Errorf("storage: %q is not served from %s", url, s.baseURL)
+	}
+	key = filepath.ToSlash(filepath.Clean("/" + key))[1:]
+	if key == "" {
+		return "", fmt.Errorf("storage: empty key")
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorageDelete(t *testing.T) {
+	root := t.TempDir()
+	s := NewLocalStorage(root, "/uploads/")
+
+	url, err := s.Save("attendances/4/a.jpg", strings.NewReader("photo"))
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	path := filepath.Join(root, "attendances", "4", "a.jpg")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("saved file missing: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"stored file", url, false},
+		{"already deleted", url, false},
+		{"other base URL", "/static/attendances/4/a.jpg", true},
+		{"empty key", "/uploads/", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Delete(tt.url); (err != nil) != tt.wantErr {
+				t.Errorf("Delete(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file still present after Delete: %v", err)
+	}
+}
+
+func TestLocalStorageOpen(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "/uploads")
+
+	url, err := s.Save("attendances/4/a.jpg", strings.NewReader("photo"))
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	f, err := s.Open(url)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	content, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || string(content) != "photo" {
+		t.Errorf("Open() content = %q, %v, want %q", content, err, "photo")
+	}
+
+	// Keys cannot climb out of the root
+	if _, err := s.Open("/uploads/../../etc/passwd"); err == nil {
+		t.Errorf("Open() outside the root error = nil, want an error")
+	}
+	if _, err := s.Open("/static/attendances/4/a.jpg"); err == nil {
+		t.Errorf("Open() with another base URL error = nil, want an error")
+	}
+}
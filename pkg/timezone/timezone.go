@@ -0,0 +1,159 @@
+package timezone
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embedded IANA database, so resolved zones load without system tzdata
+	_ "time/tzdata"
+)
+
+// ErrNotFound is returned when no zone can be resolved for a coordinate
+var ErrNotFound = errors.New("no timezone found for coordinates")
+
+// Resolver looks up the IANA timezone for a coordinate
+type Resolver interface {
+	Resolve(latitude, longitude float64) (string, error)
+}
+
+// zone1970.tab from the IANA tz database (public domain): the principal
+// location of every zone
+//
+//go:embed zone1970.tab
+var zoneTab string
+
+type referencePoint struct {
+	zone      string
+	latitude  float64
+	longitude float64
+}
+
+// NearestResolver resolves a coordinate to the zone whose principal location is
+// closest, using only embedded data. It is an approximation that can be wrong
+// close to a zone boundary far from both principal locations; deployments that
+// need exact answers can plug in a boundary based Resolver instead.
+type NearestResolver struct {
+	points      []referencePoint
+	maxDistance float64 // in meters, 0 means unlimited
+}
+
+// NewNearestResolver creates a resolver from the embedded zone table. Coordinates
+// farther than maxDistanceKm from every principal location are not resolved,
+// 0 disables the limit.
+func NewNearestResolver(maxDistanceKm float64) (*NearestResolver, error) {
+	points, err := parseZoneTab(zoneTab)
+	if err != nil {
+		return nil, err
+	}
+	return &NearestResolver{points: points, maxDistance: maxDistanceKm * 1000}, nil
+}
+
+// Resolve returns the zone whose principal location is closest to the coordinate
+func (r *NearestResolver) Resolve(latitude, longitude float64) (string, error) {
+	best := -1
+	bestDistance := math.Inf(1)
+	for i, p := range r.points {
+		if d := distance(latitude, longitude, p.latitude, p.longitude); d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	if best < 0 || (r.maxDistance > 0 && bestDistance > r.maxDistance) {
+		return "", ErrNotFound
+	}
+
+	zone := r.points[best].zone
+	if _, err := time.LoadLocation(zone); err != nil {
+		return "", fmt.Errorf("resolved unknown zone %q: %w", zone, err)
+	}
+	return zone, nil
+}
+
+// parseZoneTab reads the country, coordinates and zone columns of zone1970.tab
+func parseZoneTab(data string) ([]referencePoint, error) {
+	var points []referencePoint
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("malformed zone table line %q", line)
+		}
+		latitude, longitude, err := parseISO6709(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("zone %s: %w", fields[2], err)
+		}
+		points = append(points, referencePoint{zone: fields[2], latitude: latitude, longitude: longitude})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, errors.New("zone table is empty")
+	}
+	return points, nil
+}
+
+// parseISO6709 parses coordinates in the ±DDMM±DDDMM or ±DDMMSS±DDDMMSS form
+func parseISO6709(s string) (float64, float64, error) {
+	split := strings.LastIndexAny(s, "+-")
+	if split <= 0 {
+		return 0, 0, fmt.Errorf("invalid coordinates %q", s)
+	}
+	latitude, err := parseDegrees(s[:split], 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	longitude, err := parseDegrees(s[split:], 3)
+	if err != nil {
+		return 0, 0, err
+	}
+	return latitude, longitude, nil
+}
+
+// parseDegrees parses a signed degrees, minutes and optional seconds value
+// whose degrees part has degreeDigits digits
+func parseDegrees(s string, degreeDigits int) (float64, error) {
+	sign := 1.0
+	if s[0] == '-' {
+		sign = -1
+	}
+	digits := s[1:]
+	if len(digits) != degreeDigits+2 && len(digits) != degreeDigits+4 {
+		return 0, fmt.Errorf("invalid coordinate %q", s)
+	}
+
+	parts := []string{digits[:degreeDigits], digits[degreeDigits : degreeDigits+2]}
+	if len(digits) == degreeDigits+4 {
+		parts = append(parts, digits[degreeDigits+2:])
+	}
+	value := 0.0
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid coordinate %q", s)
+		}
+		value += float64(n) / math.Pow(60, float64(i))
+	}
+	return sign * value, nil
+}
+
+// distance returns the great-circle distance between two coordinates in meters
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,79 @@
+package timezone
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNearestResolverResolve(t *testing.T) {
+	resolver, err := NewNearestResolver(1000)
+	if err != nil {
+		t.Fatalf("NewNearestResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		want      string
+		wantErr   error
+	}{
+		{"Jakarta", -6.2, 106.8, "Asia/Jakarta", nil},
+		{"Bandung", -6.9, 107.6, "Asia/Jakarta", nil},
+		{"Denpasar", -8.65, 115.22, "Asia/Makassar", nil},
+		{"Jayapura", -2.53, 140.72, "Asia/Jayapura", nil},
+		{"London", 51.5, -0.12, "Europe/London", nil},
+		{"middle of the Pacific", -50, -125, "", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(tt.latitude, tt.longitude)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearestResolverUnlimited(t *testing.T) {
+	resolver, err := NewNearestResolver(0)
+	if err != nil {
+		t.Fatalf("NewNearestResolver() error = %v", err)
+	}
+	if got, err := resolver.Resolve(-50, -125); err != nil || got == "" {
+		t.Errorf("Resolve() = %q, %v, want the nearest zone without a distance limit", got, err)
+	}
+}
+
+func TestParseISO6709(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantLatitude  float64
+		wantLongitude float64
+		wantErr       bool
+	}{
+		{"degrees and minutes", "-0610+10648", -(6 + 10.0/60), 106 + 48.0/60, false},
+		{"with seconds", "+513030-0000731", 51 + 30.0/60 + 30.0/3600, -(7.0/60 + 31.0/3600), false},
+		{"missing longitude", "-0610", 0, 0, true},
+		{"wrong digit count", "-061+10648", 0, 0, true},
+		{"not a number", "-06ab+10648", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latitude, longitude, err := parseISO6709(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseISO6709() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(latitude-tt.wantLatitude) > 1e-9 || math.Abs(longitude-tt.wantLongitude) > 1e-9 {
+				t.Errorf("parseISO6709() = %f, %f, want %f, %f", latitude, longitude, tt.wantLatitude, tt.wantLongitude)
+			}
+		})
+	}
+}